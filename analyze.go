@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Analyze returns warnings about Function input that's valid, but probably
+// doesn't do what its author intended. It warns about PatchSets that aren't
+// used, resource templates with the same name, patches to a composed resource
+// that a later patch always overwrites, and patches from different resource
+// templates that replace the same field path of the composite resource, the
+// environment, or the Function pipeline context. Patch indexes are those of a
+// resource template's patches after its PatchSets and default patches are
+// resolved.
+func Analyze(r *v1beta1.Resources) []string {
+	warnings := []string{}
+
+	used := make(map[string]bool, len(r.PatchSets))
+	refs := func(ps []v1beta1.ComposedPatch) {
+		for _, p := range ps {
+			if p.GetType() == v1beta1.PatchTypePatchSet && p.PatchSetName != nil {
+				used[*p.PatchSetName] = true
+			}
+		}
+	}
+	if r.Defaults != nil {
+		refs(r.Defaults.Patches)
+	}
+	for _, t := range r.Resources {
+		refs(t.Patches)
+	}
+	// A PatchSet that's only included by another PatchSet is used if that
+	// PatchSet is used. Iterate until no more PatchSets are marked used.
+	for changed := true; changed; {
+		changed = false
+		for _, ps := range r.PatchSets {
+			if !used[ps.Name] {
+				continue
+			}
+			for _, p := range ps.Patches {
+				if p.GetType() == v1beta1.PatchTypePatchSet && p.PatchSetName != nil && !used[*p.PatchSetName] {
+					used[*p.PatchSetName] = true
+					changed = true
+				}
+			}
+		}
+	}
+	for i, ps := range r.PatchSets {
+		if !used[ps.Name] {
+			warnings = append(warnings, fmt.Sprintf("patchSets[%d]: PatchSet %q isn't used by any resource template", i, ps.Name))
+		}
+	}
+
+	names := make(map[string]int, len(r.Resources))
+	for i, t := range r.Resources {
+		if j, ok := names[t.Name]; ok {
+			warnings = append(warnings, fmt.Sprintf("resources[%d].name: resource template %q has the same name as resources[%d], so only one of them is rendered", i, t.Name, j))
+			continue
+		}
+		names[t.Name] = i
+	}
+
+	for i, t := range r.Resources {
+		if t.Base == nil || r.StripBaseNulls {
+			continue
+		}
+		obj := map[string]any{}
+		if err := json.Unmarshal(t.Base.Raw, &obj); err != nil {
+			continue
+		}
+		for _, p := range NullFieldPaths(obj) {
+			if !strings.HasPrefix(p, "[") {
+				p = "." + p
+			}
+			warnings = append(warnings, fmt.Sprintf("resources[%d].base%s: resource template %q has a null value, which is usually a typo: remove it, or set stripBaseNulls to remove nulls from base templates", i, p, t.Name))
+		}
+	}
+
+	cts, err := ComposedTemplates(r.PatchSets, WithDefaultPatches(r.Defaults, r.Resources))
+	if err != nil {
+		return warnings
+	}
+	for i, t := range cts {
+		shadowed := ShadowedPatches(t.Patches)
+		for j := range t.Patches {
+			k, ok := shadowed[j]
+			if !ok {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("resources[%d].patches[%d]: patch to %s is always overwritten by patch %d", i, j, t.Patches[j].GetToFieldPath(), k))
+		}
+	}
+	for _, c := range ConflictingPatches(cts) {
+		warnings = append(warnings, fmt.Sprintf("resources[%d].patches[%d]: %s patch to %s replaces the same field path as resources[%d].patches[%d], so the resource template rendered last wins", c.Resource, c.Patch, cts[c.Resource].Patches[c.Patch].GetType(), cts[c.Resource].Patches[c.Patch].GetToFieldPath(), c.OtherResource, c.OtherPatch))
+	}
+	return warnings
+}
+
+// ShadowedPatches returns the index of each of the supplied patches to a
+// composed resource that a later patch always overwrites, mapped to the index
+// of the first such later patch. A later patch always overwrites an earlier
+// patch if it replaces the same field path and its from field path is
+// required. A later patch from an optional field path doesn't, because the
+// earlier patch is a fallback for when the field path doesn't exist.
+func ShadowedPatches(ps []v1beta1.ComposedPatch) map[int]int {
+	shadowed := map[int]int{}
+	for i := range ps {
+		p := &ps[i]
+		if !ToComposedResource(p) || IsToFieldPathTemplate(p.GetToFieldPath()) {
+			continue
+		}
+		for j := i + 1; j < len(ps); j++ {
+			q := &ps[j]
+			if !ToComposedResource(q) || q.GetToFieldPath() != p.GetToFieldPath() {
+				continue
+			}
+			if q.GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicyReplace && q.GetPolicy().GetFromFieldPathPolicy() == v1beta1.FromFieldPathPolicyRequired {
+				shadowed[i] = j
+				break
+			}
+		}
+	}
+	return shadowed
+}
+
+// A PatchConflict is a patch that replaces the same field path as a patch of
+// an earlier resource template. Patches are identified by the index of their
+// resource template, and their index within it.
+type PatchConflict struct {
+	Resource int
+	Patch    int
+
+	OtherResource int
+	OtherPatch    int
+}
+
+// ConflictingPatches returns each patch of the supplied resource templates
+// that replaces the same field path of the composite resource, the
+// environment, or the Function pipeline context as a patch of an earlier
+// resource template. Whichever is applied last overwrites the other. Patches
+// within the same resource template aren't considered, because they're
+// applied in order.
+func ConflictingPatches(cts []v1beta1.ComposedTemplate) []PatchConflict {
+	type target struct {
+		to   string
+		path string
+	}
+	type location struct {
+		resource int
+		patch    int
+	}
+	var conflicts []PatchConflict
+	first := map[target]location{}
+	for i, t := range cts {
+		patched := map[target]location{}
+		for j := range t.Patches {
+			p := &t.Patches[j]
+			if IsToFieldPathTemplate(p.GetToFieldPath()) || p.GetPolicy().GetToFieldPathPolicy() != v1beta1.ToFieldPathPolicyReplace {
+				continue
+			}
+			var to string
+			switch p.GetType() { //nolint:exhaustive // Only these types patch from a composed resource.
+			case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
+				to = "composite"
+			case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment:
+				to = "environment"
+			case v1beta1.PatchTypeToContextFieldPath:
+				to = "context"
+			default:
+				continue
+			}
+			k := target{to: to, path: p.GetToFieldPath()}
+			if l, ok := first[k]; ok {
+				conflicts = append(conflicts, PatchConflict{Resource: i, Patch: j, OtherResource: l.resource, OtherPatch: l.patch})
+				continue
+			}
+			if _, ok := patched[k]; !ok {
+				patched[k] = location{resource: i, patch: j}
+			}
+		}
+		for k, l := range patched {
+			first[k] = l
+		}
+	}
+	return conflicts
+}
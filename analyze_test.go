@@ -0,0 +1,182 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestAnalyze(t *testing.T) {
+	required := &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)}
+
+	cases := map[string]struct {
+		reason string
+		r      *v1beta1.Resources
+		want   []string
+	}{
+		"NoWarnings": {
+			reason: "Input whose PatchSets are all used, and whose patches are all effective, shouldn't return warnings.",
+			r: &v1beta1.Resources{
+				PatchSets: []v1beta1.PatchSet{
+					{
+						Name: "region",
+						Patches: []v1beta1.PatchSetPatch{
+							{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.region")}},
+						},
+					},
+				},
+				Resources: []v1beta1.ComposedTemplate{
+					{
+						Name: "bucket",
+						Patches: []v1beta1.ComposedPatch{
+							{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("region")},
+							{
+								// An optional patch to the same field path
+								// doesn't shadow the PatchSet's patch, which
+								// is a fallback.
+								Type:  v1beta1.PatchTypeFromEnvironmentFieldPath,
+								Patch: v1beta1.Patch{FromFieldPath: ptr.To("region"), ToFieldPath: ptr.To("spec.region")},
+							},
+						},
+					},
+				},
+			},
+			want: []string{},
+		},
+		"NestedPatchSets": {
+			reason: "A PatchSet that's only included by a used PatchSet is used.",
+			r: &v1beta1.Resources{
+				PatchSets: []v1beta1.PatchSet{
+					{Name: "org"},
+					{Name: "team", Patches: []v1beta1.PatchSetPatch{{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("org")}}},
+				},
+				Resources: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("team")}}},
+				},
+			},
+			want: []string{},
+		},
+		"NullBaseValues": {
+			reason: "Null values in a base template should return warnings, unless nulls are stripped.",
+			r: &v1beta1.Resources{
+				Resources: []v1beta1.ComposedTemplate{
+					{
+						Name: "bucket",
+						Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","metadata":{"labels":{"example.org/team":null}},"spec":{"region":null}}`)},
+					},
+				},
+			},
+			want: []string{
+				`resources[0].base.metadata.labels[example.org/team]: resource template "bucket" has a null value, which is usually a typo: remove it, or set stripBaseNulls to remove nulls from base templates`,
+				`resources[0].base.spec.region: resource template "bucket" has a null value, which is usually a typo: remove it, or set stripBaseNulls to remove nulls from base templates`,
+			},
+		},
+		"NullBaseValuesStripped": {
+			reason: "Null values in a base template shouldn't return warnings if nulls are stripped.",
+			r: &v1beta1.Resources{
+				StripBaseNulls: true,
+				Resources: []v1beta1.ComposedTemplate{
+					{
+						Name: "bucket",
+						Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","spec":{"region":null}}`)},
+					},
+				},
+			},
+			want: []string{},
+		},
+		"ConflictingPatches": {
+			reason: "Patches from different resource templates that replace the same field path should return warnings, but patches within one resource template or that merge shouldn't.",
+			r: &v1beta1.Resources{
+				Resources: []v1beta1.ComposedTemplate{
+					{
+						Name: "bucket",
+						Patches: []v1beta1.ComposedPatch{
+							{Type: v1beta1.PatchTypeToCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.id"), ToFieldPath: ptr.To("status.id")}},
+							{Type: v1beta1.PatchTypeToCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.arn"), ToFieldPath: ptr.To("status.id")}},
+						},
+					},
+					{
+						Name: "queue",
+						Patches: []v1beta1.ComposedPatch{
+							{Type: v1beta1.PatchTypeToCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.id"), ToFieldPath: ptr.To("status.id")}},
+							{Type: v1beta1.PatchTypeToEnvironmentFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.id"), ToFieldPath: ptr.To("status.id")}},
+							{
+								Type: v1beta1.PatchTypeToCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To("status.tags"),
+									ToFieldPath:   ptr.To("status.tags"),
+									Policy:        &v1beta1.PatchPolicy{ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjects)},
+								},
+							},
+						},
+					},
+					{
+						Name: "topic",
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeToCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To("status.tags"),
+									ToFieldPath:   ptr.To("status.tags"),
+									Policy:        &v1beta1.PatchPolicy{ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjects)},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: []string{
+				"resources[1].patches[0]: ToCompositeFieldPath patch to status.id replaces the same field path as resources[0].patches[0], so the resource template rendered last wins",
+			},
+		},
+		"Warnings": {
+			reason: "Unused PatchSets, duplicate resource template names, and shadowed patches should return warnings.",
+			r: &v1beta1.Resources{
+				PatchSets: []v1beta1.PatchSet{
+					{
+						Name: "unused",
+						Patches: []v1beta1.PatchSetPatch{
+							{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.region")}},
+						},
+					},
+				},
+				Resources: []v1beta1.ComposedTemplate{
+					{
+						Name: "bucket",
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.region")},
+							},
+							{
+								Type:  v1beta1.PatchTypeFromEnvironmentFieldPath,
+								Patch: v1beta1.Patch{FromFieldPath: ptr.To("region"), ToFieldPath: ptr.To("spec.region"), Policy: required},
+							},
+						},
+					},
+					{
+						Name: "bucket",
+					},
+				},
+			},
+			want: []string{
+				`patchSets[0]: PatchSet "unused" isn't used by any resource template`,
+				`resources[1].name: resource template "bucket" has the same name as resources[0], so only one of them is rendered`,
+				`resources[0].patches[0]: patch to spec.region is always overwritten by patch 1`,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Analyze(tc.r)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nAnalyze(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
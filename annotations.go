@@ -0,0 +1,108 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// crossplaneAnnotations are the annotations Crossplane uses to manage a
+// resource that patches may be allowed or denied to change.
+var crossplaneAnnotations = []string{meta.AnnotationKeyExternalName, meta.AnnotationKeyReconciliationPaused}
+
+// CrossplaneAnnotationPolicies returns the policy of each annotation patches
+// may be allowed or denied to change, by annotation key. Annotations without a
+// policy have the supplied default policy.
+func CrossplaneAnnotationPolicies(a *v1beta1.CrossplaneAnnotations, def v1beta1.CrossplaneAnnotationPolicy) map[string]v1beta1.CrossplaneAnnotationPolicy {
+	return map[string]v1beta1.CrossplaneAnnotationPolicy{
+		meta.AnnotationKeyExternalName:         a.GetExternalNamePolicy(def),
+		meta.AnnotationKeyReconciliationPaused: a.GetPausedPolicy(def),
+	}
+}
+
+// AllowedCompositeAnnotations returns the annotations prefixed with
+// crossplane.io/ that patches may change on the composite resource.
+func AllowedCompositeAnnotations(a *v1beta1.CrossplaneAnnotations) map[string]bool {
+	return annotationsWithPolicy(CrossplaneAnnotationPolicies(a, v1beta1.CrossplaneAnnotationPolicyDeny), v1beta1.CrossplaneAnnotationPolicyAllow)
+}
+
+// DeniedComposedAnnotations returns the annotations Crossplane uses to manage
+// a resource that patches may not change on a composed resource.
+func DeniedComposedAnnotations(a *v1beta1.CrossplaneAnnotations) map[string]bool {
+	return annotationsWithPolicy(CrossplaneAnnotationPolicies(a, v1beta1.CrossplaneAnnotationPolicyAllow), v1beta1.CrossplaneAnnotationPolicyDeny)
+}
+
+func annotationsWithPolicy(policies map[string]v1beta1.CrossplaneAnnotationPolicy, p v1beta1.CrossplaneAnnotationPolicy) map[string]bool {
+	keys := make(map[string]bool, len(policies))
+	for k, kp := range policies {
+		if kp == p {
+			keys[k] = true
+		}
+	}
+	return keys
+}
+
+// PatchedAnnotation returns the key of the annotation the supplied to field
+// path patches, if it patches a single annotation.
+func PatchedAnnotation(path string) (string, bool) {
+	segs, err := fieldpath.Parse(path)
+	if err != nil || len(segs) != 3 || segs[0].Field != "metadata" || segs[1].Field != "annotations" {
+		return "", false
+	}
+	return segs[2].Field, true
+}
+
+// RemovedCrossplaneAnnotations returns the annotations Crossplane uses to
+// manage a resource that are in the supplied before annotations, but not the
+// supplied after annotations. Annotations in the supplied ignore set aren't
+// returned.
+func RemovedCrossplaneAnnotations(before, after map[string]string, ignore map[string]bool) []string {
+	var removed []string
+	for _, k := range crossplaneAnnotations {
+		if ignore[k] {
+			continue
+		}
+		if _, ok := before[k]; !ok {
+			continue
+		}
+		if _, ok := after[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	return removed
+}
+
+// RestoreAnnotations restores the supplied annotations of the supplied
+// composed resource to their supplied values from before it was patched. It
+// returns the keys of the annotations it restored, sorted.
+func RestoreAnnotations(cd *composed.Unstructured, before map[string]string, keys map[string]bool) []string {
+	a := cd.GetAnnotations()
+	var restored []string
+	for k := range keys {
+		bv, bok := before[k]
+		av, aok := a[k]
+		if bok == aok && bv == av {
+			continue
+		}
+		if bok {
+			if a == nil {
+				a = make(map[string]string, len(keys))
+			}
+			a[k] = bv
+		} else {
+			delete(a, k)
+		}
+		restored = append(restored, k)
+	}
+	if len(restored) == 0 {
+		return nil
+	}
+	cd.SetAnnotations(a)
+	sort.Strings(restored)
+	return restored
+}
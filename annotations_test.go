@@ -0,0 +1,144 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestRestoreAnnotations(t *testing.T) {
+	type args struct {
+		cd     *composed.Unstructured
+		before map[string]string
+		keys   map[string]bool
+	}
+	type want struct {
+		cd       *composed.Unstructured
+		restored []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Unchanged": {
+			reason: "Annotations that weren't changed shouldn't be restored.",
+			args: args{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"metadata": map[string]any{
+						"annotations": map[string]any{"crossplane.io/external-name": "cool"},
+					},
+				}}},
+				before: map[string]string{"crossplane.io/external-name": "cool"},
+				keys:   map[string]bool{"crossplane.io/external-name": true},
+			},
+			want: want{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"metadata": map[string]any{
+						"annotations": map[string]any{"crossplane.io/external-name": "cool"},
+					},
+				}}},
+			},
+		},
+		"ChangedAndAdded": {
+			reason: "Annotations that were changed should be restored, and annotations that were added should be removed.",
+			args: args{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"metadata": map[string]any{
+						"annotations": map[string]any{
+							"crossplane.io/external-name": "patched",
+							"crossplane.io/paused":        "true",
+							"note":                        "hi",
+						},
+					},
+				}}},
+				before: map[string]string{"crossplane.io/external-name": "cool"},
+				keys:   map[string]bool{"crossplane.io/external-name": true, "crossplane.io/paused": true},
+			},
+			want: want{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"metadata": map[string]any{
+						"annotations": map[string]any{
+							"crossplane.io/external-name": "cool",
+							"note":                        "hi",
+						},
+					},
+				}}},
+				restored: []string{"crossplane.io/external-name", "crossplane.io/paused"},
+			},
+		},
+		"Removed": {
+			reason: "Annotations that were removed should be restored.",
+			args: args{
+				cd:     &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}},
+				before: map[string]string{"crossplane.io/paused": "true"},
+				keys:   map[string]bool{"crossplane.io/paused": true},
+			},
+			want: want{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"metadata": map[string]any{
+						"annotations": map[string]any{"crossplane.io/paused": "true"},
+					},
+				}}},
+				restored: []string{"crossplane.io/paused"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			restored := RestoreAnnotations(tc.args.cd, tc.args.before, tc.args.keys)
+			if diff := cmp.Diff(tc.want.restored, restored); diff != "" {
+				t.Errorf("%s\nRestoreAnnotations(...): -want restored, +got restored:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("%s\nRestoreAnnotations(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRemovedCrossplaneAnnotations(t *testing.T) {
+	type args struct {
+		before map[string]string
+		after  map[string]string
+		ignore map[string]bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"Removed": {
+			reason: "Crossplane annotations that were removed should be returned, unless they're ignored.",
+			args: args{
+				before: map[string]string{"crossplane.io/external-name": "cool", "crossplane.io/paused": "true", "note": "hi"},
+				after:  map[string]string{"owner": "me"},
+				ignore: map[string]bool{"crossplane.io/paused": true},
+			},
+			want: []string{"crossplane.io/external-name"},
+		},
+		"Kept": {
+			reason: "Crossplane annotations that were kept shouldn't be returned.",
+			args: args{
+				before: map[string]string{"crossplane.io/external-name": "cool"},
+				after:  map[string]string{"crossplane.io/external-name": "other"},
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RemovedCrossplaneAnnotations(tc.args.before, tc.args.after, tc.args.ignore)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nRemovedCrossplaneAnnotations(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
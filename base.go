@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+// ParseBase parses the supplied base template. Fields whose value is null are
+// removed if stripNulls is true.
+func ParseBase(raw []byte, stripNulls bool) (*composed.Unstructured, error) {
+	cd := composed.New()
+	if err := json.Unmarshal(raw, cd); err != nil {
+		return nil, err
+	}
+	if stripNulls {
+		StripNulls(cd.Object)
+	}
+	return cd, nil
+}
+
+// StripNulls recursively removes all fields whose value is null from the
+// supplied object, including from objects in arrays.
+func StripNulls(obj map[string]any) {
+	for k, v := range obj {
+		if v == nil {
+			delete(obj, k)
+			continue
+		}
+		stripNulls(v)
+	}
+}
+
+func stripNulls(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		StripNulls(v)
+	case []any:
+		for _, e := range v {
+			stripNulls(e)
+		}
+	}
+}
+
+// NullFieldPaths returns the field paths of all fields of the supplied object
+// whose value is null, sorted. Nulls in arrays aren't included, because they
+// usually mean something.
+func NullFieldPaths(obj map[string]any) []string {
+	paths := []string{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, ev := range v {
+				p := k
+				if strings.ContainsAny(k, ".[]") {
+					p = fmt.Sprintf("[%s]", k)
+				}
+				if prefix != "" && !strings.HasPrefix(p, "[") {
+					p = "." + p
+				}
+				if ev == nil {
+					paths = append(paths, prefix+p)
+					continue
+				}
+				walk(prefix+p, ev)
+			}
+		case []any:
+			for i, ev := range v {
+				walk(fmt.Sprintf("%s[%d]", prefix, i), ev)
+			}
+		}
+	}
+	walk("", obj)
+	sort.Strings(paths)
+	return paths
+}
+
+// ValidateBase validates the supplied inline base template. It must be an
+// object with an apiVersion and kind.
+func ValidateBase(raw []byte) field.ErrorList {
+	errs := field.ErrorList{}
+	var obj any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return append(errs, field.Invalid(field.NewPath("base"), "", errors.Wrap(err, "cannot parse base template").Error()))
+	}
+	m, ok := obj.(map[string]any)
+	if !ok {
+		return append(errs, field.Invalid(field.NewPath("base"), "", fmt.Sprintf("base template must be an object, got %s", environmentFieldType(obj))))
+	}
+	for _, k := range []string{"apiVersion", "kind"} {
+		switch v := m[k].(type) {
+		case string:
+			if v == "" {
+				errs = append(errs, field.Required(field.NewPath("base", k), fmt.Sprintf("base template must have a %s", k)))
+			}
+		case nil:
+			errs = append(errs, field.Required(field.NewPath("base", k), fmt.Sprintf("base template must have a %s", k)))
+		default:
+			errs = append(errs, field.Invalid(field.NewPath("base", k), "", fmt.Sprintf("%s must be a string, got %s", k, environmentFieldType(v))))
+		}
+	}
+	return errs
+}
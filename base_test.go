@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestParseBase(t *testing.T) {
+	type args struct {
+		raw        []byte
+		stripNulls bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   map[string]any
+	}{
+		"KeepNulls": {
+			reason: "Null values should be kept if nulls aren't stripped.",
+			args: args{
+				raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","spec":{"region":null,"size":3}}`),
+			},
+			want: map[string]any{
+				"apiVersion": "example.org/v1",
+				"kind":       "Bucket",
+				"spec":       map[string]any{"region": nil, "size": int64(3)},
+			},
+		},
+		"StripNulls": {
+			reason: "Null values should be removed, including from objects in arrays, if nulls are stripped.",
+			args: args{
+				raw:        []byte(`{"apiVersion":"example.org/v1","kind":"Bucket","spec":{"region":null,"rules":[{"name":"a","action":null}]}}`),
+				stripNulls: true,
+			},
+			want: map[string]any{
+				"apiVersion": "example.org/v1",
+				"kind":       "Bucket",
+				"spec":       map[string]any{"rules": []any{map[string]any{"name": "a"}}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseBase(tc.args.raw, tc.args.stripNulls)
+			if err != nil {
+				t.Fatalf("%s\nParseBase(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got.Object); diff != "" {
+				t.Errorf("%s\nParseBase(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateBase(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		raw    []byte
+		want   field.ErrorList
+	}{
+		"Valid": {
+			reason: "A base template with an apiVersion and kind should be valid.",
+			raw:    []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`),
+			want:   field.ErrorList{},
+		},
+		"NotJSON": {
+			reason: "A base template that can't be parsed should be invalid.",
+			raw:    []byte(`{`),
+			want:   field.ErrorList{{Type: field.ErrorTypeInvalid, Field: "base"}},
+		},
+		"NotAnObject": {
+			reason: "A base template that isn't an object should be invalid.",
+			raw:    []byte(`["cool"]`),
+			want:   field.ErrorList{{Type: field.ErrorTypeInvalid, Field: "base"}},
+		},
+		"MissingKind": {
+			reason: "A base template without a kind should be invalid.",
+			raw:    []byte(`{"apiVersion":"example.org/v1","kind":null}`),
+			want:   field.ErrorList{{Type: field.ErrorTypeRequired, Field: "base.kind"}},
+		},
+		"APIVersionNotAString": {
+			reason: "A base template whose apiVersion isn't a string should be invalid.",
+			raw:    []byte(`{"apiVersion":1,"kind":"Bucket"}`),
+			want:   field.ErrorList{{Type: field.ErrorTypeInvalid, Field: "base.apiVersion"}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ValidateBase(tc.raw)
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateBase(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"regexp"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// regexpCacheSize is the number of compiled regular expressions to cache.
+const regexpCacheSize = 1024
+
+// regexps caches compiled regular expressions. Transforms and readiness checks
+// compile the same few expressions every time the Function is called.
+var regexps = newLRU[string, *regexp.Regexp](regexpCacheSize)
+
+// compileRegexp is like regexp.Compile, but returns a cached regular
+// expression if the supplied one was compiled before.
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Get(expr); ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Add(expr, re)
+	return re, nil
+}
+
+// An lru is a least recently used cache, safe for concurrent use.
+type lru[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{size: size, order: list.New(), items: make(map[K]*list.Element, size)}
+}
+
+// Get the value of the supplied key, if it's cached.
+func (c *lru[K, V]) Get(k K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[k]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry[K, V]).value, true //nolint:forcetypeassert // We only store *lruEntry.
+}
+
+// Add the supplied value to the cache, evicting the least recently used value
+// if the cache is full.
+func (c *lru[K, V]) Add(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[k]; ok {
+		e.Value.(*lruEntry[K, V]).value = v //nolint:forcetypeassert // We only store *lruEntry.
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[k] = c.order.PushFront(&lruEntry[K, V]{key: k, value: v})
+	if c.order.Len() <= c.size {
+		return
+	}
+	oldest := c.order.Back()
+	c.order.Remove(oldest)
+	delete(c.items, oldest.Value.(*lruEntry[K, V]).key) //nolint:forcetypeassert // We only store *lruEntry.
+}
+
+// Len returns the number of cached values.
+func (c *lru[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// A parsedInput is Function input that has been validated, and whose resource
+// templates have had their PatchSets and default patches resolved. It must not
+// be mutated, because it's shared by concurrent Function calls.
+type parsedInput struct {
+	input     *v1beta1.Resources
+	templates []v1beta1.ComposedTemplate
+
+	// Parsed inline base templates, by resource template name. Base
+	// templates that can't be parsed aren't included.
+	bases map[string]*composed.Unstructured
+
+	// Warnings about input that's valid, but probably doesn't do what its
+	// author intended.
+	warnings []string
+}
+
+func newParsedInput(input *v1beta1.Resources, cts []v1beta1.ComposedTemplate) *parsedInput {
+	pi := &parsedInput{input: input, templates: cts, bases: make(map[string]*composed.Unstructured, len(cts)), warnings: Analyze(input)}
+	for _, t := range cts {
+		if t.Base == nil {
+			continue
+		}
+		cd, err := ParseBase(t.Base.Raw, input.StripBaseNulls)
+		if err != nil {
+			continue
+		}
+		pi.bases[t.Name] = cd
+	}
+	return pi
+}
+
+// Base returns a copy of the supplied resource template's parsed inline base
+// template, if it has one that could be parsed.
+func (pi *parsedInput) Base(name string) (*composed.Unstructured, bool) {
+	cd, ok := pi.bases[name]
+	if !ok {
+		return nil, false
+	}
+	return cd.DeepCopy(), true
+}
+
+// An InputCache caches parsed Function input, by a hash of the input. A nil
+// *InputCache caches nothing.
+type InputCache struct {
+	inputs *lru[[sha256.Size]byte, *parsedInput]
+}
+
+// NewInputCache returns a cache of up to the supplied number of parsed inputs.
+func NewInputCache(size int) *InputCache {
+	return &InputCache{inputs: newLRU[[sha256.Size]byte, *parsedInput](size)}
+}
+
+// Get the parsed form of the supplied input, if it's cached.
+func (c *InputCache) Get(in *structpb.Struct) (*parsedInput, bool) {
+	if c == nil || in == nil {
+		return nil, false
+	}
+	k, ok := inputKey(in)
+	if !ok {
+		return nil, false
+	}
+	return c.inputs.Get(k)
+}
+
+// Add the parsed form of the supplied input to the cache.
+func (c *InputCache) Add(in *structpb.Struct, pi *parsedInput) {
+	if c == nil || in == nil {
+		return
+	}
+	k, ok := inputKey(in)
+	if !ok {
+		return
+	}
+	c.inputs.Add(k, pi)
+}
+
+func inputKey(in *structpb.Struct) ([sha256.Size]byte, bool) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(in)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256(b), true
+}
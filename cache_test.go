@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestLRU(t *testing.T) {
+	c := newLRU[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	// Getting a makes b the least recently used value.
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("c.Get(%q): want cached value", "a")
+	}
+	c.Add("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("c.Get(%q): want least recently used value to be evicted", "b")
+	}
+	for k, want := range map[string]int{"a": 1, "c": 3} {
+		got, ok := c.Get(k)
+		if !ok {
+			t.Errorf("c.Get(%q): want cached value", k)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("c.Get(%q): -want, +got:\n%s", k, diff)
+		}
+	}
+	if diff := cmp.Diff(2, c.Len()); diff != "" {
+		t.Errorf("c.Len(): -want, +got:\n%s", diff)
+	}
+}
+
+func TestRunFunctionWithInputCache(t *testing.T) {
+	req := &fnv1.RunFunctionRequest{
+		Input: resource.MustStructObject(&v1beta1.Resources{
+			PatchSets: []v1beta1.PatchSet{
+				{
+					Name: "widgets",
+					Patches: []v1beta1.PatchSetPatch{
+						{
+							Type: v1beta1.PatchTypeFromCompositeFieldPath,
+							Patch: v1beta1.Patch{
+								FromFieldPath: ptr.To[string]("spec.widgets"),
+								ToFieldPath:   ptr.To[string]("spec.watchers"),
+							},
+						},
+					},
+				},
+			},
+			Resources: []v1beta1.ComposedTemplate{
+				{
+					Name: "cool-resource",
+					Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+					Patches: []v1beta1.ComposedPatch{
+						{
+							Type:         v1beta1.PatchTypePatchSet,
+							PatchSetName: ptr.To[string]("widgets"),
+						},
+					},
+				},
+			},
+		}),
+		Observed: &fnv1.State{
+			Composite: &fnv1.Resource{
+				Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+			},
+		},
+	}
+
+	f := &Function{log: logging.NewNopLogger()}
+	want, err := f.RunFunction(context.Background(), req)
+	if err != nil {
+		t.Fatalf("f.RunFunction(...): %s", err)
+	}
+
+	cf := &Function{log: logging.NewNopLogger(), inputs: NewInputCache(1)}
+	for i := range 2 {
+		got, err := cf.RunFunction(context.Background(), req)
+		if err != nil {
+			t.Fatalf("cf.RunFunction(...): %s", err)
+		}
+		if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("cf.RunFunction(...) call %d: -want rsp without cache, +got rsp with cache:\n%s", i, diff)
+		}
+	}
+	if diff := cmp.Diff(1, cf.inputs.inputs.Len()); diff != "" {
+		t.Errorf("cf.inputs.Len(): -want, +got:\n%s", diff)
+	}
+}
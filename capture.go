@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+)
+
+// A CapturingRunner runs Functions, and records each RunFunctionRequest and
+// its response to a directory as YAML. Captures can be used to build test
+// fixtures, or attached to bug reports. Connection details, credentials, and
+// the data of Secrets are redacted, but captures may contain other sensitive
+// data, so only use this for local development.
+type CapturingRunner struct {
+	fnv1.UnimplementedFunctionRunnerServiceServer
+
+	wrapped fnv1.FunctionRunnerServiceServer
+	dir     string
+	log     logging.Logger
+
+	// captured is the number of requests captured so far. It's used to name
+	// capture files in the order requests were run.
+	captured atomic.Uint64
+}
+
+// NewCapturingRunner returns a runner that runs the supplied Function, and
+// records each request and response to the supplied directory.
+func NewCapturingRunner(fn fnv1.FunctionRunnerServiceServer, dir string, log logging.Logger) *CapturingRunner {
+	return &CapturingRunner{wrapped: fn, dir: dir, log: log}
+}
+
+// RunFunction runs the wrapped Function, and captures the request and its
+// response. Failing to capture them doesn't fail the Function.
+func (c *CapturingRunner) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
+	rsp, err := c.wrapped.RunFunction(ctx, req)
+	path, cerr := c.capture(req, rsp)
+	if cerr != nil {
+		c.log.Info("Cannot capture RunFunctionRequest and response", "error", cerr)
+		return rsp, err
+	}
+	c.log.Debug("Captured RunFunctionRequest and response", "path", path)
+	return rsp, err
+}
+
+// capture writes the supplied request and response to a new file, and returns
+// its path.
+func (c *CapturingRunner) capture(req *fnv1.RunFunctionRequest, rsp *fnv1.RunFunctionResponse) (string, error) {
+	capture := map[string]any{}
+	for k, m := range map[string]proto.Message{"request": RedactRequest(req), "response": RedactResponse(rsp)} {
+		if m == nil {
+			continue
+		}
+		j, err := protojson.Marshal(m)
+		if err != nil {
+			return "", errors.Wrapf(err, "cannot marshal %s to JSON", k)
+		}
+		var obj map[string]any
+		if err := json.Unmarshal(j, &obj); err != nil {
+			return "", errors.Wrapf(err, "cannot unmarshal %s JSON", k)
+		}
+		capture[k] = obj
+	}
+	b, err := sigsyaml.Marshal(capture)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot marshal capture to YAML")
+	}
+
+	name := req.GetObserved().GetComposite().GetResource().GetFields()["metadata"].GetStructValue().GetFields()["name"].GetStringValue()
+	if name == "" {
+		name = "unknown"
+	}
+	path := filepath.Join(c.dir, fmt.Sprintf("%06d-%s.yaml", c.captured.Add(1), name))
+	return path, errors.Wrapf(os.WriteFile(path, b, 0o600), "cannot write capture file %s", path)
+}
+
+// RedactRequest returns a copy of the supplied request with the values of
+// connection details, credentials, and Secret data redacted.
+func RedactRequest(req *fnv1.RunFunctionRequest) *fnv1.RunFunctionRequest {
+	if req == nil {
+		return nil
+	}
+	req = proto.Clone(req).(*fnv1.RunFunctionRequest) //nolint:forcetypeassert // Clone returns the type it's passed.
+	redactState(req.GetObserved())
+	redactState(req.GetDesired())
+	for _, rs := range req.GetExtraResources() {
+		for _, r := range rs.GetItems() {
+			redactSecret(r.GetResource())
+		}
+	}
+	for _, c := range req.GetCredentials() {
+		for k := range c.GetCredentialData().GetData() {
+			c.GetCredentialData().Data[k] = []byte(redacted)
+		}
+	}
+	return req
+}
+
+// RedactResponse returns a copy of the supplied response with the values of
+// connection details and Secret data redacted.
+func RedactResponse(rsp *fnv1.RunFunctionResponse) *fnv1.RunFunctionResponse {
+	if rsp == nil {
+		return nil
+	}
+	rsp = proto.Clone(rsp).(*fnv1.RunFunctionResponse) //nolint:forcetypeassert // Clone returns the type it's passed.
+	redactState(rsp.GetDesired())
+	return rsp
+}
+
+// redactState redacts the values of the connection details of the supplied
+// state's composite and composed resources, and the data of any of them
+// that's a Secret.
+func redactState(s *fnv1.State) {
+	rs := []*fnv1.Resource{s.GetComposite()}
+	for _, r := range s.GetResources() {
+		rs = append(rs, r)
+	}
+	for _, r := range rs {
+		for k := range r.GetConnectionDetails() {
+			r.ConnectionDetails[k] = []byte(redacted)
+		}
+		redactSecret(r.GetResource())
+	}
+}
+
+// redactSecret redacts the values of the data and stringData of the supplied
+// resource, if it's a Secret.
+func redactSecret(r *structpb.Struct) {
+	f := r.GetFields()
+	if f["apiVersion"].GetStringValue() != "v1" || f["kind"].GetStringValue() != "Secret" {
+		return
+	}
+	for _, field := range []string{"data", "stringData"} {
+		data := f[field].GetStructValue()
+		for k := range data.GetFields() {
+			data.Fields[k] = structpb.NewStringValue(redacted)
+		}
+	}
+}
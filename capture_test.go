@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+type fakeRunner struct {
+	fnv1.UnimplementedFunctionRunnerServiceServer
+
+	rsp *fnv1.RunFunctionResponse
+}
+
+func (r *fakeRunner) RunFunction(_ context.Context, _ *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
+	return r.rsp, nil
+}
+
+func TestCapturingRunner(t *testing.T) {
+	req := &fnv1.RunFunctionRequest{
+		Observed: &fnv1.State{
+			Composite: &fnv1.Resource{
+				Resource:          resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","metadata":{"name":"cool-xr"}}`),
+				ConnectionDetails: map[string][]byte{"password": []byte("secret")},
+			},
+			Resources: map[string]*fnv1.Resource{
+				"cool-secret": {Resource: resource.MustStructJSON(`{"apiVersion":"v1","kind":"Secret","data":{"password":"c2VjcmV0"}}`)},
+			},
+		},
+		ExtraResources: map[string]*fnv1.Resources{
+			"secrets": {Items: []*fnv1.Resource{
+				{Resource: resource.MustStructJSON(`{"apiVersion":"v1","kind":"Secret","data":{"token":"c2VjcmV0"}}`)},
+				{Resource: resource.MustStructJSON(`{"apiVersion":"v1","kind":"ConfigMap","data":{"region":"us-east-2"}}`)},
+			}},
+		},
+		Credentials: map[string]*fnv1.Credentials{
+			"cloud": {Source: &fnv1.Credentials_CredentialData{CredentialData: &fnv1.CredentialData{Data: map[string][]byte{"token": []byte("secret")}}}},
+		},
+	}
+	rsp := &fnv1.RunFunctionResponse{
+		Desired: &fnv1.State{
+			Resources: map[string]*fnv1.Resource{
+				"cool-resource": {ConnectionDetails: map[string][]byte{"password": []byte("secret")}},
+				"cool-secret":   {Resource: resource.MustStructJSON(`{"apiVersion":"v1","kind":"Secret","stringData":{"password":"secret"}}`)},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	c := NewCapturingRunner(&fakeRunner{rsp: rsp}, dir, logging.NewNopLogger())
+
+	got, err := c.RunFunction(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RunFunction(...): %s", err)
+	}
+	if diff := cmp.Diff(rsp, got, protocmp.Transform()); diff != "" {
+		t.Errorf("RunFunction(...): the wrapped Function's response should be returned unchanged: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff([]byte("secret"), req.GetObserved().GetComposite().GetConnectionDetails()["password"]); diff != "" {
+		t.Errorf("RunFunction(...): the request shouldn't be redacted in place: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff("c2VjcmV0", req.GetObserved().GetResources()["cool-secret"].GetResource().AsMap()["data"].(map[string]any)["password"]); diff != "" {
+		t.Errorf("RunFunction(...): the request's Secrets shouldn't be redacted in place: -want, +got:\n%s", diff)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "000001-cool-xr.yaml"))
+	if err != nil {
+		t.Fatalf("cannot read capture file: %s", err)
+	}
+	capture := map[string]any{}
+	if err := sigsyaml.Unmarshal(b, &capture); err != nil {
+		t.Fatalf("cannot unmarshal capture file: %s", err)
+	}
+
+	// Redacted values are base64 encoded, because they're bytes.
+	want := map[string]any{
+		"request": map[string]any{
+			"observed": map[string]any{
+				"composite": map[string]any{
+					"resource":          map[string]any{"apiVersion": "example.org/v1", "kind": "XR", "metadata": map[string]any{"name": "cool-xr"}},
+					"connectionDetails": map[string]any{"password": "UkVEQUNURUQ="},
+				},
+				"resources": map[string]any{
+					"cool-secret": map[string]any{
+						"resource": map[string]any{"apiVersion": "v1", "kind": "Secret", "data": map[string]any{"password": "REDACTED"}},
+					},
+				},
+			},
+			"extraResources": map[string]any{
+				"secrets": map[string]any{"items": []any{
+					map[string]any{"resource": map[string]any{"apiVersion": "v1", "kind": "Secret", "data": map[string]any{"token": "REDACTED"}}},
+					map[string]any{"resource": map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "data": map[string]any{"region": "us-east-2"}}},
+				}},
+			},
+			"credentials": map[string]any{
+				"cloud": map[string]any{"credentialData": map[string]any{"data": map[string]any{"token": "UkVEQUNURUQ="}}},
+			},
+		},
+		"response": map[string]any{
+			"desired": map[string]any{
+				"resources": map[string]any{
+					"cool-resource": map[string]any{"connectionDetails": map[string]any{"password": "UkVEQUNURUQ="}},
+					"cool-secret": map[string]any{
+						"resource": map[string]any{"apiVersion": "v1", "kind": "Secret", "stringData": map[string]any{"password": "REDACTED"}},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, capture); diff != "" {
+		t.Errorf("RunFunction(...): -want capture, +got capture:\n%s", diff)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+// Labels Crossplane adds to a composite resource that was created by a claim.
+const (
+	labelKeyClaimName      = "crossplane.io/claim-name"
+	labelKeyClaimNamespace = "crossplane.io/claim-namespace"
+)
+
+// ClaimObject returns an object that FromClaimFieldPath patches can read the
+// supplied composite resource's claim from. It has the claim's apiVersion,
+// kind, name, and namespace, read from the composite resource's
+// spec.claimRef, or from its claim labels if it has no claim reference. Fields
+// that aren't known are omitted, so the object is empty if the composite
+// resource wasn't created by a claim.
+func ClaimObject(xr *composite.Unstructured) *unstructured.Unstructured {
+	o := &unstructured.Unstructured{Object: map[string]any{}}
+	set := func(key, value string) {
+		if value != "" {
+			o.Object[key] = value
+		}
+	}
+
+	ref, _ := getInEitherLayout("spec.claimRef", fieldpath.Pave(xr.Object).GetStringObject)
+	set("apiVersion", ref["apiVersion"])
+	set("kind", ref["kind"])
+	set("name", ref["name"])
+	set("namespace", ref["namespace"])
+
+	if _, ok := o.Object["name"]; !ok {
+		set("name", xr.GetLabels()[labelKeyClaimName])
+	}
+	if _, ok := o.Object["namespace"]; !ok {
+		set("namespace", xr.GetLabels()[labelKeyClaimNamespace])
+	}
+
+	return o
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+func TestClaimObject(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		xr     map[string]any
+		want   map[string]any
+	}{
+		"ClaimRef": {
+			reason: "The claim should be read from the composite resource's claim reference.",
+			xr: map[string]any{
+				"metadata": map[string]any{
+					"labels": map[string]any{
+						labelKeyClaimName: "label-name",
+					},
+				},
+				"spec": map[string]any{
+					"claimRef": map[string]any{
+						"apiVersion": "example.org/v1",
+						"kind":       "Claim",
+						"name":       "cool-claim",
+						"namespace":  "default",
+					},
+				},
+			},
+			want: map[string]any{
+				"apiVersion": "example.org/v1",
+				"kind":       "Claim",
+				"name":       "cool-claim",
+				"namespace":  "default",
+			},
+		},
+		"Labels": {
+			reason: "The claim's name and namespace should be read from labels if the composite resource has no claim reference.",
+			xr: map[string]any{
+				"metadata": map[string]any{
+					"labels": map[string]any{
+						labelKeyClaimName:      "cool-claim",
+						labelKeyClaimNamespace: "default",
+					},
+				},
+			},
+			want: map[string]any{
+				"name":      "cool-claim",
+				"namespace": "default",
+			},
+		},
+		"NoClaim": {
+			reason: "The object should be empty if the composite resource wasn't created by a claim.",
+			xr: map[string]any{
+				"metadata": map[string]any{"name": "cool-xr"},
+			},
+			want: map[string]any{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ClaimObject(&composite.Unstructured{Unstructured: unstructured.Unstructured{Object: tc.xr}})
+			if diff := cmp.Diff(tc.want, got.Object); diff != "" {
+				t.Errorf("%s\nClaimObject(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
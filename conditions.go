@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Reasons of status conditions.
+const (
+	reasonResourcesReady    = "ResourcesReady"
+	reasonResourcesNotReady = "ResourcesNotReady"
+)
+
+// IsReservedConditionType returns true if the supplied condition type is
+// managed by Crossplane, and so can't be a status condition.
+func IsReservedConditionType(t string) bool {
+	return t == string(xpv1.TypeReady) || t == string(xpv1.TypeSynced)
+}
+
+// SetStatusConditions sets the supplied status conditions on the supplied
+// desired composite resource. A condition is True if the names of ready
+// resource templates include all of its resources. A condition's
+// lastTransitionTime is that of the observed composite resource's condition
+// unless its status changed, in which case it's the supplied time.
+func SetStatusConditions(dxr, oxr *composite.Unstructured, scs []v1beta1.StatusCondition, ready map[string]bool, now time.Time) {
+	for _, sc := range scs {
+		var unready []string
+		for _, name := range sc.Resources {
+			if !ready[name] {
+				unready = append(unready, name)
+			}
+		}
+
+		c := xpv1.Condition{
+			Type:               xpv1.ConditionType(sc.Type),
+			Status:             corev1.ConditionTrue,
+			Reason:             reasonResourcesReady,
+			ObservedGeneration: oxr.GetGeneration(),
+		}
+		if len(unready) > 0 {
+			c.Status = corev1.ConditionFalse
+			c.Reason = reasonResourcesNotReady
+			c.Message = fmt.Sprintf("composed resources of resource templates %s aren't ready", strings.Join(unready, ", "))
+		}
+
+		c.LastTransitionTime = metav1.NewTime(now)
+		if o := oxr.GetCondition(c.Type); o.Status == c.Status {
+			c.LastTransitionTime = o.LastTransitionTime
+		}
+		dxr.SetConditions(c)
+	}
+}
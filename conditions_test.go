@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestSetStatusConditions(t *testing.T) {
+	then := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	scs := []v1beta1.StatusCondition{
+		{Type: "NetworkReady", Resources: []string{"vpc", "subnet"}},
+		{Type: "DatabaseReady", Resources: []string{"db"}},
+	}
+
+	type args struct {
+		observed []xpv1.Condition
+		ready    map[string]bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []xpv1.Condition
+	}{
+		"New": {
+			reason: "Conditions the observed XR doesn't have should transition now.",
+			args: args{
+				ready: map[string]bool{"vpc": true, "subnet": false},
+			},
+			want: []xpv1.Condition{
+				{
+					Type:               "NetworkReady",
+					Status:             corev1.ConditionFalse,
+					Reason:             reasonResourcesNotReady,
+					Message:            "composed resources of resource templates subnet aren't ready",
+					LastTransitionTime: metav1.NewTime(now),
+				},
+				{
+					Type:               "DatabaseReady",
+					Status:             corev1.ConditionFalse,
+					Reason:             reasonResourcesNotReady,
+					Message:            "composed resources of resource templates db aren't ready",
+					LastTransitionTime: metav1.NewTime(now),
+				},
+			},
+		},
+		"Transitions": {
+			reason: "Only conditions whose status changed should transition now.",
+			args: args{
+				observed: []xpv1.Condition{
+					{Type: "NetworkReady", Status: corev1.ConditionFalse, Reason: reasonResourcesNotReady, LastTransitionTime: then},
+					{Type: "DatabaseReady", Status: corev1.ConditionTrue, Reason: reasonResourcesReady, LastTransitionTime: then},
+				},
+				ready: map[string]bool{"vpc": true, "subnet": true, "db": true},
+			},
+			want: []xpv1.Condition{
+				{
+					Type:               "NetworkReady",
+					Status:             corev1.ConditionTrue,
+					Reason:             reasonResourcesReady,
+					LastTransitionTime: metav1.NewTime(now),
+				},
+				{
+					Type:               "DatabaseReady",
+					Status:             corev1.ConditionTrue,
+					Reason:             reasonResourcesReady,
+					LastTransitionTime: then,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			oxr := composite.New()
+			oxr.SetConditions(tc.args.observed...)
+			dxr := composite.New()
+
+			SetStatusConditions(dxr, oxr, scs, tc.args.ready, now)
+
+			got := make([]xpv1.Condition, 0, len(scs))
+			for _, sc := range scs {
+				got = append(got, dxr.GetCondition(xpv1.ConditionType(sc.Type)))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSetStatusConditions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
 
@@ -9,72 +14,264 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
+	fnresource "github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
+// AnnotationKeyPendingConnectionDetails is the composite resource annotation
+// that lists the FromStatusFieldPath connection details whose field path
+// doesn't exist yet.
+const AnnotationKeyPendingConnectionDetails = "pt.fn.crossplane.io/pending-connection-details"
+
+const (
+	errFmtConnectionDetailTransforms = "cannot apply transforms to connection detail %q"
+	errFmtConnectionDetailValue      = "cannot encode value of connection detail %q"
+	errFmtConnectionDetailCombine    = "cannot combine connection detail %q"
+)
+
 // ConnectionDetailsExtractor extracts the connection details of a resource.
 type ConnectionDetailsExtractor interface {
 	// ExtractConnection of the supplied resource.
-	ExtractConnection(cd resource.Composed, conn managed.ConnectionDetails, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error)
+	ExtractConnection(cd resource.Composed, conn managed.ConnectionDetails, env runtime.Object, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error)
 }
 
 // A ConnectionDetailsExtractorFn is a function that satisfies
 // ConnectionDetailsExtractor.
-type ConnectionDetailsExtractorFn func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error)
+type ConnectionDetailsExtractorFn func(cd resource.Composed, conn managed.ConnectionDetails, env runtime.Object, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error)
 
 // ExtractConnection of the supplied resource.
-func (fn ConnectionDetailsExtractorFn) ExtractConnection(cd resource.Composed, conn managed.ConnectionDetails, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
-	return fn(cd, conn, cfg...)
+func (fn ConnectionDetailsExtractorFn) ExtractConnection(cd resource.Composed, conn managed.ConnectionDetails, env runtime.Object, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
+	return fn(cd, conn, env, cfg...)
 }
 
 // ExtractConnectionDetails extracts XR connection details from the supplied
-// composed resource. If no ExtractConfigs are supplied no connection details
-// will be returned.
-func ExtractConnectionDetails(cd resource.Composed, data managed.ConnectionDetails, cfgs ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
+// composed resource, or from the supplied Composition environment. If no
+// ExtractConfigs are supplied no connection details will be returned.
+func ExtractConnectionDetails(cd resource.Composed, data managed.ConnectionDetails, env runtime.Object, cfgs ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
 	out := map[string][]byte{}
 	for _, cfg := range cfgs {
 		if err := ValidateConnectionDetail(cfg); err != nil {
 			return nil, errors.Wrap(err, "invalid")
 		}
+		var v any
 		switch cfg.Type {
-		case v1beta1.ConnectionDetailTypeFromValue:
-			out[cfg.Name] = []byte(*cfg.Value)
-		case v1beta1.ConnectionDetailTypeFromConnectionSecretKey:
-			if data[*cfg.FromConnectionSecretKey] == nil {
-				// We don't consider this an error because it's possible the
-				// key will still be written at some point in the future.
+		case v1beta1.ConnectionDetailTypeCombine:
+			vars := make([]any, len(cfg.Combine.Variables))
+			ok := true
+			for i, cv := range cfg.Combine.Variables {
+				// If any variable is missing we don't combine. A format
+				// string like '%s:%s' would otherwise produce a bogus value.
+				if vars[i], ok = extractConnectionValue(cd, data, env, cv.Type, cv.FromConnectionSecretKey, cv.FromFieldPath, cv.Value); !ok {
+					break
+				}
+			}
+			if !ok {
 				continue
 			}
-			out[cfg.Name] = data[*cfg.FromConnectionSecretKey]
-		case v1beta1.ConnectionDetailTypeFromFieldPath:
-			// Note we're checking that the error _is_ nil. If we hit an error
-			// we silently avoid including this connection secret. It's possible
-			// the path will start existing with a valid value in future.
-			if b, err := fromFieldPath(cd, *cfg.FromFieldPath); err == nil {
-				out[cfg.Name] = b
+			cb, err := Combine(v1beta1.Combine{Strategy: cfg.Combine.Strategy, String: cfg.Combine.String}, vars)
+			if err != nil {
+				return nil, errors.Wrapf(err, errFmtConnectionDetailCombine, cfg.Name)
+			}
+			v = cb
+		default:
+			in, ok := extractConnectionValue(cd, data, env, cfg.Type, cfg.FromConnectionSecretKey, cfg.FromFieldPath, cfg.Value)
+			if !ok {
+				continue
 			}
+			v = in
+		}
+
+		v, err := ResolveTransforms(cfg.Transforms, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConnectionDetailTransforms, cfg.Name)
+		}
+		b, err := toConnectionValue(v, cfg.GetEncoding())
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConnectionDetailValue, cfg.Name)
 		}
+		out[cfg.Name] = b
 	}
 	return out, nil
 }
 
-// fromFieldPath tries to read the value from the supplied field path first as a
-// plain string. If this fails, it falls back to reading it as JSON.
-func fromFieldPath(from runtime.Object, path string) ([]byte, error) {
-	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+// ExtractCompositeConnectionDetails extracts the supplied top-level XR
+// connection details. Connection details with a fromResource are extracted
+// from the observed composed resource of the named resource template, and
+// are skipped if it doesn't exist yet. Others are extracted from the supplied
+// Composition environment.
+func ExtractCompositeConnectionDetails(observed map[fnresource.Name]fnresource.ObservedComposed, env runtime.Object, cfgs ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
+	out := managed.ConnectionDetails{}
+	for _, cfg := range cfgs {
+		var cd resource.Composed
+		var data managed.ConnectionDetails
+		if cfg.FromResource != nil {
+			ocd, ok := observed[fnresource.Name(*cfg.FromResource)]
+			if !ok {
+				continue
+			}
+			cd, data = ocd.Resource, managed.ConnectionDetails(ocd.ConnectionDetails)
+		}
+		conn, err := ExtractConnectionDetails(cd, data, env, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range conn {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// extractConnectionValue extracts a single connection detail value of the
+// supplied type. It returns false if the value doesn't exist (yet).
+func extractConnectionValue(cd resource.Composed, data managed.ConnectionDetails, env runtime.Object, t v1beta1.ConnectionDetailType, key, path, value *string) (any, bool) {
+	switch t { //nolint:exhaustive // Combine is handled by our caller.
+	case v1beta1.ConnectionDetailTypeFromValue:
+		return *value, true
+	case v1beta1.ConnectionDetailTypeFromConnectionSecretKey:
+		// We don't consider a missing key an error because it's possible
+		// the key will still be written at some point in the future.
+		if data[*key] == nil {
+			return nil, false
+		}
+		return string(data[*key]), true
+	case v1beta1.ConnectionDetailTypeFromFieldPath, v1beta1.ConnectionDetailTypeFromStatusFieldPath:
+		// Note we're checking that the error _is_ nil. If we hit an error
+		// we silently avoid including this connection secret. It's possible
+		// the path will start existing with a valid value in future.
+		in, err := fromFieldPath(cd, *path)
+		return in, err == nil
+	case v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath:
+		// As above, a value that doesn't exist in the environment yet
+		// may exist in future.
+		if env == nil {
+			return nil, false
+		}
+		in, err := fromFieldPath(env, *path)
+		return in, err == nil
+	}
+	return nil, false
+}
+
+// PendingConnectionDetails returns the names of the supplied FromStatusFieldPath
+// connection details whose field path doesn't exist (yet) in the supplied
+// observed composed resource, which is nil if it doesn't exist. It also returns
+// true if any of them is required.
+func PendingConnectionDetails(cd resource.Composed, cfgs ...v1beta1.ConnectionDetail) ([]string, bool) {
+	var pending []string
+	required := false
+	for _, cfg := range cfgs {
+		if cfg.Type != v1beta1.ConnectionDetailTypeFromStatusFieldPath || cfg.FromFieldPath == nil {
+			continue
+		}
+		if cd != nil {
+			if _, err := fromFieldPath(cd, *cfg.FromFieldPath); err == nil {
+				continue
+			}
+		}
+		pending = append(pending, cfg.Name)
+		if cfg.Policy.GetFromFieldPathPolicy() == v1beta1.FromFieldPathPolicyRequired {
+			required = true
+		}
+	}
+	return pending, required
+}
+
+// AnnotatePendingConnectionDetails annotates the supplied composite resource
+// with the supplied pending connection details, sorted and deduplicated.
+func AnnotatePendingConnectionDetails(xr *composite.Unstructured, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	names = slices.Clone(names)
+	slices.Sort(names)
+	names = slices.Compact(names)
+	a := xr.GetAnnotations()
+	if a == nil {
+		a = make(map[string]string, 1)
+	}
+	a[AnnotationKeyPendingConnectionDetails] = strings.Join(names, ",")
+	xr.SetAnnotations(a)
+}
+
+// ApplyConnectionSecretRef sets spec.writeConnectionSecretToRef of the
+// supplied desired composed resource. Variables in its name and namespace are
+// replaced with values read from the supplied composite resource.
+func ApplyConnectionSecretRef(ref *v1beta1.ConnectionSecretRef, xr *composite.Unstructured, dcd *composed.Unstructured) error {
+	objs := map[string]map[string]any{"xr": xr.Object}
+	name, err := renderVariables(ref.Name, objs)
 	if err != nil {
-		return nil, err
+		return errors.Wrap(err, "cannot render connection secret name")
 	}
+	out := map[string]any{"name": name}
+	if ref.Namespace != nil {
+		ns, err := renderVariables(*ref.Namespace, objs)
+		if err != nil {
+			return errors.Wrap(err, "cannot render connection secret namespace")
+		}
+		out["namespace"] = ns
+	}
+	return errors.Wrap(dcd.SetValue("spec.writeConnectionSecretToRef", out), "cannot set connection secret reference")
+}
+
+// supportsConnectionDetails returns true if the supplied composite resource
+// supports connection details. Legacy composite resources do. Crossplane v2
+// composite resources, which have a spec.crossplane field, don't.
+func supportsConnectionDetails(xr *composite.Unstructured) bool {
+	return !IsV2Layout(xr)
+}
 
-	str, err := fieldpath.Pave(fromMap).GetString(path)
-	if err == nil {
-		return []byte(str), nil
+// UnsupportedConnectionDetails returns the fields of the supplied input and
+// composite resource that configure connection details, if the composite
+// resource doesn't support them.
+func UnsupportedConnectionDetails(in *v1beta1.Resources, xr *composite.Unstructured) []string {
+	if supportsConnectionDetails(xr) {
+		return nil
+	}
+	var fields []string
+	if len(in.ConnectionDetails) > 0 {
+		fields = append(fields, "connectionDetails")
+	}
+	for i, t := range in.Resources {
+		if len(t.ConnectionDetails) > 0 {
+			fields = append(fields, fmt.Sprintf("resources[%d].connectionDetails", i))
+		}
 	}
+	if xr.GetWriteConnectionSecretToReference() != nil {
+		fields = append(fields, "composite resource spec.writeConnectionSecretToRef")
+	}
+	return fields
+}
 
-	in, err := fieldpath.Pave(fromMap).GetValue(path)
+// fromFieldPath reads the value at the supplied field path.
+func fromFieldPath(from runtime.Object, path string) (any, error) {
+	fromMap, err := unstructuredContent(from)
 	if err != nil {
 		return nil, err
 	}
+	return fieldpath.Pave(fromMap).GetValue(path)
+}
 
-	return json.Marshal(in)
+// toConnectionValue returns the supplied value serialized using the supplied
+// encoding. By default it returns the value as a plain string if it is one,
+// and otherwise falls back to encoding it as JSON.
+func toConnectionValue(v any, e v1beta1.ConnectionDetailEncoding) ([]byte, error) {
+	switch e {
+	case v1beta1.ConnectionDetailEncodingJSON:
+		return json.Marshal(v)
+	case v1beta1.ConnectionDetailEncodingBase64:
+		b, err := toConnectionValue(v, v1beta1.ConnectionDetailEncodingNone)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(base64.StdEncoding.EncodeToString(b)), nil
+	case v1beta1.ConnectionDetailEncodingNone:
+	}
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
@@ -14,6 +16,10 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
+	fnresource "github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
@@ -21,6 +27,7 @@ func TestExtractConnectionDetails(t *testing.T) {
 	type args struct {
 		cd   resource.Composed
 		data managed.ConnectionDetails
+		env  runtime.Object
 		cfg  []v1beta1.ConnectionDetail
 	}
 	type want struct {
@@ -103,10 +110,212 @@ func TestExtractConnectionDetails(t *testing.T) {
 				},
 			},
 		},
+		"Transforms": {
+			reason: "Should apply transforms to extracted connection details",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				},
+				data: managed.ConnectionDetails{
+					"encoded": []byte("c2VjcmV0"),
+				},
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "decoded",
+						FromConnectionSecretKey: ptr.To[string]("encoded"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeString,
+							String: &v1beta1.StringTransform{
+								Type:    v1beta1.StringTransformTypeConvert,
+								Convert: ptr.To(v1beta1.StringConversionTypeFromBase64),
+							},
+						}},
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:          "url",
+						FromFieldPath: ptr.To[string]("objectMeta.name"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeString,
+							String: &v1beta1.StringTransform{
+								Type:   v1beta1.StringTransformTypeFormat,
+								Format: ptr.To[string]("jdbc:postgresql://%s:5432/db"),
+							},
+						}},
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"decoded": []byte("secret"),
+					"url":     []byte("jdbc:postgresql://test:5432/db"),
+				},
+			},
+		},
+		"Combine": {
+			reason: "Should combine several sources into a single connection detail",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				},
+				data: managed.ConnectionDetails{
+					"host": []byte("db.example.org"),
+				},
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type: v1beta1.ConnectionDetailTypeCombine,
+						Name: "connectionString",
+						Combine: &v1beta1.ConnectionDetailCombine{
+							Variables: []v1beta1.ConnectionDetailCombineVariable{
+								{
+									Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+									FromConnectionSecretKey: ptr.To[string]("host"),
+								},
+								{
+									Type:  v1beta1.ConnectionDetailTypeFromValue,
+									Value: ptr.To[string]("5432"),
+								},
+								{
+									Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
+									FromFieldPath: ptr.To[string]("objectMeta.name"),
+								},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "postgres://%s:%s/%s"},
+						},
+					},
+					{
+						Type: v1beta1.ConnectionDetailTypeCombine,
+						Name: "missing",
+						Combine: &v1beta1.ConnectionDetailCombine{
+							Variables: []v1beta1.ConnectionDetailCombineVariable{
+								{
+									Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+									FromConnectionSecretKey: ptr.To[string]("host"),
+								},
+								{
+									Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+									FromConnectionSecretKey: ptr.To[string]("port"),
+								},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s:%s"},
+						},
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"connectionString": []byte("postgres://db.example.org:5432/test"),
+				},
+			},
+		},
+		"TransformError": {
+			reason: "Should return an error if a transform fails",
+			args: args{
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:  v1beta1.ConnectionDetailTypeFromValue,
+						Name:  "port",
+						Value: ptr.To[string]("5432"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeMath,
+							Math: &v1beta1.MathTransform{
+								Type:     v1beta1.MathTransformTypeMultiply,
+								Multiply: ptr.To[int64](2),
+							},
+						}},
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtMathInputNonNumber, "5432"), errFmtTransformTypeFailed, v1beta1.TransformTypeMath), errFmtTransformAtIndex, 0, "math Multiply", "5432"), errFmtConnectionDetailTransforms, "port"),
+			},
+		},
+		"FromEnvironmentFieldPath": {
+			reason: "Should extract connection details from the environment",
+			args: args{
+				env: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "internal.crossplane.io/v1alpha1",
+					"kind":       "Environment",
+					"db": map[string]any{
+						"host": "db.example.org",
+					},
+				}},
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+						Name:          "host",
+						FromFieldPath: ptr.To[string]("db.host"),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+						Name:          "port",
+						FromFieldPath: ptr.To[string]("db.port"),
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"host": []byte("db.example.org"),
+				},
+			},
+		},
+		"Encoding": {
+			reason: "Should serialize values using each connection detail's encoding",
+			args: args{
+				env: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "internal.crossplane.io/v1alpha1",
+					"kind":       "Environment",
+					"db": map[string]any{
+						"host": "db.example.org",
+						"port": int64(5432),
+					},
+				}},
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+						Name:          "db",
+						FromFieldPath: ptr.To[string]("db"),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+						Name:          "host-base64",
+						FromFieldPath: ptr.To[string]("db.host"),
+						Encoding:      ptr.To(v1beta1.ConnectionDetailEncodingBase64),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+						Name:          "host-json",
+						FromFieldPath: ptr.To[string]("db.host"),
+						Encoding:      ptr.To(v1beta1.ConnectionDetailEncodingJSON),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+						Name:          "port-json",
+						FromFieldPath: ptr.To[string]("db.port"),
+						Encoding:      ptr.To(v1beta1.ConnectionDetailEncodingJSON),
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"db":          []byte(`{"host":"db.example.org","port":5432}`),
+					"host-base64": []byte("ZGIuZXhhbXBsZS5vcmc="),
+					"host-json":   []byte(`"db.example.org"`),
+					"port-json":   []byte("5432"),
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			conn, err := ExtractConnectionDetails(tc.args.cd, tc.args.data, tc.args.cfg...)
+			conn, err := ExtractConnectionDetails(tc.args.cd, tc.args.data, tc.args.env, tc.args.cfg...)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("\n%s\nExtractConnectionDetails(...): -want, +got:\n%s", tc.reason, diff)
 			}
@@ -116,3 +325,299 @@ func TestExtractConnectionDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractCompositeConnectionDetails(t *testing.T) {
+	observed := map[fnresource.Name]fnresource.ObservedComposed{
+		"database": {
+			Resource:          &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{"status": map[string]any{"endpoint": "db.example.org"}}}},
+			ConnectionDetails: fnresource.ConnectionDetails{"password": []byte("secret")},
+		},
+		"cache": {
+			Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{"status": map[string]any{"endpoint": "cache.example.org"}}}},
+		},
+	}
+	env := &unstructured.Unstructured{Object: map[string]any{"port": "5432"}}
+
+	type want struct {
+		conn managed.ConnectionDetails
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		cfg    []v1beta1.ConnectionDetail
+		want   want
+	}{
+		"SeveralResources": {
+			reason: "Connection details should be extracted from the composed resource each names, or from the environment.",
+			cfg: []v1beta1.ConnectionDetail{
+				{Name: "db-password", Type: v1beta1.ConnectionDetailTypeFromConnectionSecretKey, FromConnectionSecretKey: ptr.To("password"), FromResource: ptr.To("database")},
+				{Name: "db-endpoint", Type: v1beta1.ConnectionDetailTypeFromFieldPath, FromFieldPath: ptr.To("status.endpoint"), FromResource: ptr.To("database")},
+				{Name: "cache-endpoint", Type: v1beta1.ConnectionDetailTypeFromFieldPath, FromFieldPath: ptr.To("status.endpoint"), FromResource: ptr.To("cache")},
+				{Name: "port", Type: v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath, FromFieldPath: ptr.To("port")},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"db-password":    []byte("secret"),
+					"db-endpoint":    []byte("db.example.org"),
+					"cache-endpoint": []byte("cache.example.org"),
+					"port":           []byte("5432"),
+				},
+			},
+		},
+		"ResourceNotObserved": {
+			reason: "Connection details from a composed resource that doesn't exist yet should be skipped.",
+			cfg: []v1beta1.ConnectionDetail{
+				{Name: "queue-url", Type: v1beta1.ConnectionDetailTypeFromFieldPath, FromFieldPath: ptr.To("status.url"), FromResource: ptr.To("queue")},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			conn, err := ExtractCompositeConnectionDetails(observed, env, tc.cfg...)
+			if diff := cmp.Diff(tc.want.conn, conn); diff != "" {
+				t.Errorf("\n%s\nExtractCompositeConnectionDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExtractCompositeConnectionDetails(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPendingConnectionDetails(t *testing.T) {
+	cd := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{
+			"atProvider": map[string]any{
+				"endpoint": "example.org",
+			},
+		},
+	}}}
+
+	type args struct {
+		cd  resource.Composed
+		cfg []v1beta1.ConnectionDetail
+	}
+	type want struct {
+		pending  []string
+		required bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoneMissing": {
+			reason: "A from status field path connection detail whose field path exists shouldn't be pending.",
+			args: args{
+				cd: cd,
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+						Name:          "endpoint",
+						FromFieldPath: ptr.To("status.atProvider.endpoint"),
+						Policy:        &v1beta1.ConnectionDetailPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)},
+					},
+				},
+			},
+			want: want{},
+		},
+		"OptionalMissing": {
+			reason: "A missing optional from status field path connection detail should be pending, but not required. Other types should be ignored.",
+			args: args{
+				cd: cd,
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:          "port",
+						FromFieldPath: ptr.To("status.atProvider.port"),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+						Name:          "username",
+						FromFieldPath: ptr.To("status.atProvider.username"),
+					},
+				},
+			},
+			want: want{
+				pending: []string{"username"},
+			},
+		},
+		"RequiredMissing": {
+			reason: "A missing required from status field path connection detail should be pending and required.",
+			args: args{
+				cd: cd,
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+						Name:          "username",
+						FromFieldPath: ptr.To("status.atProvider.username"),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+						Name:          "password",
+						FromFieldPath: ptr.To("status.atProvider.password"),
+						Policy:        &v1beta1.ConnectionDetailPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)},
+					},
+				},
+			},
+			want: want{
+				pending:  []string{"username", "password"},
+				required: true,
+			},
+		},
+		"ResourceDoesNotExist": {
+			reason: "Every from status field path connection detail should be pending if the composed resource doesn't exist yet.",
+			args: args{
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+						Name:          "endpoint",
+						FromFieldPath: ptr.To("status.atProvider.endpoint"),
+					},
+				},
+			},
+			want: want{
+				pending: []string{"endpoint"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pending, required := PendingConnectionDetails(tc.args.cd, tc.args.cfg...)
+			if diff := cmp.Diff(tc.want.pending, pending); diff != "" {
+				t.Errorf("\n%s\nPendingConnectionDetails(...): -want pending, +got pending:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.required, required); diff != "" {
+				t.Errorf("\n%s\nPendingConnectionDetails(...): -want required, +got required:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUnsupportedConnectionDetails(t *testing.T) {
+	in := &v1beta1.Resources{
+		ConnectionDetails: []v1beta1.ConnectionDetail{{Name: "url"}},
+		Resources: []v1beta1.ComposedTemplate{
+			{Name: "a"},
+			{Name: "b", ConnectionDetails: []v1beta1.ConnectionDetail{{Name: "password"}}},
+		},
+	}
+
+	type args struct {
+		in *v1beta1.Resources
+		xr *composite.Unstructured
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"LegacyXR": {
+			reason: "Legacy composite resources support connection details.",
+			args: args{
+				in: in,
+				xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{
+						"writeConnectionSecretToRef": map[string]any{"name": "cool-secret", "namespace": "default"},
+					},
+				}}},
+			},
+		},
+		"V2XRWithoutConnectionDetails": {
+			reason: "A Crossplane v2 composite resource is fine if nothing configures connection details.",
+			args: args{
+				in: &v1beta1.Resources{Resources: []v1beta1.ComposedTemplate{{Name: "a"}}},
+				xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{"crossplane": map[string]any{}},
+				}}},
+			},
+		},
+		"V2XRWithConnectionDetails": {
+			reason: "Every field that configures connection details of a Crossplane v2 composite resource should be returned.",
+			args: args{
+				in: in,
+				xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{
+						"crossplane":                 map[string]any{},
+						"writeConnectionSecretToRef": map[string]any{"name": "cool-secret"},
+					},
+				}}},
+			},
+			want: []string{"connectionDetails", "resources[1].connectionDetails", "composite resource spec.writeConnectionSecretToRef"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := UnsupportedConnectionDetails(tc.args.in, tc.args.xr)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nUnsupportedConnectionDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyConnectionSecretRef(t *testing.T) {
+	xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"uid": "cool-uid"},
+		"spec":     map[string]any{"namespace": "cool-ns"},
+	}}}
+
+	type want struct {
+		ref map[string]any
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ref    *v1beta1.ConnectionSecretRef
+		want   want
+	}{
+		"NameOnly": {
+			reason: "A connection secret reference without a namespace should only set a name.",
+			ref:    &v1beta1.ConnectionSecretRef{Name: "{xr.metadata.uid}-db"},
+			want: want{
+				ref: map[string]any{"name": "cool-uid-db"},
+			},
+		},
+		"NameAndNamespace": {
+			reason: "Variables in the name and namespace should be rendered.",
+			ref:    &v1beta1.ConnectionSecretRef{Name: "db", Namespace: ptr.To("{xr.spec.namespace}")},
+			want: want{
+				ref: map[string]any{"name": "db", "namespace": "cool-ns"},
+			},
+		},
+		"MissingFieldPath": {
+			reason: "A variable whose field path doesn't exist should return an error.",
+			ref:    &v1beta1.ConnectionSecretRef{Name: "{xr.spec.secretName}"},
+			want: want{
+				err: cmpopts.AnyError,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dcd := composed.New()
+			err := ApplyConnectionSecretRef(tc.ref, xr, dcd)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("\n%s\nApplyConnectionSecretRef(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if tc.want.err != nil {
+				return
+			}
+			got, _ := dcd.GetValue("spec.writeConnectionSecretToRef")
+			if diff := cmp.Diff(tc.want.ref, got); diff != "" {
+				t.Errorf("\n%s\nApplyConnectionSecretRef(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+const (
+	errFmtEnvironmentMissingKey = "environment missing key %s"
+	errFmtEnvironmentKeyType    = "environment key %s is a %s, not a %s"
+)
+
+// DefaultEnvironment merges the supplied default data beneath the supplied
+// environment. Data in the environment takes precedence over default data.
+func DefaultEnvironment(data map[string]extv1.JSON, env *unstructured.Unstructured) error {
+	if len(data) == 0 {
+		return nil
+	}
+	defaults := make(map[string]any, len(data))
+	for k, v := range data {
+		var value any
+		if err := json.Unmarshal(v.Raw, &value); err != nil {
+			return errors.Wrapf(err, "cannot parse environment default data %q", k)
+		}
+		defaults[k] = value
+	}
+	env.SetUnstructuredContent(mergeObjects(defaults, env.UnstructuredContent()))
+	return nil
+}
+
+// EnvironmentConfigsFetched returns true if Crossplane has fetched all of the
+// supplied EnvironmentConfigs, so they could be merged into the environment.
+// Crossplane fetches them after the first time the Function is called.
+func EnvironmentConfigsFetched(ecs []v1beta1.EnvironmentConfig, extras map[string][]resource.Extra) bool {
+	for i := range ecs {
+		if _, ok := extras[fmt.Sprintf("%s%d", extraResourcesKeyPrefixEnvironmentConfig, i)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckEnvironmentSchema returns an error describing each of the supplied
+// fields that the supplied environment doesn't have, or whose value has the
+// wrong type.
+func CheckEnvironmentSchema(fields []v1beta1.EnvironmentField, env *unstructured.Unstructured) error {
+	var errs []error
+	p := fieldpath.Pave(env.Object)
+	for _, f := range fields {
+		v, err := p.GetValue(f.FieldPath)
+		if fieldpath.IsNotFound(err) {
+			errs = append(errs, errors.Errorf(errFmtEnvironmentMissingKey, f.FieldPath))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "cannot get environment key %s", f.FieldPath))
+			continue
+		}
+		if f.Type == nil {
+			continue
+		}
+		if t := environmentFieldType(v); !matchesEnvironmentFieldType(t, *f.Type) {
+			errs = append(errs, errors.Errorf(errFmtEnvironmentKeyType, f.FieldPath, t, *f.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// environmentFieldType returns the type of the supplied environment value.
+func environmentFieldType(v any) v1beta1.EnvironmentFieldType {
+	switch v := v.(type) {
+	case string:
+		return v1beta1.EnvironmentFieldTypeString
+	case bool:
+		return v1beta1.EnvironmentFieldTypeBoolean
+	case int, int32, int64:
+		return v1beta1.EnvironmentFieldTypeInteger
+	case float64:
+		if v == math.Trunc(v) {
+			return v1beta1.EnvironmentFieldTypeInteger
+		}
+		return v1beta1.EnvironmentFieldTypeNumber
+	case map[string]any:
+		return v1beta1.EnvironmentFieldTypeObject
+	case []any:
+		return v1beta1.EnvironmentFieldTypeArray
+	}
+	return "null"
+}
+
+// matchesEnvironmentFieldType returns true if a value of type got satisfies a
+// field of type want. Integers are numbers too.
+func matchesEnvironmentFieldType(got, want v1beta1.EnvironmentFieldType) bool {
+	return got == want || (got == v1beta1.EnvironmentFieldTypeInteger && want == v1beta1.EnvironmentFieldTypeNumber)
+}
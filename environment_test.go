@@ -0,0 +1,200 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestDefaultEnvironment(t *testing.T) {
+	type args struct {
+		data map[string]extv1.JSON
+		env  map[string]any
+	}
+	type want struct {
+		env map[string]any
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoDefaultData": {
+			reason: "The environment shouldn't change if there's no default data.",
+			args: args{
+				env: map[string]any{"region": "us-east-1"},
+			},
+			want: want{
+				env: map[string]any{"region": "us-east-1"},
+			},
+		},
+		"EmptyEnvironment": {
+			reason: "Default data should seed an empty environment.",
+			args: args{
+				data: map[string]extv1.JSON{
+					"region": {Raw: []byte(`"us-west-2"`)},
+					"tags":   {Raw: []byte(`{"team":"platform"}`)},
+				},
+				env: map[string]any{},
+			},
+			want: want{
+				env: map[string]any{
+					"region": "us-west-2",
+					"tags":   map[string]any{"team": "platform"},
+				},
+			},
+		},
+		"MergedBeneath": {
+			reason: "Data in the environment should take precedence over default data, recursively.",
+			args: args{
+				data: map[string]extv1.JSON{
+					"region": {Raw: []byte(`"us-west-2"`)},
+					"tags":   {Raw: []byte(`{"team":"platform","cost-center":"1234"}`)},
+				},
+				env: map[string]any{
+					"apiVersion": "internal.crossplane.io/v1alpha1",
+					"kind":       "Environment",
+					"region":     "us-east-1",
+					"tags":       map[string]any{"team": "data"},
+				},
+			},
+			want: want{
+				env: map[string]any{
+					"apiVersion": "internal.crossplane.io/v1alpha1",
+					"kind":       "Environment",
+					"region":     "us-east-1",
+					"tags":       map[string]any{"team": "data", "cost-center": "1234"},
+				},
+			},
+		},
+		"InvalidJSON": {
+			reason: "Default data that isn't valid JSON should return an error.",
+			args: args{
+				data: map[string]extv1.JSON{
+					"region": {Raw: []byte(`{`)},
+				},
+				env: map[string]any{},
+			},
+			want: want{
+				env: map[string]any{},
+				err: errors.Wrap(errors.New("unexpected end of JSON input"), `cannot parse environment default data "region"`),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := &unstructured.Unstructured{Object: tc.args.env}
+			err := DefaultEnvironment(tc.args.data, env)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nDefaultEnvironment(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.env, env.Object); diff != "" {
+				t.Errorf("\n%s\nDefaultEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEnvironmentConfigsFetched(t *testing.T) {
+	ecs := []v1beta1.EnvironmentConfig{{}, {}}
+
+	cases := map[string]struct {
+		reason string
+		extras map[string][]resource.Extra
+		want   bool
+	}{
+		"NotFetched": {
+			reason: "EnvironmentConfigs aren't fetched the first time the Function is called.",
+			want:   false,
+		},
+		"PartiallyFetched": {
+			reason: "All of the EnvironmentConfigs must be fetched.",
+			extras: map[string][]resource.Extra{
+				extraResourcesKeyPrefixEnvironmentConfig + "0": {},
+			},
+			want: false,
+		},
+		"Fetched": {
+			reason: "EnvironmentConfigs are fetched even if none matched.",
+			extras: map[string][]resource.Extra{
+				extraResourcesKeyPrefixEnvironmentConfig + "0": {},
+				extraResourcesKeyPrefixEnvironmentConfig + "1": nil,
+			},
+			want: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := EnvironmentConfigsFetched(ecs, tc.extras)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nEnvironmentConfigsFetched(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckEnvironmentSchema(t *testing.T) {
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"region": "us-east-2",
+		"networks": map[string]any{
+			"vpcId":   "vpc-42",
+			"subnets": []any{"a", "b"},
+			"mtu":     float64(1500),
+			"ratio":   float64(0.5),
+		},
+	}}
+
+	cases := map[string]struct {
+		reason string
+		fields []v1beta1.EnvironmentField
+		want   error
+	}{
+		"Matches": {
+			reason: "An environment that has every field with the right type should match. Integers are numbers too.",
+			fields: []v1beta1.EnvironmentField{
+				{FieldPath: "region"},
+				{FieldPath: "networks", Type: ptr.To(v1beta1.EnvironmentFieldTypeObject)},
+				{FieldPath: "networks.vpcId", Type: ptr.To(v1beta1.EnvironmentFieldTypeString)},
+				{FieldPath: "networks.subnets", Type: ptr.To(v1beta1.EnvironmentFieldTypeArray)},
+				{FieldPath: "networks.mtu", Type: ptr.To(v1beta1.EnvironmentFieldTypeInteger)},
+				{FieldPath: "networks.mtu", Type: ptr.To(v1beta1.EnvironmentFieldTypeNumber)},
+				{FieldPath: "networks.ratio", Type: ptr.To(v1beta1.EnvironmentFieldTypeNumber)},
+			},
+		},
+		"Mismatches": {
+			reason: "Every missing field, and every field with the wrong type, should be reported.",
+			fields: []v1beta1.EnvironmentField{
+				{FieldPath: "networks.vpcId"},
+				{FieldPath: "networks.zone"},
+				{FieldPath: "networks.ratio", Type: ptr.To(v1beta1.EnvironmentFieldTypeInteger)},
+			},
+			want: errors.Join(
+				errors.Errorf(errFmtEnvironmentMissingKey, "networks.zone"),
+				errors.Errorf(errFmtEnvironmentKeyType, "networks.ratio", v1beta1.EnvironmentFieldTypeNumber, v1beta1.EnvironmentFieldTypeInteger),
+			),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckEnvironmentSchema(tc.fields, env)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCheckEnvironmentSchema(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/response"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Variables of event messages are field paths of the composite resource or of
+// the event's observed composed resource, prefixed with one of these and
+// enclosed in braces.
+const (
+	eventVariablePrefixComposite = "xr."
+	eventVariablePrefixResource  = "resource."
+)
+
+// EmitEvents adds a result to the supplied response for each of the supplied
+// events that should be emitted. Crossplane emits results as Kubernetes events.
+// Events about a resource template are only emitted if the names of ready
+// resource templates include it.
+func EmitEvents(log logging.Logger, rsp *fnv1.RunFunctionResponse, events []v1beta1.Event, xr *resource.Composite, observed map[resource.Name]resource.ObservedComposed, ready map[string]bool) {
+	for i, e := range events {
+		objs := map[string]map[string]any{"xr": xr.Resource.Object}
+		d := ResultDetails{}
+		if e.Resource != nil {
+			if !ready[*e.Resource] {
+				continue
+			}
+			objs["resource"] = observed[resource.Name(*e.Resource)].Resource.Object
+			d.Resource = *e.Resource
+		}
+
+		msg, err := renderVariables(e.Message, objs)
+		if err != nil {
+			// The field paths the message reads may exist in future.
+			log.Debug("Cannot render event message", "index", i, "error", err)
+			continue
+		}
+
+		var r *response.ResultOption
+		switch e.GetType() {
+		case v1beta1.EventTypeWarning:
+			r = response.Warning(rsp, errors.New(msg))
+		default:
+			r = response.Normal(rsp, msg)
+		}
+		if e.Reason != nil {
+			r = r.WithReason(*e.Reason)
+			d.Reason = *e.Reason
+		}
+		switch e.GetTarget() {
+		case v1beta1.EventTargetComposite:
+			r.TargetComposite()
+		default:
+			r.TargetCompositeAndClaim()
+		}
+		recordResult(rsp, rsp.GetResults()[len(rsp.GetResults())-1], d)
+	}
+}
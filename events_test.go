@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestEmitEvents(t *testing.T) {
+	xr := &resource.Composite{Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"region": "eu-west-1"},
+	}}}}
+	observed := map[resource.Name]resource.ObservedComposed{
+		"vpc": {Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"status": map[string]any{"atProvider": map[string]any{"id": "vpc-42"}},
+		}}}},
+	}
+
+	type args struct {
+		events []v1beta1.Event
+		ready  map[string]bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []*fnv1.Result
+	}{
+		"CompositeEvent": {
+			reason: "An event that isn't about a resource template should be emitted for the composite resource and its claim.",
+			args: args{
+				events: []v1beta1.Event{{Message: "Region is {xr.spec.region}"}},
+			},
+			want: []*fnv1.Result{
+				{
+					Severity: fnv1.Severity_SEVERITY_NORMAL,
+					Message:  "Region is eu-west-1",
+					Target:   fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+				},
+			},
+		},
+		"ResourceNotReady": {
+			reason: "An event about a resource template shouldn't be emitted until its composed resource is ready.",
+			args: args{
+				events: []v1beta1.Event{{Message: "VPC {resource.status.atProvider.id} created", Resource: ptr.To("vpc")}},
+				ready:  map[string]bool{},
+			},
+			want: nil,
+		},
+		"ResourceReady": {
+			reason: "An event about a resource template should be emitted once its composed resource is ready, using the configured type, reason, and target.",
+			args: args{
+				events: []v1beta1.Event{{
+					Message:  "VPC {resource.status.atProvider.id} created in {xr.spec.region}",
+					Resource: ptr.To("vpc"),
+					Type:     ptr.To(v1beta1.EventTypeWarning),
+					Reason:   ptr.To("VPCCreated"),
+					Target:   ptr.To(v1beta1.EventTargetComposite),
+				}},
+				ready: map[string]bool{"vpc": true},
+			},
+			want: []*fnv1.Result{
+				{
+					Severity: fnv1.Severity_SEVERITY_WARNING,
+					Message:  "VPC vpc-42 created in eu-west-1",
+					Reason:   ptr.To("VPCCreated"),
+					Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+				},
+			},
+		},
+		"FieldPathNotFound": {
+			reason: "An event shouldn't be emitted while a field path its message reads doesn't exist.",
+			args: args{
+				events: []v1beta1.Event{{Message: "Zone is {xr.spec.zone}"}},
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rsp := &fnv1.RunFunctionResponse{}
+			EmitEvents(logging.NewNopLogger(), rsp, tc.args.events, xr, observed, tc.args.ready)
+			if diff := cmp.Diff(tc.want, rsp.GetResults(), protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nEmitEvents(...): -want results, +got results:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
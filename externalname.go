@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Variables of external name formats are field paths of the composite
+// resource, prefixed with xr. and enclosed in braces.
+const externalNameVariablePrefix = "xr."
+
+var externalNameVariable = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ExternalNameVariables returns the variables of the supplied external name
+// format.
+func ExternalNameVariables(format string) []string {
+	ms := externalNameVariable.FindAllStringSubmatch(format, -1)
+	vars := make([]string, len(ms))
+	for i, m := range ms {
+		vars[i] = m[1]
+	}
+	return vars
+}
+
+// RenderExternalName replaces the variables of the supplied external name
+// format with values read from the supplied composite resource.
+func RenderExternalName(format string, xr *composite.Unstructured) (string, error) {
+	return renderVariables(format, map[string]map[string]any{"xr": xr.Object})
+}
+
+// renderVariables replaces the variables of the supplied format with values
+// read from the supplied objects. The first segment of a variable's field path
+// selects the object it's read from, for example {xr.spec.region}.
+func renderVariables(format string, objs map[string]map[string]any) (string, error) {
+	var err error
+	out := externalNameVariable.ReplaceAllStringFunc(format, func(v string) string {
+		if err != nil {
+			return ""
+		}
+		obj, path, _ := strings.Cut(strings.Trim(v, "{}"), ".")
+		from, ok := objs[obj]
+		if !ok {
+			err = errors.Errorf("unknown variable %s", v)
+			return ""
+		}
+		var value any
+		value, err = fieldpath.Pave(from).GetValue(path)
+		if err != nil {
+			return ""
+		}
+		switch value.(type) {
+		case string, bool, int64, float64:
+			return fmt.Sprint(value)
+		}
+		err = errors.Errorf("%s is a %T, not a string, number, or boolean", path, value)
+		return ""
+	})
+	return out, err
+}
+
+// ApplyExternalName sets the external name of the supplied desired composed
+// resource. If the external name's policy is IfUnset and the supplied observed
+// composed resource has an external name, it's kept.
+func ApplyExternalName(en *v1beta1.ExternalName, xr *composite.Unstructured, ocd, dcd *composed.Unstructured) error {
+	if ocd != nil && en.GetPolicy() == v1beta1.OverwritePolicyIfUnset {
+		if name := meta.GetExternalName(ocd); name != "" {
+			meta.SetExternalName(dcd, name)
+			return nil
+		}
+	}
+	name, err := RenderExternalName(en.Format, xr)
+	if err != nil {
+		return errors.Wrap(err, "cannot render external name")
+	}
+	meta.SetExternalName(dcd, name)
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestRenderExternalName(t *testing.T) {
+	xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "cool-xr"},
+		"spec":     map[string]any{"replicas": float64(3), "tags": map[string]any{}},
+	}}}
+
+	type want struct {
+		name     string
+		notFound bool
+		err      bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		format string
+		want   want
+	}{
+		"Variables": {
+			reason: "Variables should be replaced with the values at their composite resource field paths.",
+			format: "{xr.metadata.name}-db-{xr.spec.replicas}",
+			want:   want{name: "cool-xr-db-3"},
+		},
+		"NoVariables": {
+			reason: "A format without variables should be returned unchanged.",
+			format: "cool-db",
+			want:   want{name: "cool-db"},
+		},
+		"NotFound": {
+			reason: "A variable whose field path doesn't exist should return a not found error.",
+			format: "{xr.spec.region}-db",
+			want:   want{err: true, notFound: true},
+		},
+		"NotScalar": {
+			reason: "A variable whose value isn't a scalar should return an error.",
+			format: "{xr.spec.tags}-db",
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := RenderExternalName(tc.format, xr)
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("%s\nRenderExternalName(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.notFound, fieldpath.IsNotFound(err)); diff != "" {
+				t.Errorf("%s\nRenderExternalName(...): -want not found, +got not found:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.name, got); diff != "" {
+				t.Errorf("%s\nRenderExternalName(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyExternalName(t *testing.T) {
+	xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "cool-xr"},
+	}}}
+	observed := func() *composed.Unstructured {
+		cd := composed.New()
+		meta.SetExternalName(cd, "observed-db")
+		return cd
+	}
+
+	type args struct {
+		en  *v1beta1.ExternalName
+		ocd *composed.Unstructured
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   string
+	}{
+		"NewResource": {
+			reason: "The external name of a new composed resource should be rendered.",
+			args: args{
+				en: &v1beta1.ExternalName{Format: "{xr.metadata.name}-db"},
+			},
+			want: "cool-xr-db",
+		},
+		"IfUnset": {
+			reason: "The observed external name should be kept by default.",
+			args: args{
+				en:  &v1beta1.ExternalName{Format: "{xr.metadata.name}-db"},
+				ocd: observed(),
+			},
+			want: "observed-db",
+		},
+		"Always": {
+			reason: "The observed external name should be overwritten if the policy is Always.",
+			args: args{
+				en:  &v1beta1.ExternalName{Format: "{xr.metadata.name}-db", Policy: ptr.To(v1beta1.OverwritePolicyAlways)},
+				ocd: observed(),
+			},
+			want: "cool-xr-db",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dcd := composed.New()
+			if err := ApplyExternalName(tc.args.en, xr, tc.args.ocd, dcd); err != nil {
+				t.Fatalf("%s\nApplyExternalName(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, meta.GetExternalName(dcd)); diff != "" {
+				t.Errorf("%s\nApplyExternalName(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
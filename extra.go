@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// EnvironmentConfigs are requested as extra resources with this prefix, followed
+// by their index in the input's environment.environmentConfigs.
+const extraResourcesKeyPrefixEnvironmentConfig = "pt.fn.crossplane.io/environment-config-"
+
+// The API version and kind of EnvironmentConfigs.
+const (
+	environmentConfigAPIVersion = "apiextensions.crossplane.io/v1alpha1"
+	environmentConfigKind       = "EnvironmentConfig"
+)
+
+// ExtraResourcesRequirements returns the requirements that ask Crossplane for
+// the supplied extra resources.
+func ExtraResourcesRequirements(ers []v1beta1.ExtraResource) *fnv1.Requirements {
+	rq := &fnv1.Requirements{ExtraResources: make(map[string]*fnv1.ResourceSelector, len(ers))}
+	for _, er := range ers {
+		rs := &fnv1.ResourceSelector{ApiVersion: er.APIVersion, Kind: er.Kind}
+		if er.MatchName != nil {
+			rs.Match = &fnv1.ResourceSelector_MatchName{MatchName: *er.MatchName}
+		} else {
+			rs.Match = &fnv1.ResourceSelector_MatchLabels{MatchLabels: &fnv1.MatchLabels{Labels: er.MatchLabels}}
+		}
+		rq.ExtraResources[er.Name] = rs
+	}
+	return rq
+}
+
+// ExtraResourcesObject returns an object that FromExtraResourceFieldPath
+// patches can read the supplied extra resources from. Each of its keys is the
+// name of a requested extra resource. Its value is the matching resource if the
+// resource was requested by name, or a list of the matching resources ordered
+// by name if it was requested by labels. Resources that Crossplane hasn't
+// fetched yet are omitted.
+func ExtraResourcesObject(ers []v1beta1.ExtraResource, extras map[string][]resource.Extra) *unstructured.Unstructured {
+	o := &unstructured.Unstructured{Object: map[string]any{}}
+	for _, er := range ers {
+		matched, ok := extras[er.Name]
+		if !ok {
+			continue
+		}
+		if er.MatchName != nil {
+			if len(matched) > 0 {
+				o.Object[er.Name] = matched[0].Resource.UnstructuredContent()
+			}
+			continue
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].Resource.GetName() < matched[j].Resource.GetName()
+		})
+		l := make([]any, len(matched))
+		for i := range matched {
+			l[i] = matched[i].Resource.UnstructuredContent()
+		}
+		o.Object[er.Name] = l
+	}
+	o.SetGroupVersionKind(internalExtraResourcesGVK)
+	return o
+}
+
+// EnvironmentConfigsRequirements returns the extra resource selectors that ask
+// Crossplane for the supplied EnvironmentConfigs. Label values may be read from
+// the supplied composite resource.
+func EnvironmentConfigsRequirements(ecs []v1beta1.EnvironmentConfig, xr *composite.Unstructured) (map[string]*fnv1.ResourceSelector, error) {
+	rss := make(map[string]*fnv1.ResourceSelector, len(ecs))
+	for i, ec := range ecs {
+		rs := &fnv1.ResourceSelector{ApiVersion: environmentConfigAPIVersion, Kind: environmentConfigKind}
+		switch ec.GetType() {
+		case v1beta1.EnvironmentConfigTypeReference:
+			rs.Match = &fnv1.ResourceSelector_MatchName{MatchName: ec.Ref.Name}
+		case v1beta1.EnvironmentConfigTypeSelector:
+			labels := make(map[string]string, len(ec.Selector.MatchLabels))
+			for _, m := range ec.Selector.MatchLabels {
+				v, err := labelValue(m, xr)
+				if err != nil {
+					return nil, errors.Wrapf(err, "cannot select environment config at index %d", i)
+				}
+				labels[m.Key] = v
+			}
+			rs.Match = &fnv1.ResourceSelector_MatchLabels{MatchLabels: &fnv1.MatchLabels{Labels: labels}}
+		}
+		rss[fmt.Sprintf("%s%d", extraResourcesKeyPrefixEnvironmentConfig, i)] = rs
+	}
+	return rss, nil
+}
+
+func labelValue(m v1beta1.EnvironmentConfigLabelMatcher, xr *composite.Unstructured) (string, error) {
+	if m.GetType() == v1beta1.EnvironmentConfigLabelMatcherTypeValue {
+		return *m.Value, nil
+	}
+	v, err := getInEitherLayout(*m.ValueFromFieldPath, fieldpath.Pave(xr.Object).GetString)
+	return v, errors.Wrapf(err, "cannot get value of label %q", m.Key)
+}
+
+// MergeEnvironmentConfigs merges the data of the supplied EnvironmentConfigs
+// that Crossplane has fetched into the supplied environment, in order.
+func MergeEnvironmentConfigs(ecs []v1beta1.EnvironmentConfig, extras map[string][]resource.Extra, env *unstructured.Unstructured) {
+	for i := range ecs {
+		matched := extras[fmt.Sprintf("%s%d", extraResourcesKeyPrefixEnvironmentConfig, i)]
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].Resource.GetName() < matched[j].Resource.GetName()
+		})
+		for _, m := range matched {
+			data, ok := m.Resource.Object["data"].(map[string]any)
+			if !ok {
+				continue
+			}
+			// The environment is patched, so it mustn't share any data
+			// with the extra resources.
+			data = runtime.DeepCopyJSONValue(data).(map[string]any) //nolint:forcetypeassert // DeepCopyJSONValue returns the type it's passed.
+			mergeObjects(env.Object, data)
+		}
+	}
+}
+
+// IsReservedExtraResourceName returns true if the supplied extra resource name
+// is reserved for the Function's own use.
+func IsReservedExtraResourceName(name string) bool {
+	return strings.HasPrefix(name, extraResourcesKeyPrefixEnvironmentConfig)
+}
@@ -2,21 +2,24 @@ package main
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/util/json"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
-	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 
 	fncontext "github.com/crossplane/function-sdk-go/context"
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/crossplane/function-sdk-go/request"
 	"github.com/crossplane/function-sdk-go/resource"
-	"github.com/crossplane/function-sdk-go/resource/composed"
 	"github.com/crossplane/function-sdk-go/response"
 
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
@@ -26,7 +29,18 @@ import (
 type Function struct {
 	fnv1.UnimplementedFunctionRunnerServiceServer
 
-	log logging.Logger
+	log     logging.Logger
+	metrics *Metrics
+	inputs  *InputCache
+	tracer  trace.Tracer
+
+	// maxConcurrentRenders is the maximum number of resource templates to
+	// render concurrently. Templates are rendered one at a time if it's less
+	// than two.
+	maxConcurrentRenders int
+
+	// debugPatches logs how each composed resource patch is evaluated.
+	debugPatches bool
 }
 
 // RunFunction runs the Function.
@@ -39,18 +53,42 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 	// TODO(negz): We can probably use a longer TTL if all resources are ready.
 	rsp := response.To(req, response.DefaultTTL)
 
-	input := &v1beta1.Resources{}
-	if err := request.GetInput(req, input); err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot get Function input"))
-		return rsp, nil
-	}
+	ctx, span := startSpan(ctx, f.tracer, "RunFunction")
+	defer func() { endRunSpan(span, rsp) }()
+
+	// Set once we know what kind of XR we're running for.
+	var xrAPIVersion, xrKind string
+	started := time.Now()
+	defer func() { f.metrics.RunFinished(xrAPIVersion, xrKind, started, rsp) }()
+
+	// Parsing, validating, and resolving the PatchSets of our input is
+	// relatively expensive. The input only changes when the Composition does,
+	// so we cache the result.
+	pi, ok := f.inputs.Get(req.GetInput())
+	if !ok {
+		input, err := DecodeInput(req.GetInput().AsMap(), false)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot get Function input"))
+			return rsp, nil
+		}
 
-	// Our input is an opaque object nested in a Composition, so unfortunately
-	// it won't handle validation for us.
-	if err := ValidateResources(input); err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "invalid Function input"))
-		return rsp, nil
+		// Our input is an opaque object nested in a Composition, so
+		// unfortunately it won't handle validation for us.
+		if errs := ValidateResources(input); len(errs) > 0 {
+			Fatal(rsp, errors.Wrap(errs.ToAggregate(), "invalid Function input"), ResultDetails{Reason: ReasonInvalidInput})
+			return rsp, nil
+		}
+
+		cts, err := ComposedTemplates(input.PatchSets, WithDefaultPatches(input.Defaults, input.Resources))
+		if err != nil {
+			Fatal(rsp, errors.Wrap(err, "cannot resolve PatchSets"), ResultDetails{Reason: ReasonInvalidInput})
+			return rsp, nil
+		}
+
+		pi = newParsedInput(input, cts)
+		f.inputs.Add(req.GetInput(), pi)
 	}
+	input, cts := pi.input, pi.templates
 
 	// The composite resource that actually exists.
 	oxr, err := request.GetObservedCompositeResource(req)
@@ -59,11 +97,21 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 		return rsp, nil
 	}
 
+	xrAPIVersion, xrKind = oxr.Resource.GetAPIVersion(), oxr.Resource.GetKind()
+
 	log = log.WithValues(
 		"xr-version", oxr.Resource.GetAPIVersion(),
 		"xr-kind", oxr.Resource.GetKind(),
 		"xr-name", oxr.Resource.GetName(),
 	)
+	span.SetAttributes(
+		attribute.String(attrXRAPIVersion, oxr.Resource.GetAPIVersion()),
+		attribute.String(attrXRKind, oxr.Resource.GetKind()),
+		attribute.String(attrXRName, oxr.Resource.GetName()),
+	)
+	if name, err := getInEitherLayout("spec.compositionRef.name", oxr.Resource.GetString); err == nil {
+		span.SetAttributes(attribute.String(attrComposition, name))
+	}
 
 	// The composite resource desired by previous functions in the pipeline.
 	dxr, err := request.GetDesiredCompositeResource(req)
@@ -98,22 +146,23 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 		return rsp, nil
 	}
 
-	cts, err := ComposedTemplates(input.PatchSets, input.Resources)
-	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot resolve PatchSets"))
-		return rsp, nil
-	}
-
 	// The Composition environment. This could be set by Crossplane, and/or by a
 	// previous Function in the pipeline.
 	env := &unstructured.Unstructured{}
-	if v, ok := request.GetContextKey(req, fncontext.KeyEnvironment); ok {
+	v, envSupplied := request.GetContextKey(req, fncontext.KeyEnvironment)
+	if envSupplied {
 		if err := resource.AsObject(v.GetStructValue(), env); err != nil {
 			response.Fatal(rsp, errors.Wrapf(err, "cannot get Composition environment from %T context key %q", req, fncontext.KeyEnvironment))
 			return rsp, nil
 		}
 		log.Debug("Loaded Composition environment from Function context", "context-key", fncontext.KeyEnvironment)
 	}
+	if input.Environment != nil {
+		if err := DefaultEnvironment(input.Environment.DefaultData, env); err != nil {
+			Fatal(rsp, err, ResultDetails{Reason: ReasonInvalidInput})
+			return rsp, nil
+		}
+	}
 
 	// Patching code assumes that the environment has a GVK, as it uses
 	// runtime.DefaultUnstructuredConverter.FromUnstructured. This is a bit odd,
@@ -123,27 +172,92 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 		env.SetGroupVersionKind(internalEnvironmentGVK)
 	}
 
+	// The Function pipeline context. Context patches may read from or write to
+	// any key of the context. Like the environment, patching code needs this
+	// object to have a GVK. The environment key is managed separately above.
+	fctx := &unstructured.Unstructured{Object: req.GetContext().AsMap()}
+	fctx.SetGroupVersionKind(internalContextGVK)
+
+	// The extra resources and EnvironmentConfigs requested by our input. We
+	// must return our requirements every time we're called. Crossplane calls
+	// us again once it has fetched any extra resources we require that it
+	// hadn't fetched already.
+	rq := ExtraResourcesRequirements(input.ExtraResources)
 	if input.Environment != nil {
-		// Run all patches that are from the (observed) XR to the environment or
-		// from the environment to the (desired) XR.
-		for i := range input.Environment.Patches {
-			p := &input.Environment.Patches[i]
-			if err := ApplyEnvironmentPatch(p, env, oxr.Resource, dxr.Resource); err != nil {
-
-				// Ignore not found errors if patch policy is set to Optional
-				if fieldpath.IsNotFound(err) && p.GetPolicy().GetFromFieldPathPolicy() == v1beta1.FromFieldPathPolicyOptional {
-					continue
-				}
+		ecs, err := EnvironmentConfigsRequirements(input.Environment.EnvironmentConfigs, oxr.Resource)
+		if err != nil {
+			Fatal(rsp, err, ResultDetails{Reason: ReasonInvalidInput})
+			return rsp, nil
+		}
+		for k, v := range ecs {
+			rq.ExtraResources[k] = v
+		}
+	}
+	if len(rq.GetExtraResources()) > 0 {
+		rsp.Requirements = rq
+	}
+	extras, err := request.GetExtraResources(req)
+	if err != nil {
+		response.Fatal(rsp, errors.Wrapf(err, "cannot get extra resources from %T", req))
+		return rsp, nil
+	}
+	extra := ExtraResourcesObject(input.ExtraResources, extras)
+	vars, err := VariablesObject(input.Variables)
+	if err != nil {
+		Fatal(rsp, err, ResultDetails{Reason: ReasonInvalidInput})
+		return rsp, nil
+	}
+	if input.Environment != nil {
+		MergeEnvironmentConfigs(input.Environment.EnvironmentConfigs, extras, env)
 
-				response.Fatal(rsp, errors.Wrapf(err, "cannot apply the %q environment patch at index %d", p.GetType(), i))
+		// There's no point checking the environment until Crossplane has
+		// fetched the EnvironmentConfigs that are merged into it.
+		if EnvironmentConfigsFetched(input.Environment.EnvironmentConfigs, extras) {
+			if err := CheckEnvironmentSchema(input.Environment.Schema, env); err != nil {
+				Fatal(rsp, errors.Wrap(err, "environment doesn't match its schema"), ResultDetails{Reason: ReasonInvalidEnvironment})
 				return rsp, nil
 			}
 		}
 	}
 
+	// The Crossplane annotations patches may change on the desired XR.
+	allowed := AllowedCompositeAnnotations(input.CrossplaneAnnotations)
+
+	// Patches to the desired XR that delete the keys they merged last time
+	// read which keys those were from the observed XR.
+	if slices.ContainsFunc(cts, func(t v1beta1.ComposedTemplate) bool { return DeletesKeysNotInSource(t.Patches) }) ||
+		(input.Environment != nil && DeletesKeysNotInSource(input.Environment.Patches)) {
+		CarryMergedKeys(oxr.Resource, dxr.Resource)
+	}
+
+	if input.Environment != nil {
+		// Run all patches that are from the (observed) XR to the environment or
+		// from the environment to the (desired) XR, and that should run before
+		// any composed resources are rendered.
+		if err := ApplyEnvironmentPatches(input.Environment.Patches, v1beta1.EnvironmentPatchStageBefore, env, oxr.Resource, dxr.Resource, observed, allowed); err != nil {
+			Fatal(rsp, err, ResultDetails{Reason: ReasonPatchFailed})
+			return rsp, nil
+		}
+	}
+
 	// Increment this if you emit a warning result.
 	warnings := 0
 
+	for _, w := range pi.warnings {
+		Warning(rsp, errors.New(w), ResultDetails{Reason: ReasonSuspiciousInput})
+		warnings++
+	}
+
+	// Crossplane v2 composite resources don't support connection details, so
+	// we don't extract any. Tell the author, rather than silently ignoring
+	// the connection details they configured.
+	conns := supportsConnectionDetails(oxr.Resource)
+	if fields := UnsupportedConnectionDetails(input, oxr.Resource); len(fields) > 0 {
+		Warning(rsp, errors.Errorf("ignoring %s: Crossplane v2 composite resources don't support connection details. Compose a Secret resource that contains the connection details instead, for example by patching to it from the composed resources' status fields", strings.Join(fields, ", ")), ResultDetails{Reason: ReasonConnectionDetailsUnsupported})
+		log.Info("Ignoring connection details of Crossplane v2 composite resource", "fields", fields)
+		warnings++
+	}
+
 	// Increment this for each resource template with an existing, observed
 	// composed resource.
 	existing := 0
@@ -151,121 +265,176 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 	// Increments this for each resource template that has been skipped
 	skipped := 0
 
-	for _, t := range cts {
-		log := log.WithValues("resource-template-name", t.Name)
-		log.Debug("Processing resource template")
-
-		dcd := &resource.DesiredComposed{Resource: composed.New()}
-
-		// If we have a base template, render it into our desired resource. If a
-		// previous Function produced a desired resource with this name we'll
-		// overwrite it. If we don't have a base template we'll try to patch to
-		// and from a desired resource produced by a previous Function in the
-		// pipeline.
-		switch t.Base {
-		case nil:
-			cd, ok := desired[resource.Name(t.Name)]
-			if !ok {
-				response.Fatal(rsp, errors.Errorf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", t.Name))
-				return rsp, nil
-			}
-			// We want to return this resource unmutated if rendering fails.
-			dcd.Resource = cd.Resource.DeepCopy()
-		default:
-			if err := json.Unmarshal(t.Base.Raw, dcd.Resource); err != nil {
-				response.Fatal(rsp, errors.Wrapf(err, "cannot parse base template of composed resource %q", t.Name))
-				return rsp, nil
-			}
+	// Increment this for each import-only resource template whose composed
+	// resource doesn't exist.
+	ignored := 0
+
+	// Composed resources of renamed resource templates keep their previous
+	// name in the desired state.
+	renamed := ResolvePreviousNames(cts, observed)
+	desiredName := func(t v1beta1.ComposedTemplate) resource.Name {
+		if p, ok := renamed[t.Name]; ok {
+			return p
+		}
+		return resource.Name(t.Name)
+	}
+
+	s := &renderState{
+		input:        input,
+		parsed:       pi,
+		oxr:          oxr,
+		dxr:          dxr,
+		observed:     observed,
+		desired:      desired,
+		env:          env,
+		fctx:         fctx,
+		extra:        extra,
+		claim:        ClaimObject(oxr.Resource),
+		vars:         vars,
+		allowed:      allowed,
+		xrAPIVersion: xrAPIVersion,
+		xrKind:       xrKind,
+	}
+
+	// The names of resource templates whose observed composed resources are
+	// ready.
+	ready := make(map[string]bool, len(cts))
+
+	// The names of connection details that can't be extracted yet.
+	var pending []string
+
+	// Resource templates may be rendered concurrently, but we add their
+	// results and desired composed resources in resource template order.
+	// Warnings that many resource templates have in common are aggregated.
+	results := NewResultCollector()
+	rendered := make([]resource.Name, 0, len(cts))
+	for i, r := range f.renderTemplates(ctx, log, s, cts) {
+		results.Collect(r.rsp)
+		if r.fatal {
+			results.MergeInto(rsp)
+			return rsp, nil
 		}
 
-		ocd, exists := observed[resource.Name(t.Name)]
-		if exists {
+		warnings += r.warnings
+		if r.exists {
 			existing++
-			log.Debug("Resource template corresponds to existing composed resource", "metadata-name", ocd.Resource.GetName())
-
-			// If this template corresponds to an existing observed resource we
-			// want to keep them associated. We copy only the namespace and
-			// name, not the entire observed state, because we're trying to
-			// produce only a partial 'overlay' of desired state.
-			dcd.Resource.SetNamespace(ocd.Resource.GetNamespace())
-			dcd.Resource.SetName(ocd.Resource.GetName())
-
-			conn, err := ExtractConnectionDetails(ocd.Resource, managed.ConnectionDetails(ocd.ConnectionDetails), t.ConnectionDetails...)
-			if err != nil {
-				response.Warning(rsp, errors.Wrapf(err, "cannot extract composite resource connection details from composed resource %q", t.Name))
-				log.Info("Cannot extract composite resource connection details from composed resource", "warning", err)
-				warnings++
-			}
-			for k, v := range conn {
+		}
+		if conns {
+			for k, v := range r.conn {
 				dxr.ConnectionDetails[k] = v
 			}
-
-			ready, err := IsReady(ctx, ocd.Resource, t.ReadinessChecks...)
-			if err != nil {
-				response.Warning(rsp, errors.Wrapf(err, "cannot check readiness of composed resource %q", t.Name))
-				log.Info("Cannot check readiness of composed resource", "warning", err)
-				warnings++
-			}
-			if ready {
-				dcd.Ready = resource.ReadyTrue
-			}
-
-			log.Debug("Found corresponding observed resource",
-				"ready", ready,
-				"name", ocd.Resource.GetName())
+			pending = append(pending, r.pending...)
 		}
 
-		// Run all patches that are to a desired composed resource, or from an
-		// observed composed resource.
-		skip := false
-		for i := range t.Patches {
-			p := &t.Patches[i]
-			if err := ApplyComposedPatch(p, ocd.Resource, dcd.Resource, oxr.Resource, dxr.Resource, env); err != nil {
-				if fieldpath.IsNotFound(err) {
-					// This is a patch from a required field path that does not
-					// exist. The point of FromFieldPathPolicyRequired is to
-					// block creation of the new 'to' resource until the 'from'
-					// field path exists.
-					//
-					// The only kind of resource we could be patching to that
-					// might not exist at this point is a composed resource. So
-					// if we're patching to a composed resource that doesn't
-					// exist we want to avoid creating it. Otherwise, we just
-					// treat the patch from a required field path the same way
-					// we'd treat a patch from an optional field path and skip
-					// it.
-					if p.GetPolicy().GetFromFieldPathPolicy() == v1beta1.FromFieldPathPolicyRequired {
-						if ToComposedResource(p) && !exists {
-							response.Warning(rsp, errors.Wrapf(err, "not adding new composed resource %q to desired state because %q patch at index %d has 'policy.fromFieldPath: Required'", t.Name, p.GetType(), i))
-
-							// There's no point processing further patches.
-							// They'll either be from an observed composed
-							// resource that doesn't exist yet, or to a desired
-							// composed resource that we'll discard.
-							skip = true
-							break
-						}
-						response.Warning(rsp, errors.Wrapf(err, "cannot render composed resource %q %q patch at index %d: ignoring 'policy.fromFieldPath: Required' because 'to' resource already exists", t.Name, p.GetType(), i))
-					}
-
-					// If any optional field path isn't found we just skip this
-					// patch and move on. The path may be populated by a
-					// subsequent patch.
-					continue
-				}
-				response.Fatal(rsp, errors.Wrapf(err, "cannot render composed resource %q %q patch at index %d", t.Name, p.GetType(), i))
-				return rsp, nil
-			}
+		// Import-only resources are never created.
+		if r.ignored {
+			ignored++
+			continue
 		}
 
 		// Skip adding this resource to the desired state because it doesn't
 		// exist yet, and a required FromFieldPath was not (yet) found.
-		if skip {
+		if r.skipped {
 			skipped++
 			continue
 		}
 
-		desired[resource.Name(t.Name)] = dcd
+		ready[cts[i].Name] = r.exists && r.dcd.Ready == resource.ReadyTrue
+		desired[desiredName(cts[i])] = r.dcd
+		rendered = append(rendered, desiredName(cts[i]))
+	}
+
+	if input.AnnotateRenderOrdinals {
+		for name, o := range RenderOrdinals(rendered, observed) {
+			AnnotateRenderOrdinal(desired[name].Resource, o)
+		}
+	}
+
+	results.MergeInto(rsp)
+
+	// Don't add new composed resources to the desired state until the
+	// resources they depend on are ready, and the environment has the values
+	// they wait for. We only know whether a resource is ready once its
+	// resource template is rendered, so we do this last.
+	for _, t := range cts {
+		if _, ok := observed[resource.Name(t.Name)]; ok {
+			continue
+		}
+		if _, ok := desired[desiredName(t)]; !ok {
+			continue
+		}
+		if unready := UnreadyDependencies(t, ready); len(unready) > 0 {
+			log.Debug("Not adding new composed resource to desired state until its dependencies are ready", "resource-template-name", t.Name, "unready-dependencies", unready)
+			delete(desired, desiredName(t))
+			skipped++
+			continue
+		}
+		if WaitingForEnvironment(t, env) {
+			log.Debug("Not adding new composed resource to desired state until the environment has the value it waits for", "resource-template-name", t.Name, "environment-field-path", t.WaitFor.EnvironmentFieldPath)
+			delete(desired, desiredName(t))
+			skipped++
+		}
+	}
+
+	// Two resource templates that render the same composed resource would
+	// silently overwrite each other when Crossplane applies them.
+	for _, c := range ConflictingComposedResources(cts, desired, renamed) {
+		id := c.Name
+		if c.Namespace != "" {
+			id = c.Namespace + "/" + c.Name
+		}
+		Warning(rsp, errors.Errorf("resource templates %q and %q both render %s %q, so the one Crossplane applies last overwrites the other", c.Other, c.Resource, c.GroupKind, id), ResultDetails{Reason: ReasonComposedResourceConflict, Resource: c.Resource})
+		warnings++
+	}
+
+	// Decide what happens to observed composed resources that no resource
+	// template rendered, for example because their template was removed.
+	if input.Prune != nil {
+		for _, name := range OrphanedComposedResources(observed, desired, renamed) {
+			if *input.Prune {
+				Warning(rsp, errors.Errorf("composed resource %q will be deleted because no resource template renders it, and prune is true", name), ResultDetails{Reason: ReasonComposedResourcePruned, Resource: string(name)})
+				warnings++
+				continue
+			}
+			log.Debug("Retaining observed composed resource that no resource template renders, because prune is false", "composed-resource-name", name)
+			desired[name] = &resource.DesiredComposed{Resource: RetainObserved(observed[name].Resource)}
+		}
+	}
+
+	if input.Environment != nil {
+		// Run all environment patches that should run after the composed
+		// resources are rendered. These may depend on values that composed
+		// resources patched to the environment above.
+		if err := ApplyEnvironmentPatches(input.Environment.Patches, v1beta1.EnvironmentPatchStageAfter, env, oxr.Resource, dxr.Resource, observed, allowed); err != nil {
+			Fatal(rsp, err, ResultDetails{Reason: ReasonPatchFailed})
+			return rsp, nil
+		}
+	}
+
+	// Extract any connection details that don't come from a composed resource.
+	// These run last so they can read anything patched to the environment.
+	if conns {
+		conn, err := ExtractCompositeConnectionDetails(observed, env, input.ConnectionDetails...)
+		if err != nil {
+			Warning(rsp, errors.Wrap(err, "cannot extract composite resource connection details"), ResultDetails{Reason: ReasonConnectionDetailsFailed})
+			log.Info("Cannot extract composite resource connection details", "warning", err)
+			warnings++
+		}
+		for k, v := range conn {
+			dxr.ConnectionDetails[k] = v
+		}
+		AnnotatePendingConnectionDetails(dxr.Resource, pending)
+	}
+
+	EmitEvents(log, rsp, input.Events, oxr, observed, ready)
+	SetStatusConditions(dxr.Resource, oxr.Resource, input.StatusConditions, ready, time.Now())
+
+	if p := input.WriteInventoryTo; p != nil {
+		if err := fieldpath.Pave(dxr.Resource.Object).SetValue(*p, Inventory(cts, desired, renamed, ready)); err != nil {
+			Warning(rsp, errors.Wrapf(err, "cannot write inventory to %s", *p), ResultDetails{Reason: ReasonInventoryFailed})
+			log.Info("Cannot write inventory", "warning", err)
+			warnings++
+		}
 	}
 
 	if err := response.SetDesiredCompositeResource(rsp, dxr); err != nil {
@@ -282,19 +451,64 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 		return rsp, nil
 	}
 
-	v, err := resource.AsStruct(env)
-	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot convert Composition environment to protobuf Struct well-known type"))
-		return rsp, nil
+	for k, v := range fctx.Object {
+		switch k {
+		case "apiVersion", "kind", fncontext.KeyEnvironment, ContextKeyResults:
+			// Either added above so we could patch the context, or set below.
+			// Results are recorded directly in the response's context.
+			continue
+		}
+		sv, err := structpb.NewValue(v)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot convert Function context key %q to protobuf Value well-known type", k))
+			return rsp, nil
+		}
+		response.SetContextKey(rsp, k, sv)
+	}
+
+	// Only write the environment if there is one. An empty environment
+	// that nothing supplied or patched would only confuse later Functions.
+	if envSupplied || input.Environment.GetPassthrough() || !isEmptyEnvironment(env) {
+		v, err := resource.AsStruct(env)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot convert Composition environment to protobuf Struct well-known type"))
+			return rsp, nil
+		}
+		response.SetContextKey(rsp, fncontext.KeyEnvironment, structpb.NewStructValue(v))
 	}
-	response.SetContextKey(rsp, fncontext.KeyEnvironment, structpb.NewStructValue(v))
+
+	f.metrics.ResourcesRendered(xrAPIVersion, xrKind, len(cts)-skipped-ignored)
 
 	log.Info("Successfully processed patch-and-transform resources",
 		"resource-templates", len(input.Resources),
 		"existing-resources", existing,
 		"warnings", warnings,
 		"skipped", skipped,
+		"ignored", ignored,
 	)
 
 	return rsp, nil
 }
+
+// isEmptyEnvironment returns true if the supplied environment has no fields
+// other than its apiVersion and kind.
+func isEmptyEnvironment(env *unstructured.Unstructured) bool {
+	for k := range env.Object {
+		if k != "apiVersion" && k != "kind" {
+			return false
+		}
+	}
+	return true
+}
+
+// requeueAfter shortens the TTL of the supplied response to the supplied
+// duration, unless it's already shorter.
+func requeueAfter(rsp *fnv1.RunFunctionResponse, d time.Duration) {
+	if rsp.GetMeta() == nil {
+		rsp.Meta = &fnv1.ResponseMeta{}
+	}
+	if ttl := rsp.GetMeta().GetTtl(); ttl != nil && ttl.AsDuration() <= d {
+		return
+	}
+	rsp.Meta.Ttl = durationpb.New(d)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
@@ -47,10 +50,16 @@ func TestRunFunction(t *testing.T) {
 			want: want{
 				rsp: &fnv1.RunFunctionResponse{
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity": "SEVERITY_FATAL",
+						"reason":   ReasonInvalidInput,
+						"message":  "invalid Function input: resources: Required value: resources, environment patches, or environment configs are required",
+					}),
 					Results: []*fnv1.Result{
 						{
 							Severity: fnv1.Severity_SEVERITY_FATAL,
-							Message:  "invalid Function input: resources: Required value: resources or environment patches are required",
+							Message:  "invalid Function input: resources: Required value: resources, environment patches, or environment configs are required",
+							Reason:   ptr.To(ReasonInvalidInput),
 							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
 						},
 					},
@@ -58,10 +67,53 @@ func TestRunFunction(t *testing.T) {
 			},
 		},
 		"RenderBaseTemplateWithoutPatches": {
-			reason: "A simple base template with no patches should be rendered and returned as a desired object.",
+			reason: "A simple base template with no patches should be rendered and returned as a desired object. No environment should be written to the context, because there isn't one.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"EnvironmentPassthrough": {
+			reason: "An empty environment should be written to the context if environment passthrough is enabled.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
+						Environment: &v1beta1.Environment{
+							Passthrough: ptr.To(true),
+						},
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
@@ -209,42 +261,31 @@ func TestRunFunction(t *testing.T) {
 							},
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"PatchDesiredResource": {
-			reason: "It should be possible to patch & transform a desired resource returned by a previous Function in the pipeline.",
+		"PatchWholeSpecFromV1Layout": {
+			reason: "Patching the whole spec of a Crossplane v1 composite resource shouldn't add the fields Crossplane manages in the v2 layout, but patches should still be able to read them from the v2 layout.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
-								// This template base no base, so we try to
-								// patch the resource named "cool-resource" in
-								// the desired resources array.
 								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 								Patches: []v1beta1.ComposedPatch{
 									{
 										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.widgets"),
-											ToFieldPath:   ptr.To[string]("spec.watchers"),
-											Transforms: []v1beta1.Transform{
-												{
-													Type: v1beta1.TransformTypeConvert,
-													Convert: &v1beta1.ConvertTransform{
-														ToType: v1beta1.TransformIOTypeInt64,
-													},
-												},
-												{
-													Type: v1beta1.TransformTypeMath,
-													Math: &v1beta1.MathTransform{
-														Type:     v1beta1.MathTransformTypeMultiply,
-														Multiply: ptr.To[int64](3),
-													},
-												},
-											},
+											FromFieldPath: ptr.To[string]("spec"),
+											ToFieldPath:   ptr.To[string]("spec.forProvider"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.crossplane.compositionRevisionRef.name"),
+											ToFieldPath:   ptr.To[string]("metadata.labels[revision]"),
 										},
 									},
 								},
@@ -253,17 +294,12 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"region":"us-east-1","compositionRevisionRef":{"name":"cool-abc123"}}}`),
 						},
 					},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
-						},
-						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
-							},
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 					},
 				},
@@ -273,35 +309,39 @@ func TestRunFunction(t *testing.T) {
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 						Resources: map[string]*fnv1.Resource{
 							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":30}}`),
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"labels":{"revision":"cool-abc123"}},"spec":{"forProvider":{"region":"us-east-1","compositionRevisionRef":{"name":"cool-abc123"}}}}`),
 							},
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"NothingToPatch": {
-			reason: "We should return an error if we're trying to patch a desired resource that doesn't exist.",
+		"PatchWholeSpecFromV2Layout": {
+			reason: "Patching the whole spec of a Crossplane v2 composite resource shouldn't add the fields Crossplane manages in the v1 layout, but patches should still be able to read them from the v1 layout.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
-								// This template base no base, so we try to
-								// patch the resource named "cool-resource" in
-								// the desired resources array.
 								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 								Patches: []v1beta1.ComposedPatch{
 									{
 										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.widgets"),
-											ToFieldPath:   ptr.To[string]("spec.watchers"),
+											FromFieldPath: ptr.To[string]("spec"),
+											ToFieldPath:   ptr.To[string]("spec.forProvider"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.compositionRevisionRef.name"),
+											ToFieldPath:   ptr.To[string]("metadata.labels[revision]"),
 										},
 									},
 								},
@@ -310,12 +350,12 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"region":"us-east-1","crossplane":{"compositionRevisionRef":{"name":"cool-abc123"}}}}`),
 						},
 					},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 					},
 				},
@@ -325,28 +365,31 @@ func TestRunFunction(t *testing.T) {
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
-					},
-					Results: []*fnv1.Result{
-						{
-							Severity: fnv1.Severity_SEVERITY_FATAL,
-							Message:  fmt.Sprintf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", "cool-resource"),
-							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"labels":{"revision":"cool-abc123"}},"spec":{"forProvider":{"region":"us-east-1","crossplane":{"compositionRevisionRef":{"name":"cool-abc123"}}}}}`),
+							},
 						},
 					},
 				},
 			},
 		},
-		"ReplaceDesiredResource": {
-			reason: "A simple base template with no patches should be rendered and replace an existing desired object with the same name.",
+		"AnnotateRenderOrdinals": {
+			reason: "Composed resources should be annotated with the index of their resource template.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
+						AnnotateRenderOrdinals: true,
 						Resources: []v1beta1.ComposedTemplate{
 							{
-								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"widgets":9001}}`)},
+								Name: "first",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+							{
+								Name: "second",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 							},
 						},
 					}),
@@ -355,13 +398,58 @@ func TestRunFunction(t *testing.T) {
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":42}}`),
+							"first": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"annotations":{"pt.fn.crossplane.io/render-ordinal":"0"}}}`),
+							},
+							"second": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"annotations":{"pt.fn.crossplane.io/render-ordinal":"1"}}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"AnnotateRenderOrdinalsAfterInsertingTemplate": {
+			reason: "Existing composed resources should keep their observed render ordinals when a resource template is inserted before them. The new composed resource should get the next ordinal.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						AnnotateRenderOrdinals: true,
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "first",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+							{
+								Name: "inserted",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+							{
+								Name: "second",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"first": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"first-abc","annotations":{"pt.fn.crossplane.io/render-ordinal":"0"}}}`),
+							},
+							"second": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"second-abc","annotations":{"pt.fn.crossplane.io/render-ordinal":"1"}}}`),
 							},
 						},
 					},
@@ -375,27 +463,32 @@ func TestRunFunction(t *testing.T) {
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"widgets":9001}}`),
+							"first": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"first-abc","annotations":{"pt.fn.crossplane.io/render-ordinal":"0"}}}`),
+							},
+							"inserted": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"annotations":{"pt.fn.crossplane.io/render-ordinal":"2"}}}`),
+							},
+							"second": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"second-abc","annotations":{"pt.fn.crossplane.io/render-ordinal":"1"}}}`),
 							},
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"OptionalFieldPathNotFound": {
-			reason: "If we fail to patch a desired resource because an optional field path was not found we should skip the patch.",
+		"AnnotatePatchedPaths": {
+			reason: "Composed resources should be annotated with the field paths set by patches that were applied.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
+						AnnotatePatchedPaths: true,
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 								Patches: []v1beta1.ComposedPatch{
 									{
-										// This patch should work.
 										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
 											FromFieldPath: ptr.To[string]("spec.widgets"),
@@ -403,11 +496,23 @@ func TestRunFunction(t *testing.T) {
 										},
 									},
 									{
-										// This patch should be skipped, because
-										// the path is not found
+										// This patch isn't applied, because its
+										// from field path doesn't exist.
 										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
+											FromFieldPath: ptr.To[string]("spec.missing"),
+											ToFieldPath:   ptr.To[string]("spec.missing"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeCombineFromComposite,
+										Patch: v1beta1.Patch{
+											Combine: &v1beta1.Combine{
+												Variables: []v1beta1.CombineVariable{{FromFieldPath: "spec.widgets"}, {FromFieldPath: "spec.widgets"}},
+												Strategy:  v1beta1.CombineStrategyString,
+												String:    &v1beta1.StringCombine{Format: "%s-%s"},
+											},
+											ToFieldPath: ptr.To[string]("spec.combined"),
 										},
 									},
 								},
@@ -430,70 +535,44 @@ func TestRunFunction(t *testing.T) {
 						},
 						Resources: map[string]*fnv1.Resource{
 							"cool-resource": {
-								// Watchers becomes "10" because our first patch
-								// worked. We only skipped the second patch.
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10"}}`),
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"annotations":{"pt.fn.crossplane.io/patched-paths":"spec.watchers<-spec.widgets,spec.combined<-spec.widgets+spec.widgets"}},"spec":{"watchers":"10","combined":"10-10"}}`),
 							},
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"RequiredFieldPathNotFound": {
-			reason: "If we fail to patch a desired resource because a required field path was not found, and the resource doesn't exist, we should not add it to desired state (i.e. create it).",
+		"PatchDesiredResource": {
+			reason: "It should be possible to patch & transform a desired resource returned by a previous Function in the pipeline.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
-								Name: "new-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
-								Patches: []v1beta1.ComposedPatch{
-									{
-										// This patch will fail because the path
-										// is not found.
-										Type: v1beta1.PatchTypeFromCompositeFieldPath,
-										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
-											Policy: &v1beta1.PatchPolicy{
-												FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
-											},
-										},
-									},
-								},
-							},
-							{
-								Name: "existing-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"targetObject": {"keep": "me"}}}`)},
+								// This template base no base, so we try to
+								// patch the resource named "cool-resource" in
+								// the desired resources array.
+								Name: "cool-resource",
 								Patches: []v1beta1.ComposedPatch{
 									{
-										// This patch should work.
 										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
 											FromFieldPath: ptr.To[string]("spec.widgets"),
 											ToFieldPath:   ptr.To[string]("spec.watchers"),
-										},
-									},
-									{
-										// This patch should work too and properly handle mergeOptions.
-										Type: v1beta1.PatchTypeFromCompositeFieldPath,
-										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.sourceObject"),
-											ToFieldPath:   ptr.To[string]("spec.targetObject"),
-											Policy: &v1beta1.PatchPolicy{
-												ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjects),
-											},
-										},
-									},
-									{
-										// This patch will fail because the path
-										// is not found.
-										Type: v1beta1.PatchTypeFromCompositeFieldPath,
-										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
-											Policy: &v1beta1.PatchPolicy{
-												FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
+											Transforms: []v1beta1.Transform{
+												{
+													Type: v1beta1.TransformTypeConvert,
+													Convert: &v1beta1.ConvertTransform{
+														ToType: v1beta1.TransformIOTypeInt64,
+													},
+												},
+												{
+													Type: v1beta1.TransformTypeMath,
+													Math: &v1beta1.MathTransform{
+														Type:     v1beta1.MathTransformTypeMultiply,
+														Multiply: ptr.To[int64](3),
+													},
+												},
 											},
 										},
 									},
@@ -503,20 +582,18 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10", "sourceObject": {"me": "too"}}}`),
-						},
-						Resources: map[string]*fnv1.Resource{
-							// "existing-resource" exists.
-							"existing-resource": {},
-
-							// Note "new-resource" doesn't appear in the
-							// observed resources. It doesn't yet exist.
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
 					},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
 					},
 				},
 			},
@@ -526,60 +603,35 @@ func TestRunFunction(t *testing.T) {
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
-							Ready:    fnv1.Ready_READY_FALSE,
 						},
 						Resources: map[string]*fnv1.Resource{
-							// Note that the first patch did work. We only
-							// skipped the patch from the required field path.
-							"existing-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10", "targetObject": {"me": "too", "keep": "me"}}}`),
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":30}}`),
 							},
-
-							// Note "new-resource" doesn't appear here.
-						},
-					},
-					Context: contextWithEnvironment(nil),
-					Results: []*fnv1.Result{
-						{
-							Severity: fnv1.Severity_SEVERITY_WARNING,
-							Message:  `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
-							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
-						},
-						{
-							Severity: fnv1.Severity_SEVERITY_WARNING,
-							Message:  `cannot render composed resource "existing-resource" "FromCompositeFieldPath" patch at index 2: ignoring 'policy.fromFieldPath: Required' because 'to' resource already exists: spec.doesNotExist: no such field`,
-							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
 						},
 					},
 				},
 			},
 		},
-		"PatchErrorIsFatal": {
-			reason: "If we fail to patch a desired resource we should return a fatal result.",
+		"NothingToPatch": {
+			reason: "We should return an error if we're trying to patch a desired resource that doesn't exist.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
+								// This template base no base, so we try to
+								// patch the resource named "cool-resource" in
+								// the desired resources array.
 								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
 								Patches: []v1beta1.ComposedPatch{
 									{
-										// This patch should work.
 										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
 											FromFieldPath: ptr.To[string]("spec.widgets"),
 											ToFieldPath:   ptr.To[string]("spec.watchers"),
 										},
 									},
-									{
-										// This patch should return an error,
-										// because the path is not an array.
-										Type: v1beta1.PatchTypeFromCompositeFieldPath,
-										Patch: v1beta1.Patch{
-											FromFieldPath: ptr.To[string]("spec.widgets[0]"),
-										},
-									},
 								},
 							},
 						},
@@ -604,25 +656,32 @@ func TestRunFunction(t *testing.T) {
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
 					},
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity": "SEVERITY_FATAL",
+						"reason":   ReasonInvalidBase,
+						"message":  fmt.Sprintf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", "cool-resource"),
+						"resource": "cool-resource",
+					}),
 					Results: []*fnv1.Result{
 						{
 							Severity: fnv1.Severity_SEVERITY_FATAL,
-							Message:  fmt.Sprintf("cannot render composed resource %q %q patch at index 1: spec.widgets: not an array", "cool-resource", "FromCompositeFieldPath"),
+							Message:  fmt.Sprintf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", "cool-resource"),
+							Reason:   ptr.To(ReasonInvalidBase),
 							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
 						},
 					},
 				},
 			},
 		},
-		"ObservedResourceKeepsItsName": {
-			reason: "If a template corresponds to an existing observed resource we should keep its name (and namespace).",
+		"ReplaceDesiredResource": {
+			reason: "A simple base template with no patches should be rendered and replace an existing desired object with the same name.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"widgets":9001}}`)},
 							},
 						},
 					}),
@@ -630,16 +689,16 @@ func TestRunFunction(t *testing.T) {
 						Composite: &fnv1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
-						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
-							},
-						},
 					},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":42}}`),
+							},
+						},
 					},
 				},
 			},
@@ -652,28 +711,38 @@ func TestRunFunction(t *testing.T) {
 						},
 						Resources: map[string]*fnv1.Resource{
 							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"widgets":9001}}`),
 							},
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"ExtractCompositeConnectionDetails": {
-			reason: "We should extract any XR connection details specified by a composed template.",
+		"OptionalFieldPathNotFound": {
+			reason: "If we fail to patch a desired resource because an optional field path was not found we should skip the patch.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
-								ConnectionDetails: []v1beta1.ConnectionDetail{
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								Patches: []v1beta1.ComposedPatch{
 									{
-										Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
-										Name:                    "very",
-										FromConnectionSecretKey: ptr.To[string]("very"),
+										// This patch should work.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											ToFieldPath:   ptr.To[string]("spec.watchers"),
+										},
+									},
+									{
+										// This patch should be skipped, because
+										// the path is not found
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
+										},
 									},
 								},
 							},
@@ -681,23 +750,7 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
-						},
-						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
-								ConnectionDetails: map[string][]byte{
-									"very": []byte("secret"),
-								},
-							},
-						},
-					},
-					Desired: &fnv1.State{
-						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
-							ConnectionDetails: map[string][]byte{
-								"existing": []byte("supersecretvalue"),
-							},
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
 					},
 				},
@@ -708,50 +761,72 @@ func TestRunFunction(t *testing.T) {
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
-							ConnectionDetails: map[string][]byte{
-								"existing": []byte("supersecretvalue"),
-								"very":     []byte("secret"),
-							},
 						},
 						Resources: map[string]*fnv1.Resource{
 							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+								// Watchers becomes "10" because our first patch
+								// worked. We only skipped the second patch.
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10"}}`),
 							},
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"PatchToComposite": {
-			reason: "A basic ToCompositeFieldPath patch should work.",
+		"RequiredFieldPathNotFound": {
+			reason: "If we fail to patch a desired resource because a required field path was not found, and the resource doesn't exist, we should not add it to desired state (i.e. create it).",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
-								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Name: "new-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
 								Patches: []v1beta1.ComposedPatch{
 									{
-										Type: v1beta1.PatchTypeToCompositeFieldPath,
+										// This patch will fail because the path
+										// is not found.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
+											Policy: &v1beta1.PatchPolicy{
+												FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
+											},
+										},
+									},
+								},
+							},
+							{
+								Name: "existing-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"targetObject": {"keep": "me"}}}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										// This patch should work.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
 										Patch: v1beta1.Patch{
 											FromFieldPath: ptr.To[string]("spec.widgets"),
 											ToFieldPath:   ptr.To[string]("spec.watchers"),
-											Transforms: []v1beta1.Transform{
-												{
-													Type: v1beta1.TransformTypeConvert,
-													Convert: &v1beta1.ConvertTransform{
-														ToType: v1beta1.TransformIOTypeInt64,
-													},
-												},
-												{
-													Type: v1beta1.TransformTypeMath,
-													Math: &v1beta1.MathTransform{
-														Type:     v1beta1.MathTransformTypeMultiply,
-														Multiply: ptr.To[int64](3),
-													},
-												},
+										},
+									},
+									{
+										// This patch should work too and properly handle mergeOptions.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.sourceObject"),
+											ToFieldPath:   ptr.To[string]("spec.targetObject"),
+											Policy: &v1beta1.PatchPolicy{
+												ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjects),
+											},
+										},
+									},
+									{
+										// This patch will fail because the path
+										// is not found.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
+											Policy: &v1beta1.PatchPolicy{
+												FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
 											},
 										},
 									},
@@ -761,17 +836,19 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10", "sourceObject": {"me": "too"}}}`),
 						},
 						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"spec":{"widgets":"10"}}`),
-							},
+							// "existing-resource" exists.
+							"existing-resource": {},
+
+							// Note "new-resource" doesn't appear in the
+							// observed resources. It doesn't yet exist.
 						},
 					},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
 					},
 				},
@@ -781,49 +858,74 @@ func TestRunFunction(t *testing.T) {
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"watchers":30}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+							Ready:    fnv1.Ready_READY_FALSE,
 						},
 						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							// Note that the first patch did work. We only
+							// skipped the patch from the required field path.
+							"existing-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10", "targetObject": {"me": "too", "keep": "me"}}}`),
 							},
+
+							// Note "new-resource" doesn't appear here.
+						},
+					},
+					Context: contextWithResults(nil,
+						map[string]interface{}{
+							"severity":   "SEVERITY_WARNING",
+							"reason":     ReasonRequiredFieldPathNotFound,
+							"target":     fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.String(),
+							"message":    `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
+							"resource":   "new-resource",
+							"patchIndex": 0,
+							"patchType":  "FromCompositeFieldPath",
+						},
+						map[string]interface{}{
+							"severity":   "SEVERITY_WARNING",
+							"reason":     ReasonRequiredFieldPathNotFound,
+							"target":     fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.String(),
+							"message":    `cannot render composed resource "existing-resource" "FromCompositeFieldPath" patch at index 2: ignoring 'policy.fromFieldPath: Required' because 'to' resource already exists: spec.doesNotExist: no such field`,
+							"resource":   "existing-resource",
+							"patchIndex": 2,
+							"patchType":  "FromCompositeFieldPath",
+						},
+					),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
+							Reason:   ptr.To(ReasonRequiredFieldPathNotFound),
+							Target:   fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `cannot render composed resource "existing-resource" "FromCompositeFieldPath" patch at index 2: ignoring 'policy.fromFieldPath: Required' because 'to' resource already exists: spec.doesNotExist: no such field`,
+							Reason:   ptr.To(ReasonRequiredFieldPathNotFound),
+							Target:   fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
 						},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 		},
-		"PatchToCompositeWithEnvironmentPatches": {
-			reason: "A basic ToCompositeFieldPath patch should work with environment.patches.",
+		"RequiredFieldPathNotFoundIsFatalWhenStrict": {
+			reason: "In strict mode a patch from a required field path that doesn't exist should return a fatal result.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
+						Strict: true,
 						Resources: []v1beta1.ComposedTemplate{
 							{
-								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
-							},
-						},
-						Environment: &v1beta1.Environment{
-							Patches: []v1beta1.EnvironmentPatch{
-								{
-									Type: v1beta1.PatchTypeToCompositeFieldPath,
-									Patch: v1beta1.Patch{
-										FromFieldPath: ptr.To[string]("widgets"),
-										ToFieldPath:   ptr.To[string]("spec.watchers"),
-										Transforms: []v1beta1.Transform{
-											{
-												Type: v1beta1.TransformTypeConvert,
-												Convert: &v1beta1.ConvertTransform{
-													ToType: v1beta1.TransformIOTypeInt64,
-												},
-											},
-											{
-												Type: v1beta1.TransformTypeMath,
-												Math: &v1beta1.MathTransform{
-													Type:     v1beta1.MathTransformTypeMultiply,
-													Multiply: ptr.To[int64](3),
-												},
+								Name: "new-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
+											ToFieldPath:   ptr.To[string]("spec.explode"),
+											Policy: &v1beta1.PatchPolicy{
+												FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
 											},
 										},
 									},
@@ -833,67 +935,208 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
-						Resources: map[string]*fnv1.Resource{},
 					},
-					Context: contextWithEnvironment(map[string]interface{}{
-						"widgets": "10",
-					}),
 				},
 			},
 			want: want{
 				rsp: &fnv1.RunFunctionResponse{
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
-					Desired: &fnv1.State{
-						Composite: &fnv1.Resource{
-							// spec.watchers = 10 * 3 = 30
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":30}}`),
-						},
-						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity":   "SEVERITY_FATAL",
+						"reason":     ReasonRequiredFieldPathNotFound,
+						"message":    `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
+						"resource":   "new-resource",
+						"patchIndex": 0,
+						"patchType":  "FromCompositeFieldPath",
+					}),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_FATAL,
+							Message:  `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
+							Reason:   ptr.To(ReasonRequiredFieldPathNotFound),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"RequiredFieldPathNotFoundRequeuesSooner": {
+			reason: "A patch from a required field path that doesn't exist should shorten the response TTL to its requeueAfter policy.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "new-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.doesNotExist"),
+											ToFieldPath:   ptr.To[string]("spec.explode"),
+											Policy: &v1beta1.PatchPolicy{
+												FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
+												RequeueAfter:  &metav1.Duration{Duration: 30 * time.Second},
+											},
+										},
+									},
+								},
 							},
 						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+						},
 					},
-					Context: contextWithEnvironment(map[string]interface{}{
-						"widgets": "10",
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(30 * time.Second)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							Ready:    fnv1.Ready_READY_FALSE,
+						},
+					},
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity":   "SEVERITY_WARNING",
+						"reason":     ReasonRequiredFieldPathNotFound,
+						"target":     fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.String(),
+						"message":    `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
+						"resource":   "new-resource",
+						"patchIndex": 0,
+						"patchType":  "FromCompositeFieldPath",
 					}),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `not adding new composed resource "new-resource" to desired state because "FromCompositeFieldPath" patch at index 0 has 'policy.fromFieldPath: Required': spec.doesNotExist: no such field`,
+							Reason:   ptr.To(ReasonRequiredFieldPathNotFound),
+							Target:   fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
 				},
 			},
 		},
-		"EnvironmentPatchToEnvironment": {
-			reason: "A basic ToEnvironment patch should work with environment.patches.",
+		"PatchErrorIsFatal": {
+			reason: "If we fail to patch a desired resource we should return a fatal result.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
-								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										// This patch should work.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											ToFieldPath:   ptr.To[string]("spec.watchers"),
+										},
+									},
+									{
+										// This patch should return an error,
+										// because the path is not an array.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets[0]"),
+										},
+									},
+								},
 							},
 						},
-						Environment: &v1beta1.Environment{
-							Patches: []v1beta1.EnvironmentPatch{
-								{
-									Type: v1beta1.PatchTypeFromCompositeFieldPath,
-									Patch: v1beta1.Patch{
-										FromFieldPath: ptr.To[string]("spec.watchers"),
-										ToFieldPath:   ptr.To[string]("widgets"),
-										Transforms: []v1beta1.Transform{
-											{
-												Type: v1beta1.TransformTypeMath,
-												Math: &v1beta1.MathTransform{
-													Type:     v1beta1.MathTransformTypeMultiply,
-													Multiply: ptr.To[int64](3),
-												},
-											},
-											{
-												Type: v1beta1.TransformTypeConvert,
-												Convert: &v1beta1.ConvertTransform{
-													ToType: v1beta1.TransformIOTypeString,
-												},
-											},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+						},
+					},
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity":   "SEVERITY_FATAL",
+						"reason":     ReasonPatchFailed,
+						"message":    fmt.Sprintf("cannot render composed resource %q %q patch at index 1: spec.widgets: not an array", "cool-resource", "FromCompositeFieldPath"),
+						"resource":   "cool-resource",
+						"patchIndex": 1,
+						"patchType":  "FromCompositeFieldPath",
+					}),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_FATAL,
+							Message:  fmt.Sprintf("cannot render composed resource %q %q patch at index 1: spec.widgets: not an array", "cool-resource", "FromCompositeFieldPath"),
+							Reason:   ptr.To(ReasonPatchFailed),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"PatchErrorIsSkipped": {
+			reason: "If a resource template's patch failure policy isn't Fail we should skip the failed patch, and keep rendering.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						OnPatchFailure: ptr.To(v1beta1.PatchFailurePolicySkip),
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:           "warn-resource",
+								Base:           &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								OnPatchFailure: ptr.To(v1beta1.PatchFailurePolicyWarn),
+								Patches: []v1beta1.ComposedPatch{
+									{
+										// This patch should return an error,
+										// because the path is not an array.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets[0]"),
+										},
+									},
+									{
+										// This patch should still work.
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											ToFieldPath:   ptr.To[string]("spec.watchers"),
+										},
+									},
+								},
+							},
+							{
+								Name: "skip-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets[0]"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											ToFieldPath:   ptr.To[string]("spec.watchers"),
 										},
 									},
 								},
@@ -902,11 +1145,9 @@ func TestRunFunction(t *testing.T) {
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":10}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
 						},
-						Resources: map[string]*fnv1.Resource{},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 			want: want{
@@ -914,22 +1155,38 @@ func TestRunFunction(t *testing.T) {
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 						Resources: map[string]*fnv1.Resource{
-							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							"warn-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10"}}`),
+							},
+							"skip-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10"}}`),
 							},
 						},
 					},
-					Context: contextWithEnvironment(map[string]interface{}{
-						"widgets": "30",
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity":   "SEVERITY_WARNING",
+						"reason":     ReasonPatchFailed,
+						"message":    fmt.Sprintf("cannot render composed resource %q %q patch at index 0: skipping patch: spec.widgets: not an array", "warn-resource", "FromCompositeFieldPath"),
+						"resource":   "warn-resource",
+						"patchIndex": 0,
+						"patchType":  "FromCompositeFieldPath",
 					}),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  fmt.Sprintf("cannot render composed resource %q %q patch at index 0: skipping patch: spec.widgets: not an array", "warn-resource", "FromCompositeFieldPath"),
+							Reason:   ptr.To(ReasonPatchFailed),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
 				},
 			},
 		},
-		"PatchToCompositeSupportsFromEnvironmentFieldPath": {
-			reason: "A basic FromEnvironmentFieldPath patch should work with environment.patches.",
+		"ObservedResourceKeepsItsName": {
+			reason: "If a template corresponds to an existing observed resource we should keep its name (and namespace).",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
@@ -939,42 +1196,1463 @@ func TestRunFunction(t *testing.T) {
 								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 							},
 						},
-						Environment: &v1beta1.Environment{
-							Patches: []v1beta1.EnvironmentPatch{
-								{
-									Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
-									Patch: v1beta1.Patch{
-										FromFieldPath: ptr.To[string]("widgets"),
-										ToFieldPath:   ptr.To[string]("spec.watchers"),
-										Transforms: []v1beta1.Transform{
-											{
-												Type: v1beta1.TransformTypeConvert,
-												Convert: &v1beta1.ConvertTransform{
-													ToType: v1beta1.TransformIOTypeInt64,
-												},
-											},
-											{
-												Type: v1beta1.TransformTypeMath,
-												Math: &v1beta1.MathTransform{
-													Type:     v1beta1.MathTransformTypeMultiply,
-													Multiply: ptr.To[int64](3),
-												},
-											},
-										},
-									},
-								},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"ExtractCompositeConnectionDetails": {
+			reason: "We should extract any XR connection details specified by a composed template.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ConnectionDetails: []v1beta1.ConnectionDetail{
+									{
+										Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+										Name:                    "very",
+										FromConnectionSecretKey: ptr.To[string]("very"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+								ConnectionDetails: map[string][]byte{
+									"very": []byte("secret"),
+								},
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							ConnectionDetails: map[string][]byte{
+								"existing": []byte("supersecretvalue"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							ConnectionDetails: map[string][]byte{
+								"existing": []byte("supersecretvalue"),
+								"very":     []byte("secret"),
+							},
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"ExtractConnectionDetailsFromStatus": {
+			reason: "We should extract connection details from composed resource status, annotate the XR with those that don't exist yet, and not consider the resource ready until the required ones exist.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ConnectionDetails: []v1beta1.ConnectionDetail{
+									{
+										Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+										Name:          "endpoint",
+										FromFieldPath: ptr.To[string]("status.atProvider.endpoint"),
+									},
+									{
+										Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+										Name:          "password",
+										FromFieldPath: ptr.To[string]("status.atProvider.password"),
+										Policy:        &v1beta1.ConnectionDetailPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)},
+									},
+									{
+										Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+										Name:          "username",
+										FromFieldPath: ptr.To[string]("status.atProvider.username"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"status":{"atProvider":{"endpoint":"example.org"},"conditions":[{"type":"Ready","status":"True"}]}}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","metadata":{"annotations":{"pt.fn.crossplane.io/pending-connection-details":"password,username"}}}`),
+							ConnectionDetails: map[string][]byte{
+								"endpoint": []byte("example.org"),
+							},
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"ConnectionDetailsWhenReady": {
+			reason: "We shouldn't extract connection details from a composed resource that isn't ready if its connection details policy is WhenReady.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:                    "cool-resource",
+								Base:                    &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ConnectionDetailsPolicy: ptr.To(v1beta1.ConnectionDetailsPolicyWhenReady),
+								ConnectionDetails: []v1beta1.ConnectionDetail{
+									{
+										Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+										Name:                    "very",
+										FromConnectionSecretKey: ptr.To[string]("very"),
+									},
+								},
+							},
+							{
+								Name:                    "ready-resource",
+								Base:                    &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ConnectionDetailsPolicy: ptr.To(v1beta1.ConnectionDetailsPolicyWhenReady),
+								ConnectionDetails: []v1beta1.ConnectionDetail{
+									{
+										Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+										Name:                    "much",
+										FromConnectionSecretKey: ptr.To[string]("much"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+								ConnectionDetails: map[string][]byte{
+									"very": []byte("placeholder"),
+								},
+							},
+							"ready-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"ready-42"},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`),
+								ConnectionDetails: map[string][]byte{
+									"much": []byte("secret"),
+								},
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							ConnectionDetails: map[string][]byte{
+								"much": []byte("secret"),
+							},
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+							"ready-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"ready-42"}}`),
+								Ready:    fnv1.Ready_READY_TRUE,
+							},
+						},
+					},
+				},
+			},
+		},
+		"ExtractCompositeConnectionDetailsFromEnvironment": {
+			reason: "We should extract XR connection details from values patched to the environment.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeToEnvironmentFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.endpoint"),
+											ToFieldPath:   ptr.To[string]("endpoint"),
+										},
+									},
+								},
+							},
+						},
+						ConnectionDetails: []v1beta1.ConnectionDetail{
+							{
+								Type:          v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+								Name:          "endpoint",
+								FromFieldPath: ptr.To[string]("endpoint"),
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","status":{"endpoint":"db.example.org"}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							ConnectionDetails: map[string][]byte{
+								"endpoint": []byte("db.example.org"),
+							},
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"endpoint": "db.example.org",
+					}),
+				},
+			},
+		},
+		"BaseFromEnvironment": {
+			reason: "A resource template should be able to read its base from the environment.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:     "cool-resource",
+								BaseFrom: &v1beta1.BaseFrom{EnvironmentFieldPath: ptr.To[string]("bases.cool")},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"bases": map[string]interface{}{
+							"cool": map[string]interface{}{
+								"apiVersion": "example.org/v1",
+								"kind":       "CD",
+								"spec":       map[string]interface{}{"region": "eu-west-1"},
+							},
+						},
+					}),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"region":"eu-west-1"}}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"bases": map[string]interface{}{
+							"cool": map[string]interface{}{
+								"apiVersion": "example.org/v1",
+								"kind":       "CD",
+								"spec":       map[string]interface{}{"region": "eu-west-1"},
+							},
+						},
+					}),
+				},
+			},
+		},
+		"PatchToComposite": {
+			reason: "A basic ToCompositeFieldPath patch should work.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeToCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											ToFieldPath:   ptr.To[string]("spec.watchers"),
+											Transforms: []v1beta1.Transform{
+												{
+													Type: v1beta1.TransformTypeConvert,
+													Convert: &v1beta1.ConvertTransform{
+														ToType: v1beta1.TransformIOTypeInt64,
+													},
+												},
+												{
+													Type: v1beta1.TransformTypeMath,
+													Math: &v1beta1.MathTransform{
+														Type:     v1beta1.MathTransformTypeMultiply,
+														Multiply: ptr.To[int64](3),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"spec":{"widgets":"10"}}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"watchers":30}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"PatchToCompositeInV1Layout": {
+			reason: "A ToCompositeFieldPath patch to a field Crossplane manages in the v2 layout should be written in the v1 layout of a Crossplane v1 composite resource, without adding any other fields Crossplane manages.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeToCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.secretName"),
+											ToFieldPath:   ptr.To[string]("spec.crossplane.writeConnectionSecretToRef.name"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"compositionRef":{"name":"cool-composition"}}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"status":{"secretName":"cool-secret"}}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"writeConnectionSecretToRef":{"name":"cool-secret"}}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"PatchToCompositeInV2Layout": {
+			reason: "A ToCompositeFieldPath patch to a field Crossplane manages in the v1 layout should be written in the v2 layout of a Crossplane v2 composite resource, without adding any other fields Crossplane manages.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeToCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.secretName"),
+											ToFieldPath:   ptr.To[string]("spec.writeConnectionSecretToRef.name"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"crossplane":{"compositionRef":{"name":"cool-composition"}}}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"status":{"secretName":"cool-secret"}}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"crossplane":{"writeConnectionSecretToRef":{"name":"cool-secret"}}}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"PatchToCompositeWithEnvironmentPatches": {
+			reason: "A basic ToCompositeFieldPath patch should work with environment.patches.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+						Environment: &v1beta1.Environment{
+							Patches: []v1beta1.EnvironmentPatch{
+								{
+									Type: v1beta1.PatchTypeToCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("widgets"),
+										ToFieldPath:   ptr.To[string]("spec.watchers"),
+										Transforms: []v1beta1.Transform{
+											{
+												Type: v1beta1.TransformTypeConvert,
+												Convert: &v1beta1.ConvertTransform{
+													ToType: v1beta1.TransformIOTypeInt64,
+												},
+											},
+											{
+												Type: v1beta1.TransformTypeMath,
+												Math: &v1beta1.MathTransform{
+													Type:     v1beta1.MathTransformTypeMultiply,
+													Multiply: ptr.To[int64](3),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`),
+						},
+						Resources: map[string]*fnv1.Resource{},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							// spec.watchers = 10 * 3 = 30
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":30}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+		},
+		"EnvironmentPatchToEnvironment": {
+			reason: "A basic ToEnvironment patch should work with environment.patches.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+						Environment: &v1beta1.Environment{
+							Patches: []v1beta1.EnvironmentPatch{
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.watchers"),
+										ToFieldPath:   ptr.To[string]("widgets"),
+										Transforms: []v1beta1.Transform{
+											{
+												Type: v1beta1.TransformTypeMath,
+												Math: &v1beta1.MathTransform{
+													Type:     v1beta1.MathTransformTypeMultiply,
+													Multiply: ptr.To[int64](3),
+												},
+											},
+											{
+												Type: v1beta1.TransformTypeConvert,
+												Convert: &v1beta1.ConvertTransform{
+													ToType: v1beta1.TransformIOTypeString,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":10}}`),
+						},
+						Resources: map[string]*fnv1.Resource{},
+					},
+					Context: contextWithEnvironment(nil),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "30",
+					}),
+				},
+			},
+		},
+		"PatchToCompositeSupportsFromEnvironmentFieldPath": {
+			reason: "A basic FromEnvironmentFieldPath patch should work with environment.patches.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+						Environment: &v1beta1.Environment{
+							Patches: []v1beta1.EnvironmentPatch{
+								{
+									Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("widgets"),
+										ToFieldPath:   ptr.To[string]("spec.watchers"),
+										Transforms: []v1beta1.Transform{
+											{
+												Type: v1beta1.TransformTypeConvert,
+												Convert: &v1beta1.ConvertTransform{
+													ToType: v1beta1.TransformIOTypeInt64,
+												},
+											},
+											{
+												Type: v1beta1.TransformTypeMath,
+												Math: &v1beta1.MathTransform{
+													Type:     v1beta1.MathTransformTypeMultiply,
+													Multiply: ptr.To[int64](3),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`),
+						},
+						Resources: map[string]*fnv1.Resource{},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							// spec.watchers = 10 * 3 = 30
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":30}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+		},
+		"EnvironmentPatchSupportsToEnvironmentFieldPath": {
+			reason: "ToEnvironmentFieldPath patch should work with environment.patches.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+						Environment: &v1beta1.Environment{
+							Patches: []v1beta1.EnvironmentPatch{
+								{
+									Type: v1beta1.PatchTypeToEnvironmentFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.watchers"),
+										ToFieldPath:   ptr.To[string]("widgets"),
+										Transforms: []v1beta1.Transform{
+											{
+												Type: v1beta1.TransformTypeMath,
+												Math: &v1beta1.MathTransform{
+													Type:     v1beta1.MathTransformTypeMultiply,
+													Multiply: ptr.To[int64](3),
+												},
+											},
+											{
+												Type: v1beta1.TransformTypeConvert,
+												Convert: &v1beta1.ConvertTransform{
+													ToType: v1beta1.TransformIOTypeString,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":10}}`),
+						},
+						Resources: map[string]*fnv1.Resource{},
+					},
+					Context: contextWithEnvironment(nil),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "30",
+					}),
+				},
+			},
+		},
+		"EnvironmentPatchAfterResources": {
+			reason: "An environment patch with stage After should see values patched to the environment by composed resources.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeToEnvironmentFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.widgets"),
+											ToFieldPath:   ptr.To[string]("widgets"),
+										},
+									},
+								},
+							},
+						},
+						Environment: &v1beta1.Environment{
+							Patches: []v1beta1.EnvironmentPatch{
+								{
+									// This patch runs before the composed
+									// resource patches the environment, so
+									// it should be skipped.
+									Type: v1beta1.PatchTypeToCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("widgets"),
+										ToFieldPath:   ptr.To[string]("status.before"),
+									},
+								},
+								{
+									Type:  v1beta1.PatchTypeToCompositeFieldPath,
+									Stage: v1beta1.EnvironmentPatchStageAfter,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("widgets"),
+										ToFieldPath:   ptr.To[string]("status.after"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","status":{"widgets":"10"}}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(nil),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"after":"10"}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+		},
+		"PatchToAndFromContext": {
+			reason: "Context patches should read from and write to arbitrary Function pipeline context keys.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromContextFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("[example.org/in].widgets"),
+											ToFieldPath:   ptr.To[string]("spec.widgets"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeToContextFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.id"),
+											ToFieldPath:   ptr.To[string]("[example.org/out].id"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","status":{"id":"cool-42"}}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{
+						"example.org/in": structpb.NewStructValue(resource.MustStructJSON(`{"widgets":"10"}`)),
+					}},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"widgets":"10"}}`),
+							},
+						},
+					},
+					Context: func() *structpb.Struct {
+						c := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+						c.Fields["example.org/in"] = structpb.NewStructValue(resource.MustStructJSON(`{"widgets":"10"}`))
+						c.Fields["example.org/out"] = structpb.NewStructValue(resource.MustStructJSON(`{"id":"cool-42"}`))
+						return c
+					}(),
+				},
+			},
+		},
+		"PatchFromExtraResources": {
+			reason: "Extra resources should be requested, and patches should read from those Crossplane has fetched.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						ExtraResources: []v1beta1.ExtraResource{
+							{
+								Name:       "config",
+								APIVersion: "v1",
+								Kind:       "ConfigMap",
+								MatchName:  ptr.To[string]("cool-config"),
+							},
+							{
+								Name:        "regions",
+								APIVersion:  "example.org/v1",
+								Kind:        "Region",
+								MatchLabels: map[string]string{"cool": "true"},
+							},
+						},
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromExtraResourceFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("config.data.widgets"),
+											ToFieldPath:   ptr.To[string]("spec.widgets"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromExtraResourceFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("regions[0].metadata.name"),
+											ToFieldPath:   ptr.To[string]("spec.region"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+					ExtraResources: map[string]*fnv1.Resources{
+						"regions": {
+							Items: []*fnv1.Resource{
+								{Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"Region","metadata":{"name":"us-west-2"}}`)},
+								{Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"Region","metadata":{"name":"us-east-2"}}`)},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"region":"us-east-2"}}`),
+							},
+						},
+					},
+					Requirements: &fnv1.Requirements{
+						ExtraResources: map[string]*fnv1.ResourceSelector{
+							"config": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1.ResourceSelector_MatchName{MatchName: "cool-config"},
+							},
+							"regions": {
+								ApiVersion: "example.org/v1",
+								Kind:       "Region",
+								Match:      &fnv1.ResourceSelector_MatchLabels{MatchLabels: &fnv1.MatchLabels{Labels: map[string]string{"cool": "true"}}},
+							},
+						},
+					},
+				},
+			},
+		},
+		"PatchFromClaim": {
+			reason: "Claim patches should read the composite resource's claim.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromClaimFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("namespace"),
+											ToFieldPath:   ptr.To[string]("metadata.labels[example.org/claim-namespace]"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"claimRef":{"name":"cool-claim","namespace":"cool-namespace"}}}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"labels":{"example.org/claim-namespace":"cool-namespace"}}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"PatchFromVariable": {
+			reason: "Variable patches should read the input's variables.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Variables: map[string]extv1.JSON{
+							"domain":  {Raw: []byte(`"example.org"`)},
+							"network": {Raw: []byte(`{"cidrs":["10.0.0.0/16"]}`)},
+						},
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromVariableFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("domain"),
+											ToFieldPath:   ptr.To[string]("spec.forProvider.domain"),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromVariableFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("network.cidrs[0]"),
+											ToFieldPath:   ptr.To[string]("spec.forProvider.cidr"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"forProvider":{"domain":"example.org","cidr":"10.0.0.0/16"}}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"BaseFromObserved": {
+			reason: "A resource template should be able to clone another observed composed resource as its base.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:             "green",
+								BaseFromObserved: ptr.To("blue"),
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.version"),
+											ToFieldPath:   ptr.To[string]("spec.forProvider.version"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"version":"2"}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"blue": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-blue","uid":"abc","annotations":{"crossplane.io/external-name":"blue"}},"spec":{"forProvider":{"region":"us-east-2","version":"1"}},"status":{"ready":true}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"green": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{},"spec":{"forProvider":{"region":"us-east-2","version":"2"}}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"PreviousNames": {
+			reason: "A renamed resource template should render the composed resource observed under its previous name, and keep that name.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:          "database",
+								PreviousNames: []string{"db"},
+								Base:          &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeToCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.endpoint"),
+											ToFieldPath:   ptr.To[string]("status.endpoint"),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"db": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-db"},"status":{"endpoint":"db.example.org"}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"endpoint":"db.example.org"}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"db": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-db"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"SkipIfNotObserved": {
+			reason: "An import-only resource template should only be rendered if its composed resource exists, without making the XR unready.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:              "imported",
+								Base:              &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								SkipIfNotObserved: true,
+							},
+							{
+								Name:              "not-imported",
+								Base:              &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								SkipIfNotObserved: true,
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"imported": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"imported","annotations":{"crossplane.io/external-name":"legacy"}}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"imported": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"imported"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"ConflictingComposedResources": {
+			reason: "Two resource templates that render the same composed resource should return a warning.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool"}}`)},
+							},
+							{
+								Name: "uncool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool"}}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool"}}`),
+							},
+							"uncool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool"}}`),
+							},
+						},
+					},
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity": "SEVERITY_WARNING",
+						"reason":   ReasonComposedResourceConflict,
+						"message":  `resource templates "cool-resource" and "uncool-resource" both render CD.example.org "cool", so the one Crossplane applies last overwrites the other`,
+						"resource": "uncool-resource",
+					}),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `resource templates "cool-resource" and "uncool-resource" both render CD.example.org "cool", so the one Crossplane applies last overwrites the other`,
+							Reason:   ptr.To(ReasonComposedResourceConflict),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"PruneOrphanedResource": {
+			reason: "An observed composed resource that no resource template renders should be omitted with a warning if prune is true.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Prune: ptr.To(true),
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-resource"}}`),
+							},
+							"removed-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"removed-resource"}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-resource"}}`),
+							},
+						},
+					},
+					Context: contextWithResults(nil, map[string]interface{}{
+						"severity": "SEVERITY_WARNING",
+						"reason":   ReasonComposedResourcePruned,
+						"message":  `composed resource "removed-resource" will be deleted because no resource template renders it, and prune is true`,
+						"resource": "removed-resource",
+					}),
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `composed resource "removed-resource" will be deleted because no resource template renders it, and prune is true`,
+							Reason:   ptr.To(ReasonComposedResourcePruned),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"RetainOrphanedResource": {
+			reason: "An observed composed resource that no resource template renders should be kept in the desired state as it's observed if prune is false.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Prune: ptr.To(false),
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"removed-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"removed-resource"},"spec":{"size":"large"},"status":{"ready":true}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+							"removed-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"removed-resource"},"spec":{"size":"large"}}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"ReadyFrom": {
+			reason: "A composed resource's readiness should be overridden with a boolean of the composite resource, even if its readiness checks fail.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:      "cool-resource",
+								Base:      &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ReadyFrom: &v1beta1.ReadyFrom{FieldPath: "spec.maintenance"},
 							},
 						},
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"maintenance":true}}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-resource"},"status":{"conditions":[{"type":"Ready","status":"False"}]}}`),
+							},
 						},
-						Resources: map[string]*fnv1.Resource{},
 					},
-					Context: contextWithEnvironment(map[string]interface{}{
-						"widgets": "10",
-					}),
 				},
 			},
 			want: want{
@@ -982,66 +2660,36 @@ func TestRunFunction(t *testing.T) {
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							// spec.watchers = 10 * 3 = 30
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":30}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
 						Resources: map[string]*fnv1.Resource{
 							"cool-resource": {
-								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-resource"}}`),
+								Ready:    fnv1.Ready_READY_TRUE,
 							},
 						},
 					},
-					Context: contextWithEnvironment(map[string]interface{}{
-						"widgets": "10",
-					}),
 				},
 			},
 		},
-		"EnvironmentPatchSupportsToEnvironmentFieldPath": {
-			reason: "ToEnvironmentFieldPath patch should work with environment.patches.",
+		"WriteInventoryTo": {
+			reason: "A summary of the desired composed resources should be written to the composite resource's status.",
 			args: args{
 				req: &fnv1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
+						WriteInventoryTo: ptr.To("status.inventory"),
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
 								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 							},
 						},
-						Environment: &v1beta1.Environment{
-							Patches: []v1beta1.EnvironmentPatch{
-								{
-									Type: v1beta1.PatchTypeToEnvironmentFieldPath,
-									Patch: v1beta1.Patch{
-										FromFieldPath: ptr.To[string]("spec.watchers"),
-										ToFieldPath:   ptr.To[string]("widgets"),
-										Transforms: []v1beta1.Transform{
-											{
-												Type: v1beta1.TransformTypeMath,
-												Math: &v1beta1.MathTransform{
-													Type:     v1beta1.MathTransformTypeMultiply,
-													Multiply: ptr.To[int64](3),
-												},
-											},
-											{
-												Type: v1beta1.TransformTypeConvert,
-												Convert: &v1beta1.ConvertTransform{
-													ToType: v1beta1.TransformIOTypeString,
-												},
-											},
-										},
-									},
-								},
-							},
-						},
 					}),
 					Observed: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":10}}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
 						},
-						Resources: map[string]*fnv1.Resource{},
 					},
-					Context: contextWithEnvironment(nil),
 				},
 			},
 			want: want{
@@ -1049,7 +2697,7 @@ func TestRunFunction(t *testing.T) {
 					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
 					Desired: &fnv1.State{
 						Composite: &fnv1.Resource{
-							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"inventory":{"desired":1,"ready":0,"resources":[{"name":"cool-resource","apiVersion":"example.org/v1","kind":"CD","ready":false}]}}}`),
 						},
 						Resources: map[string]*fnv1.Resource{
 							"cool-resource": {
@@ -1057,9 +2705,6 @@ func TestRunFunction(t *testing.T) {
 							},
 						},
 					},
-					Context: contextWithEnvironment(map[string]interface{}{
-						"widgets": "30",
-					}),
 				},
 			},
 		},
@@ -1209,12 +2854,17 @@ func TestRunFunction(t *testing.T) {
 			},
 			want: want{
 				rsp: &fnv1.RunFunctionResponse{
-					Meta:    &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
-					Context: contextWithEnvironment(map[string]interface{}{}),
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Context: contextWithResults(contextWithEnvironment(map[string]interface{}{}), map[string]interface{}{
+						"severity": "SEVERITY_FATAL",
+						"reason":   ReasonPatchFailed,
+						"message":  `cannot apply the "FromCompositeFieldPath" environment patch at index 1: spec.doesNotExist: no such field`,
+					}),
 					Results: []*fnv1.Result{
 						{
 							Severity: fnv1.Severity_SEVERITY_FATAL,
 							Message:  `cannot apply the "FromCompositeFieldPath" environment patch at index 1: spec.doesNotExist: no such field`,
+							Reason:   ptr.To(ReasonPatchFailed),
 							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
 						},
 					},
@@ -1421,6 +3071,178 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"EnvironmentConfigsMergedIntoEnvironment": {
+			reason: "Selected EnvironmentConfigs should be requested, and those Crossplane has fetched should be merged into the environment in order.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Environment: &v1beta1.Environment{
+							EnvironmentConfigs: []v1beta1.EnvironmentConfig{
+								{
+									Type: v1beta1.EnvironmentConfigTypeReference,
+									Ref:  &v1beta1.EnvironmentConfigReference{Name: "defaults"},
+								},
+								{
+									Type: v1beta1.EnvironmentConfigTypeSelector,
+									Selector: &v1beta1.EnvironmentConfigSelector{
+										MatchLabels: []v1beta1.EnvironmentConfigLabelMatcher{
+											{
+												Key:                "region",
+												ValueFromFieldPath: ptr.To[string]("spec.region"),
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"region":"us-east-2"}}`),
+						},
+					},
+					ExtraResources: map[string]*fnv1.Resources{
+						"pt.fn.crossplane.io/environment-config-0": {
+							Items: []*fnv1.Resource{
+								{Resource: resource.MustStructJSON(`{"apiVersion":"apiextensions.crossplane.io/v1alpha1","kind":"EnvironmentConfig","metadata":{"name":"defaults"},"data":{"size":"small","tags":{"team":"cool"}}}`)},
+							},
+						},
+						"pt.fn.crossplane.io/environment-config-1": {
+							Items: []*fnv1.Resource{
+								{Resource: resource.MustStructJSON(`{"apiVersion":"apiextensions.crossplane.io/v1alpha1","kind":"EnvironmentConfig","metadata":{"name":"us-east-2-b"},"data":{"size":"large"}}`)},
+								{Resource: resource.MustStructJSON(`{"apiVersion":"apiextensions.crossplane.io/v1alpha1","kind":"EnvironmentConfig","metadata":{"name":"us-east-2-a"},"data":{"size":"medium","tags":{"region":"us-east-2"}}}`)},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+					Requirements: &fnv1.Requirements{
+						ExtraResources: map[string]*fnv1.ResourceSelector{
+							"pt.fn.crossplane.io/environment-config-0": {
+								ApiVersion: "apiextensions.crossplane.io/v1alpha1",
+								Kind:       "EnvironmentConfig",
+								Match:      &fnv1.ResourceSelector_MatchName{MatchName: "defaults"},
+							},
+							"pt.fn.crossplane.io/environment-config-1": {
+								ApiVersion: "apiextensions.crossplane.io/v1alpha1",
+								Kind:       "EnvironmentConfig",
+								Match:      &fnv1.ResourceSelector_MatchLabels{MatchLabels: &fnv1.MatchLabels{Labels: map[string]string{"region": "us-east-2"}}},
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"size": "large",
+						"tags": map[string]interface{}{
+							"team":   "cool",
+							"region": "us-east-2",
+						},
+					}),
+				},
+			},
+		},
+		"DependsOnUnreadyResource": {
+			reason: "A new composed resource shouldn't be added to the desired state until the resources it depends on are ready.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:      "user",
+								Base:      &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"User"}`)},
+								DependsOn: []string{"database"},
+							},
+							{
+								Name: "database",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Database"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"Database","status":{"conditions":[{"type":"Ready","status":"False"}]}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							Ready:    fnv1.Ready_READY_FALSE,
+						},
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"Database"}`),
+							},
+						},
+					},
+				},
+			},
+		},
+		"DependsOnReadyResource": {
+			reason: "A new composed resource should be added to the desired state once the resources it depends on are ready.",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:      "user",
+								Base:      &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"User"}`)},
+								DependsOn: []string{"database"},
+							},
+							{
+								Name: "database",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Database"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"Database","status":{"conditions":[{"type":"Ready","status":"True"}]}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"Database"}`),
+								Ready:    fnv1.Ready_READY_TRUE,
+							},
+							"user": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"User"}`),
+							},
+						},
+					},
+				},
+			},
+		},
 		"OnlyEnvironmentPatchesIsAllowed": {
 			reason: "Having only environment patches should be allowed and work as expected.",
 			args: args{
@@ -1495,3 +3317,209 @@ func contextWithEnvironment(data map[string]interface{}) *structpb.Struct {
 	}
 	return &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(d)}}
 }
+
+// contextWithResults returns a copy of the supplied context with the supplied
+// structured result details recorded at the results context key.
+func contextWithResults(c *structpb.Struct, results ...map[string]interface{}) *structpb.Struct {
+	out := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for k, v := range c.GetFields() {
+		out.Fields[k] = v
+	}
+	l := make([]interface{}, len(results))
+	for i := range results {
+		// Most results target the composite resource.
+		if _, ok := results[i]["target"]; !ok {
+			results[i]["target"] = fnv1.Target_TARGET_COMPOSITE.String()
+		}
+		l[i] = results[i]
+	}
+	v, err := structpb.NewList(l)
+	if err != nil {
+		panic(err)
+	}
+	out.Fields[ContextKeyResults] = structpb.NewListValue(v)
+	return out
+}
+
+func TestRunFunctionDeleteKeysNotInSource(t *testing.T) {
+	in := resource.MustStructObject(&v1beta1.Resources{
+		Resources: []v1beta1.ComposedTemplate{
+			{
+				Name: "cool-resource",
+				Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"forProvider":{"tags":{"b":"default"}}}}`)},
+				Patches: []v1beta1.ComposedPatch{
+					{
+						Type: v1beta1.PatchTypeFromCompositeFieldPath,
+						Patch: v1beta1.Patch{
+							FromFieldPath: ptr.To[string]("spec.tags"),
+							ToFieldPath:   ptr.To[string]("spec.forProvider.tags"),
+							Policy: &v1beta1.PatchPolicy{
+								ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	type want struct {
+		tags   map[string]any
+		merged string
+	}
+
+	cases := map[string]struct {
+		reason string
+		calls  []string
+		want   want
+	}{
+		"SourceKeyRemoved": {
+			reason: "A key removed from the source between two calls should be deleted from the composed resource, rather than falling back to the value in the base template.",
+			calls: []string{
+				`{"apiVersion":"example.org/v1","kind":"XR","spec":{"tags":{"a":"1","b":"2"}}}`,
+				`{"apiVersion":"example.org/v1","kind":"XR","spec":{"tags":{"a":"1"}}}`,
+			},
+			want: want{
+				tags:   map[string]any{"a": "1"},
+				merged: `{"spec.forProvider.tags":["a"]}`,
+			},
+		},
+		"SourceKeyAdded": {
+			reason: "A key added to the source between two calls should be merged into the composed resource.",
+			calls: []string{
+				`{"apiVersion":"example.org/v1","kind":"XR","spec":{"tags":{"a":"1"}}}`,
+				`{"apiVersion":"example.org/v1","kind":"XR","spec":{"tags":{"a":"1","c":"3"}}}`,
+			},
+			want: want{
+				tags:   map[string]any{"a": "1", "b": "default", "c": "3"},
+				merged: `{"spec.forProvider.tags":["a","c"]}`,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := &Function{log: logging.NewNopLogger()}
+
+			// Each call observes the composed resources the previous call
+			// desired, as if Crossplane had applied them.
+			observed := map[string]*fnv1.Resource{}
+			var rsp *fnv1.RunFunctionResponse
+			for i, xr := range tc.calls {
+				req := &fnv1.RunFunctionRequest{
+					Input: in,
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{Resource: resource.MustStructJSON(xr)},
+						Resources: observed,
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{Resource: resource.MustStructJSON(xr)},
+					},
+				}
+				var err error
+				rsp, err = f.RunFunction(context.Background(), req)
+				if err != nil {
+					t.Fatalf("%s\nf.RunFunction(...) call %d: unexpected error: %v", tc.reason, i, err)
+				}
+				observed = rsp.GetDesired().GetResources()
+			}
+
+			cd := rsp.GetDesired().GetResources()["cool-resource"].GetResource().AsMap()
+			tags, _, _ := unstructured.NestedMap(cd, "spec", "forProvider", "tags")
+			if diff := cmp.Diff(tc.want.tags, tags); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want tags, +got tags:\n%s", tc.reason, diff)
+			}
+			merged, _, _ := unstructured.NestedString(cd, "metadata", "annotations", AnnotationKeyMergedKeys)
+			if diff := cmp.Diff(tc.want.merged, merged); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want merged keys, +got merged keys:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRunFunctionFieldOwnershipHint(t *testing.T) {
+	in := resource.MustStructObject(&v1beta1.Resources{
+		Resources: []v1beta1.ComposedTemplate{
+			{
+				Name: "cool-resource",
+				Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"forProvider":{"instanceType":"small"}}}`)},
+				FieldOwnership: []v1beta1.FieldOwnership{
+					{FieldPath: "spec.forProvider.instanceType", Policy: v1beta1.FieldOwnershipPolicyHint},
+				},
+			},
+		},
+	})
+	xr := `{"apiVersion":"example.org/v1","kind":"XR"}`
+
+	// A reconcile observes the supplied composed resource, and wants the
+	// supplied instance type, or none if it's empty.
+	type reconcile struct {
+		observed     string
+		instanceType string
+	}
+
+	cases := map[string]struct {
+		reason     string
+		reconciles []reconcile
+	}{
+		"OnlyManagedByCrossplane": {
+			reason: "A hint only Crossplane manages should be sent on every reconcile, so server-side apply doesn't delete it.",
+			reconciles: []reconcile{
+				{
+					instanceType: "small",
+				},
+				{
+					observed:     `{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42","managedFields":[{"manager":"apiextensions.crossplane.io/composed/cool-xr","operation":"Apply","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:forProvider":{"f:instanceType":{}}}}}]},"spec":{"forProvider":{"instanceType":"small"}}}`,
+					instanceType: "small",
+				},
+				{
+					observed:     `{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42","managedFields":[{"manager":"apiextensions.crossplane.io/composed/cool-xr","operation":"Apply","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:forProvider":{"f:instanceType":{}}}}}]},"spec":{"forProvider":{"instanceType":"small"}}}`,
+					instanceType: "small",
+				},
+			},
+		},
+		"TakenOverByAnotherManager": {
+			reason: "A hint should be omitted once another field manager manages it, so that field manager takes it over.",
+			reconciles: []reconcile{
+				{
+					observed:     `{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42","managedFields":[{"manager":"apiextensions.crossplane.io/composed/cool-xr","operation":"Apply","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:forProvider":{"f:instanceType":{}}}}}]},"spec":{"forProvider":{"instanceType":"small"}}}`,
+					instanceType: "small",
+				},
+				{
+					observed: `{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42","managedFields":[{"manager":"kubectl-edit","operation":"Update","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:forProvider":{"f:instanceType":{}}}}}]},"spec":{"forProvider":{"instanceType":"large"}}}`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := &Function{log: logging.NewNopLogger()}
+			for i, r := range tc.reconciles {
+				req := &fnv1.RunFunctionRequest{
+					Input: in,
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{Resource: resource.MustStructJSON(xr)},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{Resource: resource.MustStructJSON(xr)},
+					},
+				}
+				if r.observed != "" {
+					req.Observed.Resources = map[string]*fnv1.Resource{
+						"cool-resource": {Resource: resource.MustStructJSON(r.observed)},
+					}
+				}
+				rsp, err := f.RunFunction(context.Background(), req)
+				if err != nil {
+					t.Fatalf("%s\nf.RunFunction(...) reconcile %d: unexpected error: %v", tc.reason, i, err)
+				}
+				cd := rsp.GetDesired().GetResources()["cool-resource"].GetResource().AsMap()
+				got, _, _ := unstructured.NestedString(cd, "spec", "forProvider", "instanceType")
+				if diff := cmp.Diff(r.instanceType, got); diff != "" {
+					t.Errorf("%s\nf.RunFunction(...) reconcile %d: -want instance type, +got instance type:\n%s", tc.reason, i, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+)
+
+// A HealthTracker runs Functions, and tracks how long each RunFunction call
+// has been running. A Function is unhealthy if any call has been running for
+// longer than the stall timeout, for example because it's deadlocked. Probes
+// can then restart the wedged process, instead of it silently stalling every
+// composite resource that uses it.
+type HealthTracker struct {
+	fnv1.UnimplementedFunctionRunnerServiceServer
+
+	wrapped fnv1.FunctionRunnerServiceServer
+	timeout time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	next    uint64
+	running map[uint64]time.Time
+}
+
+// NewHealthTracker returns a runner that runs the supplied Function, and
+// reports it unhealthy if a call runs for longer than the supplied timeout.
+// It's always healthy if the timeout isn't positive.
+func NewHealthTracker(fn fnv1.FunctionRunnerServiceServer, timeout time.Duration) *HealthTracker {
+	return &HealthTracker{wrapped: fn, timeout: timeout, now: time.Now, running: map[uint64]time.Time{}}
+}
+
+// RunFunction runs the wrapped Function, tracking how long it runs.
+func (h *HealthTracker) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
+	h.mu.Lock()
+	id := h.next
+	h.next++
+	h.running[id] = h.now()
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.running, id)
+		h.mu.Unlock()
+	}()
+
+	return h.wrapped.RunFunction(ctx, req)
+}
+
+// Healthy returns an error if any RunFunction call has been running for
+// longer than the stall timeout.
+func (h *HealthTracker) Healthy() error {
+	if h.timeout <= 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stalled := 0
+	var longest time.Duration
+	now := h.now()
+	for _, started := range h.running {
+		if d := now.Sub(started); d > h.timeout {
+			stalled++
+			longest = max(longest, d)
+		}
+	}
+	if stalled > 0 {
+		return errors.Errorf("%d RunFunction calls have been running for longer than %s, the longest for %s", stalled, h.timeout, longest.Round(time.Second))
+	}
+	return nil
+}
+
+// Check implements the gRPC health checking protocol. The Function is serving
+// unless it's unhealthy. Only the overall server and the FunctionRunnerService
+// are known services.
+func (h *HealthTracker) Check(_ context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	switch req.GetService() {
+	case "", fnv1.FunctionRunnerService_ServiceDesc.ServiceName:
+	default:
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	if err := h.Healthy(); err != nil {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// Watch isn't supported. Kubernetes gRPC probes only use Check.
+func (h *HealthTracker) Watch(_ *healthpb.HealthCheckRequest, _ healthpb.Health_WatchServer) error {
+	return status.Error(codes.Unimplemented, "watching health isn't supported")
+}
+
+// ServeHTTP serves an HTTP health check. It returns 200 OK if the Function is
+// healthy, and 503 Service Unavailable if it isn't.
+func (h *HealthTracker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if err := h.Healthy(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+)
+
+type blockingRunner struct {
+	fnv1.UnimplementedFunctionRunnerServiceServer
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRunner) RunFunction(_ context.Context, _ *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
+	close(r.started)
+	<-r.release
+	return &fnv1.RunFunctionResponse{}, nil
+}
+
+func TestHealthTracker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type want struct {
+		status healthpb.HealthCheckResponse_ServingStatus
+		code   int
+	}
+
+	cases := map[string]struct {
+		reason  string
+		timeout time.Duration
+		elapsed time.Duration
+		want    want
+	}{
+		"Running": {
+			reason:  "A Function whose calls are running for less than the stall timeout should be healthy.",
+			timeout: time.Minute,
+			elapsed: 30 * time.Second,
+			want:    want{status: healthpb.HealthCheckResponse_SERVING, code: http.StatusOK},
+		},
+		"Stalled": {
+			reason:  "A Function with a call that's running for longer than the stall timeout should be unhealthy.",
+			timeout: time.Minute,
+			elapsed: 2 * time.Minute,
+			want:    want{status: healthpb.HealthCheckResponse_NOT_SERVING, code: http.StatusServiceUnavailable},
+		},
+		"Disabled": {
+			reason:  "A Function without a stall timeout should always be healthy.",
+			elapsed: time.Hour,
+			want:    want{status: healthpb.HealthCheckResponse_SERVING, code: http.StatusOK},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &blockingRunner{started: make(chan struct{}), release: make(chan struct{})}
+			h := NewHealthTracker(r, tc.timeout)
+			h.now = func() time.Time { return now }
+
+			done := make(chan struct{})
+			go func() {
+				_, _ = h.RunFunction(context.Background(), &fnv1.RunFunctionRequest{})
+				close(done)
+			}()
+			<-r.started
+			h.now = func() time.Time { return now.Add(tc.elapsed) }
+
+			rsp, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			if err != nil {
+				t.Fatalf("h.Check(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want.status, rsp.GetStatus()); diff != "" {
+				t.Errorf("\n%s\nh.Check(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if diff := cmp.Diff(tc.want.code, w.Code); diff != "" {
+				t.Errorf("\n%s\nh.ServeHTTP(...): -want code, +got code:\n%s", tc.reason, diff)
+			}
+
+			close(r.release)
+			<-done
+			if err := h.Healthy(); err != nil {
+				t.Errorf("\n%s\nh.Healthy(): a Function with no running calls should be healthy, got: %s", tc.reason, err)
+			}
+		})
+	}
+}
+
+func TestHealthTrackerUnknownService(t *testing.T) {
+	h := NewHealthTracker(&blockingRunner{}, time.Minute)
+	_, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "example.Unknown"})
+	if diff := cmp.Diff(codes.NotFound, status.Code(err)); diff != "" {
+		t.Errorf("h.Check(...): unknown services should return NotFound: -want code, +got code:\n%s", diff)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	v1 "github.com/crossplane-contrib/function-patch-and-transform/input/v1"
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// DecodeInput decodes the supplied Function input. The Function uses v1beta1
+// Resources internally, so v1 input is converted to v1beta1. Input of any
+// other apiVersion is decoded as v1beta1, for backward compatibility. Unknown
+// fields are an error if strict is true.
+func DecodeInput(in map[string]any, strict bool) (*v1beta1.Resources, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal input to JSON")
+	}
+	decode := func(into any) error {
+		d := json.NewDecoder(bytes.NewReader(b))
+		if strict {
+			d.DisallowUnknownFields()
+		}
+		return d.Decode(into)
+	}
+
+	if av, _ := in["apiVersion"].(string); av == v1.Group+"/"+v1.Version {
+		r := &v1.Resources{}
+		if err := decode(r); err != nil {
+			return nil, errors.Wrap(err, "cannot parse v1 input")
+		}
+		return v1.ConvertToV1beta1(r)
+	}
+
+	r := &v1beta1.Resources{}
+	if err := decode(r); err != nil {
+		return nil, errors.Wrap(err, "cannot parse input")
+	}
+	return r, nil
+}
@@ -4,7 +4,11 @@
 // NOTE(negz): See the below link for details on what is happening here.
 // https://github.com/golang/go/wiki/Modules#how-can-i-track-tool-dependencies-for-a-module
 
-//go:generate go run -tags generate sigs.k8s.io/controller-tools/cmd/controller-gen paths=./v1beta1 object crd:crdVersions=v1 output:artifacts:config=../package/input
+// Generate the v1 input API before controller-gen generates its deepcopy
+// functions and CRD.
+//go:generate go run ./internal/v1gen ./v1beta1 ./v1
+
+//go:generate go run -tags generate sigs.k8s.io/controller-tools/cmd/controller-gen paths=./... object crd:crdVersions=v1 output:artifacts:config=../package/input
 
 package input
 
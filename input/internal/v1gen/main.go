@@ -0,0 +1,159 @@
+// Package main generates the v1 input API from the v1beta1 input API.
+//
+// The v1 schema is the v1beta1 schema, minus deprecated fields and values.
+// Generating it, rather than maintaining a copy by hand, means a field added to
+// v1beta1 can't be forgotten in v1, where the JSON round-trip conversion would
+// silently drop it.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+const (
+	srcVersion = "v1beta1"
+	dstVersion = "v1"
+)
+
+var (
+	// A deprecated constant's value, e.g. `Foo Type = "Foo"`.
+	reDeprecatedValue = regexp.MustCompile(`=\s*"([^"]+)"`)
+
+	// An enum validation marker, e.g. +kubebuilder:validation:Enum=Foo;Bar.
+	reEnum = regexp.MustCompile(`^(\s*// \+kubebuilder:validation:Enum=)(.+)$`)
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s <v1beta1 dir> <v1 dir>\n", os.Args[0])
+		os.Exit(1)
+	}
+	if err := run(os.Args[1], os.Args[2]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(srcDir, dstDir string) error {
+	names, err := sources(srcDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		src, err := os.ReadFile(filepath.Clean(filepath.Join(srcDir, name)))
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %s", name)
+		}
+		out, err := generate(name, src)
+		if err != nil {
+			return errors.Wrapf(err, "cannot generate %s", name)
+		}
+		if err := os.WriteFile(filepath.Join(dstDir, name), out, 0o644); err != nil { //nolint:gosec // Generated source isn't sensitive.
+			return errors.Wrapf(err, "cannot write %s", name)
+		}
+	}
+	return nil
+}
+
+// sources returns the names of the hand written Go source files in the
+// supplied directory.
+func sources(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read directory %s", dir)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || filepath.Ext(n) != ".go" || strings.HasSuffix(n, "_test.go") || strings.HasPrefix(n, "zz_generated") {
+			continue
+		}
+		names = append(names, n)
+	}
+	return names, nil
+}
+
+// generate returns the v1 version of the supplied v1beta1 source file. It
+// renames the package and version, marks v1 as the storage version, and drops
+// deprecated constants along with their enum values and the doc comments that
+// mention them.
+func generate(name string, src []byte) ([]byte, error) {
+	lines := strings.Split(string(src), "\n")
+
+	// Find deprecated constants first, so their values can be dropped from
+	// enum markers and doc comments that appear before them.
+	deprecated := map[string]bool{}
+	for i, l := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(l), "// Deprecated:") || i+1 >= len(lines) {
+			continue
+		}
+		m := reDeprecatedValue.FindStringSubmatch(lines[i+1])
+		if m == nil {
+			return nil, errors.Errorf("line %d: deprecated declaration isn't a string constant", i+2)
+		}
+		deprecated[m[1]] = true
+	}
+
+	out := make([]string, 0, len(lines)+2)
+	out = append(out, fmt.Sprintf("// Code generated by v1gen from ../%s/%s. DO NOT EDIT.", srcVersion, name), "")
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		t := strings.TrimSpace(l)
+
+		switch {
+		case strings.HasPrefix(t, "// Deprecated:"):
+			// Skip the deprecated declaration too.
+			i++
+			continue
+		case mentionsDeprecated(t, deprecated):
+			continue
+		}
+
+		if m := reEnum.FindStringSubmatch(l); m != nil {
+			values := strings.Split(m[2], ";")
+			kept := make([]string, 0, len(values))
+			for _, v := range values {
+				if !deprecated[v] {
+					kept = append(kept, v)
+				}
+			}
+			l = m[1] + strings.Join(kept, ";")
+		}
+
+		l = strings.ReplaceAll(l, "package "+srcVersion, "package "+dstVersion)
+		l = strings.ReplaceAll(l, "// Package "+srcVersion+" ", "// Package "+dstVersion+" ")
+		l = strings.ReplaceAll(l, "+versionName="+srcVersion, "+versionName="+dstVersion)
+		l = strings.ReplaceAll(l, "Version = \""+srcVersion+"\"", "Version = \""+dstVersion+"\"")
+		out = append(out, l)
+
+		if t == "// +kubebuilder:object:root=true" {
+			out = append(out, "// +kubebuilder:storageversion")
+		}
+	}
+
+	formatted, err := format.Source([]byte(strings.Join(out, "\n")))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot format generated source")
+	}
+	return bytes.TrimLeft(formatted, "\n"), nil
+}
+
+// mentionsDeprecated returns true if the supplied line is a doc comment line
+// that documents one of the supplied deprecated values, e.g. "'Foo' is
+// deprecated, use 'Bar' instead".
+func mentionsDeprecated(line string, deprecated map[string]bool) bool {
+	for v := range deprecated {
+		if strings.HasPrefix(line, "// '"+v+"' ") {
+			return true
+		}
+	}
+	return false
+}
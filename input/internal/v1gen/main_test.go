@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGeneratedV1IsUpToDate(t *testing.T) {
+	names, err := sources("../../v1beta1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join("../../v1beta1", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := generate(name, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(filepath.Join("../../v1", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("\n../../v1/%s is out of date with ../../v1beta1/%s, run go generate ./...\n-want, +got:\n%s", name, name, diff)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	type want struct {
+		out string
+		err bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		src    string
+		want   want
+	}{
+		"RenamesVersion": {
+			reason: "The package, version name, and Version constant should be renamed to v1.",
+			src: `// Package v1beta1 contains the input type.
+// +versionName=v1beta1
+package v1beta1
+
+const Version = "v1beta1"
+`,
+			want: want{
+				out: `// Code generated by v1gen from ../v1beta1/f.go. DO NOT EDIT.
+
+// Package v1 contains the input type.
+// +versionName=v1
+package v1
+
+const Version = "v1"
+`,
+			},
+		},
+		"MarksStorageVersion": {
+			reason: "The root object should be marked as the storage version.",
+			src: `package v1beta1
+
+// +kubebuilder:object:root=true
+
+// Resources are resources.
+type Resources struct{}
+`,
+			want: want{
+				out: `// Code generated by v1gen from ../v1beta1/f.go. DO NOT EDIT.
+
+package v1
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// Resources are resources.
+type Resources struct{}
+`,
+			},
+		},
+		"DropsDeprecatedValues": {
+			reason: "Deprecated constants, their enum values, and the doc comments that mention them should be dropped.",
+			src: `package v1beta1
+
+type Policy string
+
+const (
+	PolicyNew Policy = "New"
+
+	// Deprecated: Use New, which is functionally identical.
+	PolicyOld Policy = "Old"
+)
+
+type Patch struct {
+	// Policy is a policy.
+	// 'Old' is deprecated, use 'New' instead.
+	// +kubebuilder:validation:Enum=New;Old
+	Policy Policy
+}
+`,
+			want: want{
+				out: `// Code generated by v1gen from ../v1beta1/f.go. DO NOT EDIT.
+
+package v1
+
+type Policy string
+
+const (
+	PolicyNew Policy = "New"
+)
+
+type Patch struct {
+	// Policy is a policy.
+	// +kubebuilder:validation:Enum=New
+	Policy Policy
+}
+`,
+			},
+		},
+		"DeprecatedNonConstant": {
+			reason: "A deprecated declaration that isn't a string constant should return an error, because its uses can't be dropped.",
+			src: `package v1beta1
+
+type Patch struct {
+	// Deprecated: Use New.
+	Old bool
+}
+`,
+			want: want{
+				err: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := generate("f.go", []byte(tc.src))
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\ngenerate(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.out, string(out)); diff != "" {
+				t.Errorf("\n%s\ngenerate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
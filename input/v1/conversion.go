@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// The Function uses v1beta1 Resources internally. The v1 schema is generated
+// from the v1beta1 schema, minus deprecated fields and values, so a v1
+// Resources can be converted to v1beta1 by round-tripping it through JSON.
+// Conversion fails rather than dropping a v1 field that v1beta1 doesn't have.
+// Any v1 field that's renamed or restructured later must be converted
+// explicitly here.
+
+// removedToFieldPathPolicies are v1beta1 toFieldPath policies that v1 doesn't
+// support, mapped to the policy that replaces them.
+var removedToFieldPathPolicies = map[string]ToFieldPathPolicy{
+	"MergeObject": ToFieldPathPolicyMergeObjects,
+	"AppendArray": ToFieldPathPolicyForceMergeObjectsAppendArrays,
+}
+
+// ConvertToV1beta1 converts the supplied v1 Resources to v1beta1. It returns
+// an error if the supplied Resources uses a value v1 doesn't support.
+func ConvertToV1beta1(r *Resources) (*v1beta1.Resources, error) {
+	j, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal v1 Resources to JSON")
+	}
+	var m any
+	if err := json.Unmarshal(j, &m); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal v1 Resources from JSON")
+	}
+	if err := checkRemovedValues(m, ""); err != nil {
+		return nil, err
+	}
+	out := &v1beta1.Resources{}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.DisallowUnknownFields()
+	if err := d.Decode(out); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal v1beta1 Resources from JSON")
+	}
+	out.APIVersion = v1beta1.Group + "/" + v1beta1.Version
+	return out, nil
+}
+
+// checkRemovedValues returns an error if any patch policy under the supplied
+// JSON value uses a toFieldPath policy that v1 doesn't support.
+func checkRemovedValues(v any, path string) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if p, ok := v["toFieldPath"].(string); ok && (path == "policy" || strings.HasSuffix(path, ".policy")) {
+			if r, ok := removedToFieldPathPolicies[p]; ok {
+				return errors.Errorf("%s.toFieldPath: toFieldPath policy %q isn't supported by %s/%s, use %q", path, p, Group, Version, r)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			if err := checkRemovedValues(v[k], child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, e := range v {
+			if err := checkRemovedValues(e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// populated returns a v1beta1 Resources with every field set, so a field that
+// v1 doesn't have, or that conversion drops, makes the round trip fail.
+func populated(t *testing.T) *v1beta1.Resources {
+	t.Helper()
+	r := &v1beta1.Resources{}
+	populate(reflect.ValueOf(r).Elem(), map[reflect.Type]bool{})
+	r.TypeMeta = metav1.TypeMeta{APIVersion: v1beta1.Group + "/" + v1beta1.Version, Kind: "Resources"}
+	r.ObjectMeta = metav1.ObjectMeta{Name: "cool-resources"}
+	return r
+}
+
+// populate sets the supplied value, and every exported field under it, to a
+// non-zero value. It leaves a recursive field unset once the type it recurses
+// into has been populated.
+func populate(v reflect.Value, seen map[reflect.Type]bool) {
+	switch v.Interface().(type) {
+	case extv1.JSON:
+		v.Set(reflect.ValueOf(extv1.JSON{Raw: []byte(`{"cool":"value"}`)}))
+		return
+	case runtime.RawExtension:
+		v.Set(reflect.ValueOf(runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)}))
+		return
+	case resource.Quantity:
+		v.Set(reflect.ValueOf(resource.MustParse("1Gi")))
+		return
+	case metav1.TypeMeta, metav1.ObjectMeta:
+		return
+	}
+
+	switch v.Kind() { //nolint:exhaustive // Other kinds don't appear in the input API.
+	case reflect.Ptr:
+		if seen[v.Type().Elem()] {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem(), seen)
+	case reflect.Struct:
+		seen[v.Type()] = true
+		defer delete(seen, v.Type())
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				populate(v.Field(i), seen)
+			}
+		}
+	case reflect.Slice:
+		if seen[v.Type().Elem()] {
+			return
+		}
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0), seen)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		e := reflect.New(v.Type().Elem()).Elem()
+		populate(e, seen)
+		k := reflect.New(v.Type().Key()).Elem()
+		populate(k, seen)
+		v.SetMapIndex(k, e)
+	case reflect.String:
+		v.SetString("cool")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(42)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(4.2)
+	}
+}
+
+func TestConvertToV1beta1(t *testing.T) {
+	type want struct {
+		r   *v1beta1.Resources
+		err bool
+	}
+
+	policy := ToFieldPathPolicy("MergeObject")
+
+	cases := map[string]struct {
+		reason string
+		v1beta string
+		v1     *Resources
+		want   want
+	}{
+		"FullyPopulatedRoundTrip": {
+			reason: "A fully populated v1beta1 Resources should survive a round trip through v1 unchanged.",
+			want: want{
+				r: populated(t),
+			},
+		},
+		"RemovedToFieldPathPolicy": {
+			reason: "A toFieldPath policy v1 doesn't support should return an error.",
+			v1: &Resources{
+				Resources: []ComposedTemplate{{
+					Name: "cool-resource",
+					Patches: []ComposedPatch{{
+						Type:  PatchTypeFromCompositeFieldPath,
+						Patch: Patch{Policy: &PatchPolicy{ToFieldPath: &policy}},
+					}},
+				}},
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			in := tc.v1
+			if in == nil {
+				// Decode the v1beta1 fixture as v1, failing on any field v1
+				// doesn't have.
+				j, err := json.Marshal(tc.want.r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				in = &Resources{}
+				d := json.NewDecoder(bytes.NewReader(j))
+				d.DisallowUnknownFields()
+				if err := d.Decode(in); err != nil {
+					t.Fatalf("\n%s\ncannot decode v1beta1 Resources as v1: %v", tc.reason, err)
+				}
+			}
+
+			got, err := ConvertToV1beta1(in)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("\n%s\nConvertToV1beta1(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.r, got, cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 })); diff != "" {
+				t.Errorf("\n%s\nConvertToV1beta1(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
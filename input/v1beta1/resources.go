@@ -5,14 +5,20 @@
 package v1beta1
 
 import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Group and Version of the input type.
+const (
+	Group   = "pt.fn.crossplane.io"
+	Version = "v1beta1"
+)
+
 // This isn't a custom resource, in the sense that we never install its CRD.
 // It is a KRM-like object, so we generate a CRD to describe its schema.
 
 // +kubebuilder:object:root=true
-// +kubebuilder:storageversion
 
 // Resources specifies Patch & Transform resource templates.
 // +kubebuilder:resource:categories=crossplane
@@ -21,10 +27,15 @@ type Resources struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	// PatchSets define a named set of patches that may be included by any
-	// resource. PatchSets cannot themselves refer to other PatchSets.
+	// resource. PatchSets may include other PatchSets, as long as no PatchSet
+	// includes itself, directly or indirectly.
 	// +optional
 	PatchSets []PatchSet `json:"patchSets,omitempty"`
 
+	// Defaults apply to every resource template.
+	// +optional
+	Defaults *Defaults `json:"defaults,omitempty"`
+
 	// Environment represents the Composition environment.
 	//
 	// THIS IS AN ALPHA FIELD.
@@ -32,7 +43,250 @@ type Resources struct {
 	// +optional
 	Environment *Environment `json:"environment,omitempty"`
 
+	// ExtraResources requests resources that exist in the cluster, for
+	// example ProviderConfigs or ConfigMaps. Patches of type
+	// FromExtraResourceFieldPath can read them. Crossplane fetches the
+	// requested resources and calls the Function again, so the requested
+	// resources aren't available the first time the Function is called.
+	// +optional
+	ExtraResources []ExtraResource `json:"extraResources,omitempty"`
+
+	// Variables are constants that patches of type FromVariableFieldPath can
+	// read, for example a domain suffix shared by many patches. The first
+	// segment of such a patch's fromFieldPath is the variable's name, for
+	// example domain or network.cidrs[0].
+	// +optional
+	Variables map[string]extv1.JSON `json:"variables,omitempty"`
+
 	// Resources is a list of resource templates that will be used when a
 	// composite resource is created.
 	Resources []ComposedTemplate `json:"resources"`
+
+	// ConnectionDetails lists composite resource connection details that
+	// don't come from any particular composed resource. Only the FromValue
+	// and FromEnvironmentFieldPath types are supported, or a Combine of
+	// variables of those types.
+	// +optional
+	ConnectionDetails []ConnectionDetail `json:"connectionDetails,omitempty"`
+
+	// PropagateMetadata copies the selected labels and annotations of the
+	// composite resource to every composed resource before patches are
+	// applied.
+	// +optional
+	PropagateMetadata *PropagateMetadata `json:"propagateMetadata,omitempty"`
+
+	// OnPatchFailure specifies what happens when a resource template's patch
+	// fails. Skip and Warn skip the failed patch, and apply the remaining
+	// patches. Warn also returns a warning result. Fail returns a fatal result,
+	// which stops the Function pipeline from producing any desired state.
+	// Resource templates may override this. The default is Fail.
+	// +kubebuilder:validation:Enum=Skip;Warn;Fail
+	// +optional
+	OnPatchFailure *PatchFailurePolicy `json:"onPatchFailure,omitempty"`
+
+	// Strict returns a fatal result instead of a warning when a patch from a
+	// required field path can't be applied because the field path doesn't
+	// exist. This prevents the composite resource from becoming ready while
+	// one of its composed resources can't be rendered.
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+
+	// AnnotatePatchedPaths annotates each composed resource with the field
+	// paths its patches set, and where they set them from. The annotation
+	// is pt.fn.crossplane.io/patched-paths.
+	// +optional
+	AnnotatePatchedPaths bool `json:"annotatePatchedPaths,omitempty"`
+
+	// AnnotateRenderOrdinals annotates each composed resource with its render
+	// ordinal at the pt.fn.crossplane.io/render-ordinal annotation. A new
+	// composed resource's ordinal is the next after the largest ordinal of the
+	// observed composed resources, in resource template order. An existing
+	// composed resource keeps its observed ordinal, so inserting or removing a
+	// resource template doesn't change the ordinals of other composed
+	// resources. Use it to order composed resources stably, for example when
+	// diffing the output of successive renders in CI.
+	// +optional
+	AnnotateRenderOrdinals bool `json:"annotateRenderOrdinals,omitempty"`
+
+	// StripBaseNulls removes fields whose value is null from base templates
+	// before they're patched. A null is usually a typo, like a field with no
+	// value in YAML.
+	// +optional
+	StripBaseNulls bool `json:"stripBaseNulls,omitempty"`
+
+	// Prune configures what happens to an observed composed resource that no
+	// resource template renders, for example because its resource template
+	// was removed, and that isn't in the desired state produced by previous
+	// Functions in the pipeline. If true it's omitted from the desired
+	// state, so Crossplane deletes it, and the Function returns a warning
+	// naming it. If false it's kept in the desired state as it's observed,
+	// so Crossplane doesn't delete it. If unset it's omitted without a
+	// warning. Leave it unset if later Functions in the pipeline produce
+	// composed resources, because this Function can't tell them apart from
+	// composed resources whose resource template was removed.
+	// +optional
+	Prune *bool `json:"prune,omitempty"`
+
+	// Events are emitted as Kubernetes events of the composite resource,
+	// and optionally of its claim, each time the Function is called.
+	// +optional
+	Events []Event `json:"events,omitempty"`
+
+	// CrossplaneAnnotations configures whether patches may change the
+	// composite resource's crossplane.io/external-name and
+	// crossplane.io/paused annotations. Like other annotations prefixed with
+	// crossplane.io/, patches may not change them by default.
+	// +optional
+	CrossplaneAnnotations *CrossplaneAnnotations `json:"crossplaneAnnotations,omitempty"`
+
+	// StatusConditions are conditions of the composite resource's status that
+	// reflect whether the composed resources of the named resource templates
+	// are ready, for example NetworkReady or DatabaseReady.
+	// +optional
+	StatusConditions []StatusCondition `json:"statusConditions,omitempty"`
+
+	// WriteInventoryTo is a field path of the composite resource's status,
+	// for example status.inventory, to write a summary of its desired
+	// composed resources to. The summary includes the number of desired and
+	// ready composed resources, and the resource template name, API version,
+	// kind, and readiness of each. The composite resource's schema must
+	// allow the field.
+	// +optional
+	WriteInventoryTo *string `json:"writeInventoryTo,omitempty"`
+}
+
+// A StatusCondition is a condition of the composite resource's status. It's
+// True once the composed resources of all of its resource templates exist and
+// are ready, and False otherwise.
+type StatusCondition struct {
+	// Type of the condition, for example NetworkReady. Crossplane manages
+	// the Ready and Synced conditions, so they can't be used.
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+
+	// Resources are the names of the resource templates whose composed
+	// resources must be ready for the condition to be True.
+	// +kubebuilder:validation:MinItems=1
+	Resources []string `json:"resources"`
+}
+
+// An EventType is the type of a Kubernetes event.
+type EventType string
+
+// Event types.
+const (
+	EventTypeNormal  EventType = "Normal" // Default
+	EventTypeWarning EventType = "Warning"
+)
+
+// An EventTarget is the resource an event is emitted for.
+type EventTarget string
+
+// Event targets.
+const (
+	EventTargetComposite         EventTarget = "Composite"
+	EventTargetCompositeAndClaim EventTarget = "CompositeAndClaim" // Default
+)
+
+// An Event is emitted as a Kubernetes event.
+type Event struct {
+	// Message of the event. Variables in braces are replaced with the value
+	// of a field path. Use {xr.spec.region} to read the composite resource,
+	// or {resource.status.atProvider.id} to read the observed composed
+	// resource of the event's resource template. An event isn't emitted
+	// while any of its variables' field paths don't exist.
+	// +kubebuilder:validation:MinLength=1
+	Message string `json:"message"`
+
+	// Resource is the name of the resource template the event is about. If
+	// set, the event is only emitted once the resource template's composed
+	// resource exists and is ready.
+	// +optional
+	Resource *string `json:"resource,omitempty"`
+
+	// Type of the event. The default is Normal.
+	// +kubebuilder:validation:Enum=Normal;Warning
+	// +optional
+	Type *EventType `json:"type,omitempty"`
+
+	// Reason is a PascalCase, machine-readable reason for the event.
+	// +optional
+	Reason *string `json:"reason,omitempty"`
+
+	// Target is the resource the event is emitted for. The default,
+	// CompositeAndClaim, emits the event for both the composite resource and
+	// its claim, if it has one.
+	// +kubebuilder:validation:Enum=Composite;CompositeAndClaim
+	// +optional
+	Target *EventTarget `json:"target,omitempty"`
+}
+
+// GetType returns the type of the event, defaulting to Normal.
+func (e *Event) GetType() EventType {
+	if e.Type == nil {
+		return EventTypeNormal
+	}
+	return *e.Type
+}
+
+// GetTarget returns the target of the event, defaulting to
+// CompositeAndClaim.
+func (e *Event) GetTarget() EventTarget {
+	if e.Target == nil {
+		return EventTargetCompositeAndClaim
+	}
+	return *e.Target
+}
+
+// An ExtraResource requests resources that exist in the cluster.
+type ExtraResource struct {
+	// Name identifies the requested resources. It's the first segment of
+	// the fromFieldPath of patches that read them, for example
+	// "provider-config.spec.region". When matchName is set the segment
+	// refers to the matching resource. When matchLabels is set it refers to
+	// a list of the matching resources, ordered by name, for example
+	// "config-maps[0].data.key".
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// APIVersion of the requested resources.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the requested resources.
+	Kind string `json:"kind"`
+
+	// MatchName requests the resource with this name. Exactly one of
+	// matchName or matchLabels is required.
+	// +optional
+	MatchName *string `json:"matchName,omitempty"`
+
+	// MatchLabels requests all resources with these labels. Exactly one of
+	// matchName or matchLabels is required.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// Defaults apply to every resource template.
+type Defaults struct {
+	// Patches are prepended to the patches of every resource template that
+	// doesn't set skipDefaultPatches. They may refer to PatchSets.
+	// +optional
+	Patches []ComposedPatch `json:"patches,omitempty"`
+}
+
+// PropagateMetadata selects the composite resource metadata that is copied
+// to composed resources.
+type PropagateMetadata struct {
+	// All copies all of the composite resource's labels and annotations.
+	// +optional
+	All bool `json:"all,omitempty"`
+
+	// Labels are the keys of the composite resource labels to copy.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// Annotations are the keys of the composite resource annotations to
+	// copy.
+	// +optional
+	Annotations []string `json:"annotations,omitempty"`
 }
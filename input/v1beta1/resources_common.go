@@ -2,6 +2,8 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -36,8 +38,9 @@ func (ps *PatchSet) GetComposedPatches() []ComposedPatch {
 	out := make([]ComposedPatch, len(ps.Patches))
 	for i, p := range ps.Patches {
 		out[i] = ComposedPatch{
-			Type:  p.GetType(),
-			Patch: p.Patch,
+			Type:         p.GetType(),
+			PatchSetName: p.PatchSetName,
+			Patch:        p.Patch,
 		}
 	}
 	return out
@@ -49,6 +52,15 @@ type ComposedTemplate struct {
 	// A Name uniquely identifies this entry within its resources array.
 	Name string `json:"name"`
 
+	// PreviousNames are names this resource template had before it was
+	// renamed. If no composed resource exists with the template's name, but
+	// one exists with a previous name, the template renders that composed
+	// resource instead of creating a new one. The composed resource keeps
+	// its previous name in the composite resource's resource references, so
+	// Crossplane doesn't delete and recreate it.
+	// +optional
+	PreviousNames []string `json:"previousNames,omitempty"`
+
 	// Base of the composed resource that patches will be applied to and from.
 	// If base is omitted, a previous Function within the pipeline must have
 	// produced the named composed resource. Patches will be applied to and from
@@ -59,10 +71,30 @@ type ComposedTemplate struct {
 	// +optional
 	Base *runtime.RawExtension `json:"base,omitempty"`
 
+	// BaseFrom reads the base of the composed resource from the Composition
+	// environment or the Function pipeline context, instead of specifying it
+	// inline. It can't be set together with base.
+	// +optional
+	BaseFrom *BaseFrom `json:"baseFrom,omitempty"`
+
+	// BaseFromObserved clones the observed composed resource of the named
+	// resource template, and uses it as the base of this composed resource.
+	// Its status, and metadata that identifies it, are omitted. A new
+	// composed resource isn't added to the desired state until the cloned
+	// composed resource exists. It can't be set together with base or
+	// baseFrom.
+	// +optional
+	BaseFromObserved *string `json:"baseFromObserved,omitempty"`
+
 	// Patches to and from the composed resource.
 	// +optional
 	Patches []ComposedPatch `json:"patches,omitempty"`
 
+	// SkipDefaultPatches opts this resource template out of the default
+	// patches that are otherwise prepended to its patches.
+	// +optional
+	SkipDefaultPatches bool `json:"skipDefaultPatches,omitempty"`
+
 	// ConnectionDetails lists the propagation secret keys from this composed
 	// resource to the composition instance connection secret.
 	// +optional
@@ -70,11 +102,346 @@ type ComposedTemplate struct {
 
 	// ReadinessChecks allows users to define custom readiness checks. All
 	// checks have to return true in order for resource to be considered ready.
+	// Use the "AnyOf" type to group checks, any of which may return true.
 	// The default readiness check is to have the "Ready" condition to be
 	// "True".
 	// +optional
 	// +kubebuilder:default={{type:"MatchCondition",matchCondition:{type:"Ready",status:"True"}}}
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// ReadyFrom overrides the readiness of the composed resource with a
+	// boolean at a field path of the observed composite resource or the
+	// Composition environment, for example to mark all composed resources
+	// ready during maintenance. The override applies whether or not the
+	// composed resource exists, and takes precedence over ReadinessChecks.
+	// If the field path doesn't exist the readiness checks decide.
+	// +optional
+	ReadyFrom *ReadyFrom `json:"readyFrom,omitempty"`
+
+	// ManagementPolicies to set at spec.managementPolicies of the composed
+	// resource. They're set before any patches are applied. If the composite
+	// resource specifies spec.managementPolicies, its value is used instead.
+	// Only set this for composed resources whose schema supports it, for
+	// example managed resources.
+	// +optional
+	ManagementPolicies xpv1.ManagementPolicies `json:"managementPolicies,omitempty"`
+
+	// DeletionPolicy to set at spec.deletionPolicy of the composed resource.
+	// It's set before any patches are applied. If the composite resource
+	// specifies spec.deletionPolicy, its value is used instead. Only set this
+	// for composed resources whose schema supports it, for example managed
+	// resources.
+	// +optional
+	DeletionPolicy *xpv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// OnPatchFailure specifies what happens when one of this resource
+	// template's patches fails. It overrides the top-level onPatchFailure.
+	// +kubebuilder:validation:Enum=Skip;Warn;Fail
+	// +optional
+	OnPatchFailure *PatchFailurePolicy `json:"onPatchFailure,omitempty"`
+
+	// RenderPolicy specifies what the desired composed resource is rendered
+	// from. The default, Base, renders it only from the base template. Use
+	// MergeObserved to start from the observed composed resource, without its
+	// status, and merge the base template on top of it. This keeps fields
+	// that are defaulted by providers or mutated by webhooks in the desired
+	// composed resource. Patches are applied after the base template in both
+	// cases.
+	// +kubebuilder:validation:Enum=Base;MergeObserved
+	// +optional
+	RenderPolicy *RenderPolicy `json:"renderPolicy,omitempty"`
+
+	// FieldOwnership marks fields of the desired composed resource as owned
+	// by the Function, or as hints. Crossplane server-side applies the
+	// desired composed resource, so it owns every field in it and resets
+	// them if anything else changes them. A hint is only set until another
+	// field manager, such as a provider or a person, also manages the
+	// observed composed resource's value for it. After that it's omitted from
+	// the desired composed resource, so the other field manager takes the
+	// field over. If the other field manager only manages some fields inside
+	// a hint, only those fields are omitted. Fields are owned unless they're
+	// a hint.
+	// +optional
+	FieldOwnership []FieldOwnership `json:"fieldOwnership,omitempty"`
+
+	// DependsOn lists the names of resource templates this resource
+	// template depends on. The composed resource isn't added to the desired
+	// state until the observed composed resources of all of its
+	// dependencies exist and are ready, according to their readiness
+	// checks. Composed resources that already exist are always kept.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// WaitFor gates the composed resource on a value of the environment. The
+	// composed resource isn't added to the desired state until the value at
+	// the environment field path equals the supplied value. Use it to roll
+	// out composed resources in stages across Function pipeline runs.
+	// Composed resources that already exist are always kept.
+	// +optional
+	WaitFor *WaitFor `json:"waitFor,omitempty"`
+
+	// SkipIfNotObserved only renders the composed resource if it already
+	// exists, so the Function never creates it. Use it to gradually adopt
+	// existing resources into a composition, for example by importing them
+	// with the crossplane.io/external-name annotation.
+	// +optional
+	SkipIfNotObserved bool `json:"skipIfNotObserved,omitempty"`
+
+	// Metadata sets labels and annotations of the composed resource, for
+	// example standard tags. They're set after the base template is rendered
+	// and the composite resource's metadata is propagated, and before
+	// patches are applied.
+	// +optional
+	Metadata *ComposedMetadata `json:"metadata,omitempty"`
+
+	// ExternalName sets the crossplane.io/external-name annotation of the
+	// composed resource. It's set before patches are applied.
+	// +optional
+	ExternalName *ExternalName `json:"externalName,omitempty"`
+
+	// WriteConnectionSecretToRef sets spec.writeConnectionSecretToRef of the
+	// composed resource, so the secret it writes its connection details to
+	// can be derived from the composite resource. It's set before patches
+	// are applied. Only set this for composed resources whose schema
+	// supports it, for example managed resources.
+	// +optional
+	WriteConnectionSecretToRef *ConnectionSecretRef `json:"writeConnectionSecretToRef,omitempty"`
+
+	// ConnectionDetailsPolicy specifies when connection details are
+	// extracted from the composed resource. The default, Always, extracts
+	// them whenever the composed resource exists. Use WhenReady to extract
+	// them only once the composed resource passes its readiness checks, so
+	// placeholder values some providers publish before a resource is usable
+	// aren't propagated to the composite resource.
+	// +kubebuilder:validation:Enum=Always;WhenReady
+	// +optional
+	ConnectionDetailsPolicy *ConnectionDetailsPolicy `json:"connectionDetailsPolicy,omitempty"`
+
+	// CrossplaneAnnotations configures whether patches may change the
+	// composed resource's crossplane.io/external-name and
+	// crossplane.io/paused annotations. Patches may change them by default.
+	// Use Deny to keep the values set by the base template or externalName.
+	// +optional
+	CrossplaneAnnotations *CrossplaneAnnotations `json:"crossplaneAnnotations,omitempty"`
+
+	// Timeouts configures how long the composed resource may take to become
+	// ready before the Function reports it.
+	// +optional
+	Timeouts *Timeouts `json:"timeouts,omitempty"`
+}
+
+// ComposedMetadata is the labels and annotations of a composed resource.
+// Variables in braces in their values are replaced with the value at a field
+// path of the observed composite resource, for example {xr.metadata.name} or
+// {xr.metadata.uid}. A label or annotation isn't set if the composite resource
+// doesn't have one of its value's field paths, for example {xr.metadata.namespace}
+// of a cluster scoped composite resource.
+type ComposedMetadata struct {
+	// Labels of the composed resource.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of the composed resource.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Timeouts configures how long a composed resource may take to become ready.
+type Timeouts struct {
+	// Ready is how long the composed resource may take to become ready,
+	// measured from its creation timestamp. If it isn't ready in time, the
+	// Function returns a warning result each time it's called until it is.
+	Ready metav1.Duration `json:"ready"`
+
+	// Condition is the type of a custom condition to set on the composite
+	// resource and its claim, for example DatabaseReady. It's True once the
+	// composed resource is ready, and False while it isn't. Its reason is
+	// ReadyTimeout once the composed resource isn't ready in time.
+	// +optional
+	Condition *string `json:"condition,omitempty"`
+}
+
+// A CrossplaneAnnotationPolicy specifies whether patches may change an
+// annotation Crossplane uses to manage a resource.
+type CrossplaneAnnotationPolicy string
+
+// Crossplane annotation policies.
+const (
+	CrossplaneAnnotationPolicyAllow CrossplaneAnnotationPolicy = "Allow"
+	CrossplaneAnnotationPolicyDeny  CrossplaneAnnotationPolicy = "Deny"
+)
+
+// CrossplaneAnnotations configures whether patches may change the
+// annotations Crossplane uses to manage a resource.
+type CrossplaneAnnotations struct {
+	// ExternalName specifies whether patches may change the
+	// crossplane.io/external-name annotation.
+	// +kubebuilder:validation:Enum=Allow;Deny
+	// +optional
+	ExternalName *CrossplaneAnnotationPolicy `json:"externalName,omitempty"`
+
+	// Paused specifies whether patches may change the crossplane.io/paused
+	// annotation.
+	// +kubebuilder:validation:Enum=Allow;Deny
+	// +optional
+	Paused *CrossplaneAnnotationPolicy `json:"paused,omitempty"`
+}
+
+// GetExternalNamePolicy returns the policy of the crossplane.io/external-name
+// annotation, defaulting to the supplied policy if not specified.
+func (a *CrossplaneAnnotations) GetExternalNamePolicy(def CrossplaneAnnotationPolicy) CrossplaneAnnotationPolicy {
+	if a == nil || a.ExternalName == nil {
+		return def
+	}
+	return *a.ExternalName
+}
+
+// GetPausedPolicy returns the policy of the crossplane.io/paused annotation,
+// defaulting to the supplied policy if not specified.
+func (a *CrossplaneAnnotations) GetPausedPolicy(def CrossplaneAnnotationPolicy) CrossplaneAnnotationPolicy {
+	if a == nil || a.Paused == nil {
+		return def
+	}
+	return *a.Paused
+}
+
+// WaitFor gates a composed resource on a value of the environment.
+type WaitFor struct {
+	// EnvironmentFieldPath is the field path of the environment value to
+	// wait for, for example stages.networkDone.
+	EnvironmentFieldPath string `json:"environmentFieldPath"`
+
+	// Equals is the value to wait for. The environment value must be equal
+	// to it, including its type, so "true" doesn't equal true.
+	Equals extv1.JSON `json:"equals"`
+}
+
+// An ExternalName configures the external name of a composed resource.
+type ExternalName struct {
+	// Format of the external name. Variables in braces are replaced with the
+	// value at a field path of the observed composite resource, for example
+	// "{xr.metadata.name}-db". A new composed resource isn't added to the
+	// desired state until all of the field paths exist.
+	Format string `json:"format"`
+
+	// Policy specifies whether the external name overwrites an external name
+	// the observed composed resource already has. The default is 'IfUnset',
+	// which keeps the observed external name. Use 'Always' to always set the
+	// external name.
+	// +kubebuilder:validation:Enum=Always;IfUnset
+	// +optional
+	Policy *OverwritePolicy `json:"policy,omitempty"`
+}
+
+// GetPolicy returns the OverwritePolicy of the external name, defaulting to
+// OverwritePolicyIfUnset if not specified.
+func (en *ExternalName) GetPolicy() OverwritePolicy {
+	if en.Policy == nil {
+		return OverwritePolicyIfUnset
+	}
+	return *en.Policy
+}
+
+// A ConnectionSecretRef configures the secret a composed resource writes its
+// connection details to.
+type ConnectionSecretRef struct {
+	// Name of the secret. Variables in braces are replaced with the value at
+	// a field path of the observed composite resource, for example
+	// "{xr.metadata.uid}-db". A new composed resource isn't added to the
+	// desired state until all of the field paths exist.
+	Name string `json:"name"`
+
+	// Namespace of the secret. It supports the same variables as name. Omit
+	// it for namespaced composed resources, which write their connection
+	// details to a secret in their own namespace.
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+// A RenderPolicy specifies what a desired composed resource is rendered from.
+type RenderPolicy string
+
+// Render policies.
+const (
+	RenderPolicyBase          RenderPolicy = "Base" // Default
+	RenderPolicyMergeObserved RenderPolicy = "MergeObserved"
+)
+
+// A FieldOwnershipPolicy specifies whether a field is owned by the Function,
+// or only a hint.
+type FieldOwnershipPolicy string
+
+// Field ownership policies.
+const (
+	FieldOwnershipPolicyOwned FieldOwnershipPolicy = "Owned" // Default
+	FieldOwnershipPolicyHint  FieldOwnershipPolicy = "Hint"
+)
+
+// FieldOwnership specifies whether a field of a desired composed resource is
+// owned by the Function, or only a hint.
+type FieldOwnership struct {
+	// FieldPath of the field, for example spec.forProvider.instanceType.
+	FieldPath string `json:"fieldPath"`
+
+	// Policy specifies whether the field is owned or a hint. Use Owned to
+	// keep a field inside a hint, for example to own
+	// spec.forProvider.tags.team while spec.forProvider.tags is a hint.
+	// +kubebuilder:validation:Enum=Owned;Hint
+	// +kubebuilder:default=Owned
+	// +optional
+	Policy FieldOwnershipPolicy `json:"policy,omitempty"`
+}
+
+// GetPolicy returns the field's ownership policy, defaulting to
+// FieldOwnershipPolicyOwned.
+func (o *FieldOwnership) GetPolicy() FieldOwnershipPolicy {
+	if o.Policy == "" {
+		return FieldOwnershipPolicyOwned
+	}
+	return o.Policy
+}
+
+// A ConnectionDetailsPolicy specifies when connection details are extracted
+// from a composed resource.
+type ConnectionDetailsPolicy string
+
+// Connection details policies.
+const (
+	ConnectionDetailsPolicyAlways    ConnectionDetailsPolicy = "Always" // Default
+	ConnectionDetailsPolicyWhenReady ConnectionDetailsPolicy = "WhenReady"
+)
+
+// A PatchFailurePolicy specifies what happens when a patch fails.
+type PatchFailurePolicy string
+
+// Patch failure policies.
+const (
+	// PatchFailurePolicySkip skips the failed patch. The remaining patches
+	// are still applied. The failure is only logged.
+	PatchFailurePolicySkip PatchFailurePolicy = "Skip"
+
+	// PatchFailurePolicyWarn skips the failed patch and returns a warning
+	// result. The remaining patches are still applied.
+	PatchFailurePolicyWarn PatchFailurePolicy = "Warn"
+
+	// PatchFailurePolicyFail returns a fatal result, which stops the Function
+	// pipeline from producing any desired state.
+	PatchFailurePolicyFail PatchFailurePolicy = "Fail" // Default
+)
+
+// BaseFrom specifies where to read the base of a composed resource from.
+// Exactly one field must be set.
+type BaseFrom struct {
+	// EnvironmentFieldPath is the path of a field in the Composition
+	// environment whose value is the base of the composed resource.
+	// +optional
+	EnvironmentFieldPath *string `json:"environmentFieldPath,omitempty"`
+
+	// ContextFieldPath is the path of a field in the Function pipeline context
+	// whose value is the base of the composed resource. The first segment of
+	// the path is a context key, for example [example.org/bases].bucket.
+	// +optional
+	ContextFieldPath *string `json:"contextFieldPath,omitempty"`
 }
 
 // ReadinessCheckType is used for readiness check types.
@@ -84,11 +451,16 @@ type ReadinessCheckType string
 const (
 	ReadinessCheckTypeNonEmpty       ReadinessCheckType = "NonEmpty"
 	ReadinessCheckTypeMatchString    ReadinessCheckType = "MatchString"
+	ReadinessCheckTypeMatchRegexp    ReadinessCheckType = "MatchRegexp"
 	ReadinessCheckTypeMatchInteger   ReadinessCheckType = "MatchInteger"
 	ReadinessCheckTypeMatchTrue      ReadinessCheckType = "MatchTrue"
 	ReadinessCheckTypeMatchFalse     ReadinessCheckType = "MatchFalse"
 	ReadinessCheckTypeMatchCondition ReadinessCheckType = "MatchCondition"
 	ReadinessCheckTypeNone           ReadinessCheckType = "None"
+	ReadinessCheckTypeAnyOf          ReadinessCheckType = "AnyOf"
+	ReadinessCheckTypeAllOf          ReadinessCheckType = "AllOf"
+
+	ReadinessCheckTypeMatchConnectionDetailKey ReadinessCheckType = "MatchConnectionDetailKey"
 )
 
 // IsValid returns true if the readiness check type is valid.
@@ -96,23 +468,82 @@ func (t *ReadinessCheckType) IsValid() bool {
 	switch *t {
 	case ReadinessCheckTypeNonEmpty,
 		ReadinessCheckTypeMatchString,
+		ReadinessCheckTypeMatchRegexp,
 		ReadinessCheckTypeMatchInteger,
 		ReadinessCheckTypeMatchTrue,
 		ReadinessCheckTypeMatchFalse,
 		ReadinessCheckTypeMatchCondition,
-		ReadinessCheckTypeNone:
+		ReadinessCheckTypeNone,
+		ReadinessCheckTypeAnyOf,
+		ReadinessCheckTypeAllOf,
+		ReadinessCheckTypeMatchConnectionDetailKey:
+		return true
+	}
+	return false
+}
+
+// A ReadinessCheckSource is the object a readiness check runs against.
+type ReadinessCheckSource string
+
+// The possible values for readiness check source.
+const (
+	ReadinessCheckSourceComposed    ReadinessCheckSource = "Composed"
+	ReadinessCheckSourceComposite   ReadinessCheckSource = "Composite"
+	ReadinessCheckSourceEnvironment ReadinessCheckSource = "Environment"
+)
+
+// IsValid returns true if the readiness check source is valid.
+func (s *ReadinessCheckSource) IsValid() bool {
+	switch *s {
+	case ReadinessCheckSourceComposed,
+		ReadinessCheckSourceComposite,
+		ReadinessCheckSourceEnvironment:
 		return true
 	}
 	return false
 }
 
+// ReadyFrom overrides the readiness of a composed resource with a boolean.
+type ReadyFrom struct {
+	// Source is the object the boolean is read from. The default is
+	// 'Composite', which means the observed composite resource. Use
+	// 'Environment' to read it from the Composition environment.
+	// +optional
+	// +kubebuilder:validation:Enum=Composite;Environment
+	// +kubebuilder:default=Composite
+	Source ReadinessCheckSource `json:"source,omitempty"`
+
+	// FieldPath of the boolean, for example spec.maintenance.ready.
+	FieldPath string `json:"fieldPath"`
+}
+
+// GetSource returns the source of the readiness override, defaulting to
+// ReadinessCheckSourceComposite.
+func (r *ReadyFrom) GetSource() ReadinessCheckSource {
+	if r.Source == "" {
+		return ReadinessCheckSourceComposite
+	}
+	return r.Source
+}
+
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
 // for consumption
 type ReadinessCheck struct {
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"MatchCondition";"MatchTrue";"MatchFalse";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchRegexp";"MatchInteger";"NonEmpty";"MatchCondition";"MatchTrue";"MatchFalse";"None";"AnyOf";"AllOf";"MatchConnectionDetailKey"
 	Type ReadinessCheckType `json:"type"`
 
+	// Source is the object the check runs against. The default is
+	// 'Composed', which means the check runs against the observed composed
+	// resource. Use 'Composite' to run the check against the observed
+	// composite resource, or 'Environment' to run it against the Composition
+	// environment. The 'MatchCondition' type does not support 'Environment',
+	// and the 'MatchConnectionDetailKey' type only supports 'Composed'.
+	// +optional
+	// +kubebuilder:validation:Enum=Composed;Composite;Environment
+	// +kubebuilder:default=Composed
+	Source ReadinessCheckSource `json:"source,omitempty"`
+
 	// FieldPath shows the path of the field whose value will be used.
 	// +optional
 	FieldPath *string `json:"fieldPath,omitempty"`
@@ -121,6 +552,12 @@ type ReadinessCheck struct {
 	// +optional
 	MatchString *string `json:"matchString,omitempty"`
 
+	// MatchRegexp is a regular expression you'd like the value to match if
+	// you're using "MatchRegexp" type. See https://pkg.go.dev/regexp/ for
+	// details.
+	// +optional
+	MatchRegexp *string `json:"matchRegexp,omitempty"`
+
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
 	// +optional
 	MatchInteger *int64 `json:"matchInteger,omitempty"`
@@ -128,6 +565,38 @@ type ReadinessCheck struct {
 	// MatchCondition specifies the condition you'd like to match if you're using "MatchCondition" type.
 	// +optional
 	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+
+	// ConnectionDetailKey is the key that must exist in the observed composed
+	// resource's connection details if you're using
+	// "MatchConnectionDetailKey" type. Use it for resources that become
+	// ready before their provider publishes their connection details.
+	// +optional
+	ConnectionDetailKey *string `json:"connectionDetailKey,omitempty"`
+
+	// AnyOf is a group of readiness checks, at least one of which must pass
+	// if you're using "AnyOf" type.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=array
+	// +kubebuilder:pruning:PreserveUnknownFields
+	AnyOf []ReadinessCheck `json:"anyOf,omitempty"`
+
+	// AllOf is a group of readiness checks, all of which must pass if you're
+	// using "AllOf" type.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=array
+	// +kubebuilder:pruning:PreserveUnknownFields
+	AllOf []ReadinessCheck `json:"allOf,omitempty"`
+}
+
+// GetSource returns the readiness check source. If the source is not set, it
+// returns the default source.
+func (r *ReadinessCheck) GetSource() ReadinessCheckSource {
+	if r.Source == "" {
+		return ReadinessCheckSourceComposed
+	}
+	return r.Source
 }
 
 // MatchConditionReadinessCheck is used to indicate how to tell whether a resource is ready
@@ -140,6 +609,15 @@ type MatchConditionReadinessCheck struct {
 	// Status is the status of the condition you'd like to match.
 	// +kubebuilder:default="True"
 	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is a regular expression the condition's reason must match, for
+	// example to ignore a Ready condition with a transient reason.
+	// +optional
+	Reason *string `json:"reason,omitempty"`
+
+	// Message is a regular expression the condition's message must match.
+	// +optional
+	Message *string `json:"message,omitempty"`
 }
 
 // A ConnectionDetailType is a type of connection detail.
@@ -147,9 +625,12 @@ type ConnectionDetailType string
 
 // ConnectionDetailType types.
 const (
-	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
-	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
-	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeFromConnectionSecretKey  ConnectionDetailType = "FromConnectionSecretKey"
+	ConnectionDetailTypeFromFieldPath            ConnectionDetailType = "FromFieldPath"
+	ConnectionDetailTypeFromValue                ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeFromEnvironmentFieldPath ConnectionDetailType = "FromEnvironmentFieldPath"
+	ConnectionDetailTypeFromStatusFieldPath      ConnectionDetailType = "FromStatusFieldPath"
+	ConnectionDetailTypeCombine                  ConnectionDetailType = "Combine"
 )
 
 // IsValid returns true if the connection detail type is valid.
@@ -157,12 +638,34 @@ func (t *ConnectionDetailType) IsValid() bool {
 	switch *t {
 	case ConnectionDetailTypeFromConnectionSecretKey,
 		ConnectionDetailTypeFromFieldPath,
-		ConnectionDetailTypeFromValue:
+		ConnectionDetailTypeFromValue,
+		ConnectionDetailTypeFromEnvironmentFieldPath,
+		ConnectionDetailTypeFromStatusFieldPath,
+		ConnectionDetailTypeCombine:
 		return true
 	}
 	return false
 }
 
+// A ConnectionDetailEncoding determines how a connection detail's value is
+// serialized into the connection secret.
+type ConnectionDetailEncoding string
+
+// Connection detail encodings.
+const (
+	// ConnectionDetailEncodingNone writes strings as is, and other values
+	// as compact JSON.
+	ConnectionDetailEncodingNone ConnectionDetailEncoding = "None"
+
+	// ConnectionDetailEncodingBase64 base64 encodes the value that
+	// ConnectionDetailEncodingNone would write.
+	ConnectionDetailEncodingBase64 ConnectionDetailEncoding = "Base64"
+
+	// ConnectionDetailEncodingJSON writes all values, including strings, as
+	// compact JSON.
+	ConnectionDetailEncodingJSON ConnectionDetailEncoding = "JSON"
+)
+
 // ConnectionDetail includes the information about the propagation of the connection
 // information from one secret to another.
 type ConnectionDetail struct {
@@ -173,7 +676,7 @@ type ConnectionDetail struct {
 	// Type sets the connection detail fetching behavior to be used. Each
 	// connection detail type may require its own fields to be set on the
 	// ConnectionDetail object.
-	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;FromEnvironmentFieldPath;FromStatusFieldPath;Combine
 	Type ConnectionDetailType `json:"type"`
 
 	// FromConnectionSecretKey is the key that will be used to fetch the value
@@ -183,13 +686,124 @@ type ConnectionDetail struct {
 
 	// FromFieldPath is the path of the field on the composed resource whose
 	// value to be used as input. Name must be specified if the type is
-	// FromFieldPath.
+	// FromFieldPath. If the type is FromEnvironmentFieldPath this is the path
+	// of the field in the Composition environment. If the type is
+	// FromStatusFieldPath this is the path of a field under the observed
+	// composed resource's status, for example status.atProvider.endpoint.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// Policy configures how a FromStatusFieldPath connection detail is
+	// extracted.
+	// +optional
+	Policy *ConnectionDetailPolicy `json:"policy,omitempty"`
+
 	// Value that will be propagated to the connection secret of the composite
 	// resource. May be set to inject a fixed, non-sensitive connection secret
 	// value, for example a well-known port.
 	// +optional
 	Value *string `json:"value,omitempty"`
+
+	// Combine combines several connection secret keys, field paths or values
+	// into a single connection detail. Required if the type is Combine.
+	// +optional
+	Combine *ConnectionDetailCombine `json:"combine,omitempty"`
+
+	// Transforms are the list of functions that are used as a FIFO pipe for
+	// the extracted value to be transformed before it is written to the
+	// connection secret of the composite resource.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
+
+	// Encoding determines how the extracted, transformed value is serialized
+	// into the connection secret. 'None' writes strings as is, and other
+	// values, like objects, as compact JSON. 'Base64' base64 encodes the
+	// value 'None' would write. 'JSON' writes all values as compact JSON,
+	// so strings are quoted. The default is 'None'.
+	// +kubebuilder:validation:Enum=None;Base64;JSON
+	// +optional
+	Encoding *ConnectionDetailEncoding `json:"encoding,omitempty"`
+
+	// FromResource is the name of the resource template whose composed
+	// resource the connection detail is extracted from. It's only supported
+	// by the top-level connectionDetails, so the composite resource's
+	// connection details can be assembled from several composed resources
+	// in one place. It's required for connection detail types that read a
+	// composed resource. Resource templates' connection details always read
+	// their own composed resource.
+	// +optional
+	FromResource *string `json:"fromResource,omitempty"`
+}
+
+// GetEncoding returns the Encoding of this ConnectionDetail, defaulting to
+// ConnectionDetailEncodingNone if not specified.
+func (cd *ConnectionDetail) GetEncoding() ConnectionDetailEncoding {
+	if cd.Encoding == nil {
+		return ConnectionDetailEncodingNone
+	}
+	return *cd.Encoding
+}
+
+// A ConnectionDetailPolicy configures how a connection detail is extracted.
+type ConnectionDetailPolicy struct {
+	// FromFieldPath specifies how to treat a FromStatusFieldPath connection
+	// detail whose field path doesn't exist yet. Either way the connection
+	// detail is listed in the pt.fn.crossplane.io/pending-connection-details
+	// annotation of the composite resource until it exists. If it's
+	// 'Required' the composed resource also isn't considered ready until it
+	// exists. The default is 'Optional'.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+}
+
+// GetFromFieldPathPolicy returns the FromFieldPathPolicy for this
+// ConnectionDetailPolicy, defaulting to FromFieldPathPolicyOptional if not
+// specified.
+func (cp *ConnectionDetailPolicy) GetFromFieldPathPolicy() FromFieldPathPolicy {
+	if cp == nil || cp.FromFieldPath == nil {
+		return FromFieldPathPolicyOptional
+	}
+	return *cp.FromFieldPath
+}
+
+// A ConnectionDetailCombineVariable defines the source of a value that is
+// combined with others to form a connection detail.
+type ConnectionDetailCombineVariable struct {
+	// Type sets where the value of this variable is read from.
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;FromEnvironmentFieldPath
+	Type ConnectionDetailType `json:"type"`
+
+	// FromConnectionSecretKey is the key that will be used to fetch the value
+	// from the composed resource's connection secret.
+	// +optional
+	FromConnectionSecretKey *string `json:"fromConnectionSecretKey,omitempty"`
+
+	// FromFieldPath is the path of the field on the composed resource, or in
+	// the Composition environment, whose value to be used as input.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+
+	// Value is a fixed value to be used as input.
+	// +optional
+	Value *string `json:"value,omitempty"`
+}
+
+// A ConnectionDetailCombine combines more than one input value into a single
+// connection detail.
+type ConnectionDetailCombine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined.
+	// +kubebuilder:validation:MinItems=1
+	Variables []ConnectionDetailCombineVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable values.
+	// Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
 }
@@ -1,5 +1,10 @@
 package v1beta1
 
+import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // A PatchType is a type of patch.
 type PatchType string
 
@@ -23,6 +28,27 @@ const (
 	PatchTypeCombineToEnvironment     PatchType = "CombineToEnvironment"
 )
 
+// Context patch types.
+const (
+	PatchTypeFromContextFieldPath PatchType = "FromContextFieldPath"
+	PatchTypeToContextFieldPath   PatchType = "ToContextFieldPath"
+)
+
+// Extra resource patch types.
+const (
+	PatchTypeFromExtraResourceFieldPath PatchType = "FromExtraResourceFieldPath"
+)
+
+// Claim patch types.
+const (
+	PatchTypeFromClaimFieldPath PatchType = "FromClaimFieldPath"
+)
+
+// Variable patch types.
+const (
+	PatchTypeFromVariableFieldPath PatchType = "FromVariableFieldPath"
+)
+
 // A FromFieldPathPolicy determines how to patch from a field path.
 type FromFieldPathPolicy string
 
@@ -43,12 +69,26 @@ const (
 	ToFieldPathPolicyForceMergeObjects             ToFieldPathPolicy = "ForceMergeObjects"
 	ToFieldPathPolicyForceMergeObjectsAppendArrays ToFieldPathPolicy = "ForceMergeObjectsAppendArrays"
 
+	ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource ToFieldPathPolicy = "MergeObjectsDeleteKeysNotInSource"
+
+	ToFieldPathPolicySetIfEmpty ToFieldPathPolicy = "SetIfEmpty"
+
 	// Deprecated: Use MergeObjects, which is functionally identical.
 	ToFieldPathPolicyMergeObject ToFieldPathPolicy = "MergeObject"
 	// Deprecated: Use ForceMergeObjectsAppendArrays, which is functionally identical.
 	ToFieldPathPolicyAppendArray ToFieldPathPolicy = "AppendArray"
 )
 
+// An OverwritePolicy determines whether a patch to the composite resource
+// overwrites a value the composite resource already has.
+type OverwritePolicy string
+
+// Overwrite patch policies.
+const (
+	OverwritePolicyAlways  OverwritePolicy = "Always"
+	OverwritePolicyIfUnset OverwritePolicy = "IfUnset"
+)
+
 // A PatchPolicy configures the specifics of patching behaviour.
 type PatchPolicy struct {
 	// FromFieldPath specifies how to patch from a field path. The default is
@@ -68,12 +108,107 @@ type PatchPolicy struct {
 	// 'ForceMergeObjects' to recursively merge the patch object with the target object, overwriting
 	// any target object keys, including array values, or use
 	// 'ForceMergeObjectsAppendArrays' to recursively merge the patch object with the target object,
-	// overwriting target object keys, and appending any array values to target array values.
+	// overwriting target object keys, and appending any array values to target array values, or use
+	// 'MergeObjectsDeleteKeysNotInSource' to merge the patch object with the target object like
+	// 'ForceMergeObjects', while deleting any target object keys that the patch merged last time it
+	// was applied but that are no longer in the patch object. This policy only supports patching
+	// objects to a composed or composite resource. It records the keys it merged in the
+	// pt.fn.crossplane.io/merged-keys annotation, or use
+	// 'SetIfEmpty' to replace the target field like 'Replace', but only if it doesn't
+	// exist or is null, an empty string, an empty array, or an empty object in the
+	// desired state, so a value set by an earlier Function in the pipeline isn't
+	// overwritten. 'SetIfEmpty' doesn't support wildcards in the toFieldPath.
 	// 'MergeObject' is deprecated, use 'MergeObjects' instead, which is functionally identical.
 	// 'AppendArray' is deprecated, use 'ForceMergeObjectsAppendArrays' instead, which is functionally identical.
-	// +kubebuilder:validation:Enum=Replace;MergeObjects;MergeObjectsAppendArrays;ForceMergeObjects;ForceMergeObjectsAppendArrays;MergeObject;AppendArray
+	// +kubebuilder:validation:Enum=Replace;MergeObjects;MergeObjectsAppendArrays;ForceMergeObjects;ForceMergeObjectsAppendArrays;MergeObjectsDeleteKeysNotInSource;SetIfEmpty;MergeObject;AppendArray
 	// +optional
 	ToFieldPath *ToFieldPathPolicy `json:"toFieldPath,omitempty"`
+
+	// Overwrite specifies whether a patch to the composite resource overwrites
+	// a value that's already set in the observed composite resource. The
+	// default is 'Always'. Use 'IfUnset' to only patch the field if it isn't
+	// set, for example to default a spec field. If it's set, the observed
+	// value is kept in the desired composite resource. This stops a composite
+	// resource from losing a spec field that was patched when it was unset.
+	// Patches to other resources ignore this policy.
+	// +kubebuilder:validation:Enum=Always;IfUnset
+	// +optional
+	Overwrite *OverwritePolicy `json:"overwrite,omitempty"`
+
+	// RequeueAfter shortens how long Crossplane waits before it calls the
+	// Function again if the patch's fromFieldPath policy is 'Required' and
+	// the fromFieldPath doesn't exist yet, for example because an observed
+	// composed resource hasn't populated its status. This helps the composite
+	// resource become ready sooner. Longer durations than the Function's
+	// default TTL have no effect.
+	// +optional
+	RequeueAfter *metav1.Duration `json:"requeueAfter,omitempty"`
+
+	// WhenSettled only applies a ToCompositeFieldPath or CombineToComposite
+	// patch once the observed composed resource's status is settled, so
+	// stale or partial status values don't flap onto the composite resource
+	// between reconciles. Until then the composite resource keeps its
+	// observed value. Patches of other types ignore this policy.
+	// +optional
+	WhenSettled *SettledCheck `json:"whenSettled,omitempty"`
+
+	// ExpandArrays creates the array elements a toFieldPath with a [*]
+	// wildcard patches, rather than only patching the elements that exist.
+	// The patch's value must be an array. Its first element is patched to
+	// the first element of the wildcard array, its second to the second, and
+	// so on. The toFieldPath must contain exactly one wildcard.
+	// +optional
+	ExpandArrays *bool `json:"expandArrays,omitempty"`
+}
+
+// A SettledCheckType specifies how to check whether a composed resource's
+// status is settled.
+type SettledCheckType string
+
+// Settled check types.
+const (
+	// SettledCheckTypeReady checks that the composed resource's Ready
+	// condition is True.
+	SettledCheckTypeReady SettledCheckType = "Ready"
+
+	// SettledCheckTypeObservedGeneration checks that the composed resource's
+	// status.observedGeneration, or if it doesn't have one its Ready
+	// condition's observedGeneration, equals its metadata.generation.
+	SettledCheckTypeObservedGeneration SettledCheckType = "ObservedGeneration"
+
+	// SettledCheckTypeFieldPath checks that a boolean field path of the
+	// composed resource is true.
+	SettledCheckTypeFieldPath SettledCheckType = "FieldPath"
+)
+
+// A SettledCheck checks whether a composed resource's status is settled.
+type SettledCheck struct {
+	// Type of check.
+	// +kubebuilder:validation:Enum=Ready;ObservedGeneration;FieldPath
+	Type SettledCheckType `json:"type"`
+
+	// FieldPath of the observed composed resource that must be true. It's
+	// required if the type is FieldPath.
+	// +optional
+	FieldPath *string `json:"fieldPath,omitempty"`
+}
+
+// GetWhenSettled returns the SettledCheck of this PatchPolicy, or nil if
+// patches don't wait for the composed resource's status to settle.
+func (pp *PatchPolicy) GetWhenSettled() *SettledCheck {
+	if pp == nil {
+		return nil
+	}
+	return pp.WhenSettled
+}
+
+// GetExpandArrays returns true if the patch should create the array elements
+// its toFieldPath's wildcard patches.
+func (pp *PatchPolicy) GetExpandArrays() bool {
+	if pp == nil || pp.ExpandArrays == nil {
+		return false
+	}
+	return *pp.ExpandArrays
 }
 
 // GetFromFieldPathPolicy returns the FromFieldPathPolicy for this PatchPolicy, defaulting to FromFieldPathPolicyOptional if not specified.
@@ -92,15 +227,206 @@ func (pp *PatchPolicy) GetToFieldPathPolicy() ToFieldPathPolicy {
 	return *pp.ToFieldPath
 }
 
+// GetRequeueAfter returns how long to wait before calling the Function again
+// if a required field path doesn't exist, or nil if it's not specified.
+func (pp *PatchPolicy) GetRequeueAfter() *metav1.Duration {
+	if pp == nil {
+		return nil
+	}
+	return pp.RequeueAfter
+}
+
+// GetOverwritePolicy returns the OverwritePolicy for this PatchPolicy, defaulting to OverwritePolicyAlways if not specified.
+func (pp *PatchPolicy) GetOverwritePolicy() OverwritePolicy {
+	if pp == nil || pp.Overwrite == nil {
+		return OverwritePolicyAlways
+	}
+	return *pp.Overwrite
+}
+
 // Environment represents the Composition environment.
 type Environment struct {
+	// EnvironmentConfigs selects EnvironmentConfigs whose data is merged into
+	// the environment before any patches are applied. The data of later
+	// EnvironmentConfigs takes precedence over earlier ones, and over any
+	// environment Crossplane or a previous Function supplied. Crossplane
+	// fetches the selected EnvironmentConfigs and calls the Function again,
+	// so they aren't merged the first time the Function is called.
+	// +optional
+	EnvironmentConfigs []EnvironmentConfig `json:"environmentConfigs,omitempty"`
+
+	// DefaultData is merged beneath the environment supplied by Crossplane or
+	// a previous Function, so the environment has sensible defaults even if
+	// no EnvironmentConfig is selected. Each key is a top-level key of the
+	// environment, and its value may be any JSON value.
+	// +optional
+	DefaultData map[string]extv1.JSON `json:"defaultData,omitempty"`
+
 	// Patches is a list of environment patches that are executed before a
-	// composition's resources are composed. These patches are between the XR
-	// and the Environment. Either from the Environment to the XR, or vice
-	// versa.
+	// composition's resources are composed, unless their stage is 'After'.
+	// These patches are between the XR and the Environment. Either from the
+	// Environment to the XR, or vice versa.
 	Patches []EnvironmentPatch `json:"patches,omitempty"`
+
+	// Passthrough writes the environment to the Function pipeline context
+	// even if it wasn't supplied by Crossplane or a previous Function, and
+	// nothing was patched or merged into it. By default an empty environment
+	// isn't written to the context.
+	// +optional
+	Passthrough *bool `json:"passthrough,omitempty"`
+
+	// Schema lists field paths the environment must have, and optionally the
+	// types of their values. The environment is checked once any
+	// EnvironmentConfigs are merged into it, before any patches are applied.
+	// The Function returns a fatal result if it doesn't match, so a
+	// misconfigured environment fails fast instead of causing optional
+	// patches to be skipped.
+	// +optional
+	Schema []EnvironmentField `json:"schema,omitempty"`
+}
+
+// An EnvironmentFieldType is the type of an environment field's value.
+type EnvironmentFieldType string
+
+// Environment field types.
+const (
+	EnvironmentFieldTypeString  EnvironmentFieldType = "string"
+	EnvironmentFieldTypeInteger EnvironmentFieldType = "integer"
+	EnvironmentFieldTypeNumber  EnvironmentFieldType = "number"
+	EnvironmentFieldTypeBoolean EnvironmentFieldType = "boolean"
+	EnvironmentFieldTypeObject  EnvironmentFieldType = "object"
+	EnvironmentFieldTypeArray   EnvironmentFieldType = "array"
+)
+
+// An EnvironmentField is a field path the environment must have.
+type EnvironmentField struct {
+	// FieldPath that must exist in the environment, for example
+	// networks.vpcId.
+	FieldPath string `json:"fieldPath"`
+
+	// Type the field path's value must have. Any type is allowed if it's
+	// not specified.
+	// +kubebuilder:validation:Enum=string;integer;number;boolean;object;array
+	// +optional
+	Type *EnvironmentFieldType `json:"type,omitempty"`
+}
+
+// GetPassthrough returns true if the environment should always be written to
+// the Function pipeline context.
+func (e *Environment) GetPassthrough() bool {
+	if e == nil || e.Passthrough == nil {
+		return false
+	}
+	return *e.Passthrough
 }
 
+// An EnvironmentConfigType is a way to select EnvironmentConfigs.
+type EnvironmentConfigType string
+
+// EnvironmentConfig types.
+const (
+	EnvironmentConfigTypeReference EnvironmentConfigType = "Reference"
+	EnvironmentConfigTypeSelector  EnvironmentConfigType = "Selector"
+)
+
+// An EnvironmentConfig selects EnvironmentConfigs to merge into the
+// environment.
+type EnvironmentConfig struct {
+	// Type specifies how EnvironmentConfigs are selected. Use 'Reference' to
+	// select an EnvironmentConfig by name, or 'Selector' to select all
+	// EnvironmentConfigs with matching labels. EnvironmentConfigs selected by
+	// labels are merged in order of their names.
+	// +kubebuilder:validation:Enum=Reference;Selector
+	// +kubebuilder:default=Reference
+	// +optional
+	Type EnvironmentConfigType `json:"type,omitempty"`
+
+	// Ref selects an EnvironmentConfig by name. Required when type is
+	// Reference.
+	// +optional
+	Ref *EnvironmentConfigReference `json:"ref,omitempty"`
+
+	// Selector selects EnvironmentConfigs by labels. Required when type is
+	// Selector.
+	// +optional
+	Selector *EnvironmentConfigSelector `json:"selector,omitempty"`
+}
+
+// GetType returns the type of the EnvironmentConfig, defaulting to Reference
+// if not specified.
+func (ec *EnvironmentConfig) GetType() EnvironmentConfigType {
+	if ec.Type == "" {
+		return EnvironmentConfigTypeReference
+	}
+	return ec.Type
+}
+
+// An EnvironmentConfigReference selects an EnvironmentConfig by name.
+type EnvironmentConfigReference struct {
+	// Name of the EnvironmentConfig.
+	Name string `json:"name"`
+}
+
+// An EnvironmentConfigSelector selects EnvironmentConfigs by labels.
+type EnvironmentConfigSelector struct {
+	// MatchLabels the selected EnvironmentConfigs must have.
+	// +kubebuilder:validation:MinItems=1
+	MatchLabels []EnvironmentConfigLabelMatcher `json:"matchLabels"`
+}
+
+// An EnvironmentConfigLabelMatcherType determines where the value of a label
+// matcher comes from.
+type EnvironmentConfigLabelMatcherType string
+
+// EnvironmentConfig label matcher types.
+const (
+	EnvironmentConfigLabelMatcherTypeValue                  EnvironmentConfigLabelMatcherType = "Value"
+	EnvironmentConfigLabelMatcherTypeFromCompositeFieldPath EnvironmentConfigLabelMatcherType = "FromCompositeFieldPath"
+)
+
+// An EnvironmentConfigLabelMatcher matches a label of an EnvironmentConfig.
+type EnvironmentConfigLabelMatcher struct {
+	// Key of the label.
+	Key string `json:"key"`
+
+	// Type specifies where the label's value comes from. Use 'Value' to
+	// match the supplied value, or 'FromCompositeFieldPath' to match the
+	// value at a field path of the observed composite resource.
+	// +kubebuilder:validation:Enum=Value;FromCompositeFieldPath
+	// +kubebuilder:default=FromCompositeFieldPath
+	// +optional
+	Type EnvironmentConfigLabelMatcherType `json:"type,omitempty"`
+
+	// Value the label must have. Required when type is Value.
+	// +optional
+	Value *string `json:"value,omitempty"`
+
+	// ValueFromFieldPath is the field path of the composite resource whose
+	// value the label must have. Required when type is
+	// FromCompositeFieldPath.
+	// +optional
+	ValueFromFieldPath *string `json:"valueFromFieldPath,omitempty"`
+}
+
+// GetType returns the type of the label matcher, defaulting to
+// FromCompositeFieldPath if not specified.
+func (m *EnvironmentConfigLabelMatcher) GetType() EnvironmentConfigLabelMatcherType {
+	if m.Type == "" {
+		return EnvironmentConfigLabelMatcherTypeFromCompositeFieldPath
+	}
+	return m.Type
+}
+
+// An EnvironmentPatchStage determines when an environment patch is applied,
+// relative to the composed resources.
+type EnvironmentPatchStage string
+
+// Environment patch stages.
+const (
+	EnvironmentPatchStageBefore EnvironmentPatchStage = "Before"
+	EnvironmentPatchStageAfter  EnvironmentPatchStage = "After"
+)
+
 // EnvironmentPatch objects are applied between the composite resource and
 // the environment. Their behaviour depends on the Type selected. The default
 // Type, FromCompositeFieldPath, copies a value from the composite resource
@@ -113,6 +439,16 @@ type EnvironmentPatch struct {
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
+	// Stage determines when the patch is applied. The default is 'Before',
+	// which means the patch is applied before any composed resources are
+	// rendered. Use 'After' to apply the patch once all composed resources
+	// have been rendered, for example to patch a value that a composed
+	// resource patched to the environment into the composite resource.
+	// +optional
+	// +kubebuilder:validation:Enum=Before;After
+	// +kubebuilder:default=Before
+	Stage EnvironmentPatchStage `json:"stage,omitempty"`
+
 	Patch `json:",inline"`
 }
 
@@ -124,6 +460,15 @@ func (ep *EnvironmentPatch) GetType() PatchType {
 	return ep.Type
 }
 
+// GetStage returns the patch stage. If the stage is not set, it returns the
+// default stage.
+func (ep *EnvironmentPatch) GetStage() EnvironmentPatchStage {
+	if ep.Stage == "" {
+		return EnvironmentPatchStageBefore
+	}
+	return ep.Stage
+}
+
 // ComposedPatch objects are applied between composite and composed resources.
 // Their behaviour depends on the Type selected. The default Type,
 // FromCompositeFieldPath, copies a value from the composite resource to the
@@ -132,7 +477,7 @@ type ComposedPatch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its own fields to be set on the ComposedPatch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment;FromContextFieldPath;ToContextFieldPath;FromExtraResourceFieldPath;FromClaimFieldPath;FromVariableFieldPath
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
@@ -166,10 +511,16 @@ type PatchSetPatch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its own fields to be set on the ComposedPatch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment;FromContextFieldPath;ToContextFieldPath;FromExtraResourceFieldPath;FromClaimFieldPath;FromVariableFieldPath;PatchSet
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
+	// PatchSetName to include patches from. Required when type is PatchSet.
+	// A PatchSet may include other PatchSets, but not itself, directly or
+	// indirectly.
+	// +optional
+	PatchSetName *string `json:"patchSetName,omitempty"`
+
 	Patch `json:",inline"`
 }
 
@@ -181,11 +532,28 @@ func (psp *PatchSetPatch) GetType() PatchType {
 	return psp.Type
 }
 
+// GetPatchSetName returns the PatchSetName for this PatchSetPatch, or an empty
+// string if it is nil.
+func (psp *PatchSetPatch) GetPatchSetName() string {
+	if psp.PatchSetName == nil {
+		return ""
+	}
+	return *psp.PatchSetName
+}
+
 // Patch defines a patch between a source and destination.
 type Patch struct {
 	// FromFieldPath is the path of the field on the resource whose value is
 	// to be used as input. Required when type is FromCompositeFieldPath or
-	// ToCompositeFieldPath.
+	// ToCompositeFieldPath. When type is FromContextFieldPath the path is
+	// relative to the Function pipeline context, and its first segment is the
+	// context key, for example "[example.org/key].field". When type is
+	// FromExtraResourceFieldPath its first segment is the name of the
+	// requested extra resources, for example "provider-config.spec.region".
+	// When type is FromClaimFieldPath the path is relative to an object with
+	// the apiVersion, kind, name, and namespace of the composite resource's
+	// claim, for example "namespace". The special path "." refers to the
+	// whole resource, rather than one of its fields.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
@@ -196,7 +564,15 @@ type Patch struct {
 
 	// ToFieldPath is the path of the field on the resource whose value will
 	// be changed with the result of transforms. Leave empty if you'd like to
-	// propagate to the same path as fromFieldPath.
+	// propagate to the same path as fromFieldPath. It may contain Go template
+	// placeholders, for example spec.forProvider.tags[{{ .key }}]. The
+	// template's data is the value at fromFieldPath, or a list of the values
+	// of the combine variables. Its fromFieldPath function returns the value
+	// of another field of the resource the patch is from. Array elements may
+	// be selected by key, for example spec.forProvider.tags[name=env].value
+	// selects the element of the tags array whose name is env. An element is
+	// appended if none matches. A key selector only selects from an array
+	// that exists. Otherwise the segment is an object key, which may contain =.
 	// +optional
 	ToFieldPath *string `json:"toFieldPath,omitempty"`
 
@@ -208,6 +584,12 @@ type Patch struct {
 	// Policy configures the specifics of patching behaviour.
 	// +optional
 	Policy *PatchPolicy `json:"policy,omitempty"`
+
+	// Sensitive patches, for example patches from a secret in the
+	// environment, have the values they read and write redacted from errors,
+	// results, and debug logs.
+	// +optional
+	Sensitive *bool `json:"sensitive,omitempty"`
 }
 
 // GetFromFieldPath returns the FromFieldPath for this Patch, or an empty string if it is nil.
@@ -242,6 +624,11 @@ func (p *Patch) GetPolicy() *PatchPolicy {
 	return p.Policy
 }
 
+// GetSensitive returns true if this Patch is sensitive.
+func (p *Patch) GetSensitive() bool {
+	return p.Sensitive != nil && *p.Sensitive
+}
+
 // A CombineVariable defines the source of a value that is combined with
 // others to form and patch an output value. Currently, this only supports
 // retrieving values from a field path.
@@ -249,6 +636,67 @@ type CombineVariable struct {
 	// FromFieldPath is the path of the field on the source whose value is
 	// to be used as input.
 	FromFieldPath string `json:"fromFieldPath"`
+
+	// Name of the variable. It's the variable's key in the object built by
+	// the Object strategy, which requires it. Other strategies ignore it.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Policy specifies what happens if the variable's field path doesn't
+	// exist. The default, Required, doesn't combine the variables at all, so
+	// the patch isn't applied. Use Optional to combine the variable's
+	// default value instead.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	Policy *FromFieldPathPolicy `json:"policy,omitempty"`
+
+	// Default is the value of the variable if its field path doesn't exist.
+	// Required if the policy is Optional.
+	// +optional
+	Default *extv1.JSON `json:"default,omitempty"`
+
+	// Source is the object the variable's field path is read from. By
+	// default it's read from the source of the patch, so a patch can combine
+	// values from several objects, for example the composite resource and
+	// the environment.
+	// +optional
+	Source *CombineVariableSource `json:"source,omitempty"`
+}
+
+// GetPolicy returns the variable's policy, defaulting to
+// FromFieldPathPolicyRequired if not specified.
+func (v *CombineVariable) GetPolicy() FromFieldPathPolicy {
+	if v.Policy == nil {
+		return FromFieldPathPolicyRequired
+	}
+	return *v.Policy
+}
+
+// A CombineVariableSourceType is the type of object a combine variable is
+// read from.
+type CombineVariableSourceType string
+
+// The possible types of combine variable source.
+const (
+	CombineVariableSourceTypeComposite   CombineVariableSourceType = "Composite"
+	CombineVariableSourceTypeEnvironment CombineVariableSourceType = "Environment"
+	CombineVariableSourceTypeComposed    CombineVariableSourceType = "Composed"
+)
+
+// A CombineVariableSource is the object a combine variable is read from.
+type CombineVariableSource struct {
+	// Type of the source. Composite reads from the observed composite
+	// resource. Environment reads from the environment. Composed reads from
+	// the observed composed resource of the named resource template.
+	// +kubebuilder:validation:Enum=Composite;Environment;Composed
+	Type CombineVariableSourceType `json:"type"`
+
+	// ResourceName is the name of the resource template whose observed
+	// composed resource is read. Required if the type is Composed. If the
+	// composed resource doesn't exist yet, the variable's field path doesn't
+	// exist.
+	// +optional
+	ResourceName *string `json:"resourceName,omitempty"`
 }
 
 // A CombineStrategy determines what strategy will be applied to combine
@@ -258,6 +706,9 @@ type CombineStrategy string
 // CombineStrategy strategy definitions.
 const (
 	CombineStrategyString CombineStrategy = "string"
+	CombineStrategyJoin   CombineStrategy = "Join"
+	CombineStrategyObject CombineStrategy = "Object"
+	CombineStrategyMerge  CombineStrategy = "Merge"
 )
 
 // A Combine configures a patch that combines more than
@@ -268,15 +719,35 @@ type Combine struct {
 	// +kubebuilder:validation:MinItems=1
 	Variables []CombineVariable `json:"variables"`
 
-	// Strategy defines the strategy to use to combine the input variable values.
-	// Currently only string is supported.
-	// +kubebuilder:validation:Enum=string
+	// Strategy defines the strategy to use to combine the input variable
+	// values.
+	//
+	// * `string` - formats the variables using a Go format string.
+	// * `Join` - joins the variables, which must be strings, numbers, or
+	// booleans, with a separator.
+	// * `Object` - returns an object with a key for each variable, named by
+	// the variable's name.
+	// * `Merge` - deep merges the variables, which must be objects. Keys of
+	// later variables take precedence.
+	//
+	// +kubebuilder:validation:Enum=string;Join;Object;Merge
 	Strategy CombineStrategy `json:"strategy"`
 
 	// String declares that input variables should be combined into a single
 	// string, using the relevant settings for formatting purposes.
 	// +optional
 	String *StringCombine `json:"string,omitempty"`
+
+	// Join declares how input variables should be joined into a single
+	// string. Required by the Join strategy.
+	// +optional
+	Join *JoinCombine `json:"join,omitempty"`
+}
+
+// A JoinCombine joins multiple input values into a single string.
+type JoinCombine struct {
+	// Separator to join the input values with.
+	Separator string `json:"separator"`
 }
 
 // A StringCombine combines multiple input values into a single string.
@@ -2,8 +2,10 @@ package v1beta1
 
 import (
 	"encoding/json"
+	"time"
 
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
@@ -15,20 +17,30 @@ type TransformType string
 const (
 	ErrFmtConvertFormatPairNotSupported = "conversion from %s to %s is not supported with format %s"
 
-	TransformTypeMap     TransformType = "map"
-	TransformTypeMatch   TransformType = "match"
-	TransformTypeMath    TransformType = "math"
-	TransformTypeString  TransformType = "string"
-	TransformTypeConvert TransformType = "convert"
+	TransformTypeMap        TransformType = "map"
+	TransformTypeMatch      TransformType = "match"
+	TransformTypeMath       TransformType = "math"
+	TransformTypeString     TransformType = "string"
+	TransformTypeConvert    TransformType = "convert"
+	TransformTypeTime       TransformType = "time"
+	TransformTypeCIDR       TransformType = "cidr"
+	TransformTypeQuantity   TransformType = "quantity"
+	TransformTypeStringFunc TransformType = "stringFunc"
+	TransformTypeSemver     TransformType = "semver"
 )
 
 // Transform is a unit of process whose input is transformed into an output with
 // the supplied configuration.
 type Transform struct {
 	// Type of the transform to be run.
-	// +kubebuilder:validation:Enum=map;match;math;string;convert
+	// +kubebuilder:validation:Enum=map;match;math;string;convert;time;cidr;quantity;stringFunc;semver
 	Type TransformType `json:"type"`
 
+	// Optional transforms pass their input through unchanged if they fail,
+	// instead of failing the patch or connection detail they belong to.
+	// +optional
+	Optional *bool `json:"optional,omitempty"`
+
 	// Math is used to transform the input via mathematical operations such as
 	// multiplication.
 	// +optional
@@ -38,6 +50,11 @@ type Transform struct {
 	// +optional
 	Map *MapTransform `json:"map,omitempty"`
 
+	// MapOptions configure how a map transform matches the input to its keys,
+	// and what it returns if no key matches.
+	// +optional
+	MapOptions *MapOptions `json:"mapOptions,omitempty"`
+
 	// Match is a more complex version of Map that matches a list of patterns.
 	// +optional
 	Match *MatchTransform `json:"match,omitempty"`
@@ -50,6 +67,39 @@ type Transform struct {
 	// Convert is used to cast the input into the given output type.
 	// +optional
 	Convert *ConvertTransform `json:"convert,omitempty"`
+
+	// Time is used to parse, format, and do arithmetic on timestamps.
+	// +optional
+	Time *TimeTransform `json:"time,omitempty"`
+
+	// CIDR is used to carve subnets and pick host addresses from, or get the
+	// netmask of, an IP address prefix in CIDR notation.
+	// +optional
+	CIDR *CIDRTransform `json:"cidr,omitempty"`
+
+	// Quantity is used to do arithmetic on a Kubernetes resource quantity,
+	// like "500Mi" or "2", preserving its unit suffix.
+	// +optional
+	Quantity *QuantityTransform `json:"quantity,omitempty"`
+
+	// StringFunc is used to call one of a curated set of string functions,
+	// like trimming whitespace or converting the input to snake case.
+	// +optional
+	StringFunc *StringFuncTransform `json:"stringFunc,omitempty"`
+
+	// Semver is used to normalize a semantic version, get its major, minor,
+	// or patch version, or check whether it satisfies a constraint.
+	// +optional
+	Semver *SemverTransform `json:"semver,omitempty"`
+}
+
+// GetOptional returns true if the transform passes its input through
+// unchanged if it fails.
+func (t *Transform) GetOptional() bool {
+	if t == nil || t.Optional == nil {
+		return false
+	}
+	return *t.Optional
 }
 
 // GetFormat returns the format of the transform.
@@ -60,6 +110,24 @@ func (t *ConvertTransform) GetFormat() ConvertTransformFormat {
 	return ConvertTransformFormatNone
 }
 
+// GetRounding returns how the transform rounds a float64 it converts to an
+// integer, defaulting to ConvertTransformRoundingTruncate.
+func (t *ConvertTransform) GetRounding() ConvertTransformRounding {
+	if t.Rounding != nil {
+		return *t.Rounding
+	}
+	return ConvertTransformRoundingTruncate
+}
+
+// GetOverflowPolicy returns what happens when the transform's conversion
+// overflows, defaulting to ConvertTransformOverflowPolicyIgnore.
+func (t *ConvertTransform) GetOverflowPolicy() ConvertTransformOverflowPolicy {
+	if t.Overflow != nil {
+		return *t.Overflow
+	}
+	return ConvertTransformOverflowPolicyIgnore
+}
+
 // GetOutputType returns the output type of the transform.
 // It returns an error if the transform type is unknown.
 // It returns nil if the output type is not known.
@@ -74,6 +142,23 @@ func (t *Transform) GetOutputType() (*TransformIOType, error) {
 		out = TransformIOTypeString
 	case TransformTypeConvert:
 		out = t.Convert.ToType
+	case TransformTypeTime:
+		out = TransformIOTypeString
+		if t.Time != nil && t.Time.Type == TimeTransformTypeToUnix {
+			out = TransformIOTypeInt64
+		}
+	case TransformTypeCIDR, TransformTypeQuantity, TransformTypeStringFunc:
+		out = TransformIOTypeString
+	case TransformTypeSemver:
+		out = TransformIOTypeString
+		if t.Semver != nil {
+			switch t.Semver.Type { //nolint:exhaustive // Normalize returns a string.
+			case SemverTransformTypeMajor, SemverTransformTypeMinor, SemverTransformTypePatch:
+				out = TransformIOTypeInt64
+			case SemverTransformTypeSatisfies:
+				out = TransformIOTypeBool
+			}
+		}
 	default:
 		return nil, errors.Errorf("unable to get output type, unknown transform type: %s", t.Type)
 	}
@@ -88,6 +173,10 @@ const (
 	MathTransformTypeMultiply MathTransformType = "Multiply" // Default
 	MathTransformTypeClampMin MathTransformType = "ClampMin"
 	MathTransformTypeClampMax MathTransformType = "ClampMax"
+	MathTransformTypeAdd      MathTransformType = "Add"
+	MathTransformTypeSubtract MathTransformType = "Subtract"
+	MathTransformTypeDivide   MathTransformType = "Divide"
+	MathTransformTypeModulo   MathTransformType = "Modulo"
 )
 
 // MathTransform conducts mathematical operations on the input with the given
@@ -95,7 +184,7 @@ const (
 type MathTransform struct {
 	// Type of the math transform to be run.
 	// +optional
-	// +kubebuilder:validation:Enum=Multiply;ClampMin;ClampMax
+	// +kubebuilder:validation:Enum=Multiply;ClampMin;ClampMax;Add;Subtract;Divide;Modulo
 	// +kubebuilder:default=Multiply
 	Type MathTransformType `json:"type,omitempty"`
 
@@ -108,6 +197,19 @@ type MathTransform struct {
 	// ClampMax makes sure that the value is not bigger than the given value.
 	// +optional
 	ClampMax *int64 `json:"clampMax,omitempty"`
+	// Add the given value to the value.
+	// +optional
+	Add *int64 `json:"add,omitempty"`
+	// Subtract the given value from the value.
+	// +optional
+	Subtract *int64 `json:"subtract,omitempty"`
+	// Divide the value by the given value. Integer values are divided using
+	// integer division, which truncates the result.
+	// +optional
+	Divide *int64 `json:"divide,omitempty"`
+	// Modulo returns the remainder of dividing the value by the given value.
+	// +optional
+	Modulo *int64 `json:"modulo,omitempty"`
 }
 
 // MapTransform returns a value for the input from the given map.
@@ -117,6 +219,40 @@ type MapTransform struct {
 	Pairs map[string]extv1.JSON `json:",inline"`
 }
 
+// MapTransformType is the type of a map transform's keys.
+type MapTransformType string
+
+// Accepted MapTransformTypes.
+const (
+	MapTransformTypeExact  MapTransformType = "Exact" // Default
+	MapTransformTypeRegexp MapTransformType = "Regexp"
+)
+
+// MapOptions configure a map transform. They can't be part of MapTransform,
+// because any field of MapTransform would be indistinguishable from a key.
+type MapOptions struct {
+	// Type of the map's keys. Exact keys must be equal to the input. Regexp
+	// keys are regular expressions that must match the input. If several
+	// Regexp keys match, the value of the first of them in lexical order is
+	// returned. See https://pkg.go.dev/regexp/ for details.
+	// +kubebuilder:validation:Enum=Exact;Regexp
+	// +optional
+	Type *MapTransformType `json:"type,omitempty"`
+
+	// Default is returned if no key matches the input. A map transform
+	// returns an error if no key matches the input and there's no default.
+	// +optional
+	Default *extv1.JSON `json:"default,omitempty"`
+}
+
+// GetType returns the type of the map's keys.
+func (o *MapOptions) GetType() MapTransformType {
+	if o == nil || o.Type == nil {
+		return MapTransformTypeExact
+	}
+	return *o.Type
+}
+
 // NOTE(negz): The Kubernetes JSON decoder doesn't seem to like inlining a map
 // into a struct - doing so results in a seemingly successful unmarshal of the
 // data, but an empty map. We must keep the ,inline tag nevertheless in order to
@@ -159,6 +295,33 @@ type MatchTransform struct {
 	// +kubebuilder:validation:Enum=Value;Input
 	// +kubebuilder:default=Value
 	FallbackTo MatchFallbackTo `json:"fallbackTo,omitempty"`
+
+	// ArrayPolicy determines how patterns match an input that's an array,
+	// for example a list of enabled features. The default, Any, matches if
+	// any element of the array matches the pattern. Use All to match only if
+	// every element matches. An empty array never matches. If the pattern
+	// expands its result, it's expanded using the first matching element.
+	// +kubebuilder:validation:Enum=Any;All
+	// +optional
+	ArrayPolicy *MatchArrayPolicy `json:"arrayPolicy,omitempty"`
+}
+
+// MatchArrayPolicy determines how a match transform's patterns match an array
+// input.
+type MatchArrayPolicy string
+
+// Valid MatchArrayPolicies.
+const (
+	MatchArrayPolicyAny MatchArrayPolicy = "Any"
+	MatchArrayPolicyAll MatchArrayPolicy = "All"
+)
+
+// GetArrayPolicy returns how patterns match an array input, defaulting to Any.
+func (m *MatchTransform) GetArrayPolicy() MatchArrayPolicy {
+	if m.ArrayPolicy != nil {
+		return *m.ArrayPolicy
+	}
+	return MatchArrayPolicyAny
 }
 
 // MatchTransformPatternType defines the type of a MatchTransformPattern.
@@ -173,7 +336,9 @@ const (
 // MatchTransformPattern is a transform that returns the value that matches a
 // pattern.
 type MatchTransformPattern struct {
-	// Type specifies how the pattern matches the input.
+	// Type specifies how the pattern matches the input. Number and boolean
+	// inputs are matched as they'd be written in JSON, for example 200 or
+	// true.
 	//
 	// * `literal` - the pattern value has to exactly match (case sensitive) the
 	// input string. This is the default.
@@ -196,6 +361,14 @@ type MatchTransformPattern struct {
 
 	// The value that is used as result of the transform if the pattern matches.
 	Result extv1.JSON `json:"result"`
+
+	// ExpandResult expands references to the regexp's capture groups in the
+	// result, for example "$1-suffix" or "${name}-suffix". Only string
+	// values, including those nested in an object or array result, are
+	// expanded. Use "$$" for a literal "$". Only supported if `type` is
+	// `regexp`.
+	// +optional
+	ExpandResult bool `json:"expandResult,omitempty"`
 }
 
 // StringTransformType transforms a string.
@@ -210,6 +383,17 @@ const (
 	StringTransformTypeRegexp     StringTransformType = "Regexp"
 	StringTransformTypeJoin       StringTransformType = "Join"
 	StringTransformTypeReplace    StringTransformType = "Replace"
+	StringTransformTypeTruncate   StringTransformType = "Truncate"
+)
+
+// StringFormatInput specifies how many times a Format string transform passes
+// its input to the format string.
+type StringFormatInput string
+
+// Accepted StringFormatInputs.
+const (
+	StringFormatInputOnce   StringFormatInput = "Once" // Default
+	StringFormatInputRepeat StringFormatInput = "Repeat"
 )
 
 // StringConversionType converts a string.
@@ -232,7 +416,7 @@ const (
 type StringTransform struct {
 
 	// Type of the string transform to be run.
-	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp;Join;Replace;Truncate
 	// +kubebuilder:default=Format
 	Type StringTransformType `json:"type"`
 
@@ -241,6 +425,16 @@ type StringTransform struct {
 	// +optional
 	Format *string `json:"fmt,omitempty"`
 
+	// FormatInput specifies how many times the input is passed to the format
+	// string. The default, Once, passes it once, so only the first verb
+	// formats it unless verbs reference it explicitly, like %[1]s. Use Repeat
+	// to format the input with every verb, for example "%s-%s". With Repeat
+	// the format string's verbs must also suit the type of the input, for
+	// example %d requires a number.
+	// +kubebuilder:validation:Enum=Once;Repeat
+	// +optional
+	FormatInput *StringFormatInput `json:"formatInput,omitempty"`
+
 	// Optional conversion method to be specified.
 	// `ToUpper` and `ToLower` change the letter case of the input string.
 	// `ToBase64` and `FromBase64` perform a base64 conversion based on the input string.
@@ -266,6 +460,10 @@ type StringTransform struct {
 	// Search/Replace applied to the input string.
 	// +optional
 	Replace *StringTransformReplace `json:"replace,omitempty"`
+
+	// Truncate the input string.
+	// +optional
+	Truncate *StringTransformTruncate `json:"truncate,omitempty"`
 }
 
 // A StringTransformJoin joins the input strings.
@@ -295,6 +493,21 @@ type StringTransformReplace struct {
 	Replace string `json:"replace"`
 }
 
+// A StringTransformTruncate truncates the input string.
+type StringTransformTruncate struct {
+	// MaxLength is the maximum number of characters of the output string.
+	// +kubebuilder:validation:Minimum=1
+	MaxLength int `json:"maxLength"`
+
+	// SuffixHash appends a dash and a short hash of the entire input string
+	// to a truncated string, so that different strings that share a prefix
+	// are still unique once truncated. The output string, including the
+	// hash, is still at most maxLength characters. Requires a maxLength of
+	// at least 10.
+	// +optional
+	SuffixHash bool `json:"suffixHash,omitempty"`
+}
+
 // TransformIOType defines the type of a ConvertTransform.
 type TransformIOType string
 
@@ -328,12 +541,13 @@ const (
 	ConvertTransformFormatNone     ConvertTransformFormat = "none"
 	ConvertTransformFormatQuantity ConvertTransformFormat = "quantity"
 	ConvertTransformFormatJSON     ConvertTransformFormat = "json"
+	ConvertTransformFormatDuration ConvertTransformFormat = "duration"
 )
 
 // IsValid returns true if the format is valid.
 func (c ConvertTransformFormat) IsValid() bool {
 	switch c {
-	case ConvertTransformFormatNone, ConvertTransformFormatQuantity, ConvertTransformFormatJSON:
+	case ConvertTransformFormatNone, ConvertTransformFormatQuantity, ConvertTransformFormatJSON, ConvertTransformFormatDuration:
 		return true
 	}
 	return false
@@ -351,10 +565,273 @@ type ConvertTransform struct {
 	// Only used during `string -> float64` conversions.
 	// * `json` - parses the input as a JSON string.
 	// Only used during `string -> object` or `string -> list` conversions.
+	// * `duration` - parses the input as a Go duration, like "1m30s", and
+	// returns its seconds. Used during `string -> int64` conversions, which
+	// truncate to whole seconds, and `string -> float64` conversions. Also
+	// formats seconds as a Go duration during `int64 -> string` and
+	// `float64 -> string` conversions.
 	//
 	// If this property is null, the default conversion is applied.
 	//
-	// +kubebuilder:validation:Enum=none;quantity;json
+	// +kubebuilder:validation:Enum=none;quantity;json;duration
 	// +kubebuilder:validation:Default=none
 	Format *ConvertTransformFormat `json:"format,omitempty"`
+
+	// Rounding specifies how a float64 is rounded when it's converted to an
+	// int or int64. The default is 'Truncate', which rounds toward zero. Use
+	// 'Floor', 'Ceil', or 'Round', which rounds half away from zero.
+	// +kubebuilder:validation:Enum=Truncate;Floor;Ceil;Round
+	// +optional
+	Rounding *ConvertTransformRounding `json:"rounding,omitempty"`
+
+	// Overflow specifies what happens when a number can't be converted
+	// without overflowing the output type or losing precision, for example
+	// a float64 larger than the largest int64. The default is 'Ignore',
+	// which returns whatever value the conversion produces. Use 'Error' to
+	// fail the transform instead.
+	// +kubebuilder:validation:Enum=Ignore;Error
+	// +optional
+	Overflow *ConvertTransformOverflowPolicy `json:"overflow,omitempty"`
+}
+
+// ConvertTransformRounding specifies how a float64 is rounded when it's
+// converted to an integer.
+type ConvertTransformRounding string
+
+// Possible ConvertTransformRounding values.
+const (
+	ConvertTransformRoundingTruncate ConvertTransformRounding = "Truncate"
+	ConvertTransformRoundingFloor    ConvertTransformRounding = "Floor"
+	ConvertTransformRoundingCeil     ConvertTransformRounding = "Ceil"
+	ConvertTransformRoundingRound    ConvertTransformRounding = "Round"
+)
+
+// ConvertTransformOverflowPolicy specifies what happens when a number can't be
+// converted without overflowing or losing precision.
+type ConvertTransformOverflowPolicy string
+
+// Possible ConvertTransformOverflowPolicy values.
+const (
+	ConvertTransformOverflowPolicyIgnore ConvertTransformOverflowPolicy = "Ignore"
+	ConvertTransformOverflowPolicyError  ConvertTransformOverflowPolicy = "Error"
+)
+
+// TimeTransformType is the type of a TimeTransform.
+type TimeTransformType string
+
+// Accepted TimeTransformTypes.
+const (
+	TimeTransformTypeToUnix   TimeTransformType = "ToUnix"
+	TimeTransformTypeFromUnix TimeTransformType = "FromUnix"
+	TimeTransformTypeFormat   TimeTransformType = "Format"
+	TimeTransformTypeAdd      TimeTransformType = "Add"
+)
+
+// A TimeTransform parses, formats, or does arithmetic on a timestamp.
+type TimeTransform struct {
+	// Type of the time transform to be run.
+	//
+	// * `ToUnix` - parses the input timestamp and returns it as an integer
+	// number of seconds since the Unix epoch.
+	// * `FromUnix` - converts an integer number of seconds since the Unix
+	// epoch to a UTC timestamp formatted using `layout`.
+	// * `Format` - parses the input timestamp and formats it using `layout`.
+	// * `Add` - parses the input timestamp, adds `duration` to it, and formats
+	// the result using `layout`.
+	//
+	// +kubebuilder:validation:Enum=ToUnix;FromUnix;Format;Add
+	Type TimeTransformType `json:"type"`
+
+	// InputLayout is the Go time layout used to parse the input timestamp.
+	// See https://pkg.go.dev/time#pkg-constants for details. Defaults to
+	// RFC3339. Not used by FromUnix.
+	// +optional
+	InputLayout *string `json:"inputLayout,omitempty"`
+
+	// Layout is the Go time layout used to format the output timestamp. See
+	// https://pkg.go.dev/time#pkg-constants for details. Required by Format.
+	// Defaults to RFC3339 for FromUnix and to inputLayout for Add.
+	// +optional
+	Layout *string `json:"layout,omitempty"`
+
+	// Duration to add to the input timestamp, for example "24h" or "-30m".
+	// See https://pkg.go.dev/time#ParseDuration for details. Required by Add.
+	// +optional
+	Duration *string `json:"duration,omitempty"`
+}
+
+// GetInputLayout returns the layout used to parse the input timestamp.
+func (t *TimeTransform) GetInputLayout() string {
+	if t.InputLayout != nil {
+		return *t.InputLayout
+	}
+	return time.RFC3339
+}
+
+// GetLayout returns the layout used to format the output timestamp.
+func (t *TimeTransform) GetLayout() string {
+	if t.Layout != nil {
+		return *t.Layout
+	}
+	if t.Type == TimeTransformTypeAdd {
+		return t.GetInputLayout()
+	}
+	return time.RFC3339
+}
+
+// CIDRTransformType is the type of a CIDRTransform.
+type CIDRTransformType string
+
+// Accepted CIDRTransformTypes.
+const (
+	CIDRTransformTypeSubnet  CIDRTransformType = "Subnet"
+	CIDRTransformTypeHost    CIDRTransformType = "Host"
+	CIDRTransformTypeNetmask CIDRTransformType = "Netmask"
+)
+
+// A CIDRTransform does arithmetic on an IP address prefix in CIDR notation,
+// for example "10.0.0.0/16".
+type CIDRTransform struct {
+	// Type of the CIDR transform to be run.
+	//
+	// * `Subnet` - returns the subnet of the input prefix that's `newBits`
+	// longer and has subnet number `number`. For example subnet 2 of
+	// 10.0.0.0/16 with 8 new bits is 10.0.2.0/24.
+	// * `Host` - returns the address of host number `index` within the input
+	// prefix. A negative index counts back from the end of the prefix. For
+	// example host 5 of 10.0.2.0/24 is 10.0.2.5.
+	// * `Netmask` - returns the netmask of the input IPv4 prefix in dotted
+	// decimal notation. For example the netmask of 10.0.2.0/24 is
+	// 255.255.255.0.
+	//
+	// +kubebuilder:validation:Enum=Subnet;Host;Netmask
+	Type CIDRTransformType `json:"type"`
+
+	// NewBits is the number of bits to extend the input prefix by. Required
+	// by Subnet.
+	// +optional
+	NewBits *int64 `json:"newBits,omitempty"`
+
+	// Number of the subnet. It must fit in newBits. Required by Subnet.
+	// +optional
+	Number *int64 `json:"number,omitempty"`
+
+	// Index of the host within the input prefix. Required by Host.
+	// +optional
+	Index *int64 `json:"index,omitempty"`
+}
+
+// QuantityTransformType is the type of a QuantityTransform.
+type QuantityTransformType string
+
+// Accepted QuantityTransformTypes.
+const (
+	QuantityTransformTypeMultiply QuantityTransformType = "Multiply"
+	QuantityTransformTypeAdd      QuantityTransformType = "Add"
+)
+
+// A QuantityTransform does arithmetic on a Kubernetes resource quantity, for
+// example "500Mi". The output keeps the input's format, so multiplying "500Mi"
+// by 2 returns "1000Mi", and adding "500m" to "1" returns "1500m".
+type QuantityTransform struct {
+	// Type of the quantity transform to be run.
+	//
+	// * `Multiply` - multiplies the input by `multiply`, which may be
+	// fractional, for example "1.5".
+	// * `Add` - adds the quantity `add` to the input. It may be negative to
+	// subtract, for example "-512Mi".
+	//
+	// +kubebuilder:validation:Enum=Multiply;Add
+	Type QuantityTransformType `json:"type"`
+
+	// Multiply is the factor to multiply the input by. Required by Multiply.
+	// +optional
+	Multiply *resource.Quantity `json:"multiply,omitempty"`
+
+	// Add is the quantity to add to the input. Required by Add.
+	// +optional
+	Add *resource.Quantity `json:"add,omitempty"`
+}
+
+// StringFuncName is the name of a string function.
+type StringFuncName string
+
+// Accepted StringFuncNames.
+const (
+	StringFuncTrimSpace  StringFuncName = "trimSpace"
+	StringFuncIndent     StringFuncName = "indent"
+	StringFuncSnakeCase  StringFuncName = "snakecase"
+	StringFuncCamelCase  StringFuncName = "camelcase"
+	StringFuncSplitIndex StringFuncName = "splitIndex"
+)
+
+// A StringFuncTransform calls a string function on its input, which must be a
+// string. The functions are named after their Sprig equivalents.
+type StringFuncTransform struct {
+	// Func is the string function to call.
+	//
+	// * `trimSpace` - removes leading and trailing whitespace.
+	// * `indent` - indents every line by `indent` spaces.
+	// * `snakecase` - converts the input to snake case, for example
+	// "HTTPServer" to "http_server".
+	// * `camelcase` - converts the input to camel case, for example
+	// "http_server" to "HttpServer".
+	// * `splitIndex` - splits the input at `split.separator`, and returns the
+	// element at `split.index`.
+	//
+	// +kubebuilder:validation:Enum=trimSpace;indent;snakecase;camelcase;splitIndex
+	Func StringFuncName `json:"func"`
+
+	// Indent is the number of spaces to indent each line by. Required by
+	// indent.
+	// +optional
+	Indent *int64 `json:"indent,omitempty"`
+
+	// Split configures how to split the input. Required by splitIndex.
+	// +optional
+	Split *StringFuncSplit `json:"split,omitempty"`
+}
+
+// StringFuncSplit configures the splitIndex string function.
+type StringFuncSplit struct {
+	// Separator to split the input at.
+	Separator string `json:"separator"`
+
+	// Index of the element to return, starting at 0.
+	Index int64 `json:"index"`
+}
+
+// SemverTransformType is the type of a SemverTransform.
+type SemverTransformType string
+
+// Accepted SemverTransformTypes.
+const (
+	SemverTransformTypeNormalize SemverTransformType = "Normalize"
+	SemverTransformTypeMajor     SemverTransformType = "Major"
+	SemverTransformTypeMinor     SemverTransformType = "Minor"
+	SemverTransformTypePatch     SemverTransformType = "Patch"
+	SemverTransformTypeSatisfies SemverTransformType = "Satisfies"
+)
+
+// A SemverTransform parses its input, which must be a string, as a semantic
+// version. A leading "v" and a missing minor or patch version are allowed, so
+// "v1.2" is parsed as "1.2.0".
+type SemverTransform struct {
+	// Type of the semver transform to be run.
+	//
+	// * `Normalize` - returns the version in its canonical form, for example
+	// "1.2.0" for "v1.2".
+	// * `Major` - returns the major version as an int64.
+	// * `Minor` - returns the minor version as an int64.
+	// * `Patch` - returns the patch version as an int64.
+	// * `Satisfies` - returns true if the version satisfies `constraint`.
+	//
+	// +kubebuilder:validation:Enum=Normalize;Major;Minor;Patch;Satisfies
+	Type SemverTransformType `json:"type"`
+
+	// Constraint the version must satisfy, for example ">=1.2.0 <2.0.0".
+	// Ranges separated by a space must all be satisfied, and ranges
+	// separated by "||" are alternatives. Required by Satisfies.
+	// +optional
+	Constraint *string `json:"constraint,omitempty"`
 }
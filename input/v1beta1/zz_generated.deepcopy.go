@@ -5,23 +5,87 @@
 package v1beta1
 
 import (
+	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaseFrom) DeepCopyInto(out *BaseFrom) {
+	*out = *in
+	if in.EnvironmentFieldPath != nil {
+		in, out := &in.EnvironmentFieldPath, &out.EnvironmentFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.ContextFieldPath != nil {
+		in, out := &in.ContextFieldPath, &out.ContextFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaseFrom.
+func (in *BaseFrom) DeepCopy() *BaseFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(BaseFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CIDRTransform) DeepCopyInto(out *CIDRTransform) {
+	*out = *in
+	if in.NewBits != nil {
+		in, out := &in.NewBits, &out.NewBits
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Number != nil {
+		in, out := &in.Number, &out.Number
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Index != nil {
+		in, out := &in.Index, &out.Index
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CIDRTransform.
+func (in *CIDRTransform) DeepCopy() *CIDRTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(CIDRTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Combine) DeepCopyInto(out *Combine) {
 	*out = *in
 	if in.Variables != nil {
 		in, out := &in.Variables, &out.Variables
 		*out = make([]CombineVariable, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.String != nil {
 		in, out := &in.String, &out.String
 		*out = new(StringCombine)
 		**out = **in
 	}
+	if in.Join != nil {
+		in, out := &in.Join, &out.Join
+		*out = new(JoinCombine)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Combine.
@@ -37,6 +101,21 @@ func (in *Combine) DeepCopy() *Combine {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CombineVariable) DeepCopyInto(out *CombineVariable) {
 	*out = *in
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(FromFieldPathPolicy)
+		**out = **in
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(CombineVariableSource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CombineVariable.
@@ -49,6 +128,55 @@ func (in *CombineVariable) DeepCopy() *CombineVariable {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CombineVariableSource) DeepCopyInto(out *CombineVariableSource) {
+	*out = *in
+	if in.ResourceName != nil {
+		in, out := &in.ResourceName, &out.ResourceName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CombineVariableSource.
+func (in *CombineVariableSource) DeepCopy() *CombineVariableSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CombineVariableSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComposedMetadata) DeepCopyInto(out *ComposedMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedMetadata.
+func (in *ComposedMetadata) DeepCopy() *ComposedMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(ComposedMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComposedPatch) DeepCopyInto(out *ComposedPatch) {
 	*out = *in
@@ -73,11 +201,26 @@ func (in *ComposedPatch) DeepCopy() *ComposedPatch {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 	*out = *in
+	if in.PreviousNames != nil {
+		in, out := &in.PreviousNames, &out.PreviousNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Base != nil {
 		in, out := &in.Base, &out.Base
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BaseFrom != nil {
+		in, out := &in.BaseFrom, &out.BaseFrom
+		*out = new(BaseFrom)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BaseFromObserved != nil {
+		in, out := &in.BaseFromObserved, &out.BaseFromObserved
+		*out = new(string)
+		**out = **in
+	}
 	if in.Patches != nil {
 		in, out := &in.Patches, &out.Patches
 		*out = make([]ComposedPatch, len(*in))
@@ -99,6 +242,76 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ReadyFrom != nil {
+		in, out := &in.ReadyFrom, &out.ReadyFrom
+		*out = new(ReadyFrom)
+		**out = **in
+	}
+	if in.ManagementPolicies != nil {
+		in, out := &in.ManagementPolicies, &out.ManagementPolicies
+		*out = make(commonv1.ManagementPolicies, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(commonv1.DeletionPolicy)
+		**out = **in
+	}
+	if in.OnPatchFailure != nil {
+		in, out := &in.OnPatchFailure, &out.OnPatchFailure
+		*out = new(PatchFailurePolicy)
+		**out = **in
+	}
+	if in.RenderPolicy != nil {
+		in, out := &in.RenderPolicy, &out.RenderPolicy
+		*out = new(RenderPolicy)
+		**out = **in
+	}
+	if in.FieldOwnership != nil {
+		in, out := &in.FieldOwnership, &out.FieldOwnership
+		*out = make([]FieldOwnership, len(*in))
+		copy(*out, *in)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WaitFor != nil {
+		in, out := &in.WaitFor, &out.WaitFor
+		*out = new(WaitFor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = new(ComposedMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalName != nil {
+		in, out := &in.ExternalName, &out.ExternalName
+		*out = new(ExternalName)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WriteConnectionSecretToRef != nil {
+		in, out := &in.WriteConnectionSecretToRef, &out.WriteConnectionSecretToRef
+		*out = new(ConnectionSecretRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionDetailsPolicy != nil {
+		in, out := &in.ConnectionDetailsPolicy, &out.ConnectionDetailsPolicy
+		*out = new(ConnectionDetailsPolicy)
+		**out = **in
+	}
+	if in.CrossplaneAnnotations != nil {
+		in, out := &in.CrossplaneAnnotations, &out.CrossplaneAnnotations
+		*out = new(CrossplaneAnnotations)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(Timeouts)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplate.
@@ -124,11 +337,38 @@ func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(ConnectionDetailPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Value != nil {
 		in, out := &in.Value, &out.Value
 		*out = new(string)
 		**out = **in
 	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(ConnectionDetailCombine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]Transform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Encoding != nil {
+		in, out := &in.Encoding, &out.Encoding
+		*out = new(ConnectionDetailEncoding)
+		**out = **in
+	}
+	if in.FromResource != nil {
+		in, out := &in.FromResource, &out.FromResource
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetail.
@@ -141,6 +381,103 @@ func (in *ConnectionDetail) DeepCopy() *ConnectionDetail {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailCombine) DeepCopyInto(out *ConnectionDetailCombine) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]ConnectionDetailCombineVariable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(StringCombine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailCombine.
+func (in *ConnectionDetailCombine) DeepCopy() *ConnectionDetailCombine {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailCombine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailCombineVariable) DeepCopyInto(out *ConnectionDetailCombineVariable) {
+	*out = *in
+	if in.FromConnectionSecretKey != nil {
+		in, out := &in.FromConnectionSecretKey, &out.FromConnectionSecretKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailCombineVariable.
+func (in *ConnectionDetailCombineVariable) DeepCopy() *ConnectionDetailCombineVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailCombineVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailPolicy) DeepCopyInto(out *ConnectionDetailPolicy) {
+	*out = *in
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(FromFieldPathPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailPolicy.
+func (in *ConnectionDetailPolicy) DeepCopy() *ConnectionDetailPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionSecretRef) DeepCopyInto(out *ConnectionSecretRef) {
+	*out = *in
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionSecretRef.
+func (in *ConnectionSecretRef) DeepCopy() *ConnectionSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConvertTransform) DeepCopyInto(out *ConvertTransform) {
 	*out = *in
@@ -149,6 +486,16 @@ func (in *ConvertTransform) DeepCopyInto(out *ConvertTransform) {
 		*out = new(ConvertTransformFormat)
 		**out = **in
 	}
+	if in.Rounding != nil {
+		in, out := &in.Rounding, &out.Rounding
+		*out = new(ConvertTransformRounding)
+		**out = **in
+	}
+	if in.Overflow != nil {
+		in, out := &in.Overflow, &out.Overflow
+		*out = new(ConvertTransformOverflowPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConvertTransform.
@@ -161,9 +508,70 @@ func (in *ConvertTransform) DeepCopy() *ConvertTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CrossplaneAnnotations) DeepCopyInto(out *CrossplaneAnnotations) {
+	*out = *in
+	if in.ExternalName != nil {
+		in, out := &in.ExternalName, &out.ExternalName
+		*out = new(CrossplaneAnnotationPolicy)
+		**out = **in
+	}
+	if in.Paused != nil {
+		in, out := &in.Paused, &out.Paused
+		*out = new(CrossplaneAnnotationPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrossplaneAnnotations.
+func (in *CrossplaneAnnotations) DeepCopy() *CrossplaneAnnotations {
+	if in == nil {
+		return nil
+	}
+	out := new(CrossplaneAnnotations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Defaults) DeepCopyInto(out *Defaults) {
+	*out = *in
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]ComposedPatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Defaults.
+func (in *Defaults) DeepCopy() *Defaults {
+	if in == nil {
+		return nil
+	}
+	out := new(Defaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Environment) DeepCopyInto(out *Environment) {
 	*out = *in
+	if in.EnvironmentConfigs != nil {
+		in, out := &in.EnvironmentConfigs, &out.EnvironmentConfigs
+		*out = make([]EnvironmentConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DefaultData != nil {
+		in, out := &in.DefaultData, &out.DefaultData
+		*out = make(map[string]v1.JSON, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.Patches != nil {
 		in, out := &in.Patches, &out.Patches
 		*out = make([]EnvironmentPatch, len(*in))
@@ -171,6 +579,18 @@ func (in *Environment) DeepCopyInto(out *Environment) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Passthrough != nil {
+		in, out := &in.Passthrough, &out.Passthrough
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Schema != nil {
+		in, out := &in.Schema, &out.Schema
+		*out = make([]EnvironmentField, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
@@ -183,6 +603,113 @@ func (in *Environment) DeepCopy() *Environment {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfig) DeepCopyInto(out *EnvironmentConfig) {
+	*out = *in
+	if in.Ref != nil {
+		in, out := &in.Ref, &out.Ref
+		*out = new(EnvironmentConfigReference)
+		**out = **in
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(EnvironmentConfigSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfig.
+func (in *EnvironmentConfig) DeepCopy() *EnvironmentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfigLabelMatcher) DeepCopyInto(out *EnvironmentConfigLabelMatcher) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+	if in.ValueFromFieldPath != nil {
+		in, out := &in.ValueFromFieldPath, &out.ValueFromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfigLabelMatcher.
+func (in *EnvironmentConfigLabelMatcher) DeepCopy() *EnvironmentConfigLabelMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfigLabelMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfigReference) DeepCopyInto(out *EnvironmentConfigReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfigReference.
+func (in *EnvironmentConfigReference) DeepCopy() *EnvironmentConfigReference {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfigReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfigSelector) DeepCopyInto(out *EnvironmentConfigSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make([]EnvironmentConfigLabelMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfigSelector.
+func (in *EnvironmentConfigSelector) DeepCopy() *EnvironmentConfigSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfigSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentField) DeepCopyInto(out *EnvironmentField) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(EnvironmentFieldType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentField.
+func (in *EnvironmentField) DeepCopy() *EnvironmentField {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentField)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentPatch) DeepCopyInto(out *EnvironmentPatch) {
 	*out = *in
@@ -199,6 +726,143 @@ func (in *EnvironmentPatch) DeepCopy() *EnvironmentPatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Event) DeepCopyInto(out *Event) {
+	*out = *in
+	if in.Resource != nil {
+		in, out := &in.Resource, &out.Resource
+		*out = new(string)
+		**out = **in
+	}
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(EventType)
+		**out = **in
+	}
+	if in.Reason != nil {
+		in, out := &in.Reason, &out.Reason
+		*out = new(string)
+		**out = **in
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(EventTarget)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Event.
+func (in *Event) DeepCopy() *Event {
+	if in == nil {
+		return nil
+	}
+	out := new(Event)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalName) DeepCopyInto(out *ExternalName) {
+	*out = *in
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(OverwritePolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalName.
+func (in *ExternalName) DeepCopy() *ExternalName {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalName)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtraResource) DeepCopyInto(out *ExtraResource) {
+	*out = *in
+	if in.MatchName != nil {
+		in, out := &in.MatchName, &out.MatchName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtraResource.
+func (in *ExtraResource) DeepCopy() *ExtraResource {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtraResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldOwnership) DeepCopyInto(out *FieldOwnership) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldOwnership.
+func (in *FieldOwnership) DeepCopy() *FieldOwnership {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldOwnership)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JoinCombine) DeepCopyInto(out *JoinCombine) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinCombine.
+func (in *JoinCombine) DeepCopy() *JoinCombine {
+	if in == nil {
+		return nil
+	}
+	out := new(JoinCombine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapOptions) DeepCopyInto(out *MapOptions) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(MapTransformType)
+		**out = **in
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapOptions.
+func (in *MapOptions) DeepCopy() *MapOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(MapOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MapTransform) DeepCopyInto(out *MapTransform) {
 	*out = *in
@@ -224,6 +888,16 @@ func (in *MapTransform) DeepCopy() *MapTransform {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatchConditionReadinessCheck) DeepCopyInto(out *MatchConditionReadinessCheck) {
 	*out = *in
+	if in.Reason != nil {
+		in, out := &in.Reason, &out.Reason
+		*out = new(string)
+		**out = **in
+	}
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchConditionReadinessCheck.
@@ -247,6 +921,11 @@ func (in *MatchTransform) DeepCopyInto(out *MatchTransform) {
 		}
 	}
 	in.FallbackValue.DeepCopyInto(&out.FallbackValue)
+	if in.ArrayPolicy != nil {
+		in, out := &in.ArrayPolicy, &out.ArrayPolicy
+		*out = new(MatchArrayPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransform.
@@ -303,6 +982,26 @@ func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Subtract != nil {
+		in, out := &in.Subtract, &out.Subtract
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Divide != nil {
+		in, out := &in.Divide, &out.Divide
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Modulo != nil {
+		in, out := &in.Modulo, &out.Modulo
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MathTransform.
@@ -345,6 +1044,11 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 		*out = new(PatchPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Sensitive != nil {
+		in, out := &in.Sensitive, &out.Sensitive
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Patch.
@@ -370,6 +1074,26 @@ func (in *PatchPolicy) DeepCopyInto(out *PatchPolicy) {
 		*out = new(ToFieldPathPolicy)
 		**out = **in
 	}
+	if in.Overwrite != nil {
+		in, out := &in.Overwrite, &out.Overwrite
+		*out = new(OverwritePolicy)
+		**out = **in
+	}
+	if in.RequeueAfter != nil {
+		in, out := &in.RequeueAfter, &out.RequeueAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.WhenSettled != nil {
+		in, out := &in.WhenSettled, &out.WhenSettled
+		*out = new(SettledCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpandArrays != nil {
+		in, out := &in.ExpandArrays, &out.ExpandArrays
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchPolicy.
@@ -407,6 +1131,11 @@ func (in *PatchSet) DeepCopy() *PatchSet {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PatchSetPatch) DeepCopyInto(out *PatchSetPatch) {
 	*out = *in
+	if in.PatchSetName != nil {
+		in, out := &in.PatchSetName, &out.PatchSetName
+		*out = new(string)
+		**out = **in
+	}
 	in.Patch.DeepCopyInto(&out.Patch)
 }
 
@@ -420,6 +1149,56 @@ func (in *PatchSetPatch) DeepCopy() *PatchSetPatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagateMetadata) DeepCopyInto(out *PropagateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagateMetadata.
+func (in *PropagateMetadata) DeepCopy() *PropagateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuantityTransform) DeepCopyInto(out *QuantityTransform) {
+	*out = *in
+	if in.Multiply != nil {
+		in, out := &in.Multiply, &out.Multiply
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuantityTransform.
+func (in *QuantityTransform) DeepCopy() *QuantityTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(QuantityTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	*out = *in
@@ -433,6 +1212,11 @@ func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.MatchRegexp != nil {
+		in, out := &in.MatchRegexp, &out.MatchRegexp
+		*out = new(string)
+		**out = **in
+	}
 	if in.MatchInteger != nil {
 		in, out := &in.MatchInteger, &out.MatchInteger
 		*out = new(int64)
@@ -441,8 +1225,27 @@ func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	if in.MatchCondition != nil {
 		in, out := &in.MatchCondition, &out.MatchCondition
 		*out = new(MatchConditionReadinessCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionDetailKey != nil {
+		in, out := &in.ConnectionDetailKey, &out.ConnectionDetailKey
+		*out = new(string)
 		**out = **in
 	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]ReadinessCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]ReadinessCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
@@ -455,6 +1258,21 @@ func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadyFrom) DeepCopyInto(out *ReadyFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadyFrom.
+func (in *ReadyFrom) DeepCopy() *ReadyFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadyFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resources) DeepCopyInto(out *Resources) {
 	*out = *in
@@ -467,11 +1285,30 @@ func (in *Resources) DeepCopyInto(out *Resources) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(Defaults)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Environment != nil {
 		in, out := &in.Environment, &out.Environment
 		*out = new(Environment)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExtraResources != nil {
+		in, out := &in.ExtraResources, &out.ExtraResources
+		*out = make([]ExtraResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make(map[string]v1.JSON, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]ComposedTemplate, len(*in))
@@ -479,6 +1316,52 @@ func (in *Resources) DeepCopyInto(out *Resources) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = make([]ConnectionDetail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PropagateMetadata != nil {
+		in, out := &in.PropagateMetadata, &out.PropagateMetadata
+		*out = new(PropagateMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OnPatchFailure != nil {
+		in, out := &in.OnPatchFailure, &out.OnPatchFailure
+		*out = new(PatchFailurePolicy)
+		**out = **in
+	}
+	if in.Prune != nil {
+		in, out := &in.Prune, &out.Prune
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]Event, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CrossplaneAnnotations != nil {
+		in, out := &in.CrossplaneAnnotations, &out.CrossplaneAnnotations
+		*out = new(CrossplaneAnnotations)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatusConditions != nil {
+		in, out := &in.StatusConditions, &out.StatusConditions
+		*out = make([]StatusCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WriteInventoryTo != nil {
+		in, out := &in.WriteInventoryTo, &out.WriteInventoryTo
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resources.
@@ -499,6 +1382,66 @@ func (in *Resources) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SemverTransform) DeepCopyInto(out *SemverTransform) {
+	*out = *in
+	if in.Constraint != nil {
+		in, out := &in.Constraint, &out.Constraint
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SemverTransform.
+func (in *SemverTransform) DeepCopy() *SemverTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(SemverTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SettledCheck) DeepCopyInto(out *SettledCheck) {
+	*out = *in
+	if in.FieldPath != nil {
+		in, out := &in.FieldPath, &out.FieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SettledCheck.
+func (in *SettledCheck) DeepCopy() *SettledCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(SettledCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusCondition) DeepCopyInto(out *StatusCondition) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusCondition.
+func (in *StatusCondition) DeepCopy() *StatusCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringCombine) DeepCopyInto(out *StringCombine) {
 	*out = *in
@@ -514,6 +1457,46 @@ func (in *StringCombine) DeepCopy() *StringCombine {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringFuncSplit) DeepCopyInto(out *StringFuncSplit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringFuncSplit.
+func (in *StringFuncSplit) DeepCopy() *StringFuncSplit {
+	if in == nil {
+		return nil
+	}
+	out := new(StringFuncSplit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringFuncTransform) DeepCopyInto(out *StringFuncTransform) {
+	*out = *in
+	if in.Indent != nil {
+		in, out := &in.Indent, &out.Indent
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Split != nil {
+		in, out := &in.Split, &out.Split
+		*out = new(StringFuncSplit)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringFuncTransform.
+func (in *StringFuncTransform) DeepCopy() *StringFuncTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(StringFuncTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 	*out = *in
@@ -522,6 +1505,11 @@ func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FormatInput != nil {
+		in, out := &in.FormatInput, &out.FormatInput
+		*out = new(StringFormatInput)
+		**out = **in
+	}
 	if in.Convert != nil {
 		in, out := &in.Convert, &out.Convert
 		*out = new(StringConversionType)
@@ -547,6 +1535,11 @@ func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 		*out = new(StringTransformReplace)
 		**out = **in
 	}
+	if in.Truncate != nil {
+		in, out := &in.Truncate, &out.Truncate
+		*out = new(StringTransformTruncate)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransform.
@@ -609,9 +1602,80 @@ func (in *StringTransformReplace) DeepCopy() *StringTransformReplace {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformTruncate) DeepCopyInto(out *StringTransformTruncate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformTruncate.
+func (in *StringTransformTruncate) DeepCopy() *StringTransformTruncate {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformTruncate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeTransform) DeepCopyInto(out *TimeTransform) {
+	*out = *in
+	if in.InputLayout != nil {
+		in, out := &in.InputLayout, &out.InputLayout
+		*out = new(string)
+		**out = **in
+	}
+	if in.Layout != nil {
+		in, out := &in.Layout, &out.Layout
+		*out = new(string)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeTransform.
+func (in *TimeTransform) DeepCopy() *TimeTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Timeouts) DeepCopyInto(out *Timeouts) {
+	*out = *in
+	out.Ready = in.Ready
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Timeouts.
+func (in *Timeouts) DeepCopy() *Timeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(Timeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transform) DeepCopyInto(out *Transform) {
 	*out = *in
+	if in.Optional != nil {
+		in, out := &in.Optional, &out.Optional
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Math != nil {
 		in, out := &in.Math, &out.Math
 		*out = new(MathTransform)
@@ -622,6 +1686,11 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(MapTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MapOptions != nil {
+		in, out := &in.MapOptions, &out.MapOptions
+		*out = new(MapOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Match != nil {
 		in, out := &in.Match, &out.Match
 		*out = new(MatchTransform)
@@ -637,6 +1706,31 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(ConvertTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = new(TimeTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CIDR != nil {
+		in, out := &in.CIDR, &out.CIDR
+		*out = new(CIDRTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Quantity != nil {
+		in, out := &in.Quantity, &out.Quantity
+		*out = new(QuantityTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StringFunc != nil {
+		in, out := &in.StringFunc, &out.StringFunc
+		*out = new(StringFuncTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Semver != nil {
+		in, out := &in.Semver, &out.Semver
+		*out = new(SemverTransform)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transform.
@@ -663,3 +1757,19 @@ func (in *TypeReference) DeepCopy() *TypeReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaitFor) DeepCopyInto(out *WaitFor) {
+	*out = *in
+	in.Equals.DeepCopyInto(&out.Equals)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaitFor.
+func (in *WaitFor) DeepCopy() *WaitFor {
+	if in == nil {
+		return nil
+	}
+	out := new(WaitFor)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestDecodeInput(t *testing.T) {
+	resources := func(apiVersion, policy string) map[string]any {
+		return map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       "Resources",
+			"resources": []any{
+				map[string]any{
+					"name": "bucket",
+					"patches": []any{
+						map[string]any{
+							"type":          "FromCompositeFieldPath",
+							"fromFieldPath": "spec.tags",
+							"toFieldPath":   "spec.forProvider.tags",
+							"policy":        map[string]any{"toFieldPath": policy},
+						},
+					},
+				},
+			},
+		}
+	}
+	bucket := func(apiVersion string, policy v1beta1.ToFieldPathPolicy) *v1beta1.Resources {
+		r := &v1beta1.Resources{
+			Resources: []v1beta1.ComposedTemplate{{
+				Name: "bucket",
+				Patches: []v1beta1.ComposedPatch{{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.tags"),
+						ToFieldPath:   ptr.To("spec.forProvider.tags"),
+						Policy:        &v1beta1.PatchPolicy{ToFieldPath: ptr.To(policy)},
+					},
+				}},
+			}},
+		}
+		r.APIVersion = apiVersion
+		r.Kind = "Resources"
+		return r
+	}
+
+	type args struct {
+		in     map[string]any
+		strict bool
+	}
+	type want struct {
+		r   *v1beta1.Resources
+		err bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"V1beta1": {
+			reason: "v1beta1 input should be decoded as is, including deprecated values.",
+			args: args{
+				in: resources("pt.fn.crossplane.io/v1beta1", "MergeObject"),
+			},
+			want: want{
+				r: bucket("pt.fn.crossplane.io/v1beta1", v1beta1.ToFieldPathPolicyMergeObject), //nolint:staticcheck // MergeObject is deprecated but we must still support it.
+			},
+		},
+		"V1": {
+			reason: "v1 input should be converted to v1beta1.",
+			args: args{
+				in: resources("pt.fn.crossplane.io/v1", "MergeObjects"),
+			},
+			want: want{
+				r: bucket("pt.fn.crossplane.io/v1beta1", v1beta1.ToFieldPathPolicyMergeObjects),
+			},
+		},
+		"V1RemovedValue": {
+			reason: "v1 input shouldn't use values that were deprecated in v1beta1.",
+			args: args{
+				in: resources("pt.fn.crossplane.io/v1", "MergeObject"),
+			},
+			want: want{
+				err: true,
+			},
+		},
+		"StrictUnknownField": {
+			reason: "Unknown fields should be an error if strict is true.",
+			args: args{
+				in:     map[string]any{"apiVersion": "pt.fn.crossplane.io/v1", "kind": "Resources", "resoruces": []any{}},
+				strict: true,
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := DecodeInput(tc.args.in, tc.args.strict)
+			if diff := cmp.Diff(tc.want.r, r, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nDecodeInput(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("\n%s\nDecodeInput(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Inventory returns a summary of the desired composed resources of the supplied
+// resource templates, in resource template order. Composed resources of renamed
+// resource templates are desired under their previous name. Only the supplied
+// ready resource templates are ready.
+func Inventory(cts []v1beta1.ComposedTemplate, desired map[resource.Name]*resource.DesiredComposed, renamed map[string]resource.Name, ready map[string]bool) map[string]any {
+	resources := make([]any, 0, len(cts))
+	var nready int64
+	for _, t := range cts {
+		name := resource.Name(t.Name)
+		if p, ok := renamed[t.Name]; ok {
+			name = p
+		}
+		dcd, ok := desired[name]
+		if !ok {
+			continue
+		}
+		if ready[t.Name] {
+			nready++
+		}
+		resources = append(resources, map[string]any{
+			"name":       t.Name,
+			"apiVersion": dcd.Resource.GetAPIVersion(),
+			"kind":       dcd.Resource.GetKind(),
+			"ready":      ready[t.Name],
+		})
+	}
+	return map[string]any{
+		"desired":   int64(len(resources)),
+		"ready":     nready,
+		"resources": resources,
+	}
+}
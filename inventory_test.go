@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestInventory(t *testing.T) {
+	cd := func(apiVersion, kind string) *resource.DesiredComposed {
+		r := composed.New()
+		r.SetAPIVersion(apiVersion)
+		r.SetKind(kind)
+		return &resource.DesiredComposed{Resource: r}
+	}
+
+	type args struct {
+		cts     []v1beta1.ComposedTemplate
+		desired map[resource.Name]*resource.DesiredComposed
+		renamed map[string]resource.Name
+		ready   map[string]bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   map[string]any
+	}{
+		"NoResources": {
+			reason: "The inventory of no resource templates should be empty.",
+			args:   args{},
+			want: map[string]any{
+				"desired":   int64(0),
+				"ready":     int64(0),
+				"resources": []any{},
+			},
+		},
+		"DesiredResources": {
+			reason: "The inventory should only include desired composed resources, in resource template order, under their resource template's name.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "network"},
+					{Name: "database"},
+					{Name: "cache"},
+				},
+				desired: map[resource.Name]*resource.DesiredComposed{
+					"network": cd("example.org/v1", "Network"),
+					"db":      cd("example.org/v1", "Database"),
+				},
+				renamed: map[string]resource.Name{"database": "db"},
+				ready:   map[string]bool{"network": true},
+			},
+			want: map[string]any{
+				"desired": int64(2),
+				"ready":   int64(1),
+				"resources": []any{
+					map[string]any{"name": "network", "apiVersion": "example.org/v1", "kind": "Network", "ready": true},
+					map[string]any{"name": "database", "apiVersion": "example.org/v1", "kind": "Database", "ready": false},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Inventory(tc.args.cts, tc.args.desired, tc.args.renamed, tc.args.ready)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nInventory(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
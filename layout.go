@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+// crossplaneSpecFields are the fields of a composite resource's spec that
+// Crossplane manages. Crossplane v1 composite resources have them directly
+// under spec, for example spec.claimRef. Crossplane v2 composite resources
+// have them under spec.crossplane, for example spec.crossplane.claimRef.
+var crossplaneSpecFields = []string{
+	"claimRef",
+	"compositionRef",
+	"compositionRevisionRef",
+	"compositionRevisionSelector",
+	"compositionSelector",
+	"compositionUpdatePolicy",
+	"resourceRefs",
+	"writeConnectionSecretToRef",
+}
+
+// IsV2Layout returns true if the supplied composite resource uses the
+// Crossplane v2 layout, in which the fields Crossplane manages are under
+// spec.crossplane.
+func IsV2Layout(xr *composite.Unstructured) bool {
+	_, err := xr.GetValue("spec.crossplane")
+	return err == nil
+}
+
+// splitCrossplaneFieldPath returns the supplied field path relative to spec,
+// or spec.crossplane, and whether it's in the Crossplane v2 layout. It returns
+// false if the path isn't to a field Crossplane manages in either layout.
+func splitCrossplaneFieldPath(path string) (rest string, v2, ok bool) {
+	rest, ok = strings.CutPrefix(path, "spec.")
+	if !ok {
+		return "", false, false
+	}
+	if r, ok := strings.CutPrefix(rest, "crossplane."); ok {
+		rest, v2 = r, true
+	}
+	for _, f := range crossplaneSpecFields {
+		if r, ok := strings.CutPrefix(rest, f); ok && (r == "" || r[0] == '.' || r[0] == '[') {
+			return rest, v2, true
+		}
+	}
+	return "", false, false
+}
+
+// LayoutFieldPath returns the supplied field path in the Crossplane v2
+// composite resource layout if v2 is true, or in the Crossplane v1 layout if
+// it isn't. For example spec.claimRef.name is spec.crossplane.claimRef.name in
+// the Crossplane v2 layout. Paths that aren't to a field Crossplane manages
+// are returned unchanged.
+func LayoutFieldPath(path string, v2 bool) string {
+	rest, _, ok := splitCrossplaneFieldPath(path)
+	if !ok {
+		return path
+	}
+	if v2 {
+		return "spec.crossplane." + rest
+	}
+	return "spec." + rest
+}
+
+// getInEitherLayout calls the supplied getter with the supplied field path. If
+// the path is to a field Crossplane manages and isn't found, it calls it again
+// with the path in the other composite resource layout. This lets patches
+// read, for example, spec.compositionRevisionRef.name or
+// spec.crossplane.compositionRevisionRef.name regardless of whether the
+// composite resource uses the Crossplane v1 or v2 layout.
+func getInEitherLayout[T any](path string, get func(path string) (T, error)) (T, error) {
+	v, err := get(path)
+	if !fieldpath.IsNotFound(err) {
+		return v, err
+	}
+	_, v2, ok := splitCrossplaneFieldPath(path)
+	if !ok {
+		return v, err
+	}
+	if ov, oerr := get(LayoutFieldPath(path, !v2)); oerr == nil {
+		return ov, nil
+	}
+	return v, err
+}
+
+// A layoutPatch is a patch whose to field path is in a particular composite
+// resource layout.
+type layoutPatch struct {
+	PatchInterface
+
+	toFieldPath string
+}
+
+// GetToFieldPath returns the patch's to field path in its layout.
+func (p *layoutPatch) GetToFieldPath() string {
+	return p.toFieldPath
+}
+
+// PatchInLayout returns the supplied patch to a desired composite resource
+// with its to field path in the layout the supplied observed composite
+// resource uses. This lets patches write, for example, spec.resourceRefs or
+// spec.crossplane.resourceRefs regardless of whether the composite resource
+// uses the Crossplane v1 or v2 layout. Patches to fields that Crossplane
+// doesn't manage, or that are already in the right layout, are returned as is.
+func PatchInLayout(p PatchInterface, oxr *composite.Unstructured) PatchInterface {
+	if oxr == nil {
+		return p
+	}
+	to := LayoutFieldPath(p.GetToFieldPath(), IsV2Layout(oxr))
+	if to == p.GetToFieldPath() {
+		return p
+	}
+	return &layoutPatch{PatchInterface: p, toFieldPath: to}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestLayoutFieldPath(t *testing.T) {
+	type args struct {
+		path string
+		v2   bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   string
+	}{
+		"ToV2Layout": {
+			reason: "A field Crossplane manages should be mapped under spec.crossplane in the Crossplane v2 layout.",
+			args: args{
+				path: "spec.claimRef.name",
+				v2:   true,
+			},
+			want: "spec.crossplane.claimRef.name",
+		},
+		"ToV1Layout": {
+			reason: "A field Crossplane manages should be mapped out of spec.crossplane in the Crossplane v1 layout.",
+			args: args{
+				path: "spec.crossplane.resourceRefs[0].name",
+			},
+			want: "spec.resourceRefs[0].name",
+		},
+		"AlreadyInLayout": {
+			reason: "A field already in the supplied layout should be returned unchanged.",
+			args: args{
+				path: "spec.crossplane.compositionRef",
+				v2:   true,
+			},
+			want: "spec.crossplane.compositionRef",
+		},
+		"NotACrossplaneField": {
+			reason: "A field Crossplane doesn't manage should be returned unchanged.",
+			args: args{
+				path: "spec.claimRefs",
+				v2:   true,
+			},
+			want: "spec.claimRefs",
+		},
+		"WholeSpec": {
+			reason: "The whole spec should be returned unchanged.",
+			args: args{
+				path: "spec",
+				v2:   true,
+			},
+			want: "spec",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := LayoutFieldPath(tc.args.path, tc.args.v2)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nLayoutFieldPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFromFieldPathValueInEitherLayout(t *testing.T) {
+	type want struct {
+		value    any
+		notFound bool
+	}
+	cases := map[string]struct {
+		reason string
+		xr     map[string]any
+		path   string
+		want   want
+	}{
+		"V1LayoutReadFromV2Path": {
+			reason: "A field Crossplane manages should be read from the Crossplane v1 layout if it isn't in the v2 layout.",
+			xr: map[string]any{
+				"spec": map[string]any{
+					"compositionRevisionRef": map[string]any{"name": "cool-composition-abc123"},
+				},
+			},
+			path: "spec.crossplane.compositionRevisionRef.name",
+			want: want{value: "cool-composition-abc123"},
+		},
+		"V2LayoutReadFromV1Path": {
+			reason: "A field Crossplane manages should be read from the Crossplane v2 layout if it isn't in the v1 layout.",
+			xr: map[string]any{
+				"spec": map[string]any{
+					"crossplane": map[string]any{
+						"claimRef": map[string]any{"name": "cool-claim"},
+					},
+				},
+			},
+			path: "spec.claimRef.name",
+			want: want{value: "cool-claim"},
+		},
+		"BothLayouts": {
+			reason: "A field that's set in the layout the path is in should be read from that layout.",
+			xr: map[string]any{
+				"spec": map[string]any{
+					"compositionRef": map[string]any{"name": "v1"},
+					"crossplane": map[string]any{
+						"compositionRef": map[string]any{"name": "v2"},
+					},
+				},
+			},
+			path: "spec.compositionRef.name",
+			want: want{value: "v1"},
+		},
+		"NotFoundInEitherLayout": {
+			reason: "A field Crossplane manages that isn't in either layout shouldn't be found.",
+			xr: map[string]any{
+				"spec": map[string]any{},
+			},
+			path: "spec.claimRef.name",
+			want: want{notFound: true},
+		},
+		"NotACrossplaneField": {
+			reason: "A field Crossplane doesn't manage shouldn't be read from the other layout.",
+			xr: map[string]any{
+				"spec": map[string]any{
+					"crossplane": map[string]any{"region": "us-east-1"},
+				},
+			},
+			path: "spec.region",
+			want: want{notFound: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := FromFieldPathValue(tc.xr, tc.path)
+			if diff := cmp.Diff(tc.want.value, got); diff != "" {
+				t.Errorf("%s\nFromFieldPathValue(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.notFound, fieldpath.IsNotFound(err)); diff != "" {
+				t.Errorf("%s\nFromFieldPathValue(...): -want not found, +got not found:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPatchInLayout(t *testing.T) {
+	type args struct {
+		to  string
+		oxr map[string]any
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   string
+	}{
+		"ToV2Layout": {
+			reason: "A patch to a field Crossplane manages should be to spec.crossplane in a Crossplane v2 composite resource.",
+			args: args{
+				to:  "spec.resourceRefs",
+				oxr: map[string]any{"spec": map[string]any{"crossplane": map[string]any{}}},
+			},
+			want: "spec.crossplane.resourceRefs",
+		},
+		"ToV1Layout": {
+			reason: "A patch to a field Crossplane manages should be out of spec.crossplane in a Crossplane v1 composite resource.",
+			args: args{
+				to:  "spec.crossplane.writeConnectionSecretToRef.name",
+				oxr: map[string]any{"spec": map[string]any{}},
+			},
+			want: "spec.writeConnectionSecretToRef.name",
+		},
+		"NotACrossplaneField": {
+			reason: "A patch to a field Crossplane doesn't manage should be unchanged.",
+			args: args{
+				to:  "spec.region",
+				oxr: map[string]any{"spec": map[string]any{"crossplane": map[string]any{}}},
+			},
+			want: "spec.region",
+		},
+		"NoObservedXR": {
+			reason: "A patch should be unchanged if there's no observed composite resource.",
+			args: args{
+				to: "spec.resourceRefs",
+			},
+			want: "spec.resourceRefs",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var oxr *composite.Unstructured
+			if tc.args.oxr != nil {
+				oxr = &composite.Unstructured{}
+				oxr.Object = tc.args.oxr
+			}
+			p := &v1beta1.ComposedPatch{
+				Type:  v1beta1.PatchTypeToCompositeFieldPath,
+				Patch: v1beta1.Patch{ToFieldPath: ptr.To(tc.args.to)},
+			}
+			got := PatchInLayout(p, oxr).GetToFieldPath()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nPatchInLayout(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// ValidateCmd validates Function input offline.
+type ValidateCmd struct {
+	Files   []string `short:"f" required:"" type:"existingfile" help:"A YAML file specifying a Resources object, or a Composition with pipeline steps whose input is a Resources object. May be repeated."`
+	Schemas []string `short:"s" type:"existingpath" help:"A YAML file, or a directory of YAML files, specifying CRDs and XRDs. Patches are checked against their schemas. May be repeated."`
+}
+
+// Run the validate command.
+func (c *ValidateCmd) Run() error {
+	s, err := LoadSchemas(c.Schemas...)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, f := range c.Files {
+		n, err := Lint(os.Stdout, f, s)
+		if err != nil {
+			return err
+		}
+		invalid += n
+	}
+	if invalid > 0 {
+		return errors.Errorf("found %d invalid Function input(s)", invalid)
+	}
+	return nil
+}
+
+// Lint validates all Function inputs in the supplied file, writing any
+// validation errors to the supplied writer. It returns the number of invalid
+// inputs. Any patches that don't match the supplied schemas, and any input
+// that's valid but probably doesn't do what its author intended, are written
+// as warnings, and don't make an input invalid.
+func Lint(w io.Writer, path string, s Schemas) (int, error) {
+	ins, err := ReadInputs(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot read Function input from %s", path)
+	}
+	invalid := 0
+	for _, in := range ins {
+		prefix := path
+		if in.Step != "" {
+			prefix = fmt.Sprintf("%s: step %q", path, in.Step)
+		}
+		r, err := LintInput(in.Input)
+		if err != nil {
+			fmt.Fprintf(w, "%s: %s\n", prefix, err)
+			invalid++
+			continue
+		}
+		for _, warning := range Analyze(r) {
+			fmt.Fprintf(w, "%s: warning: %s\n", prefix, warning)
+		}
+		for _, warning := range CheckSchemas(r, in.CompositeTypeRef, s) {
+			fmt.Fprintf(w, "%s: warning: %s\n", prefix, warning)
+		}
+	}
+	return invalid, nil
+}
+
+// LintInput validates and returns the supplied Function input. Unlike when
+// the Function runs, input with unknown fields is considered invalid. These
+// are usually typos.
+func LintInput(in map[string]any) (*v1beta1.Resources, error) {
+	r, err := DecodeInput(in, true)
+	if err != nil {
+		return nil, err
+	}
+	if errs := ValidateResources(r); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+	return r, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	type want struct {
+		out     string
+		invalid int
+	}
+
+	cases := map[string]struct {
+		reason  string
+		content string
+		want    want
+	}{
+		"Valid": {
+			reason: "Valid input should not be reported.",
+			content: `
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: bucket
+  base: {apiVersion: example.org/v1, kind: Bucket}
+`,
+			want: want{},
+		},
+		"Invalid": {
+			reason: "Invalid input in a Composition should be reported with its step.",
+			content: `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: pt
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      resources:
+      - name: bucket
+        base: {apiVersion: example.org/v1, kind: Bucket}
+        patches:
+        - type: FromCompositeFieldPath
+          fromFieldPath: spec.name
+          transforms:
+          - type: string
+            string:
+              type: Format
+`,
+			want: want{
+				out:     `FILE: step "pt": resources[0].patches[0].transforms[0].string.fmt: Required value: format transform requires a format` + "\n",
+				invalid: 1,
+			},
+		},
+		"UnknownField": {
+			reason: "Input with unknown fields should be reported.",
+			content: `
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: bucket
+  bsae: {apiVersion: example.org/v1, kind: Bucket}
+`,
+			want: want{
+				out:     `FILE: cannot parse input: json: unknown field "bsae"` + "\n",
+				invalid: 1,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "input.yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			out := &bytes.Buffer{}
+			invalid, err := Lint(out, path, nil)
+			if err != nil {
+				t.Fatalf("\n%s\nLint(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.invalid, invalid); diff != "" {
+				t.Errorf("\n%s\nLint(...): -want invalid, +got invalid:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, string(bytes.ReplaceAll(out.Bytes(), []byte(path), []byte("FILE")))); diff != "" {
+				t.Errorf("\n%s\nLint(...): -want output, +got output:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
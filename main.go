@@ -2,32 +2,152 @@
 package main
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
 	"github.com/alecthomas/kong"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 
 	"github.com/crossplane/function-sdk-go"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 )
 
 // CLI of this Function.
 type CLI struct {
-	Debug bool `short:"d" help:"Emit debug logs in addition to info logs."`
+	Serve    ServeCmd    `cmd:"" default:"withargs" help:"Serve the Function over gRPC. This is the default command."`
+	Render   RenderCmd   `cmd:"" help:"Render the desired state the Function would produce for a composite resource."`
+	Validate ValidateCmd `cmd:"" help:"Validate Function input, for example in a Composition."`
+	Webhook  WebhookCmd  `cmd:"" help:"Serve a validating admission webhook that rejects Compositions with invalid Function input."`
+	Migrate  MigrateCmd  `cmd:"" help:"Convert a Composition that uses native patch and transform to one that uses the Function in a pipeline."`
+}
+
+// ServeCmd serves this Function over gRPC.
+type ServeCmd struct {
+	Debug        bool `short:"d" help:"Emit debug logs in addition to info logs."`
+	DebugPatches bool `help:"Log how each composed resource patch is evaluated, including the values it reads and each transform's output. Implies --debug."`
 
 	Network     string `help:"Network on which to listen for gRPC connections." default:"tcp"`
 	Address     string `help:"Address at which to listen for gRPC connections." default:":9443"`
 	TLSCertsDir string `help:"Directory containing server certs (tls.key, tls.crt) and the CA used to verify client certificates (ca.crt)" env:"TLS_SERVER_CERTS_DIR"`
 	Insecure    bool   `help:"Run without mTLS credentials. If you supply this flag --tls-server-certs-dir will be ignored."`
+
+	TLSCertFile string `help:"Path to the server certificate. Defaults to tls.crt in --tls-certs-dir." env:"TLS_SERVER_CERT_FILE"`
+	TLSKeyFile  string `help:"Path to the server certificate's private key. Defaults to tls.key in --tls-certs-dir." env:"TLS_SERVER_KEY_FILE"`
+	TLSCAFile   string `name:"tls-ca-file" help:"Path to the CA certificate used to verify client certificates. Defaults to ca.crt in --tls-certs-dir." env:"TLS_CA_FILE"`
+
+	MaxRecvMessageSize int           `help:"Maximum size in bytes of a gRPC message the Function can receive." default:"4194304"`
+	MaxSendMessageSize int           `help:"Maximum size in bytes of a gRPC message the Function can send. Defaults to gRPC's default if not set."`
+	KeepaliveTime      time.Duration `help:"How long a connection can be idle before the Function pings the client. Defaults to gRPC's default if not set."`
+	KeepaliveTimeout   time.Duration `help:"How long the Function waits for a response to a keepalive ping before it closes the connection. Defaults to gRPC's default if not set."`
+	KeepaliveMinTime   time.Duration `help:"The minimum time clients should wait between keepalive pings. Clients that ping more often are disconnected. Defaults to gRPC's default if not set."`
+
+	MetricsAddress string `help:"Address at which to serve Prometheus metrics over HTTP, for example :8080. Metrics aren't served if this isn't set."`
+
+	HealthAddress     string        `help:"Address at which to serve an HTTP health check at /healthz, for example :8081. Health checks aren't served over HTTP if this isn't set."`
+	GRPCHealthAddress string        `name:"grpc-health-address" help:"Address at which to serve the gRPC health checking protocol without TLS, for example :8082, for Kubernetes gRPC probes. The gRPC health checking protocol is also served at --address, but probes can't use it because it requires mTLS."`
+	StallTimeout      time.Duration `help:"How long a RunFunction call may run before the Function reports that it's unhealthy. Set to 0 to always report that it's healthy." default:"5m"`
+
+	MaxConcurrentRenders int `help:"Maximum number of resource templates to render concurrently. Templates are rendered one at a time by default." default:"1"`
+
+	InputCacheSize int `help:"How many distinct Function inputs (usually one per Composition revision) to cache in parsed form. Set to 0 to disable the cache." default:"128"`
+
+	TracingEndpoint    string  `help:"OTLP gRPC endpoint to which to export OpenTelemetry traces of each Function run, for example otel-collector:4317. Traces aren't exported if this isn't set."`
+	TracingInsecure    bool    `help:"Export traces to --tracing-endpoint without TLS."`
+	TracingSampleRatio float64 `help:"Ratio of Function runs to trace, between 0 and 1." default:"1"`
+
+	CaptureDir string `type:"path" help:"Record each RunFunctionRequest and its response to this directory as YAML, to build test fixtures or attach to bug reports. Connection details, credentials, and Secret data are redacted. Only for local development, so it requires --insecure."`
 }
 
 // Run this Function.
-func (c *CLI) Run() error {
-	log, err := function.NewLogger(c.Debug)
+func (c *ServeCmd) Run() error {
+	log, err := function.NewLogger(c.Debug || c.DebugPatches)
 	if err != nil {
 		return err
 	}
 
-	return function.Serve(&Function{log: log},
-		function.Listen(c.Network, c.Address),
-		function.MTLSCertificates(c.TLSCertsDir),
-		function.Insecure(c.Insecure))
+	var m *Metrics
+	if c.MetricsAddress != "" {
+		m = NewMetrics()
+		r := prometheus.NewRegistry()
+		r.MustRegister(m, collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(r, promhttp.HandlerOpts{}))
+		srv := &http.Server{Addr: c.MetricsAddress, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			log.Info("Serving metrics", "address", c.MetricsAddress)
+			if err := srv.ListenAndServe(); err != nil {
+				log.Info("Cannot serve metrics", "error", err)
+			}
+		}()
+	}
+
+	var ic *InputCache
+	if c.InputCacheSize > 0 {
+		ic = NewInputCache(c.InputCacheSize)
+	}
+
+	var t trace.Tracer
+	if c.TracingEndpoint != "" {
+		tp, err := NewTracerProvider(context.Background(), c.TracingEndpoint, c.TracingInsecure, c.TracingSampleRatio)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := tp.Shutdown(context.Background()); err != nil {
+				log.Info("Cannot flush traces", "error", err)
+			}
+		}()
+		log.Info("Exporting traces", "endpoint", c.TracingEndpoint)
+		t = tp.Tracer(tracerName)
+	}
+
+	var fn fnv1.FunctionRunnerServiceServer = &Function{log: log, metrics: m, inputs: ic, tracer: t, maxConcurrentRenders: c.MaxConcurrentRenders, debugPatches: c.DebugPatches}
+	if c.CaptureDir != "" {
+		if !c.Insecure {
+			return errors.New("--capture-dir requires --insecure: capturing requests is only for local development")
+		}
+		if err := os.MkdirAll(c.CaptureDir, 0o700); err != nil {
+			return errors.Wrapf(err, "cannot create capture directory %s", c.CaptureDir)
+		}
+		log.Info("Capturing requests and responses", "directory", c.CaptureDir)
+		fn = NewCapturingRunner(fn, c.CaptureDir, log)
+	}
+
+	health := NewHealthTracker(fn, c.StallTimeout)
+	if c.HealthAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", health)
+		srv := &http.Server{Addr: c.HealthAddress, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			log.Info("Serving health checks", "address", c.HealthAddress)
+			if err := srv.ListenAndServe(); err != nil {
+				log.Info("Cannot serve health checks", "error", err)
+			}
+		}()
+	}
+	if c.GRPCHealthAddress != "" {
+		lis, err := net.Listen("tcp", c.GRPCHealthAddress)
+		if err != nil {
+			return errors.Wrapf(err, "cannot listen for gRPC health checks at address %q", c.GRPCHealthAddress)
+		}
+		go func() {
+			log.Info("Serving gRPC health checks", "address", c.GRPCHealthAddress)
+			if err := ServeHealth(lis, health); err != nil {
+				log.Info("Cannot serve gRPC health checks", "error", err)
+			}
+		}()
+	}
+
+	return c.Serve(health, health)
 }
 
 func main() {
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// PropagateMetadata copies the composite resource labels and annotations
+// selected by the supplied configuration to the supplied composed resource.
+// Copied values overwrite any the composed resource's base template set.
+func PropagateMetadata(pm *v1beta1.PropagateMetadata, xr *composite.Unstructured, cd *composed.Unstructured) {
+	if pm == nil {
+		return
+	}
+	cd.SetLabels(propagate(xr.GetLabels(), cd.GetLabels(), pm.All, pm.Labels))
+	cd.SetAnnotations(propagate(xr.GetAnnotations(), cd.GetAnnotations(), pm.All, pm.Annotations))
+}
+
+// propagate copies the supplied keys (or all keys) from one map to another.
+func propagate(from, to map[string]string, all bool, keys []string) map[string]string {
+	if all {
+		keys = make([]string, 0, len(from))
+		for k := range from {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		v, ok := from[k]
+		if !ok {
+			continue
+		}
+		if to == nil {
+			to = make(map[string]string, len(keys))
+		}
+		to[k] = v
+	}
+	return to
+}
+
+// ApplyComposedMetadata sets the supplied labels and annotations on the
+// supplied composed resource, replacing the variables in their values with
+// values read from the supplied composite resource. Values overwrite any the
+// composed resource already has. A label or annotation whose value can't be
+// rendered isn't set. The returned error describes every one that wasn't.
+func ApplyComposedMetadata(m *v1beta1.ComposedMetadata, xr *composite.Unstructured, cd *composed.Unstructured) error {
+	if m == nil {
+		return nil
+	}
+	labels, lerr := renderMetadata("label", m.Labels, cd.GetLabels(), xr)
+	annotations, aerr := renderMetadata("annotation", m.Annotations, cd.GetAnnotations(), xr)
+	cd.SetLabels(labels)
+	cd.SetAnnotations(annotations)
+	return errors.Join(lerr, aerr)
+}
+
+// renderMetadata renders the supplied metadata into the supplied map, in key
+// order.
+func renderMetadata(kind string, from, to map[string]string, xr *composite.Unstructured) (map[string]string, error) {
+	keys := make([]string, 0, len(from))
+	for k := range from {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, k := range keys {
+		v, err := renderVariables(from[k], map[string]map[string]any{"xr": xr.Object})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "cannot render %s %q", kind, k))
+			continue
+		}
+		if to == nil {
+			to = make(map[string]string, len(from))
+		}
+		to[k] = v
+	}
+	return to, errors.Join(errs...)
+}
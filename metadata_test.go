@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestPropagateMetadata(t *testing.T) {
+	xr := func() *composite.Unstructured {
+		xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}}
+		xr.SetLabels(map[string]string{"team": "a", "env": "prod"})
+		xr.SetAnnotations(map[string]string{"cost-center": "42", "note": "hi"})
+		return xr
+	}
+
+	type args struct {
+		pm *v1beta1.PropagateMetadata
+		xr *composite.Unstructured
+		cd *composed.Unstructured
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   metav1.ObjectMeta
+	}{
+		"NoConfig": {
+			reason: "Nothing should be propagated if there's no configuration.",
+			args: args{
+				xr: xr(),
+				cd: composed.New(),
+			},
+			want: metav1.ObjectMeta{},
+		},
+		"SelectedKeys": {
+			reason: "Only the selected keys should be propagated, overwriting existing values.",
+			args: args{
+				pm: &v1beta1.PropagateMetadata{
+					Labels:      []string{"team", "missing"},
+					Annotations: []string{"cost-center"},
+				},
+				xr: xr(),
+				cd: func() *composed.Unstructured {
+					cd := composed.New()
+					cd.SetLabels(map[string]string{"team": "b", "app": "db"})
+					return cd
+				}(),
+			},
+			want: metav1.ObjectMeta{
+				Labels:      map[string]string{"team": "a", "app": "db"},
+				Annotations: map[string]string{"cost-center": "42"},
+			},
+		},
+		"All": {
+			reason: "All labels and annotations should be propagated.",
+			args: args{
+				pm: &v1beta1.PropagateMetadata{All: true},
+				xr: xr(),
+				cd: composed.New(),
+			},
+			want: metav1.ObjectMeta{
+				Labels:      map[string]string{"team": "a", "env": "prod"},
+				Annotations: map[string]string{"cost-center": "42", "note": "hi"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			PropagateMetadata(tc.args.pm, tc.args.xr, tc.args.cd)
+			got := metav1.ObjectMeta{Labels: tc.args.cd.GetLabels(), Annotations: tc.args.cd.GetAnnotations()}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nPropagateMetadata(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyComposedMetadata(t *testing.T) {
+	xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}}
+	xr.SetName("cool-xr")
+	xr.SetUID("42")
+
+	type args struct {
+		m  *v1beta1.ComposedMetadata
+		cd *composed.Unstructured
+	}
+	type want struct {
+		meta metav1.ObjectMeta
+		err  bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoConfig": {
+			reason: "Nothing should be set if there's no configuration.",
+			args: args{
+				cd: composed.New(),
+			},
+		},
+		"Variables": {
+			reason: "Labels and annotations should be set with their variables replaced, overwriting existing values.",
+			args: args{
+				m: &v1beta1.ComposedMetadata{
+					Labels:      map[string]string{"xr": "{xr.metadata.name}", "team": "platform"},
+					Annotations: map[string]string{"example.org/owner": "xr-{xr.metadata.uid}"},
+				},
+				cd: func() *composed.Unstructured {
+					cd := composed.New()
+					cd.SetLabels(map[string]string{"team": "b", "app": "db"})
+					return cd
+				}(),
+			},
+			want: want{
+				meta: metav1.ObjectMeta{
+					Labels:      map[string]string{"xr": "cool-xr", "team": "platform", "app": "db"},
+					Annotations: map[string]string{"example.org/owner": "xr-42"},
+				},
+			},
+		},
+		"MissingFieldPath": {
+			reason: "A label whose value can't be rendered shouldn't be set, but others should.",
+			args: args{
+				m: &v1beta1.ComposedMetadata{
+					Labels: map[string]string{"xr": "{xr.metadata.name}", "namespace": "{xr.metadata.namespace}"},
+				},
+				cd: composed.New(),
+			},
+			want: want{
+				meta: metav1.ObjectMeta{
+					Labels: map[string]string{"xr": "cool-xr"},
+				},
+				err: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ApplyComposedMetadata(tc.args.m, xr, tc.args.cd)
+			got := metav1.ObjectMeta{Labels: tc.args.cd.GetLabels(), Annotations: tc.args.cd.GetAnnotations()}
+			if diff := cmp.Diff(tc.want.meta, got); diff != "" {
+				t.Errorf("\n%s\nApplyComposedMetadata(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("\n%s\nApplyComposedMetadata(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
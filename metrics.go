@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+const metricsNamespace = "function_patch_and_transform"
+
+// Labels of all metrics. Together they identify the kind of composite
+// resource, and thus (usually) the Composition, a Function run was for.
+const (
+	labelXRAPIVersion = "xr_api_version"
+	labelXRKind       = "xr_kind"
+)
+
+// Metrics about Function runs. A nil *Metrics records nothing.
+type Metrics struct {
+	runDuration       *prometheus.HistogramVec
+	resourcesRendered *prometheus.CounterVec
+	patchFailures     *prometheus.CounterVec
+	readinessChecks   *prometheus.CounterVec
+}
+
+// NewMetrics returns a new set of metrics about Function runs. It must be
+// registered with a Prometheus registry.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		runDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "run_duration_seconds",
+			Help:      "How long it took to run the Function, by the most severe result it returned.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+		}, []string{labelXRAPIVersion, labelXRKind, "result"}),
+		resourcesRendered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "composed_resources_rendered_total",
+			Help:      "The number of composed resources added to the desired state.",
+		}, []string{labelXRAPIVersion, labelXRKind}),
+		patchFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "patch_failures_total",
+			Help:      "The number of composed resource patches that returned an error, by patch type.",
+		}, []string{labelXRAPIVersion, labelXRKind, "patch_type"}),
+		readinessChecks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "readiness_checks_total",
+			Help:      "The number of composed resource readiness checks, by whether the resource was ready.",
+		}, []string{labelXRAPIVersion, labelXRKind, "ready"}),
+	}
+}
+
+// Describe the metrics.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.runDuration.Describe(ch)
+	m.resourcesRendered.Describe(ch)
+	m.patchFailures.Describe(ch)
+	m.readinessChecks.Describe(ch)
+}
+
+// Collect the metrics.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.runDuration.Collect(ch)
+	m.resourcesRendered.Collect(ch)
+	m.patchFailures.Collect(ch)
+	m.readinessChecks.Collect(ch)
+}
+
+// RunFinished records that a Function run that started at the supplied time
+// returned the supplied response.
+func (m *Metrics) RunFinished(apiVersion, kind string, started time.Time, rsp *fnv1.RunFunctionResponse) {
+	if m == nil {
+		return
+	}
+	result := "Success"
+	for _, r := range rsp.GetResults() {
+		switch r.GetSeverity() { //nolint:exhaustive // Other severities don't change the result.
+		case fnv1.Severity_SEVERITY_FATAL:
+			result = "Fatal"
+		case fnv1.Severity_SEVERITY_WARNING:
+			if result == "Success" {
+				result = "Warning"
+			}
+		}
+	}
+	m.runDuration.WithLabelValues(apiVersion, kind, result).Observe(time.Since(started).Seconds())
+}
+
+// ResourcesRendered records that the supplied number of composed resources
+// were added to the desired state.
+func (m *Metrics) ResourcesRendered(apiVersion, kind string, n int) {
+	if m == nil {
+		return
+	}
+	m.resourcesRendered.WithLabelValues(apiVersion, kind).Add(float64(n))
+}
+
+// PatchFailed records that a patch of the supplied type returned an error.
+func (m *Metrics) PatchFailed(apiVersion, kind string, t v1beta1.PatchType) {
+	if m == nil {
+		return
+	}
+	m.patchFailures.WithLabelValues(apiVersion, kind, string(t)).Inc()
+}
+
+// ReadinessChecked records the result of a readiness check.
+func (m *Metrics) ReadinessChecked(apiVersion, kind string, ready bool) {
+	if m == nil {
+		return
+	}
+	m.readinessChecks.WithLabelValues(apiVersion, kind, strconv.FormatBool(ready)).Inc()
+}
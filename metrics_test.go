@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+
+	m.ResourcesRendered("example.org/v1", "XR", 2)
+	m.PatchFailed("example.org/v1", "XR", v1beta1.PatchTypeFromCompositeFieldPath)
+	m.ReadinessChecked("example.org/v1", "XR", true)
+	m.ReadinessChecked("example.org/v1", "XR", false)
+	m.ReadinessChecked("example.org/v1", "XR", false)
+	m.RunFinished("example.org/v1", "XR", time.Now(), &fnv1.RunFunctionResponse{
+		Results: []*fnv1.Result{
+			{Severity: fnv1.Severity_SEVERITY_WARNING},
+			{Severity: fnv1.Severity_SEVERITY_FATAL},
+		},
+	})
+
+	want := `
+# HELP function_patch_and_transform_composed_resources_rendered_total The number of composed resources added to the desired state.
+# TYPE function_patch_and_transform_composed_resources_rendered_total counter
+function_patch_and_transform_composed_resources_rendered_total{xr_api_version="example.org/v1",xr_kind="XR"} 2
+# HELP function_patch_and_transform_patch_failures_total The number of composed resource patches that returned an error, by patch type.
+# TYPE function_patch_and_transform_patch_failures_total counter
+function_patch_and_transform_patch_failures_total{patch_type="FromCompositeFieldPath",xr_api_version="example.org/v1",xr_kind="XR"} 1
+# HELP function_patch_and_transform_readiness_checks_total The number of composed resource readiness checks, by whether the resource was ready.
+# TYPE function_patch_and_transform_readiness_checks_total counter
+function_patch_and_transform_readiness_checks_total{ready="false",xr_api_version="example.org/v1",xr_kind="XR"} 2
+function_patch_and_transform_readiness_checks_total{ready="true",xr_api_version="example.org/v1",xr_kind="XR"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want),
+		"function_patch_and_transform_composed_resources_rendered_total",
+		"function_patch_and_transform_patch_failures_total",
+		"function_patch_and_transform_readiness_checks_total",
+	); err != nil {
+		t.Errorf("m.Collect(...): %s", err)
+	}
+
+	// We can't predict the run's duration, but we can check its result label.
+	if diff := cmp.Diff(1, testutil.CollectAndCount(m, "function_patch_and_transform_run_duration_seconds")); diff != "" {
+		t.Errorf("m.RunFinished(...): -want series, +got series:\n%s", diff)
+	}
+	if !m.runDuration.DeleteLabelValues("example.org/v1", "XR", "Fatal") {
+		t.Errorf("m.RunFinished(...): want a run with result Fatal")
+	}
+}
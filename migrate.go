@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// MigrateCmd converts a Composition that uses native patch and transform to a
+// Composition that uses this Function in a pipeline.
+type MigrateCmd struct {
+	Composition string `arg:"" type:"existingfile" help:"A YAML file specifying a Composition that uses native patch and transform, i.e. spec.resources rather than spec.pipeline."`
+
+	FunctionName string `help:"Name of the Function the pipeline step uses." default:"function-patch-and-transform"`
+	Step         string `help:"Name of the pipeline step." default:"patch-and-transform"`
+}
+
+// Run the migrate command.
+func (c *MigrateCmd) Run() error {
+	objs, err := readObjectsStrict(c.Composition)
+	if err != nil {
+		return err
+	}
+	if len(objs) != 1 {
+		return errors.Errorf("%s must contain exactly one Composition", c.Composition)
+	}
+	comp, warnings, err := MigrateComposition(objs[0], c.FunctionName, c.Step)
+	if err != nil {
+		return errors.Wrapf(err, "cannot migrate %s", c.Composition)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", c.Composition, w)
+	}
+	b, err := sigsyaml.Marshal(comp)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal Composition to YAML")
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
+
+// The fields of a native patch and transform Composition's spec that move to
+// the Function's input.
+var migratedFields = []string{"resources", "patchSets", "environment"}
+
+// MigrateComposition converts the supplied Composition that uses native patch
+// and transform to one that uses the named Function in a pipeline step. Its
+// resources, patchSets, and environment become the step's Resources input.
+// Connection details are given the type and name Crossplane infers for them,
+// and resources without a name are named after their index. It returns a
+// warning for each field the Function doesn't support, which is dropped, and
+// for each resource it names.
+func MigrateComposition(comp map[string]any, fn, step string) (map[string]any, []string, error) {
+	if kind, _ := comp["kind"].(string); kind != "Composition" {
+		return nil, nil, errors.Errorf("kind must be Composition, got %q", kind)
+	}
+	spec, ok := comp["spec"].(map[string]any)
+	if !ok {
+		return nil, nil, errors.New("Composition has no spec")
+	}
+	if mode, _ := spec["mode"].(string); mode == "Pipeline" {
+		return nil, nil, errors.New("Composition already uses a pipeline")
+	}
+
+	warnings := []string{}
+	in := map[string]any{"apiVersion": inputAPIVersion, "kind": inputKind}
+	for _, f := range migratedFields {
+		if v, ok := spec[f]; ok {
+			in[f] = v
+		}
+	}
+	rts, _ := in["resources"].([]any)
+	for i, v := range rts {
+		rt, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, _ := rt["name"].(string); name == "" {
+			rt["name"] = fmt.Sprintf("resource-%d", i)
+			warnings = append(warnings, fmt.Sprintf("spec.resources[%d] has no name, so it's named %q", i, rt["name"]))
+		}
+		cds, _ := rt["connectionDetails"].([]any)
+		for _, cd := range cds {
+			if cd, ok := cd.(map[string]any); ok {
+				inferConnectionDetail(cd)
+			}
+		}
+	}
+
+	r, err := DecodeInput(in, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if errs := ValidateResources(r); len(errs) > 0 {
+		return nil, nil, errors.Wrap(errs.ToAggregate(), "migrated Function input is invalid")
+	}
+
+	// Drop any fields the Function didn't decode.
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot marshal Function input to JSON")
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, nil, errors.Wrap(err, "cannot unmarshal Function input from JSON")
+	}
+	for _, f := range migratedFields {
+		if v, ok := in[f]; ok {
+			in[f] = dropUnknownFields(v, decoded.(map[string]any)[f], "spec."+f, &warnings)
+		}
+	}
+
+	out := map[string]any{}
+	for k, v := range comp {
+		out[k] = v
+	}
+	ospec := map[string]any{}
+	for k, v := range spec {
+		ospec[k] = v
+	}
+	for _, f := range migratedFields {
+		delete(ospec, f)
+	}
+	ospec["mode"] = "Pipeline"
+	ospec["pipeline"] = []any{map[string]any{
+		"step":        step,
+		"functionRef": map[string]any{"name": fn},
+		"input":       in,
+	}}
+	out["spec"] = ospec
+	return out, warnings, nil
+}
+
+// inferConnectionDetail sets the type of the supplied connection detail, and
+// its name if it's read from a connection secret key, if they aren't set. It
+// infers them the same way Crossplane does for native patch and transform.
+func inferConnectionDetail(cd map[string]any) {
+	p := fieldpath.Pave(cd)
+	if t, _ := p.GetString("type"); t == "" {
+		switch {
+		case cd["value"] != nil:
+			cd["type"] = "FromValue"
+		case cd["fromConnectionSecretKey"] != nil:
+			cd["type"] = "FromConnectionSecretKey"
+		case cd["fromFieldPath"] != nil:
+			cd["type"] = "FromFieldPath"
+		}
+	}
+	if n, _ := p.GetString("name"); n == "" {
+		if k, err := p.GetString("fromConnectionSecretKey"); err == nil {
+			cd["name"] = k
+		}
+	}
+}
+
+// dropUnknownFields returns the supplied value without any object fields that
+// aren't in the supplied decoded value. It appends a warning naming each field
+// it drops.
+func dropUnknownFields(v, decoded any, path string, warnings *[]string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		d, ok := decoded.(map[string]any)
+		if !ok {
+			return v
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make(map[string]any, len(v))
+		for _, k := range keys {
+			dv, ok := d[k]
+			if !ok {
+				if v[k] != nil {
+					*warnings = append(*warnings, fmt.Sprintf("%s.%s isn't supported by the Function, so it's dropped", path, k))
+				}
+				continue
+			}
+			out[k] = dropUnknownFields(v[k], dv, path+"."+k, warnings)
+		}
+		return out
+	case []any:
+		d, ok := decoded.([]any)
+		if !ok || len(d) != len(v) {
+			return v
+		}
+		out := make([]any, len(v))
+		for i := range v {
+			out[i] = dropUnknownFields(v[i], d[i], fmt.Sprintf("%s[%d]", path, i), warnings)
+		}
+		return out
+	}
+	return v
+}
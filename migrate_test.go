@@ -0,0 +1,179 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+func TestMigrateComposition(t *testing.T) {
+	type want struct {
+		comp     string
+		warnings []string
+		err      bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		comp   string
+		want   want
+	}{
+		"Migrated": {
+			reason: "Resources, patchSets, and the environment should move to the input of a pipeline step, with the connection detail types and resource names Crossplane would infer.",
+			comp: `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: cool-composition
+spec:
+  compositeTypeRef: {apiVersion: example.org/v1, kind: XDatabase}
+  environment:
+    policy: {resolution: Required}
+    environmentConfigs:
+    - type: Reference
+      ref: {name: cool-env}
+  patchSets:
+  - name: common
+    patches:
+    - fromFieldPath: spec.region
+      toFieldPath: spec.forProvider.region
+  resources:
+  - base: {apiVersion: example.org/v1, kind: Database}
+    patches:
+    - type: PatchSet
+      patchSetName: common
+    - fromFieldPath: spec.size
+      toFieldPath: spec.forProvider.size
+      transforms:
+      - type: map
+        map: {small: db.t3.small}
+    connectionDetails:
+    - fromConnectionSecretKey: password
+    - name: port
+      value: "5432"
+    readinessChecks:
+    - type: MatchString
+      fieldPath: status.atProvider.state
+      matchString: available
+`,
+			want: want{
+				comp: `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: cool-composition
+spec:
+  compositeTypeRef: {apiVersion: example.org/v1, kind: XDatabase}
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef: {name: function-patch-and-transform}
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      environment:
+        environmentConfigs:
+        - type: Reference
+          ref: {name: cool-env}
+      patchSets:
+      - name: common
+        patches:
+        - fromFieldPath: spec.region
+          toFieldPath: spec.forProvider.region
+      resources:
+      - name: resource-0
+        base: {apiVersion: example.org/v1, kind: Database}
+        patches:
+        - type: PatchSet
+          patchSetName: common
+        - fromFieldPath: spec.size
+          toFieldPath: spec.forProvider.size
+          transforms:
+          - type: map
+            map: {small: db.t3.small}
+        connectionDetails:
+        - name: password
+          type: FromConnectionSecretKey
+          fromConnectionSecretKey: password
+        - name: port
+          type: FromValue
+          value: "5432"
+        readinessChecks:
+        - type: MatchString
+          fieldPath: status.atProvider.state
+          matchString: available
+`,
+				warnings: []string{
+					`spec.resources[0] has no name, so it's named "resource-0"`,
+					"spec.environment.policy isn't supported by the Function, so it's dropped",
+				},
+			},
+		},
+		"AlreadyPipeline": {
+			reason: "A Composition that already uses a pipeline shouldn't be migrated.",
+			comp: `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline: []
+`,
+			want: want{err: true},
+		},
+		"InvalidInput": {
+			reason: "A Composition whose resources aren't valid Function input shouldn't be migrated.",
+			comp: `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  resources:
+  - name: database
+    base: {apiVersion: example.org/v1, kind: Database}
+    patches:
+    - fromFieldPath: spec.name
+      toFieldPath: spec.forProvider.name
+      transforms:
+      - type: string
+        string: {type: Format}
+`,
+			want: want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			comp := map[string]any{}
+			if err := sigsyaml.Unmarshal([]byte(tc.comp), &comp); err != nil {
+				t.Fatal(err)
+			}
+			got, warnings, err := MigrateComposition(comp, "function-patch-and-transform", "patch-and-transform")
+			if (err != nil) != tc.want.err {
+				t.Fatalf("%s\nMigrateComposition(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+			if tc.want.err {
+				return
+			}
+			want := map[string]any{}
+			if err := sigsyaml.Unmarshal([]byte(tc.want.comp), &want); err != nil {
+				t.Fatal(err)
+			}
+			// Round trip the output so numbers have the same types.
+			b, err := sigsyaml.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = map[string]any{}
+			if err := sigsyaml.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("%s\nMigrateComposition(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.warnings, warnings, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nMigrateComposition(...): -want warnings, +got warnings:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
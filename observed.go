@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Metadata fields of an observed composed resource that are set by the API
+// server, and thus shouldn't be part of a desired composed resource.
+var serverMetadataFields = []string{
+	"creationTimestamp",
+	"deletionGracePeriodSeconds",
+	"deletionTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// Metadata of an observed composed resource that identifies it, and thus
+// shouldn't be part of a clone of it.
+var (
+	identityMetadataFields = []string{
+		"name",
+		"finalizers",
+		"ownerReferences",
+	}
+	identityAnnotations = []string{
+		meta.AnnotationKeyExternalName,
+		AnnotationKeyCompositionResourceName,
+		AnnotationKeyPatchedPaths,
+	}
+)
+
+// CloneObserved returns a clone of the supplied observed composed resource,
+// to use as the base of another desired composed resource. The observed
+// composed resource's status, any metadata set by the API server, and any
+// metadata that identifies it, such as its name and external name, are
+// omitted.
+func CloneObserved(ocd *composed.Unstructured) *composed.Unstructured {
+	cd := ocd.DeepCopy()
+	delete(cd.Object, "status")
+	if m, ok := cd.Object["metadata"].(map[string]any); ok {
+		for _, f := range serverMetadataFields {
+			delete(m, f)
+		}
+		for _, f := range identityMetadataFields {
+			delete(m, f)
+		}
+	}
+	if a := cd.GetAnnotations(); len(a) > 0 {
+		for _, k := range identityAnnotations {
+			delete(a, k)
+		}
+		if len(a) == 0 {
+			a = nil
+		}
+		cd.SetAnnotations(a)
+	}
+	return cd
+}
+
+// RetainObserved returns a desired composed resource that retains the supplied
+// observed composed resource as it is. The observed composed resource's
+// status, and any metadata set by the API server, are omitted.
+func RetainObserved(ocd *composed.Unstructured) *composed.Unstructured {
+	cd := composed.New()
+	MergeObserved(ocd, cd)
+	return cd
+}
+
+// OrphanedComposedResources returns the names of the supplied observed
+// composed resources that aren't in the supplied desired composed resources,
+// sorted. Observed composed resources that were added under the name of a
+// renamed resource template are desired under their previous name, so the
+// supplied renamed resource templates are ignored.
+func OrphanedComposedResources(observed map[resource.Name]resource.ObservedComposed, desired map[resource.Name]*resource.DesiredComposed, renamed map[string]resource.Name) []resource.Name {
+	var orphaned []resource.Name
+	for name := range observed {
+		if _, ok := renamed[string(name)]; ok {
+			continue
+		}
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		orphaned = append(orphaned, name)
+	}
+	slices.Sort(orphaned)
+	return orphaned
+}
+
+// MergeObserved merges the supplied desired composed resource on top of the
+// supplied observed composed resource, and uses the result as the desired
+// composed resource. The observed composed resource's status, and any
+// metadata set by the API server, are omitted. Objects are merged
+// recursively. Any other value in the desired composed resource replaces the
+// observed value.
+func MergeObserved(ocd, dcd *composed.Unstructured) {
+	o := ocd.DeepCopy().UnstructuredContent()
+	delete(o, "status")
+	if m, ok := o["metadata"].(map[string]any); ok {
+		for _, f := range serverMetadataFields {
+			delete(m, f)
+		}
+	}
+	dcd.SetUnstructuredContent(mergeObjects(o, dcd.UnstructuredContent()))
+}
+
+// mergeObjects recursively merges src into dst, and returns dst.
+func mergeObjects(dst, src map[string]any) map[string]any {
+	for k, sv := range src {
+		sm, sok := sv.(map[string]any)
+		dm, dok := dst[k].(map[string]any)
+		if sok && dok {
+			dst[k] = mergeObjects(dm, sm)
+			continue
+		}
+		dst[k] = sv
+	}
+	return dst
+}
+
+// ResolvePreviousNames finds observed composed resources that were recorded
+// under a previous name of the supplied resource templates. It adds each to the
+// supplied observed composed resources under its resource template's name, so
+// the resource template renders it. It returns the previous name of each
+// resource template whose composed resource was found that way, by template
+// name. The resource template's desired composed resource must keep that name,
+// or Crossplane deletes the observed composed resource and creates a new one.
+func ResolvePreviousNames(cts []v1beta1.ComposedTemplate, observed map[resource.Name]resource.ObservedComposed) map[string]resource.Name {
+	renamed := map[string]resource.Name{}
+	for _, t := range cts {
+		if _, ok := observed[resource.Name(t.Name)]; ok {
+			continue
+		}
+		for _, p := range t.PreviousNames {
+			ocd, ok := observed[resource.Name(p)]
+			if !ok {
+				continue
+			}
+			observed[resource.Name(t.Name)] = ocd
+			renamed[t.Name] = resource.Name(p)
+			break
+		}
+	}
+	return renamed
+}
+
+// OmitHintFields removes each hint field of the supplied field ownership from
+// the supplied desired composed resource if the supplied observed composed
+// resource has a value for it that a field manager other than Crossplane
+// manages. If another field manager only manages some fields inside a hint
+// field, only those fields are removed. Omitting a field only Crossplane
+// manages would make server-side apply delete it, so those fields are kept.
+// Owned fields inside a removed field keep their desired value.
+func OmitHintFields(fos []v1beta1.FieldOwnership, ocd, dcd *composed.Unstructured) error {
+	o := fieldpath.Pave(ocd.Object)
+	d := fieldpath.Pave(dcd.Object)
+	for _, h := range fos {
+		if h.GetPolicy() != v1beta1.FieldOwnershipPolicyHint {
+			continue
+		}
+		if _, err := o.GetValue(h.FieldPath); err != nil {
+			if fieldpath.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "cannot get observed value of hint field %s", h.FieldPath)
+		}
+		paths, err := managedByOthers(ocd, h.FieldPath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot determine field managers of hint field %s", h.FieldPath)
+		}
+		for _, path := range paths {
+			if err := omitField(d, path, fos); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// omitField removes the supplied field path from the supplied desired
+// composed resource, unless it's an owned field or inside one. Owned fields
+// inside the removed field keep their desired value.
+func omitField(d *fieldpath.Paved, path string, fos []v1beta1.FieldOwnership) error {
+	for _, f := range fos {
+		if f.GetPolicy() == v1beta1.FieldOwnershipPolicyOwned && (f.FieldPath == path || insideFieldPath(path, f.FieldPath)) {
+			return nil
+		}
+	}
+	owned := map[string]any{}
+	for _, f := range fos {
+		if f.GetPolicy() != v1beta1.FieldOwnershipPolicyOwned || !insideFieldPath(f.FieldPath, path) {
+			continue
+		}
+		if v, err := d.GetValue(f.FieldPath); err == nil {
+			owned[f.FieldPath] = v
+		}
+	}
+	if err := d.DeleteField(path); err != nil {
+		return errors.Wrapf(err, "cannot omit hint field %s", path)
+	}
+	for _, f := range fos {
+		v, ok := owned[f.FieldPath]
+		if !ok {
+			continue
+		}
+		if err := d.SetValue(f.FieldPath, v); err != nil {
+			return errors.Wrapf(err, "cannot keep owned field %s", f.FieldPath)
+		}
+	}
+	return nil
+}
+
+// managedByOthers returns the field paths at or inside the supplied field path
+// of the supplied observed composed resource that a field manager other than
+// Crossplane manages, according to its managed fields. It returns only the
+// supplied field path if another field manager manages it as a whole.
+func managedByOthers(ocd *composed.Unstructured, path string) ([]string, error) {
+	segs, err := fieldpath.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]bool{}
+	for _, e := range ocd.GetManagedFields() {
+		if isCrossplaneFieldManager(e.Manager) || e.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]any{}
+		if err := json.Unmarshal(e.FieldsV1.Raw, &fields); err != nil {
+			return nil, errors.Wrapf(err, "cannot unmarshal managed fields of field manager %s", e.Manager)
+		}
+		for _, p := range managedFieldPaths(fields, segs) {
+			if p.String() == path {
+				return []string{path}, nil
+			}
+			paths[p.String()] = true
+		}
+	}
+	out := make([]string, 0, len(paths))
+	for p := range paths {
+		out = append(out, p)
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+// isCrossplaneFieldManager returns true if the supplied field manager is
+// Crossplane. Crossplane server-side applies composed resources using a field
+// manager prefixed with apiextensions.crossplane.io/. Older versions of
+// Crossplane use the field manager crossplane.
+func isCrossplaneFieldManager(m string) bool {
+	return m == "crossplane" || strings.HasPrefix(m, "apiextensions.crossplane.io/")
+}
+
+// managedFieldPaths returns the field paths at or inside the supplied field
+// path that the supplied managed fields, in the FieldsV1 format, include. It
+// returns only the supplied field path if the managed fields include it as a
+// whole, either as a leaf or with a "." entry. A leaf that's a parent of the
+// supplied field path, like an atomic object, includes it as a whole too.
+// Array elements are identified by key rather than index, so fields inside
+// arrays that aren't managed as a whole aren't returned.
+func managedFieldPaths(fields map[string]any, segs fieldpath.Segments) []fieldpath.Segments {
+	cur := fields
+	for _, s := range segs {
+		if s.Type != fieldpath.SegmentField {
+			return nil
+		}
+		next, ok := cur["f:"+s.Field].(map[string]any)
+		if !ok {
+			return nil
+		}
+		if len(next) == 0 {
+			return []fieldpath.Segments{segs}
+		}
+		cur = next
+	}
+	return managedInside(cur, segs)
+}
+
+// managedInside returns the supplied field path if the supplied managed fields
+// node includes it as a whole. Otherwise it returns the field paths inside it
+// that the node's children include as a whole.
+func managedInside(node map[string]any, path fieldpath.Segments) []fieldpath.Segments {
+	if _, ok := node["."]; ok || len(node) == 0 {
+		return []fieldpath.Segments{path}
+	}
+	var out []fieldpath.Segments
+	for k, v := range node {
+		f, ok := strings.CutPrefix(k, "f:")
+		if !ok {
+			continue
+		}
+		if child, ok := v.(map[string]any); ok {
+			out = append(out, managedInside(child, append(slices.Clone(path), fieldpath.Field(f)))...)
+		}
+	}
+	return out
+}
+
+// insideFieldPath returns true if the supplied field path is inside the
+// supplied parent field path.
+func insideFieldPath(path, parent string) bool {
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
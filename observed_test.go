@@ -0,0 +1,345 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestMergeObserved(t *testing.T) {
+	type args struct {
+		ocd *composed.Unstructured
+		dcd *composed.Unstructured
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   map[string]any
+	}{
+		"MergeOverObserved": {
+			reason: "The desired resource should be merged on top of the observed resource, without its status or server-set metadata.",
+			args: args{
+				ocd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"metadata": map[string]any{
+						"name":            "cool-42",
+						"resourceVersion": "7",
+						"uid":             "abc",
+						"labels":          map[string]any{"webhook": "mutated"},
+					},
+					"spec": map[string]any{
+						"forProvider": map[string]any{
+							"region":  "us-east-2",
+							"default": "set-by-provider",
+							"tags":    []any{"a", "b"},
+						},
+					},
+					"status": map[string]any{"ready": true},
+				}}},
+				dcd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"spec": map[string]any{
+						"forProvider": map[string]any{
+							"region": "eu-west-1",
+							"tags":   []any{"c"},
+						},
+					},
+				}}},
+			},
+			want: map[string]any{
+				"apiVersion": "example.org/v1",
+				"kind":       "CD",
+				"metadata": map[string]any{
+					"name":   "cool-42",
+					"labels": map[string]any{"webhook": "mutated"},
+				},
+				"spec": map[string]any{
+					"forProvider": map[string]any{
+						"region":  "eu-west-1",
+						"default": "set-by-provider",
+						"tags":    []any{"c"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			observed := tc.args.ocd.DeepCopy()
+			MergeObserved(tc.args.ocd, tc.args.dcd)
+			if diff := cmp.Diff(tc.want, tc.args.dcd.Object); diff != "" {
+				t.Errorf("\n%s\nMergeObserved(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(observed, tc.args.ocd); diff != "" {
+				t.Errorf("\n%s\nMergeObserved(...): observed resource was mutated: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCloneObserved(t *testing.T) {
+	ocd := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "CD",
+		"metadata": map[string]any{
+			"name":            "cool-42",
+			"namespace":       "default",
+			"resourceVersion": "7",
+			"uid":             "abc",
+			"finalizers":      []any{"finalizer.managedresource.crossplane.io"},
+			"ownerReferences": []any{map[string]any{"name": "cool-xr"}},
+			"labels":          map[string]any{"app": "db"},
+			"annotations": map[string]any{
+				"crossplane.io/external-name":             "cool-db",
+				"crossplane.io/composition-resource-name": "blue",
+				"example.org/team":                        "a",
+			},
+		},
+		"spec": map[string]any{
+			"forProvider": map[string]any{"region": "us-east-2"},
+		},
+		"status": map[string]any{"ready": true},
+	}}}
+
+	want := map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "CD",
+		"metadata": map[string]any{
+			"namespace":   "default",
+			"labels":      map[string]any{"app": "db"},
+			"annotations": map[string]any{"example.org/team": "a"},
+		},
+		"spec": map[string]any{
+			"forProvider": map[string]any{"region": "us-east-2"},
+		},
+	}
+
+	observed := ocd.DeepCopy()
+	got := CloneObserved(ocd)
+	if diff := cmp.Diff(want, got.Object); diff != "" {
+		t.Errorf("CloneObserved(...): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(observed, ocd); diff != "" {
+		t.Errorf("CloneObserved(...): observed composed resource shouldn't be changed: -want, +got:\n%s", diff)
+	}
+}
+
+func TestOmitHintFields(t *testing.T) {
+	ocd := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"forProvider": map[string]any{
+				"instanceType": "large",
+				"networking":   map[string]any{"vpc": "vpc-a", "subnet": "subnet-a"},
+				"tags":         map[string]any{"team": "platform", "owner": "alice"},
+			},
+		},
+	}}}
+	ocd.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:   "apiextensions.crossplane.io/composed/cool-xr",
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:forProvider":{"f:instanceType":{},"f:networking":{"f:vpc":{},"f:subnet":{}},"f:tags":{"f:team":{},"f:owner":{}}}}}`)},
+		},
+		{
+			Manager:   "provider-aws",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:forProvider":{"f:instanceType":{},"f:networking":{".":{},"f:vpc":{},"f:subnet":{}}}}}`)},
+		},
+		{
+			Manager:   "kubectl",
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:forProvider":{"f:tags":{"f:owner":{}}}}}`)},
+		},
+	})
+	dcd := func() *composed.Unstructured {
+		return &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"forProvider": map[string]any{
+					"instanceType": "small",
+					"networking":   map[string]any{"vpc": "vpc-b", "subnet": "subnet-b"},
+					"region":       "us-east-2",
+					"size":         int64(10),
+					"tags":         map[string]any{"team": "infra", "owner": "bob"},
+				},
+			},
+		}}}
+	}
+
+	cases := map[string]struct {
+		reason string
+		fos    []v1beta1.FieldOwnership
+		want   map[string]any
+	}{
+		"NoHints": {
+			reason: "Owned fields should be kept.",
+			fos:    []v1beta1.FieldOwnership{{FieldPath: "spec.forProvider.instanceType"}},
+			want:   dcd().Object,
+		},
+		"HintObserved": {
+			reason: "A hint field the observed composed resource has a value for that another field manager manages should be omitted.",
+			fos:    []v1beta1.FieldOwnership{{FieldPath: "spec.forProvider.instanceType", Policy: v1beta1.FieldOwnershipPolicyHint}},
+			want: map[string]any{
+				"spec": map[string]any{
+					"forProvider": map[string]any{
+						"networking": map[string]any{"vpc": "vpc-b", "subnet": "subnet-b"},
+						"region":     "us-east-2",
+						"size":       int64(10),
+						"tags":       map[string]any{"team": "infra", "owner": "bob"},
+					},
+				},
+			},
+		},
+		"HintOnlyManagedByCrossplane": {
+			reason: "A hint field only Crossplane manages should be kept, so server-side apply doesn't delete it.",
+			fos:    []v1beta1.FieldOwnership{{FieldPath: "spec.forProvider.tags.team", Policy: v1beta1.FieldOwnershipPolicyHint}},
+			want:   dcd().Object,
+		},
+		"HintPartlyManagedByOthers": {
+			reason: "Only the fields of a hint map that another field manager manages should be omitted, so server-side apply doesn't delete the fields only Crossplane manages.",
+			fos:    []v1beta1.FieldOwnership{{FieldPath: "spec.forProvider.tags", Policy: v1beta1.FieldOwnershipPolicyHint}},
+			want: map[string]any{
+				"spec": map[string]any{
+					"forProvider": map[string]any{
+						"instanceType": "small",
+						"networking":   map[string]any{"vpc": "vpc-b", "subnet": "subnet-b"},
+						"region":       "us-east-2",
+						"size":         int64(10),
+						"tags":         map[string]any{"team": "infra"},
+					},
+				},
+			},
+		},
+		"HintNotObserved": {
+			reason: "A hint field the observed composed resource doesn't have a value for should be kept.",
+			fos:    []v1beta1.FieldOwnership{{FieldPath: "spec.forProvider.size", Policy: v1beta1.FieldOwnershipPolicyHint}},
+			want:   dcd().Object,
+		},
+		"OwnedInsideHint": {
+			reason: "An owned field inside an omitted hint field should keep its desired value.",
+			fos: []v1beta1.FieldOwnership{
+				{FieldPath: "spec.forProvider.networking", Policy: v1beta1.FieldOwnershipPolicyHint},
+				{FieldPath: "spec.forProvider.networking.subnet"},
+			},
+			want: map[string]any{
+				"spec": map[string]any{
+					"forProvider": map[string]any{
+						"instanceType": "small",
+						"networking":   map[string]any{"subnet": "subnet-b"},
+						"region":       "us-east-2",
+						"size":         int64(10),
+						"tags":         map[string]any{"team": "infra", "owner": "bob"},
+					},
+				},
+			},
+		},
+		"OwnedFieldManagedByOthers": {
+			reason: "Fields inside a hint that another field manager manages should be omitted, except owned fields.",
+			fos: []v1beta1.FieldOwnership{
+				{FieldPath: "spec.forProvider", Policy: v1beta1.FieldOwnershipPolicyHint},
+				{FieldPath: "spec.forProvider.instanceType", Policy: v1beta1.FieldOwnershipPolicyOwned},
+			},
+			want: map[string]any{
+				"spec": map[string]any{
+					"forProvider": map[string]any{
+						"instanceType": "small",
+						"region":       "us-east-2",
+						"size":         int64(10),
+						"tags":         map[string]any{"team": "infra"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := dcd()
+			if err := OmitHintFields(tc.fos, ocd, got); err != nil {
+				t.Fatalf("%s\nOmitHintFields(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got.Object); diff != "" {
+				t.Errorf("%s\nOmitHintFields(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestManagedFieldPaths(t *testing.T) {
+	fields := map[string]any{
+		"f:spec": map[string]any{
+			"f:forProvider": map[string]any{
+				"f:instanceType": map[string]any{},
+				"f:labels":       map[string]any{".": map[string]any{}, "f:app": map[string]any{}},
+				"f:tags":         map[string]any{"f:owner": map[string]any{}, "f:cost": map[string]any{}},
+				"f:policy":       map[string]any{},
+				"f:rules": map[string]any{
+					`k:{"name":"ssh"}`: map[string]any{"f:port": map[string]any{}},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   []string
+	}{
+		"Leaf": {
+			reason: "A leaf field should be managed as a whole.",
+			path:   "spec.forProvider.instanceType",
+			want:   []string{"spec.forProvider.instanceType"},
+		},
+		"DotEntry": {
+			reason: "An object with a \".\" entry should be managed as a whole.",
+			path:   "spec.forProvider.labels",
+			want:   []string{"spec.forProvider.labels"},
+		},
+		"NotManaged": {
+			reason: "A field that isn't in the managed fields shouldn't be managed.",
+			path:   "spec.forProvider.region",
+		},
+		"InsideManagedObject": {
+			reason: "A field inside an object that's managed as a whole should be managed as a whole.",
+			path:   "spec.forProvider.policy.statement",
+			want:   []string{"spec.forProvider.policy.statement"},
+		},
+		"PartlyManagedObject": {
+			reason: "Only the fields of an object that are managed should be returned.",
+			path:   "spec.forProvider.tags",
+			want:   []string{"spec.forProvider.tags.cost", "spec.forProvider.tags.owner"},
+		},
+		"ArrayElement": {
+			reason: "Fields inside array elements can't be matched by index, so they shouldn't be managed.",
+			path:   "spec.forProvider.rules[0].port",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			segs, err := fieldpath.Parse(tc.path)
+			if err != nil {
+				t.Fatalf("fieldpath.Parse(%q): %v", tc.path, err)
+			}
+			var got []string
+			for _, p := range managedFieldPaths(fields, segs) {
+				got = append(got, p.String())
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("%s\nmanagedFieldPaths(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -14,25 +19,55 @@ import (
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 
+	"github.com/crossplane/function-sdk-go/resource"
 	"github.com/crossplane/function-sdk-go/resource/composed"
 	"github.com/crossplane/function-sdk-go/resource/composite"
 
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
+// Composite resource labels and annotations with this prefix are managed by
+// Crossplane. Patches may not change them.
+const protectedMetadataPrefix = "crossplane.io/"
+
+// AnnotationKeyMergedKeys is the annotation at which patches with the
+// MergeObjectsDeleteKeysNotInSource policy record the keys they merged, by
+// to field path.
+const AnnotationKeyMergedKeys = "pt.fn.crossplane.io/merged-keys"
+
 const (
-	errPatchSetType = "a patch in a PatchSet cannot be of type PatchSet"
+	errSensitivePatch = "cannot apply sensitive patch: the error is redacted because it may contain the patch's value"
 
 	errFmtUndefinedPatchSet           = "cannot find PatchSet by name %s"
+	errFmtPatchSetCycle               = "PatchSet %s includes itself: %s"
 	errFmtCombineStrategyNotSupported = "combine strategy %s is not supported"
 	errFmtCombineConfigMissing        = "given combine strategy %s requires configuration"
 	errFmtCombineStrategyFailed       = "%s strategy could not combine"
+	errFmtCombineVariableType         = "combine variable %d must be %s, got %T"
+	errFmtCombineVariableName         = "combine variable %d must have a name"
+	errFmtCombineVariableDefault      = "cannot parse default value of combine variable %s"
+	errFmtCombineVariableSource       = "cannot read combine variable %s from source %s"
 	errFmtExpandingArrayFieldPaths    = "cannot expand ToFieldPath %s"
+	errFmtExpandArraysWildcards       = "cannot expand ToFieldPath %s: expandArrays requires exactly one [*] wildcard"
+	errFmtExpandArraysValue           = "cannot expand ToFieldPath %s: expandArrays requires an array value, not %T"
+	errFmtBaseFromNotObject           = "base template at %s is not an object"
+	errFmtProtectedMetadata           = "cannot patch composite resource metadata key %q: keys prefixed with %s are managed by Crossplane"
 	errFmtInvalidPatchPolicy          = "invalid patch policy %s"
+	errFmtEnvironmentPatch            = "cannot apply the %q environment patch at index %d"
+	errParseToFieldPath               = "cannot parse toFieldPath template"
+	errRenderToFieldPath              = "cannot render toFieldPath template"
+	errUnmarshalMergedKeys            = "cannot unmarshal merged keys annotation"
+	errMarshalMergedKeys              = "cannot marshal merged keys annotation"
+	errFmtDeleteKeysTarget            = "toFieldPath policy %s only supports patching to a composed or composite resource"
+	errFmtDeleteKeysValue             = "toFieldPath policy %s only supports patching objects, not %T"
 )
 
 var (
 	internalEnvironmentGVK = schema.GroupVersionKind{Group: "internal.crossplane.io", Version: "v1alpha1", Kind: "Environment"}
+	internalContextGVK     = schema.GroupVersionKind{Group: "internal.crossplane.io", Version: "v1alpha1", Kind: "Context"}
+
+	internalExtraResourcesGVK = schema.GroupVersionKind{Group: "internal.crossplane.io", Version: "v1alpha1", Kind: "ExtraResources"}
+	internalVariablesGVK      = schema.GroupVersionKind{Group: "internal.crossplane.io", Version: "v1alpha1", Kind: "Variables"}
 )
 
 // A PatchInterface is a patch that can be applied between resources.
@@ -43,6 +78,7 @@ type PatchInterface interface {
 	GetCombine() *v1beta1.Combine
 	GetTransforms() []v1beta1.Transform
 	GetPolicy() *v1beta1.PatchPolicy
+	GetSensitive() bool
 }
 
 // PatchWithPatchSetName is a PatchInterface that has a PatchSetName field.
@@ -51,32 +87,93 @@ type PatchWithPatchSetName interface {
 	GetPatchSetName() string
 }
 
-// ResolveTransforms applies a list of transforms to a patch value.
+// ResolveTransforms applies a list of transforms to a patch value. Optional
+// transforms that fail pass their input to the next transform unchanged.
 func ResolveTransforms(ts []v1beta1.Transform, input any) (any, error) {
-	var err error
 	for i, t := range ts {
-		if input, err = Resolve(t, input); err != nil {
-			// TODO(negz): Including the type might help find the offending transform faster.
-			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
+		out, err := Resolve(t, input)
+		if err != nil {
+			if t.GetOptional() {
+				continue
+			}
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i, DescribeTransform(t), input)
 		}
+		input = out
 	}
 	return input, nil
 }
 
+// FieldPathWholeObject is the special from field path that refers to the
+// whole source object, rather than one of its fields.
+const FieldPathWholeObject = "."
+
+// FromFieldPathValue returns the value at the supplied from field path of the
+// supplied object. The whole object is returned if the path is
+// FieldPathWholeObject. A field Crossplane manages in a composite resource's
+// spec is read from the other composite resource layout if it isn't found.
+func FromFieldPathValue(from map[string]any, path string) (any, error) {
+	if path == FieldPathWholeObject {
+		return from, nil
+	}
+	return getInEitherLayout(path, fieldpath.Pave(from).GetValue)
+}
+
+// RedactPatchError returns the supplied error with its message redacted if the
+// supplied patch is sensitive, because errors from transforms and merges may
+// contain the patch's value. Errors that a field path doesn't exist only
+// contain the field path, so they're returned as is.
+func RedactPatchError(p PatchInterface, err error) error {
+	if err == nil || !p.GetSensitive() || fieldpath.IsNotFound(err) {
+		return err
+	}
+	return errors.New(errSensitivePatch)
+}
+
 // ApplyFromFieldPathPatch patches the "to" resource, using a source field
 // on the "from" resource. Values may be transformed if any are defined on
 // the patch.
 func ApplyFromFieldPathPatch(p PatchInterface, from, to runtime.Object) error {
-	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	fromMap, err := unstructuredContent(from)
 	if err != nil {
 		return err
 	}
 
-	in, err := fieldpath.Pave(fromMap).GetValue(p.GetFromFieldPath())
+	in, err := FromFieldPathValue(fromMap, p.GetFromFieldPath())
 	if err != nil {
 		return err
 	}
 
+	toFieldPath, err := RenderToFieldPath(p.GetToFieldPath(), in, fromMap)
+	if err != nil {
+		return err
+	}
+
+	if p.GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicySetIfEmpty {
+		set, err := fieldValueIsSet(to, toFieldPath)
+		if err != nil || set {
+			return err
+		}
+	}
+
+	// Most patches just copy a value. They don't need their value transformed,
+	// or merged into the to field path, so they can write a copy of it
+	// straight into the object. This skips the JSON round-trips below, and in
+	// fieldpath.Paved's SetValue, which dominate the cost of rendering large
+	// compositions.
+	if len(p.GetTransforms()) == 0 && p.GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicyReplace && !p.GetPolicy().GetExpandArrays() {
+		if u, ok := to.(runtime.Unstructured); ok {
+			if v, ok := copyJSONValue(in); ok {
+				// UnstructuredContent returns a new, unattached map if
+				// the object has no content.
+				c := u.UnstructuredContent()
+				if setJSONValue(c, toFieldPath, v) {
+					u.SetUnstructuredContent(c)
+					return nil
+				}
+			}
+		}
+	}
+
 	// Apply transform pipeline
 	out, err := ResolveTransforms(p.GetTransforms(), in)
 	if err != nil {
@@ -93,17 +190,25 @@ func ApplyFromFieldPathPatch(p PatchInterface, from, to runtime.Object) error {
 		return err
 	}
 
+	if p.GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource {
+		return patchFieldValueDeleteKeysNotInSource(toFieldPath, v, to)
+	}
+
 	mo, err := toMergeOption(p)
 	if err != nil {
 		return err
 	}
 
+	if p.GetPolicy().GetExpandArrays() {
+		return patchFieldValueToExpandedArray(toFieldPath, v, to, mo)
+	}
+
 	// ComposedPatch all expanded fields if the ToFieldPath contains wildcards
-	if strings.Contains(p.GetToFieldPath(), "[*]") {
-		return patchFieldValueToMultiple(p.GetToFieldPath(), v, to, mo)
+	if strings.Contains(toFieldPath, "[*]") {
+		return patchFieldValueToMultiple(toFieldPath, v, to, mo)
 	}
 
-	return errors.Wrap(patchFieldValueToObject(p.GetToFieldPath(), v, to, mo), "cannot patch to object")
+	return errors.Wrap(patchFieldValueToObject(toFieldPath, v, to, mo), "cannot patch to object")
 }
 
 func toValidJSON(value any) (any, error) {
@@ -118,6 +223,126 @@ func toValidJSON(value any) (any, error) {
 	return v, nil
 }
 
+// maxExactFloat is the largest integer a float64 can represent exactly.
+const maxExactFloat = 1 << 53
+
+// copyJSONValue returns a deep copy of the supplied value, as toValidJSON
+// would return it, without marshalling it to JSON. Integral numbers become
+// int64, like the Kubernetes JSON decoder decodes them. It returns false if
+// the value contains a type that can't be copied this way, in which case
+// toValidJSON should be used instead.
+func copyJSONValue(value any) (any, bool) {
+	switch v := value.(type) {
+	case nil, string, bool, int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case float64:
+		// JSON encodes larger numbers with less precision than a float64
+		// has, so they don't round-trip exactly.
+		if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > maxExactFloat {
+			return nil, false
+		}
+		if v == math.Trunc(v) {
+			return int64(v), true
+		}
+		return v, true
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			c, ok := copyJSONValue(e)
+			if !ok {
+				return nil, false
+			}
+			out[k] = c
+		}
+		return out, true
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			c, ok := copyJSONValue(e)
+			if !ok {
+				return nil, false
+			}
+			out[i] = c
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// setJSONValue sets the supplied value at the supplied field path of the
+// supplied object content, creating any missing parent objects. Unlike
+// fieldpath.Paved's SetValue it doesn't round-trip the value through JSON, so
+// the value must already be valid JSON, as copyJSONValue returns it. It
+// returns false without changing the content if the field path has a wildcard
+// or key selector, would need an array to be created or extended, or doesn't
+// match the content's types. fieldpath.Paved handles those cases.
+func setJSONValue(content map[string]any, path string, value any) bool {
+	segs, err := fieldpath.Parse(path)
+	if err != nil || len(segs) == 0 {
+		return false
+	}
+	for _, s := range segs {
+		if s.Type == fieldpath.SegmentField && (s.Field == "*" || strings.Contains(s.Field, "=")) {
+			return false
+		}
+	}
+
+	var parent any = content
+	last := len(segs) - 1
+	for i, s := range segs[:last] {
+		if s.Type == fieldpath.SegmentIndex {
+			a, ok := parent.([]any)
+			if !ok || s.Index >= uint(len(a)) {
+				return false
+			}
+			parent = a[s.Index]
+			continue
+		}
+		m, ok := parent.(map[string]any)
+		if !ok {
+			return false
+		}
+		next, ok := m[s.Field]
+		if ok {
+			parent = next
+			continue
+		}
+		// Only missing objects can be created directly.
+		for _, r := range segs[i:] {
+			if r.Type != fieldpath.SegmentField {
+				return false
+			}
+		}
+		for _, r := range segs[i:last] {
+			c := map[string]any{}
+			m[r.Field] = c
+			m = c
+		}
+		m[segs[last].Field] = value
+		return true
+	}
+
+	switch s := segs[last]; s.Type {
+	case fieldpath.SegmentField:
+		m, ok := parent.(map[string]any)
+		if !ok {
+			return false
+		}
+		m[s.Field] = value
+	case fieldpath.SegmentIndex:
+		a, ok := parent.([]any)
+		if !ok || s.Index >= uint(len(a)) {
+			return false
+		}
+		a[s.Index] = value
+	}
+	return true
+}
+
 // toMergeOption returns the MergeOptions from the PatchPolicy's ToFieldPathPolicy, if defined.
 func toMergeOption(p PatchInterface) (mo *xpv1.MergeOptions, err error) {
 	if p == nil {
@@ -128,13 +353,13 @@ func toMergeOption(p PatchInterface) (mo *xpv1.MergeOptions, err error) {
 		return nil, nil
 	}
 	switch pp.GetToFieldPathPolicy() {
-	case v1beta1.ToFieldPathPolicyReplace:
+	case v1beta1.ToFieldPathPolicyReplace, v1beta1.ToFieldPathPolicySetIfEmpty:
 		// nothing to do, this is the default
 	case v1beta1.ToFieldPathPolicyMergeObjects, v1beta1.ToFieldPathPolicyMergeObject: //nolint:staticcheck // MergeObject is deprecated but we must still support it.
 		mo = &xpv1.MergeOptions{KeepMapValues: ptr.To(true)}
 	case v1beta1.ToFieldPathPolicyMergeObjectsAppendArrays:
 		mo = &xpv1.MergeOptions{KeepMapValues: ptr.To(true), AppendSlice: ptr.To(true)}
-	case v1beta1.ToFieldPathPolicyForceMergeObjects:
+	case v1beta1.ToFieldPathPolicyForceMergeObjects, v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource:
 		mo = &xpv1.MergeOptions{KeepMapValues: ptr.To(false)}
 	case v1beta1.ToFieldPathPolicyForceMergeObjectsAppendArrays, v1beta1.ToFieldPathPolicyAppendArray: //nolint:staticcheck // AppendArray is deprecated but we must still support it.
 		mo = &xpv1.MergeOptions{AppendSlice: ptr.To(true)}
@@ -150,7 +375,20 @@ func toMergeOption(p PatchInterface) (mo *xpv1.MergeOptions, err error) {
 // output value may then be further transformed if they are defined on the
 // patch.
 func ApplyCombineFromVariablesPatch(p PatchInterface, from, to runtime.Object) error {
-	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	return applyCombineFromVariablesPatch(p, from, to, nil)
+}
+
+// CombineFromVariables returns a PatchFn that applies combine patches like
+// ApplyCombineFromVariablesPatch, except that variables with a source are read
+// from the supplied sources.
+func CombineFromVariables(srcs *CombineSources) PatchFn {
+	return func(p PatchInterface, from, to runtime.Object) error {
+		return applyCombineFromVariablesPatch(p, from, to, srcs)
+	}
+}
+
+func applyCombineFromVariablesPatch(p PatchInterface, from, to runtime.Object, srcs *CombineSources) error {
+	fromMap, err := unstructuredContent(from)
 	if err != nil {
 		return err
 	}
@@ -163,12 +401,16 @@ func ApplyCombineFromVariablesPatch(p PatchInterface, from, to runtime.Object) e
 	// value. If we add new variable types, this may not be the case and
 	// this code may be better served split out into a dedicated function.
 	for i, sp := range c.Variables {
-		iv, err := fieldpath.Pave(fromMap).GetValue(sp.FromFieldPath)
+		src, err := srcs.Source(sp, fromMap)
+		if err != nil {
+			return err
+		}
+		iv, err := CombineVariableValue(sp, src)
 
-		// If any source field is not found, we will not
-		// apply the patch. This is to avoid situations
-		// where a combine patch is expecting a fixed
-		// number of inputs (e.g. a string format
+		// If any required source field is not found, we
+		// will not apply the patch. This is to avoid
+		// situations where a combine patch is expecting a
+		// fixed number of inputs (e.g. a string format
 		// expecting 3 fields '%s-%s-%s' but only
 		// receiving 2 values).
 		if err != nil {
@@ -177,6 +419,18 @@ func ApplyCombineFromVariablesPatch(p PatchInterface, from, to runtime.Object) e
 		in[i] = iv
 	}
 
+	toFieldPath, err := RenderToFieldPath(p.GetToFieldPath(), in, fromMap)
+	if err != nil {
+		return err
+	}
+
+	if p.GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicySetIfEmpty {
+		set, err := fieldValueIsSet(to, toFieldPath)
+		if err != nil || set {
+			return err
+		}
+	}
+
 	// Combine input values
 	cb, err := Combine(*c, in)
 	if err != nil {
@@ -194,27 +448,167 @@ func ApplyCombineFromVariablesPatch(p PatchInterface, from, to runtime.Object) e
 		return err
 	}
 
-	return errors.Wrap(patchFieldValueToObject(p.GetToFieldPath(), out, to, mo), "cannot patch to object")
+	if p.GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource {
+		return patchFieldValueDeleteKeysNotInSource(toFieldPath, out, to)
+	}
+
+	return errors.Wrap(patchFieldValueToObject(toFieldPath, out, to, mo), "cannot patch to object")
+}
+
+// IsToFieldPathTemplate returns true if the supplied to field path contains
+// placeholders.
+func IsToFieldPathTemplate(path string) bool {
+	return strings.Contains(path, "{{")
+}
+
+// ToFieldPathTemplate parses the supplied to field path as a Go template. The
+// template's fromFieldPath function returns the value at the supplied field
+// path of the supplied object.
+func ToFieldPathTemplate(path string, from map[string]any) (*template.Template, error) {
+	t, err := template.New("toFieldPath").Option("missingkey=error").Funcs(template.FuncMap{
+		"fromFieldPath": func(fp string) (any, error) {
+			return fieldpath.Pave(from).GetValue(fp)
+		},
+	}).Parse(path)
+	return t, errors.Wrap(err, errParseToFieldPath)
+}
+
+// RenderToFieldPath renders any placeholders in the supplied to field path.
+// Placeholders are Go templates, and may refer to the patch's input value,
+// i.e. the value at its from field path, or the values of its combine
+// variables. They may also use the fromFieldPath function to read other
+// fields of the object the patch is from.
+func RenderToFieldPath(path string, value any, from map[string]any) (string, error) {
+	if !IsToFieldPathTemplate(path) {
+		return path, nil
+	}
+	t, err := ToFieldPathTemplate(path, from)
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	if err := t.Execute(b, value); err != nil {
+		return "", errors.Wrap(err, errRenderToFieldPath)
+	}
+	return b.String(), nil
+}
+
+// A PatchFn applies a patch from one object to another.
+type PatchFn func(p PatchInterface, from, to runtime.Object) error
+
+// ApplyToCompositePatch applies a patch to the desired XR using the supplied
+// PatchFn. Replacing all of the XR's labels or annotations merges them with
+// any existing labels or annotations instead, because the desired XR may
+// already have some set. Patches may not change labels or annotations that
+// Crossplane manages, except for the supplied allowed annotations. If the patch only overwrites unset fields and the
+// observed XR already has a value at the patch's to field path, that value is
+// patched to the desired XR instead. Patches to fields Crossplane manages are
+// written in the layout the observed XR uses.
+func ApplyToCompositePatch(fn PatchFn, p PatchInterface, from runtime.Object, oxr, dxr *composite.Unstructured, allowed map[string]bool) error {
+	p = PatchInLayout(p, oxr)
+	if p.GetPolicy().GetOverwritePolicy() == v1beta1.OverwritePolicyIfUnset && oxr != nil && !IsToFieldPathTemplate(p.GetToFieldPath()) {
+		v, err := fieldpath.Pave(oxr.Object).GetValue(p.GetToFieldPath())
+		switch {
+		case err == nil:
+			fn = func(p PatchInterface, _, to runtime.Object) error {
+				return patchFieldValueToObject(p.GetToFieldPath(), v, to, nil)
+			}
+		case !fieldpath.IsNotFound(err):
+			return err
+		}
+	}
+
+	labels, annotations := dxr.GetLabels(), dxr.GetAnnotations()
+	if err := fn(p, from, dxr); err != nil {
+		return err
+	}
+	l, err := mergeCompositeMetadata(p, "metadata.labels", labels, dxr.GetLabels(), nil)
+	if err == nil {
+		var a map[string]string
+		if a, err = mergeCompositeMetadata(p, "metadata.annotations", annotations, dxr.GetAnnotations(), allowed); err == nil {
+			labels, annotations = l, a
+		}
+	}
+	// If we return an error we restore the original labels and annotations,
+	// so as not to leave corrupted metadata in the desired XR.
+	dxr.SetLabels(labels)
+	dxr.SetAnnotations(annotations)
+	return err
+}
+
+// WhenSettled returns a PatchFn that applies the supplied PatchFn if the
+// supplied observed composed resource's status is settled, according to the
+// patch's whenSettled policy. Otherwise it patches the value the supplied
+// observed XR already has, if any, so an unsettled value doesn't replace it.
+func WhenSettled(fn PatchFn, ocd *composed.Unstructured, oxr *composite.Unstructured) PatchFn {
+	return func(p PatchInterface, from, to runtime.Object) error {
+		settled, err := IsSettled(p.GetPolicy().GetWhenSettled(), ocd)
+		if err != nil {
+			return errors.Wrap(err, "cannot check whether composed resource is settled")
+		}
+		if settled {
+			return fn(p, from, to)
+		}
+		if oxr == nil || IsToFieldPathTemplate(p.GetToFieldPath()) {
+			return nil
+		}
+		v, err := fieldpath.Pave(oxr.Object).GetValue(p.GetToFieldPath())
+		if fieldpath.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return patchFieldValueToObject(p.GetToFieldPath(), v, to, nil)
+	}
+}
+
+// mergeCompositeMetadata returns the labels or annotations of the XR after
+// the supplied patch was applied to them. They're merged with the labels or
+// annotations from before the patch if the patch replaced all of them. Keys
+// prefixed with crossplane.io/ may only change if they're allowed.
+func mergeCompositeMetadata(p PatchInterface, path string, before, after map[string]string, allowed map[string]bool) (map[string]string, error) {
+	for k, v := range after {
+		if ov, ok := before[k]; strings.HasPrefix(k, protectedMetadataPrefix) && !allowed[k] && (!ok || ov != v) {
+			return nil, errors.Errorf(errFmtProtectedMetadata, k, protectedMetadataPrefix)
+		}
+	}
+	if p.GetToFieldPath() != path || p.GetPolicy().GetToFieldPathPolicy() != v1beta1.ToFieldPathPolicyReplace {
+		return after, nil
+	}
+	for k, v := range before {
+		if _, ok := after[k]; ok {
+			continue
+		}
+		if after == nil {
+			after = make(map[string]string, len(before))
+		}
+		after[k] = v
+	}
+	return after, nil
 }
 
 // ApplyEnvironmentPatch applies a patch to or from the environment. Patches to
 // the environment are always from the observed XR. Patches from the environment
-// are always to the desired XR.
-func ApplyEnvironmentPatch(p *v1beta1.EnvironmentPatch, env *unstructured.Unstructured, oxr, dxr *composite.Unstructured) error {
+// are always to the desired XR, which may only have the supplied allowed
+// Crossplane annotations patched. Combine variables with a source may also
+// read from the supplied observed composed resources.
+func ApplyEnvironmentPatch(p *v1beta1.EnvironmentPatch, env *unstructured.Unstructured, oxr, dxr *composite.Unstructured, observed map[resource.Name]resource.ObservedComposed, allowed map[string]bool) error {
+	combine := CombineFromVariables(&CombineSources{Composite: oxr, Environment: env, Composed: observed})
 	switch p.GetType() {
 	// From observed XR to environment.
 	case v1beta1.PatchTypeFromCompositeFieldPath,
 		v1beta1.PatchTypeToEnvironmentFieldPath:
 		return ApplyFromFieldPathPatch(p, oxr, env)
 	case v1beta1.PatchTypeCombineFromComposite:
-		return ApplyCombineFromVariablesPatch(p, oxr, env)
+		return combine(p, oxr, env)
 
 	// From environment to desired XR.
 	case v1beta1.PatchTypeToCompositeFieldPath,
 		v1beta1.PatchTypeFromEnvironmentFieldPath:
-		return ApplyFromFieldPathPatch(p, env, dxr)
+		return ApplyToCompositePatch(ApplyFromFieldPathPatch, p, env, oxr, dxr, allowed)
 	case v1beta1.PatchTypeCombineToComposite:
-		return ApplyCombineFromVariablesPatch(p, env, dxr)
+		return ApplyToCompositePatch(combine, p, env, oxr, dxr, allowed)
 
 	// Invalid patch types in this context.
 	case v1beta1.PatchTypeCombineFromEnvironment,
@@ -227,11 +621,37 @@ func ApplyEnvironmentPatch(p *v1beta1.EnvironmentPatch, env *unstructured.Unstru
 	return nil
 }
 
+// ApplyEnvironmentPatches applies all of the supplied environment patches of
+// the supplied stage, in order. Patches from an optional field path that does
+// not exist are skipped.
+func ApplyEnvironmentPatches(ps []v1beta1.EnvironmentPatch, stage v1beta1.EnvironmentPatchStage, env *unstructured.Unstructured, oxr, dxr *composite.Unstructured, observed map[resource.Name]resource.ObservedComposed, allowed map[string]bool) error {
+	for i := range ps {
+		p := &ps[i]
+		if p.GetStage() != stage {
+			continue
+		}
+		if err := RedactPatchError(p, ApplyEnvironmentPatch(p, env, oxr, dxr, observed, allowed)); err != nil {
+
+			// Ignore not found errors if patch policy is set to Optional
+			if fieldpath.IsNotFound(err) && p.GetPolicy().GetFromFieldPathPolicy() == v1beta1.FromFieldPathPolicyOptional {
+				continue
+			}
+
+			return errors.Wrapf(err, errFmtEnvironmentPatch, p.GetType(), i)
+		}
+	}
+	return nil
+}
+
 // ApplyComposedPatch applies a patch to or from a composed resource. Patches
 // from an observed composed resource can be to the desired XR, or to the
-// environment. Patches to a desired composed resource can be from the observed
-// XR, or from the environment.
-func ApplyComposedPatch(p *v1beta1.ComposedPatch, ocd, dcd *composed.Unstructured, oxr, dxr *composite.Unstructured, env *unstructured.Unstructured) error { //nolint:gocyclo // Just a long switch.
+// environment or the Function pipeline context. Patches to a desired composed
+// resource can be from the observed XR or its claim, the environment, the
+// Function pipeline context, the requested extra resources, or the input's
+// variables. Patches to the desired XR may only change the supplied allowed
+// Crossplane annotations. Combine variables with a source may also read from
+// the supplied observed composed resources.
+func ApplyComposedPatch(p *v1beta1.ComposedPatch, ocd, dcd *composed.Unstructured, oxr, dxr *composite.Unstructured, env, fctx, extra, claim, vars *unstructured.Unstructured, observed map[resource.Name]resource.ObservedComposed, allowed map[string]bool) error { //nolint:gocyclo // Just a long switch.
 	// Don't return an error if we're patching from a composed resource that
 	// doesn't exist yet. We'll try patch from it once it's been created.
 	if ocd == nil && !ToComposedResource(p) {
@@ -242,31 +662,52 @@ func ApplyComposedPatch(p *v1beta1.ComposedPatch, ocd, dcd *composed.Unstructure
 	// folks will often want to patch from status fields, which only appear in
 	// observed state. Observed state should also eventually be consistent with
 	// desired state.
+	combine := CombineFromVariables(&CombineSources{Composite: oxr, Environment: env, Composed: observed})
 	switch t := p.GetType(); t {
 
 	// From observed composed resource to desired XR.
 	case v1beta1.PatchTypeToCompositeFieldPath:
-		return ApplyFromFieldPathPatch(p, ocd, dxr)
+		return ApplyToCompositePatch(WhenSettled(ApplyFromFieldPathPatch, ocd, oxr), p, ocd, oxr, dxr, allowed)
 	case v1beta1.PatchTypeCombineToComposite:
-		return ApplyCombineFromVariablesPatch(p, ocd, dxr)
+		return ApplyToCompositePatch(WhenSettled(combine, ocd, oxr), p, ocd, oxr, dxr, allowed)
 
 	// From observed composed resource to environment.
 	case v1beta1.PatchTypeToEnvironmentFieldPath:
 		return ApplyFromFieldPathPatch(p, ocd, env)
 	case v1beta1.PatchTypeCombineToEnvironment:
-		return ApplyCombineFromVariablesPatch(p, ocd, env)
+		return combine(p, ocd, env)
+
+	// From observed composed resource to Function pipeline context.
+	case v1beta1.PatchTypeToContextFieldPath:
+		return ApplyFromFieldPathPatch(p, ocd, fctx)
 
 	// From observed XR to desired composed resource.
 	case v1beta1.PatchTypeFromCompositeFieldPath:
 		return ApplyFromFieldPathPatch(p, oxr, dcd)
 	case v1beta1.PatchTypeCombineFromComposite:
-		return ApplyCombineFromVariablesPatch(p, oxr, dcd)
+		return combine(p, oxr, dcd)
 
 	// From environment to desired composed resource.
 	case v1beta1.PatchTypeFromEnvironmentFieldPath:
 		return ApplyFromFieldPathPatch(p, env, dcd)
 	case v1beta1.PatchTypeCombineFromEnvironment:
-		return ApplyCombineFromVariablesPatch(p, env, dcd)
+		return combine(p, env, dcd)
+
+	// From Function pipeline context to desired composed resource.
+	case v1beta1.PatchTypeFromContextFieldPath:
+		return ApplyFromFieldPathPatch(p, fctx, dcd)
+
+	// From extra resources to desired composed resource.
+	case v1beta1.PatchTypeFromExtraResourceFieldPath:
+		return ApplyFromFieldPathPatch(p, extra, dcd)
+
+	// From the observed XR's claim to desired composed resource.
+	case v1beta1.PatchTypeFromClaimFieldPath:
+		return ApplyFromFieldPathPatch(p, claim, dcd)
+
+	// From the input's variables to desired composed resource.
+	case v1beta1.PatchTypeFromVariableFieldPath:
+		return ApplyFromFieldPathPatch(p, vars, dcd)
 
 	case v1beta1.PatchTypePatchSet:
 		// Already resolved - nothing to do.
@@ -286,6 +727,18 @@ func ToComposedResource(p *v1beta1.ComposedPatch) bool {
 	// From environment to desired composed resource.
 	case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
 		return true
+	// From Function pipeline context to desired composed resource.
+	case v1beta1.PatchTypeFromContextFieldPath:
+		return true
+	// From extra resources to desired composed resource.
+	case v1beta1.PatchTypeFromExtraResourceFieldPath:
+		return true
+	// From the observed XR's claim to desired composed resource.
+	case v1beta1.PatchTypeFromClaimFieldPath:
+		return true
+	// From the input's variables to desired composed resource.
+	case v1beta1.PatchTypeFromVariableFieldPath:
+		return true
 
 	// From composed resource to composite.
 	case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
@@ -293,6 +746,9 @@ func ToComposedResource(p *v1beta1.ComposedPatch) bool {
 	// From composed resource to environment.
 	case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment:
 		return false
+	// From composed resource to Function pipeline context.
+	case v1beta1.PatchTypeToContextFieldPath:
+		return false
 	// We can ignore patchsets; they're inlined.
 	case v1beta1.PatchTypePatchSet:
 		return false
@@ -301,6 +757,60 @@ func ToComposedResource(p *v1beta1.ComposedPatch) bool {
 	return false
 }
 
+// CombineSources are the objects combine variables with a source are read
+// from.
+type CombineSources struct {
+	Composite   *composite.Unstructured
+	Environment *unstructured.Unstructured
+
+	// Composed are the observed composed resources, by resource template
+	// name.
+	Composed map[resource.Name]resource.ObservedComposed
+}
+
+// Source returns the content of the object the supplied combine variable is
+// read from. Variables without a source are read from the supplied object. A
+// variable read from a composed resource that doesn't exist yet is read from
+// an empty object, so its field path doesn't exist.
+func (s *CombineSources) Source(v v1beta1.CombineVariable, from map[string]any) (map[string]any, error) {
+	if v.Source == nil {
+		return from, nil
+	}
+	switch v.Source.Type {
+	case v1beta1.CombineVariableSourceTypeComposite:
+		if s != nil && s.Composite != nil {
+			return s.Composite.Object, nil
+		}
+	case v1beta1.CombineVariableSourceTypeEnvironment:
+		if s != nil && s.Environment != nil {
+			return s.Environment.Object, nil
+		}
+	case v1beta1.CombineVariableSourceTypeComposed:
+		if s != nil && v.Source.ResourceName != nil {
+			if ocd, ok := s.Composed[resource.Name(*v.Source.ResourceName)]; ok {
+				return ocd.Resource.Object, nil
+			}
+			return map[string]any{}, nil
+		}
+	}
+	return nil, errors.Errorf(errFmtCombineVariableSource, v.FromFieldPath, v.Source.Type)
+}
+
+// CombineVariableValue returns the value of the supplied combine variable in
+// the supplied object. It returns the variable's default value if its field
+// path doesn't exist and it's optional.
+func CombineVariableValue(v v1beta1.CombineVariable, from map[string]any) (any, error) {
+	iv, err := FromFieldPathValue(from, v.FromFieldPath)
+	if err == nil || !fieldpath.IsNotFound(err) || v.GetPolicy() != v1beta1.FromFieldPathPolicyOptional || v.Default == nil {
+		return iv, err
+	}
+	var out any
+	if err := unmarshalJSON(*v.Default, &out); err != nil {
+		return nil, errors.Wrapf(err, errFmtCombineVariableDefault, v.FromFieldPath)
+	}
+	return out, nil
+}
+
 // Combine calls the appropriate combiner.
 func Combine(c v1beta1.Combine, vars []any) (any, error) {
 	var out any
@@ -312,13 +822,67 @@ func Combine(c v1beta1.Combine, vars []any) (any, error) {
 			return nil, errors.Errorf(errFmtCombineConfigMissing, c.Strategy)
 		}
 		out = CombineString(c.String.Format, vars)
+	case v1beta1.CombineStrategyJoin:
+		if c.Join == nil {
+			return nil, errors.Errorf(errFmtCombineConfigMissing, c.Strategy)
+		}
+		out, err = CombineJoin(c.Join.Separator, vars)
+	case v1beta1.CombineStrategyObject:
+		out, err = CombineObject(c.Variables, vars)
+	case v1beta1.CombineStrategyMerge:
+		out, err = CombineMerge(vars)
 	default:
 		return nil, errors.Errorf(errFmtCombineStrategyNotSupported, c.Strategy)
 	}
 
-	// Note: There are currently no tests or triggers to exercise this error as
-	// our only strategy ("String") uses fmt.Sprintf, which cannot return an error.
-	return out, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
+	}
+	return out, nil
+}
+
+// CombineJoin returns a single string by joining all of its input variables
+// with the supplied separator.
+func CombineJoin(sep string, vars []any) (string, error) {
+	s := make([]string, len(vars))
+	for i, v := range vars {
+		switch v.(type) {
+		case string, bool, int, int64, float64:
+			s[i] = fmt.Sprint(v)
+		default:
+			return "", errors.Errorf(errFmtCombineVariableType, i, "a string, number, or boolean", v)
+		}
+	}
+	return strings.Join(s, sep), nil
+}
+
+// CombineObject returns an object with a key for each of its input variables,
+// named by the supplied combine variables.
+func CombineObject(cvs []v1beta1.CombineVariable, vars []any) (map[string]any, error) {
+	out := make(map[string]any, len(vars))
+	for i, v := range vars {
+		if i >= len(cvs) || cvs[i].Name == "" {
+			return nil, errors.Errorf(errFmtCombineVariableName, i)
+		}
+		out[cvs[i].Name] = v
+	}
+	return out, nil
+}
+
+// CombineMerge returns a single object by deep merging all of its input
+// variables, which must be objects. Keys of later variables take precedence.
+func CombineMerge(vars []any) (map[string]any, error) {
+	out := map[string]any{}
+	for i, v := range vars {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, errors.Errorf(errFmtCombineVariableType, i, "an object", v)
+		}
+		// The variables are read from other objects, which we mustn't
+		// modify by merging into them.
+		mergeObjects(out, runtime.DeepCopyJSONValue(m).(map[string]any)) //nolint:forcetypeassert // DeepCopyJSONValue returns the type it's passed.
+	}
+	return out, nil
 }
 
 // CombineString returns a single output by running a string format with all of
@@ -328,34 +892,19 @@ func CombineString(format string, vars []any) string {
 }
 
 // ComposedTemplates returns the supplied composed resource templates with any
-// supplied patchsets dereferenced.
+// supplied patchsets dereferenced. PatchSets may include other PatchSets, which
+// are dereferenced recursively.
 func ComposedTemplates(pss []v1beta1.PatchSet, cts []v1beta1.ComposedTemplate) ([]v1beta1.ComposedTemplate, error) {
-	pn := make(map[string][]v1beta1.ComposedPatch)
+	pn := make(map[string][]v1beta1.ComposedPatch, len(pss))
 	for _, s := range pss {
-		for _, p := range s.Patches {
-			if p.GetType() == v1beta1.PatchTypePatchSet {
-				return nil, errors.New(errPatchSetType)
-			}
-		}
 		pn[s.Name] = s.GetComposedPatches()
 	}
 
 	ct := make([]v1beta1.ComposedTemplate, len(cts))
 	for i, r := range cts {
-		var po []v1beta1.ComposedPatch
-		for _, p := range r.Patches {
-			if p.GetType() != v1beta1.PatchTypePatchSet {
-				po = append(po, p)
-				continue
-			}
-			if p.PatchSetName == nil {
-				return nil, errors.Errorf(errFmtRequiredField, "PatchSetName", p.GetType())
-			}
-			ps, ok := pn[*p.PatchSetName]
-			if !ok {
-				return nil, errors.Errorf(errFmtUndefinedPatchSet, *p.PatchSetName)
-			}
-			po = append(po, ps...)
+		po, err := resolvePatchSets(pn, r.Patches, nil)
+		if err != nil {
+			return nil, err
 		}
 		ct[i] = r
 		ct[i].Patches = po
@@ -363,12 +912,186 @@ func ComposedTemplates(pss []v1beta1.PatchSet, cts []v1beta1.ComposedTemplate) (
 	return ct, nil
 }
 
+// resolvePatchSets returns the supplied patches with any patches of type
+// PatchSet replaced by the patches of the PatchSet they name. The supplied
+// chain is the names of the PatchSets that include the supplied patches, in
+// order of inclusion. It's used to detect PatchSets that include themselves.
+func resolvePatchSets(pn map[string][]v1beta1.ComposedPatch, ps []v1beta1.ComposedPatch, chain []string) ([]v1beta1.ComposedPatch, error) {
+	var po []v1beta1.ComposedPatch
+	for _, p := range ps {
+		if p.GetType() != v1beta1.PatchTypePatchSet {
+			po = append(po, p)
+			continue
+		}
+		if p.PatchSetName == nil {
+			return nil, errors.Errorf(errFmtRequiredField, "PatchSetName", p.GetType())
+		}
+		name := *p.PatchSetName
+		if slices.Contains(chain, name) {
+			return nil, errors.Errorf(errFmtPatchSetCycle, name, strings.Join(append(chain, name), " -> "))
+		}
+		nested, ok := pn[name]
+		if !ok {
+			return nil, errors.Errorf(errFmtUndefinedPatchSet, name)
+		}
+		// Use a full slice expression so that appending to the chain in
+		// one branch can't clobber the chain of another.
+		resolved, err := resolvePatchSets(pn, nested, append(chain[:len(chain):len(chain)], name))
+		if err != nil {
+			return nil, err
+		}
+		po = append(po, resolved...)
+	}
+	return po, nil
+}
+
+// BaseFrom returns the JSON encoded base template that the supplied BaseFrom
+// refers to.
+func BaseFrom(bf *v1beta1.BaseFrom, env, fctx *unstructured.Unstructured) ([]byte, error) {
+	from, path := env, ptr.Deref(bf.EnvironmentFieldPath, "")
+	if bf.ContextFieldPath != nil {
+		from, path = fctx, *bf.ContextFieldPath
+	}
+	v, err := fieldpath.Pave(from.Object).GetValue(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(map[string]any); !ok {
+		return nil, errors.Errorf(errFmtBaseFromNotObject, path)
+	}
+	return json.Marshal(v)
+}
+
+// WithDefaultPatches returns the supplied composed resource templates with any
+// supplied default patches prepended to their patches. Templates that skip
+// default patches are returned unchanged.
+func WithDefaultPatches(d *v1beta1.Defaults, cts []v1beta1.ComposedTemplate) []v1beta1.ComposedTemplate {
+	if d == nil || len(d.Patches) == 0 {
+		return cts
+	}
+	ct := make([]v1beta1.ComposedTemplate, len(cts))
+	for i, r := range cts {
+		ct[i] = r
+		if r.SkipDefaultPatches {
+			continue
+		}
+		ct[i].Patches = append(append(make([]v1beta1.ComposedPatch, 0, len(d.Patches)+len(r.Patches)), d.Patches...), r.Patches...)
+	}
+	return ct
+}
+
+// OnPatchFailure returns the policy for handling a failed patch of the supplied
+// resource template. The template's policy takes precedence over the supplied
+// default policy. The fallback is to fail.
+func OnPatchFailure(def *v1beta1.PatchFailurePolicy, t v1beta1.ComposedTemplate) v1beta1.PatchFailurePolicy {
+	switch {
+	case t.OnPatchFailure != nil:
+		return *t.OnPatchFailure
+	case def != nil:
+		return *def
+	default:
+		return v1beta1.PatchFailurePolicyFail
+	}
+}
+
+// unstructuredContent returns the content of the supplied object as a map. If
+// the object is unstructured its content is returned without being copied, so
+// the map must not be modified.
+func unstructuredContent(o runtime.Object) (map[string]any, error) {
+	if u, ok := o.(runtime.Unstructured); ok {
+		return u.UnstructuredContent(), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+}
+
+// fieldValueIsSet returns true if the supplied field path of the supplied
+// object has a value that isn't null, an empty string, an empty array, or an
+// empty object.
+func fieldValueIsSet(o runtime.Object, fieldPath string) (bool, error) {
+	m, err := unstructuredContent(o)
+	if err != nil {
+		return false, err
+	}
+	v, err := fieldpath.Pave(m).GetValue(fieldPath)
+	if fieldpath.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case string:
+		return v != "", nil
+	case []any:
+		return len(v) > 0, nil
+	case map[string]any:
+		return len(v) > 0, nil
+	}
+	return true, nil
+}
+
+// paveObject returns a paved view of the supplied object, and a function that
+// writes changes to the paved view back to the object. If the object is
+// unstructured the paved view shares its content, so there's nothing to write
+// back. This avoids converting the whole object to and from a map each time
+// it's patched. Writes that change more than one field should use
+// paveObjectCopy instead, so they don't partially patch the object if they
+// fail.
+func paveObject(o runtime.Object) (*fieldpath.Paved, func() error, error) {
+	if u, ok := o.(runtime.Unstructured); ok {
+		// UnstructuredContent returns a new, unattached map if the object
+		// has no content.
+		c := u.UnstructuredContent()
+		u.SetUnstructuredContent(c)
+		return fieldpath.Pave(c), func() error { return nil }, nil
+	}
+	p, err := fieldpath.PaveObject(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, func() error {
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(p.UnstructuredContent(), o)
+	}, nil
+}
+
+// paveObjectCopy is like paveObject, except that if the object is
+// unstructured the paved view is of a copy of its content, which the returned
+// function writes back to the object. Nothing is written to the object unless
+// the returned function is called, so a write that changes more than one
+// field either changes all of them or none of them.
+func paveObjectCopy(o runtime.Object) (*fieldpath.Paved, func() error, error) {
+	u, ok := o.(runtime.Unstructured)
+	if !ok {
+		// paveObject already converts other objects to a map, and
+		// only writes it back when asked to.
+		return paveObject(o)
+	}
+	c := runtime.DeepCopyJSON(u.UnstructuredContent())
+	return fieldpath.Pave(c), func() error {
+		u.SetUnstructuredContent(c)
+		return nil
+	}, nil
+}
+
 // patchFieldValueToObject applies the value to the "to" object at the given
 // path, returning any errors as they occur.
 // If no merge options is supplied, then destination field is replaced
 // with the given value.
 func patchFieldValueToObject(fieldPath string, value any, to runtime.Object, mo *xpv1.MergeOptions) error {
-	paved, err := fieldpath.PaveObject(to)
+	pave := paveObject
+	if strings.Contains(fieldPath, "=") {
+		// Resolving key selectors may append array elements before the
+		// value is merged.
+		pave = paveObjectCopy
+	}
+	paved, write, err := pave(to)
+	if err != nil {
+		return err
+	}
+
+	fieldPath, err = resolveKeySelectors(paved, fieldPath)
 	if err != nil {
 		return err
 	}
@@ -377,14 +1100,96 @@ func patchFieldValueToObject(fieldPath string, value any, to runtime.Object, mo
 		return err
 	}
 
-	return runtime.DefaultUnstructuredConverter.FromUnstructured(paved.UnstructuredContent(), to)
+	return write()
+}
+
+// keySelector returns the key and value of the supplied field path segment if
+// it selects an array element by key, for example [name=env].
+func keySelector(s fieldpath.Segment) (key, value string, ok bool) {
+	if s.Type != fieldpath.SegmentField {
+		return "", "", false
+	}
+	key, value, ok = strings.Cut(s.Field, "=")
+	return key, value, ok && key != ""
+}
+
+// resolveKeySelectors replaces any segments of the supplied field path that
+// select an element of an existing array by key with the index of that
+// element. For example spec.tags[name=env].value selects the element of the
+// spec.tags array whose name field is env. If no element matches, a new
+// element with that name is appended to the array. Segments that select from
+// an object, or from a field that doesn't exist, are treated as object keys,
+// because object keys may contain =.
+func resolveKeySelectors(paved *fieldpath.Paved, path string) (string, error) {
+	if !strings.Contains(path, "=") {
+		return path, nil
+	}
+	segs, err := fieldpath.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	for i := 1; i < len(segs); i++ {
+		k, v, ok := keySelector(segs[i])
+		if !ok {
+			continue
+		}
+		prefix := segs[:i].String()
+		cur, err := paved.GetValue(prefix)
+		if fieldpath.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		arr, ok := cur.([]any)
+		if !ok {
+			continue
+		}
+		idx := -1
+		for j, e := range arr {
+			m, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			if ev, ok := m[k]; ok && fmt.Sprint(ev) == v {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(arr)
+			if err := paved.SetValue(prefix, append(arr, map[string]any{k: keySelectorValue(v)})); err != nil {
+				return "", err
+			}
+		}
+		segs[i] = fieldpath.Segment{Type: fieldpath.SegmentIndex, Index: uint(idx)}
+	}
+	return segs.String(), nil
+}
+
+// keySelectorValue returns the supplied key selector value as the JSON type it
+// looks like. For example ports[containerPort=80] appends an element whose
+// containerPort is the number 80, not the string "80". Values that aren't a
+// JSON number or boolean are strings.
+func keySelectorValue(v string) any {
+	var out any
+	if err := json.Unmarshal([]byte(v), &out); err != nil {
+		return v
+	}
+	switch out.(type) {
+	case int64, float64, bool:
+		if c, ok := copyJSONValue(out); ok {
+			return c
+		}
+	}
+	return v
 }
 
 // patchFieldValueToMultiple, given a path with wildcards in an array index,
 // expands the arrays paths in the "to" object and patches the value into each
 // of the resulting fields, returning any errors as they occur.
 func patchFieldValueToMultiple(fieldPath string, value any, to runtime.Object, mo *xpv1.MergeOptions) error {
-	paved, err := fieldpath.PaveObject(to)
+	paved, write, err := paveObjectCopy(to)
 	if err != nil {
 		return err
 	}
@@ -404,5 +1209,145 @@ func patchFieldValueToMultiple(fieldPath string, value any, to runtime.Object, m
 		}
 	}
 
-	return runtime.DefaultUnstructuredConverter.FromUnstructured(paved.UnstructuredContent(), to)
+	return write()
+}
+
+// patchFieldValueToExpandedArray, given a path with one wildcard in an array
+// index, patches each element of the supplied array value to the element with
+// the same index of the wildcard array, creating any elements that don't exist.
+func patchFieldValueToExpandedArray(fieldPath string, value any, to runtime.Object, mo *xpv1.MergeOptions) error {
+	before, after, ok := strings.Cut(fieldPath, "[*]")
+	if !ok || strings.Contains(after, "[*]") {
+		return errors.Errorf(errFmtExpandArraysWildcards, fieldPath)
+	}
+	values, ok := value.([]any)
+	if !ok {
+		return errors.Errorf(errFmtExpandArraysValue, fieldPath, value)
+	}
+
+	paved, write, err := paveObjectCopy(to)
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		if err := paved.MergeValue(fmt.Sprintf("%s[%d]%s", before, i, after), v, mo); err != nil {
+			return err
+		}
+	}
+	return write()
+}
+
+// DeletesKeysNotInSource returns true if any of the supplied patches has the
+// MergeObjectsDeleteKeysNotInSource toFieldPath policy.
+func DeletesKeysNotInSource[T any, PT interface {
+	*T
+	PatchInterface
+}](ps []T) bool {
+	for i := range ps {
+		if PT(&ps[i]).GetPolicy().GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource {
+			return true
+		}
+	}
+	return false
+}
+
+// CarryMergedKeys copies the keys that MergeObjectsDeleteKeysNotInSource
+// patches recorded as merged on the supplied observed object to the supplied
+// desired object, unless the desired object already records them. Desired
+// objects are rendered afresh each time the Function runs, so without this
+// the patches couldn't tell which keys they merged last time.
+func CarryMergedKeys(observed, desired metav1.Object) {
+	v, ok := observed.GetAnnotations()[AnnotationKeyMergedKeys]
+	if !ok {
+		return
+	}
+	a := desired.GetAnnotations()
+	if _, ok := a[AnnotationKeyMergedKeys]; ok {
+		return
+	}
+	if a == nil {
+		a = make(map[string]string, 1)
+	}
+	a[AnnotationKeyMergedKeys] = v
+	desired.SetAnnotations(a)
+}
+
+// patchFieldValueDeleteKeysNotInSource merges the supplied object into the
+// object at the supplied field path, overwriting existing keys. It deletes any
+// keys it merged into the field path last time it was called that aren't in
+// the supplied object. It records the keys it merged in an annotation.
+func patchFieldValueDeleteKeysNotInSource(fieldPath string, value any, to runtime.Object) error {
+	var o metav1.Object
+	switch t := to.(type) {
+	case *composed.Unstructured:
+		o = t
+	case *composite.Unstructured:
+		o = t
+	default:
+		return errors.Errorf(errFmtDeleteKeysTarget, v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource)
+	}
+
+	src, ok := value.(map[string]any)
+	if !ok {
+		return errors.Errorf(errFmtDeleteKeysValue, v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource, value)
+	}
+
+	merged := map[string][]string{}
+	if a := o.GetAnnotations()[AnnotationKeyMergedKeys]; a != "" {
+		if err := json.Unmarshal([]byte(a), &merged); err != nil {
+			return errors.Wrap(err, errUnmarshalMergedKeys)
+		}
+	}
+
+	paved, write, err := paveObjectCopy(to)
+	if err != nil {
+		return err
+	}
+
+	// Keys are recorded by the unresolved field path, because the index of a
+	// selected array element may change.
+	resolved, err := resolveKeySelectors(paved, fieldPath)
+	if err != nil {
+		return err
+	}
+
+	if cur, err := paved.GetValue(resolved); err == nil {
+		if m, ok := cur.(map[string]any); ok {
+			for _, k := range merged[fieldPath] {
+				if _, ok := src[k]; !ok {
+					delete(m, k)
+				}
+			}
+			if err := paved.SetValue(resolved, m); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := paved.MergeValue(resolved, src, &xpv1.MergeOptions{KeepMapValues: ptr.To(false)}); err != nil {
+		return err
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	merged[fieldPath] = keys
+
+	j, err := json.Marshal(merged)
+	if err != nil {
+		return errors.Wrap(err, errMarshalMergedKeys)
+	}
+	a := o.GetAnnotations()
+	if a == nil {
+		a = make(map[string]string, 1)
+	}
+	a[AnnotationKeyMergedKeys] = string(j)
+	o.SetAnnotations(a)
+	return nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -14,6 +15,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
+	"github.com/crossplane/function-sdk-go/resource"
 	"github.com/crossplane/function-sdk-go/resource/composed"
 	"github.com/crossplane/function-sdk-go/resource/composite"
 
@@ -84,6 +86,53 @@ func TestApplyFromFieldPathPatch(t *testing.T) {
 				err: nil,
 			},
 		},
+		"WholeObject": {
+			reason: "A from field path of '.' should read the whole source object",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("."),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.manifest"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeString,
+							String: &v1beta1.StringTransform{
+								Type:    v1beta1.StringTransformTypeConvert,
+								Convert: ptr.To(v1beta1.StringConversionTypeToJSON),
+							},
+						}},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"size": 3
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"manifest": "{\"apiVersion\":\"test.crossplane.io/v1\",\"kind\":\"XR\",\"spec\":{\"size\":3}}"
+								}
+							}
+						}`)},
+				},
+			},
+		},
 		"ValidFromFieldPathWithWildcards": {
 			reason: "When passed a wildcarded path, adds a field to each element of an array",
 			args: args{
@@ -309,112 +358,74 @@ func TestApplyFromFieldPathPatch(t *testing.T) {
 				err: nil,
 			},
 		},
-	}
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			err := ApplyFromFieldPathPatch(tc.args.p, tc.args.from, tc.args.to)
-
-			if diff := cmp.Diff(tc.want.to, tc.args.to); diff != "" {
-				t.Errorf("\n%s\nApplyFromFieldPathPatch(...): -want, +got:\n%s", tc.reason, diff)
-			}
-
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nApplyFromFieldPathPatch(): -want error, +got error:\n%s", tc.reason, diff)
-			}
-		})
-	}
-}
-
-func TestApplyCombineFromVariablesPatch(t *testing.T) {
-	errNotFound := func(path string) error {
-		p := &fieldpath.Paved{}
-		_, err := p.GetValue(path)
-		return err
-	}
-
-	type args struct {
-		p    PatchInterface
-		from runtime.Object
-		to   runtime.Object
-	}
-
-	type want struct {
-		to  runtime.Object
-		err error
-	}
-
-	cases := map[string]struct {
-		reason string
-		args
-		want
-	}{
-		"VariableFromFieldPathNotFound": {
-			reason: "Should return no error and not apply patch if an optional variable is missing",
+		"ToFieldPathTemplateFromInput": {
+			reason: "Should render a toFieldPath template using the patch's input value",
 			args: args{
 				p: &v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeCombineFromComposite,
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
 					Patch: v1beta1.Patch{
-						Combine: &v1beta1.Combine{
-							Variables: []v1beta1.CombineVariable{
-								{FromFieldPath: "metadata.labels.source1"},
-								{FromFieldPath: "metadata.labels.source2"},
-								{FromFieldPath: "metadata.labels.source3"},
-							},
-							Strategy: v1beta1.CombineStrategyString,
-							String:   &v1beta1.StringCombine{Format: "%s-%s"},
-						},
-						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+						FromFieldPath: ptr.To[string]("spec.tag"),
+						ToFieldPath:   ptr.To[string]("spec.tags[{{ .key }}]"),
 					},
 				},
 				from: &composite.Unstructured{
 					Unstructured: unstructured.Unstructured{Object: MustObject(`{
 							"apiVersion": "test.crossplane.io/v1",
-							"kind": "XR"
+							"kind": "XR",
+							"spec": {
+								"tag": {
+									"key": "env"
+								}
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
 						}`)},
 				},
 			},
 			want: want{
-				err: errNotFound("metadata"),
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"tags": {
+									"env": {
+										"key": "env"
+									}
+								}
+							}
+						}`)},
+				},
 			},
 		},
-		"ValidCombineFromComposite": {
-			reason: "Should correctly apply a CombineFromComposite patch with valid settings",
+		"ToFieldPathTemplateFromFieldPath": {
+			reason: "Should render a toFieldPath template using another field of the resource the patch is from",
 			args: args{
 				p: &v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeCombineFromComposite,
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
 					Patch: v1beta1.Patch{
-						Combine: &v1beta1.Combine{
-							Variables: []v1beta1.CombineVariable{
-								{FromFieldPath: "metadata.labels.source1"},
-								{FromFieldPath: "metadata.labels.source2"},
-							},
-							Strategy: v1beta1.CombineStrategyString,
-							String:   &v1beta1.StringCombine{Format: "%s-%s"},
-						},
-						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+						FromFieldPath: ptr.To[string]("spec.tagValue"),
+						ToFieldPath:   ptr.To[string](`spec.tags[{{ fromFieldPath "spec.tagKey" }}]`),
 					},
 				},
 				from: &composite.Unstructured{
 					Unstructured: unstructured.Unstructured{Object: MustObject(`{
 							"apiVersion": "test.crossplane.io/v1",
 							"kind": "XR",
-							"metadata": {
-								"labels": {
-									"source1": "foo",
-									"source2": "bar"
-								}
+							"spec": {
+								"tagKey": "env",
+								"tagValue": "prod"
 							}
 						}`)},
 				},
 				to: &composed.Unstructured{
 					Unstructured: unstructured.Unstructured{Object: MustObject(`{
 							"apiVersion": "test.crossplane.io/v1",
-							"kind": "Composed",
-							"metadata": {
-								"labels": {
-									"test": "blah"
-								}
-							}
+							"kind": "Composed"
 						}`)},
 				},
 			},
@@ -423,119 +434,1334 @@ func TestApplyCombineFromVariablesPatch(t *testing.T) {
 					Unstructured: unstructured.Unstructured{Object: MustObject(`{
 							"apiVersion": "test.crossplane.io/v1",
 							"kind": "Composed",
-							"metadata": {
-								"labels": {
-									"destination": "foo-bar",
-									"test": "blah"
+							"spec": {
+								"tags": {
+									"env": "prod"
 								}
 							}
 						}`)},
 				},
-				err: nil,
 			},
 		},
-	}
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			err := ApplyCombineFromVariablesPatch(tc.args.p, tc.args.from, tc.args.to)
-
-			if diff := cmp.Diff(tc.want.to, tc.args.to); diff != "" {
-				t.Errorf("\n%s\nApplyCombineFromVariablesPatch(...): -want, +got:\n%s", tc.reason, diff)
-			}
-
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nApplyCombineFromVariablesPatch(): -want error, +got error:\n%s", tc.reason, diff)
-			}
-		})
-	}
-}
-
-func MustObject(j string) map[string]any {
-	out := map[string]any{}
-	if err := json.Unmarshal([]byte(j), &out); err != nil {
-		panic(err)
-	}
-	return out
-}
-
-func TestComposedTemplates(t *testing.T) {
-	asJSON := func(val interface{}) extv1.JSON {
-		raw, err := json.Marshal(val)
-		if err != nil {
-			t.Fatal(err)
-		}
-		res := extv1.JSON{}
-		if err := json.Unmarshal(raw, &res); err != nil {
-			t.Fatal(err)
-		}
-		return res
-	}
-
-	type args struct {
-		pss []v1beta1.PatchSet
-		cts []v1beta1.ComposedTemplate
-	}
-
-	type want struct {
-		ct  []v1beta1.ComposedTemplate
-		err error
-	}
-
-	cases := map[string]struct {
-		reason string
-		args
-		want
-	}{
-		"NoCompositionPatchSets": {
-			reason: "Patches defined on a composite resource should be applied correctly if no PatchSets are defined on the composition",
+		"ToFieldPathTemplateError": {
+			reason: "Should return an error if a toFieldPath template can't be rendered",
 			args: args{
-				cts: []v1beta1.ComposedTemplate{
-					{
-						Patches: []v1beta1.ComposedPatch{
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.name"),
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.namespace"),
-								},
-							},
-						},
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.tag"),
+						ToFieldPath:   ptr.To[string]("spec.tags[{{ .key }}]"),
 					},
 				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"tag": {}
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
 			},
 			want: want{
-				ct: []v1beta1.ComposedTemplate{
-					{
-						Patches: []v1beta1.ComposedPatch{
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.name"),
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.namespace"),
-								},
-							},
-						},
-					},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
 				},
+				err: errors.Wrap(errors.New(`template: toFieldPath:1:13: executing "toFieldPath" at <.key>: map has no entry for key "key"`), errRenderToFieldPath),
 			},
 		},
-		"UndefinedPatchSet": {
-			reason: "Should return error and not modify the patches field when referring to an undefined PatchSet",
+		"MergeObjectsDeleteKeysNotInSource": {
+			reason: "Should merge keys from the source, and delete keys it merged last time that are no longer in the source",
 			args: args{
-				cts: []v1beta1.ComposedTemplate{{
-					Patches: []v1beta1.ComposedPatch{
-						{
-							Type:         v1beta1.PatchTypePatchSet,
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.tags"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource),
+						},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"tags": {
+									"env": "prod",
+									"team": "platform"
+								}
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {
+								"annotations": {
+									"pt.fn.crossplane.io/merged-keys": "{\"spec.forProvider.tags\":[\"env\",\"owner\"]}"
+								}
+							},
+							"spec": {
+								"forProvider": {
+									"tags": {
+										"env": "dev",
+										"owner": "alice",
+										"managed-by": "someone-else"
+									}
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {
+								"annotations": {
+									"pt.fn.crossplane.io/merged-keys": "{\"spec.forProvider.tags\":[\"env\",\"team\"]}"
+								}
+							},
+							"spec": {
+								"forProvider": {
+									"tags": {
+										"env": "prod",
+										"team": "platform",
+										"managed-by": "someone-else"
+									}
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"MergeObjectsDeleteKeysNotInSourceNotAnObject": {
+			reason: "Should return an error if the source isn't an object",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.tags"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource),
+						},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"tags": "env"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+				err: errors.Errorf(errFmtDeleteKeysValue, v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource, "env"),
+			},
+		},
+		"SetIfEmptyAlreadySet": {
+			reason: "Should not overwrite a target field that already has a value",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.region"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.region"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicySetIfEmpty),
+						},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"region": "us-west-2"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"region": "eu-west-1"
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"region": "eu-west-1"
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"SetIfEmptyEmptyString": {
+			reason: "Should write to a target field whose value is an empty string",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.region"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.region"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicySetIfEmpty),
+						},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"region": "us-west-2"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"region": ""
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"region": "us-west-2"
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"KeySelectorMatchesElement": {
+			reason: "Should patch into the array element selected by key",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.env"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags[name=env].value"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"env": "prod"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"tags": [
+										{"name": "team", "value": "platform"},
+										{"name": "env", "value": "dev"}
+									]
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"tags": [
+										{"name": "team", "value": "platform"},
+										{"name": "env", "value": "prod"}
+									]
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"KeySelectorAppendsElement": {
+			reason: "Should append an array element with the selected key if none matches",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.env"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags[name=env].value"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"env": "prod"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"tags": [
+										{"name": "team", "value": "platform"}
+									]
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"tags": [
+										{"name": "team", "value": "platform"},
+										{"name": "env", "value": "prod"}
+									]
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"ExpandArrays": {
+			reason: "Should create the elements a wildcarded path patches, one per element of the value, if expandArrays is true",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.zones"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.subnets[*].zone"),
+						Policy:        &v1beta1.PatchPolicy{ExpandArrays: ptr.To(true)},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"zones": ["us-east-2a", "us-east-2b", "us-east-2c"]
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"subnets": [
+										{
+											"cidr": "10.0.0.0/24"
+										}
+									]
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"subnets": [
+										{
+											"cidr": "10.0.0.0/24",
+											"zone": "us-east-2a"
+										},
+										{
+											"zone": "us-east-2b"
+										},
+										{
+											"zone": "us-east-2c"
+										}
+									]
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"ExpandArraysNotArray": {
+			reason: "Should return an error if expandArrays is true and the value isn't an array",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.zone"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.subnets[*].zone"),
+						Policy:        &v1beta1.PatchPolicy{ExpandArrays: ptr.To(true)},
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"zone": "us-east-2a"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+				err: errors.Errorf(errFmtExpandArraysValue, "spec.forProvider.subnets[*].zone", "us-east-2a"),
+			},
+		},
+		"KeySelectorMissingParent": {
+			reason: "Should treat a key selector as an object key if the field it selects from doesn't exist",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.value"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags[env=prod]"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"value": "prod"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {"forProvider": {"tags": {"env=prod": "prod"}}}
+						}`)},
+				},
+			},
+		},
+		"KeySelectorAppendsTypedElement": {
+			reason: "Should append an array element whose selected key has the JSON type of the key selector's value",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.value"),
+						ToFieldPath:   ptr.To[string]("spec.ports[containerPort=80].protocol"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"value": "TCP"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {"ports": [{"containerPort": 443, "protocol": "TCP"}]}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {"ports": [{"containerPort": 443, "protocol": "TCP"}, {"containerPort": 80, "protocol": "TCP"}]}
+						}`)},
+				},
+			},
+		},
+		"KeySelectorMatchesTypedElement": {
+			reason: "Should patch into the array element whose selected key is a number",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.value"),
+						ToFieldPath:   ptr.To[string]("spec.ports[containerPort=80].protocol"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"value": "UDP"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {"ports": [{"containerPort": 80, "protocol": "TCP"}]}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {"ports": [{"containerPort": 80, "protocol": "UDP"}]}
+						}`)},
+				},
+			},
+		},
+		"KeySelectorOnObject": {
+			reason: "Should treat a key selector as an object key if it selects from an object",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.env"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.params[a=b]"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {
+								"env": "prod"
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"params": {}
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"spec": {
+								"forProvider": {
+									"params": {
+										"a=b": "prod"
+									}
+								}
+							}
+						}`)},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ApplyFromFieldPathPatch(tc.args.p, tc.args.from, tc.args.to)
+
+			if diff := cmp.Diff(tc.want.to, tc.args.to); diff != "" {
+				t.Errorf("\n%s\nApplyFromFieldPathPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyFromFieldPathPatch(): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyFromFieldPathPatchFailureIsAtomic(t *testing.T) {
+	from := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+		"apiVersion": "test.crossplane.io/v1",
+		"kind": "XR",
+		"spec": {
+			"name": "cool",
+			"names": ["a", "b"]
+		}
+	}`)}}
+
+	cases := map[string]struct {
+		reason string
+		p      PatchInterface
+		to     string
+	}{
+		"Wildcard": {
+			reason: "A patch to a wildcard that fails on a later element shouldn't patch the earlier elements.",
+			p: &v1beta1.ComposedPatch{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To[string]("spec.names"),
+					ToFieldPath:   ptr.To[string]("spec.items[*].names"),
+					Policy:        &v1beta1.PatchPolicy{ToFieldPath: ptr.To(v1beta1.ToFieldPathPolicyForceMergeObjectsAppendArrays)},
+				},
+			},
+			to: `{"spec": {"items": [{"names": []}, {"names": "not-an-array"}]}}`,
+		},
+		"ExpandArrays": {
+			reason: "A patch that expands an array and fails on a later element shouldn't patch the earlier elements.",
+			p: &v1beta1.ComposedPatch{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To[string]("spec.names"),
+					ToFieldPath:   ptr.To[string]("spec.items[*].name"),
+					Policy:        &v1beta1.PatchPolicy{ExpandArrays: ptr.To(true)},
+				},
+			},
+			to: `{"spec": {"items": [{}, "not-an-object"]}}`,
+		},
+		"KeySelector": {
+			reason: "A patch to a key selector that fails shouldn't leave the array element it appended.",
+			p: &v1beta1.ComposedPatch{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To[string]("spec.name"),
+					ToFieldPath:   ptr.To[string]("spec.ports[name=https].name.first"),
+				},
+			},
+			to: `{"spec": {"ports": [{"name": "http"}]}}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			want := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(tc.to)}}
+			got := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(tc.to)}}
+			if err := ApplyFromFieldPathPatch(tc.p, from, got); err == nil {
+				t.Fatalf("\n%s\nApplyFromFieldPathPatch(...): want error, got nil", tc.reason)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("\n%s\nApplyFromFieldPathPatch(...): -want unchanged, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCopyJSONValue(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		value  any
+		ok     bool
+	}{
+		"Scalars": {
+			reason: "Scalars should be copied.",
+			value:  []any{nil, "s", true, int64(1), 2, int32(3), float64(4), 4.5, float64(-1 << 53)},
+			ok:     true,
+		},
+		"LargeNumber": {
+			reason: "Numbers JSON can't encode exactly should be rejected.",
+			value:  float64(1 << 62),
+		},
+		"Nested": {
+			reason: "Nested objects and arrays should be copied.",
+			value: map[string]any{
+				"a": map[string]any{"b": []any{float64(1), map[string]any{"c": 2.5}}},
+			},
+			ok: true,
+		},
+		"UnsupportedType": {
+			reason: "Types JSON doesn't decode to should be rejected.",
+			value:  map[string]any{"a": []string{"b"}},
+		},
+		"NaN": {
+			reason: "Numbers JSON can't encode should be rejected.",
+			value:  math.NaN(),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := copyJSONValue(tc.value)
+			if ok != tc.ok {
+				t.Fatalf("\n%s\ncopyJSONValue(...): want ok %t, got %t", tc.reason, tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			want, err := toValidJSON(tc.value)
+			if err != nil {
+				t.Fatalf("\n%s\ntoValidJSON(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("\n%s\ncopyJSONValue(...): should return what toValidJSON returns: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSetJSONValue(t *testing.T) {
+	content := func() map[string]any {
+		return map[string]any{
+			"spec": map[string]any{
+				"region": "us-east-1",
+				"ports":  []any{map[string]any{"port": int64(80)}},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		reason string
+		path   string
+		ok     bool
+	}{
+		"ExistingField": {
+			reason: "An existing field should be set.",
+			path:   "spec.region",
+			ok:     true,
+		},
+		"MissingParents": {
+			reason: "Missing parent objects should be created.",
+			path:   "spec.forProvider.tags.team",
+			ok:     true,
+		},
+		"ExistingArrayElement": {
+			reason: "A field of an existing array element should be set.",
+			path:   "spec.ports[0].name",
+			ok:     true,
+		},
+		"ArrayOutOfRange": {
+			reason: "A path that would extend an array should be left to fieldpath.Paved.",
+			path:   "spec.ports[1].name",
+		},
+		"MissingArray": {
+			reason: "A path that would create an array should be left to fieldpath.Paved.",
+			path:   "spec.rules[0].name",
+		},
+		"NotAnObject": {
+			reason: "A path through a field that isn't an object should be left to fieldpath.Paved.",
+			path:   "spec.region.name",
+		},
+		"Wildcard": {
+			reason: "A path with a wildcard should be left to fieldpath.Paved.",
+			path:   "spec.ports[*].name",
+		},
+		"KeySelector": {
+			reason: "A path with a key selector should be left to fieldpath.Paved.",
+			path:   "spec.ports[port=80].name",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := content()
+			ok := setJSONValue(got, tc.path, "cool")
+			if ok != tc.ok {
+				t.Fatalf("\n%s\nsetJSONValue(...): want ok %t, got %t", tc.reason, tc.ok, ok)
+			}
+			want := content()
+			if ok {
+				if err := fieldpath.Pave(want).SetValue(tc.path, "cool"); err != nil {
+					t.Fatalf("\n%s\nSetValue(...): %s", tc.reason, err)
+				}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("\n%s\nsetJSONValue(...): should do what SetValue does, or nothing: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// BenchmarkApplyFromFieldPathPatch compares patches that ApplyFromFieldPathPatch
+// writes directly (Fast) to the same patches written through the JSON
+// round-trips and fieldpath.Paved (Slow).
+func BenchmarkApplyFromFieldPathPatch(b *testing.B) {
+	from := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "XR",
+		"spec": map[string]any{
+			"parameters": map[string]any{
+				"region": "us-east-2",
+				"tags":   map[string]any{"team": "a", "env": "prod", "app": "db"},
+				"sizes":  []any{float64(1), float64(2), float64(3)},
+			},
+		},
+	}}}
+
+	cases := map[string]*v1beta1.ComposedPatch{
+		"Replace": {
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To[string]("spec.parameters"),
+				ToFieldPath:   ptr.To[string]("spec.forProvider"),
+			},
+		},
+		"ReplaceString": {
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To[string]("spec.parameters.region"),
+				ToFieldPath:   ptr.To[string]("spec.forProvider.region"),
+			},
+		},
+	}
+
+	// slow writes the patch the way patches that can't be written directly
+	// are written.
+	slow := func(p *v1beta1.ComposedPatch, from, to runtime.Object) error {
+		fromMap, err := unstructuredContent(from)
+		if err != nil {
+			return err
+		}
+		in, err := FromFieldPathValue(fromMap, p.GetFromFieldPath())
+		if err != nil {
+			return err
+		}
+		v, err := toValidJSON(in)
+		if err != nil {
+			return err
+		}
+		return patchFieldValueToObject(p.GetToFieldPath(), v, to, nil)
+	}
+
+	for name, p := range cases {
+		b.Run(name+"/Fast", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				to := composed.New()
+				if err := ApplyFromFieldPathPatch(p, from, to); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/Slow", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				to := composed.New()
+				if err := slow(p, from, to); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyCombineFromVariablesPatch(t *testing.T) {
+	errNotFound := func(path string) error {
+		p := &fieldpath.Paved{}
+		_, err := p.GetValue(path)
+		return err
+	}
+
+	type args struct {
+		p    PatchInterface
+		from runtime.Object
+		to   runtime.Object
+		srcs *CombineSources
+	}
+
+	type want struct {
+		to  runtime.Object
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"VariableFromFieldPathNotFound": {
+			reason: "Should return no error and not apply patch if an optional variable is missing",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{FromFieldPath: "metadata.labels.source2"},
+								{FromFieldPath: "metadata.labels.source3"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR"
+						}`)},
+				},
+			},
+			want: want{
+				err: errNotFound("metadata"),
+			},
+		},
+		"VariableSources": {
+			reason: "Should read variables with a source from that source, and other variables from the patch's source",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "spec.claimRef.name"},
+								{FromFieldPath: "region", Source: &v1beta1.CombineVariableSource{Type: v1beta1.CombineVariableSourceTypeEnvironment}},
+								{FromFieldPath: "status.index", Source: &v1beta1.CombineVariableSource{Type: v1beta1.CombineVariableSourceTypeComposed, ResourceName: ptr.To("counter")}},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s-%d"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.name"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"spec": {"claimRef": {"name": "cool-claim"}}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+				srcs: &CombineSources{
+					Environment: &unstructured.Unstructured{Object: MustObject(`{"region": "us-west-2"}`)},
+					Composed: map[resource.Name]resource.ObservedComposed{
+						"counter": {Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{"status": {"index": 3}}`)}}},
+					},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {"name": "cool-claim-us-west-2-3"}
+						}`)},
+				},
+			},
+		},
+		"VariableSourceComposedNotObserved": {
+			reason: "Should return a not found error if a variable's source is a composed resource that doesn't exist yet",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "status.index", Source: &v1beta1.CombineVariableSource{Type: v1beta1.CombineVariableSourceTypeComposed, ResourceName: ptr.To("counter")}},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%d"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.name"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR"
+						}`)},
+				},
+				srcs: &CombineSources{},
+			},
+			want: want{
+				err: errNotFound("status"),
+			},
+		},
+		"OptionalVariableDefault": {
+			reason: "Should combine an optional variable's default value if its field path doesn't exist",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{
+									FromFieldPath: "metadata.labels.source2",
+									Policy:        ptr.To(v1beta1.FromFieldPathPolicyOptional),
+									Default:       &extv1.JSON{Raw: []byte(`"default"`)},
+								},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"metadata": {
+								"labels": {
+									"source1": "foo"
+								}
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed"
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {
+								"labels": {
+									"destination": "foo-default"
+								}
+							}
+						}`)},
+				},
+			},
+		},
+		"ValidCombineFromComposite": {
+			reason: "Should correctly apply a CombineFromComposite patch with valid settings",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{FromFieldPath: "metadata.labels.source2"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				from: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"metadata": {
+								"labels": {
+									"source1": "foo",
+									"source2": "bar"
+								}
+							}
+						}`)},
+				},
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {
+								"labels": {
+									"test": "blah"
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				to: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {
+								"labels": {
+									"destination": "foo-bar",
+									"test": "blah"
+								}
+							}
+						}`)},
+				},
+				err: nil,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fn := ApplyCombineFromVariablesPatch
+			if tc.args.srcs != nil {
+				fn = CombineFromVariables(tc.args.srcs)
+			}
+			err := fn(tc.args.p, tc.args.from, tc.args.to)
+
+			if diff := cmp.Diff(tc.want.to, tc.args.to); diff != "" {
+				t.Errorf("\n%s\nApplyCombineFromVariablesPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyCombineFromVariablesPatch(): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	type args struct {
+		c    v1beta1.Combine
+		vars []any
+	}
+	type want struct {
+		out  any
+		vars []any
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Join": {
+			reason: "The Join strategy should join strings, numbers, and booleans with the separator.",
+			args: args{
+				c:    v1beta1.Combine{Strategy: v1beta1.CombineStrategyJoin, Join: &v1beta1.JoinCombine{Separator: "-"}},
+				vars: []any{"db", float64(2), true},
+			},
+			want: want{
+				out:  "db-2-true",
+				vars: []any{"db", float64(2), true},
+			},
+		},
+		"JoinObject": {
+			reason: "The Join strategy should return an error if a variable is an object.",
+			args: args{
+				c:    v1beta1.Combine{Strategy: v1beta1.CombineStrategyJoin, Join: &v1beta1.JoinCombine{Separator: "-"}},
+				vars: []any{"db", map[string]any{}},
+			},
+			want: want{
+				vars: []any{"db", map[string]any{}},
+				err:  errors.Wrapf(errors.Errorf(errFmtCombineVariableType, 1, "a string, number, or boolean", map[string]any{}), errFmtCombineStrategyFailed, v1beta1.CombineStrategyJoin),
+			},
+		},
+		"Object": {
+			reason: "The Object strategy should return an object keyed by variable name.",
+			args: args{
+				c: v1beta1.Combine{Strategy: v1beta1.CombineStrategyObject, Variables: []v1beta1.CombineVariable{
+					{FromFieldPath: "spec.region", Name: "region"},
+					{FromFieldPath: "spec.tags", Name: "tags"},
+				}},
+				vars: []any{"eu-west-1", map[string]any{"team": "a"}},
+			},
+			want: want{
+				out:  map[string]any{"region": "eu-west-1", "tags": map[string]any{"team": "a"}},
+				vars: []any{"eu-west-1", map[string]any{"team": "a"}},
+			},
+		},
+		"Merge": {
+			reason: "The Merge strategy should deep merge objects, preferring later variables, without modifying them.",
+			args: args{
+				c: v1beta1.Combine{Strategy: v1beta1.CombineStrategyMerge},
+				vars: []any{
+					map[string]any{"tags": map[string]any{"team": "a", "env": "dev"}, "size": "small"},
+					map[string]any{"tags": map[string]any{"env": "prod"}},
+				},
+			},
+			want: want{
+				out: map[string]any{"tags": map[string]any{"team": "a", "env": "prod"}, "size": "small"},
+				vars: []any{
+					map[string]any{"tags": map[string]any{"team": "a", "env": "dev"}, "size": "small"},
+					map[string]any{"tags": map[string]any{"env": "prod"}},
+				},
+			},
+		},
+		"MergeString": {
+			reason: "The Merge strategy should return an error if a variable isn't an object.",
+			args: args{
+				c:    v1beta1.Combine{Strategy: v1beta1.CombineStrategyMerge},
+				vars: []any{map[string]any{}, "db"},
+			},
+			want: want{
+				vars: []any{map[string]any{}, "db"},
+				err:  errors.Wrapf(errors.Errorf(errFmtCombineVariableType, 1, "an object", "db"), errFmtCombineStrategyFailed, v1beta1.CombineStrategyMerge),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := Combine(tc.args.c, tc.args.vars)
+
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("\n%s\nCombine(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.vars, tc.args.vars); diff != "" {
+				t.Errorf("\n%s\nCombine(...): -want vars, +got vars:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCombine(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func MustObject(j string) map[string]any {
+	out := map[string]any{}
+	if err := json.Unmarshal([]byte(j), &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func TestComposedTemplates(t *testing.T) {
+	asJSON := func(val interface{}) extv1.JSON {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := extv1.JSON{}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	type args struct {
+		pss []v1beta1.PatchSet
+		cts []v1beta1.ComposedTemplate
+	}
+
+	type want struct {
+		ct  []v1beta1.ComposedTemplate
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoCompositionPatchSets": {
+			reason: "Patches defined on a composite resource should be applied correctly if no PatchSets are defined on the composition",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				ct: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"UndefinedPatchSet": {
+			reason: "Should return error and not modify the patches field when referring to an undefined PatchSet",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{
+					Patches: []v1beta1.ComposedPatch{
+						{
+							Type:         v1beta1.PatchTypePatchSet,
 							PatchSetName: ptr.To[string]("patch-set-1"),
 						},
 					},
@@ -595,111 +1821,606 @@ func TestComposedTemplates(t *testing.T) {
 						},
 					},
 				},
-				cts: []v1beta1.ComposedTemplate{
-					{
-						Patches: []v1beta1.ComposedPatch{
-							{
-								Type:         v1beta1.PatchTypePatchSet,
-								PatchSetName: ptr.To[string]("patch-set-2"),
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.name"),
-								},
-							},
-							{
-								Type:         v1beta1.PatchTypePatchSet,
-								PatchSetName: ptr.To[string]("patch-set-1"),
-							},
-						},
+				cts: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("patch-set-2"),
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("patch-set-1"),
+							},
+						},
+					},
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("patch-set-1"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: nil,
+				ct: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-1"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-2"),
+									Transforms: []v1beta1.Transform{{
+										Type: v1beta1.TransformTypeMap,
+										Map: &v1beta1.MapTransform{
+											Pairs: map[string]extv1.JSON{
+												"k-1": asJSON("v-1"),
+												"k-2": asJSON("v-2"),
+											},
+										},
+									}},
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("spec.parameters.test"),
+								},
+							},
+						},
+					},
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("spec.parameters.test"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"NestedPatchSets": {
+			reason: "Should recursively de-reference PatchSets included by other PatchSets",
+			args: args{
+				pss: []v1beta1.PatchSet{
+					{
+						Name: "org",
+						Patches: []v1beta1.PatchSetPatch{
+							{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.org")}},
+						},
+					},
+					{
+						Name: "team",
+						Patches: []v1beta1.PatchSetPatch{
+							{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("org")},
+							{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.team")}},
+						},
+					},
+				},
+				cts: []v1beta1.ComposedTemplate{{
+					Name: "cool-resource",
+					Patches: []v1beta1.ComposedPatch{
+						{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("team")},
+						{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("org")},
+						{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.resource")}},
+					},
+				}},
+			},
+			want: want{
+				ct: []v1beta1.ComposedTemplate{{
+					Name: "cool-resource",
+					Patches: []v1beta1.ComposedPatch{
+						{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.org")}},
+						{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.team")}},
+						{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.org")}},
+						{Type: v1beta1.PatchTypeFromCompositeFieldPath, Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.resource")}},
+					},
+				}},
+			},
+		},
+		"PatchSetCycle": {
+			reason: "Should return an error if a PatchSet includes itself via another PatchSet",
+			args: args{
+				pss: []v1beta1.PatchSet{
+					{
+						Name: "org",
+						Patches: []v1beta1.PatchSetPatch{
+							{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("team")},
+						},
+					},
+					{
+						Name: "team",
+						Patches: []v1beta1.PatchSetPatch{
+							{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("org")},
+						},
+					},
+				},
+				cts: []v1beta1.ComposedTemplate{{
+					Patches: []v1beta1.ComposedPatch{
+						{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("org")},
+					},
+				}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtPatchSetCycle, "org", "org -> team -> org"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ComposedTemplates(tc.args.pss, tc.args.cts)
+
+			if diff := cmp.Diff(tc.want.ct, got); diff != "" {
+				t.Errorf("\n%s\nrs.ComposedTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nrs.ComposedTemplates(...)): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyToCompositePatch(t *testing.T) {
+	type args struct {
+		p       PatchInterface
+		from    runtime.Object
+		oxr     *composite.Unstructured
+		dxr     *composite.Unstructured
+		allowed map[string]bool
+	}
+	type want struct {
+		dxr *composite.Unstructured
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"MergeLabels": {
+			reason: "Replacing all of the XR's labels should merge them with its existing labels.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.labels"),
+						ToFieldPath:   ptr.To[string]("metadata.labels"),
+					},
+				},
+				from: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"spec": map[string]any{
+						"labels": map[string]any{"team": "a", "env": "prod"},
+					},
+				}}},
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"metadata": map[string]any{
+						"labels": map[string]any{"team": "b", "app": "db"},
+					},
+				}}},
+			},
+			want: want{
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"metadata": map[string]any{
+						"labels": map[string]any{"team": "a", "env": "prod", "app": "db"},
+					},
+				}}},
+			},
+		},
+		"ProtectedAnnotation": {
+			reason: "Patching an XR annotation managed by Crossplane should return an error and leave the XR unchanged.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.annotations"),
+						ToFieldPath:   ptr.To[string]("metadata.annotations"),
+					},
+				},
+				from: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"spec": map[string]any{
+						"annotations": map[string]any{"crossplane.io/paused": "true"},
+					},
+				}}},
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"metadata": map[string]any{
+						"annotations": map[string]any{"note": "hi"},
+					},
+				}}},
+			},
+			want: want{
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"metadata": map[string]any{
+						"annotations": map[string]any{"note": "hi"},
+					},
+				}}},
+				err: errors.Errorf(errFmtProtectedMetadata, "crossplane.io/paused", protectedMetadataPrefix),
+			},
+		},
+		"AllowedAnnotation": {
+			reason: "Patching an XR annotation managed by Crossplane should succeed if the annotation is allowed.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.paused"),
+						ToFieldPath:   ptr.To[string]("metadata.annotations[crossplane.io/paused]"),
+					},
+				},
+				from: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"spec":       map[string]any{"paused": "true"},
+				}}},
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+				}}},
+				allowed: map[string]bool{"crossplane.io/paused": true},
+			},
+			want: want{
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"metadata": map[string]any{
+						"annotations": map[string]any{"crossplane.io/paused": "true"},
 					},
-					{
-						Patches: []v1beta1.ComposedPatch{
-							{
-								Type:         v1beta1.PatchTypePatchSet,
-								PatchSetName: ptr.To[string]("patch-set-1"),
-							},
+				}}},
+			},
+		},
+		"OverwriteIfUnsetSet": {
+			reason: "A patch that only overwrites unset fields should patch the observed XR's value if it's set.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.region"),
+						ToFieldPath:   ptr.To[string]("spec.parameters.region"),
+						Policy: &v1beta1.PatchPolicy{
+							Overwrite: ptr.To(v1beta1.OverwritePolicyIfUnset),
 						},
 					},
 				},
+				from: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"spec": map[string]any{
+						"forProvider": map[string]any{"region": "us-east-2"},
+					},
+				}}},
+				oxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"spec": map[string]any{
+						"parameters": map[string]any{"region": "eu-west-1"},
+					},
+				}}},
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+				}}},
 			},
 			want: want{
-				err: nil,
-				ct: []v1beta1.ComposedTemplate{
-					{
-						Patches: []v1beta1.ComposedPatch{
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-1"),
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-2"),
-									Transforms: []v1beta1.Transform{{
-										Type: v1beta1.TransformTypeMap,
-										Map: &v1beta1.MapTransform{
-											Pairs: map[string]extv1.JSON{
-												"k-1": asJSON("v-1"),
-												"k-2": asJSON("v-2"),
-											},
-										},
-									}},
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.name"),
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.namespace"),
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("spec.parameters.test"),
-								},
-							},
-						},
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"spec": map[string]any{
+						"parameters": map[string]any{"region": "eu-west-1"},
 					},
-					{
-						Patches: []v1beta1.ComposedPatch{
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("metadata.namespace"),
-								},
-							},
-							{
-								Type: v1beta1.PatchTypeFromCompositeFieldPath,
-								Patch: v1beta1.Patch{
-									FromFieldPath: ptr.To[string]("spec.parameters.test"),
-								},
-							},
+				}}},
+			},
+		},
+		"OverwriteIfUnsetUnset": {
+			reason: "A patch that only overwrites unset fields should be applied if the observed XR's field is unset.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.region"),
+						ToFieldPath:   ptr.To[string]("spec.parameters.region"),
+						Policy: &v1beta1.PatchPolicy{
+							Overwrite: ptr.To(v1beta1.OverwritePolicyIfUnset),
 						},
 					},
 				},
+				from: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "CD",
+					"spec": map[string]any{
+						"forProvider": map[string]any{"region": "us-east-2"},
+					},
+				}}},
+				oxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+				}}},
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+				}}},
+			},
+			want: want{
+				dxr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"spec": map[string]any{
+						"parameters": map[string]any{"region": "us-east-2"},
+					},
+				}}},
 			},
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := ComposedTemplates(tc.args.pss, tc.args.cts)
+			err := ApplyToCompositePatch(ApplyFromFieldPathPatch, tc.args.p, tc.args.from, tc.args.oxr, tc.args.dxr, tc.args.allowed)
+			if diff := cmp.Diff(tc.want.dxr, tc.args.dxr); diff != "" {
+				t.Errorf("\n%s\nApplyToCompositePatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyToCompositePatch(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
 
-			if diff := cmp.Diff(tc.want.ct, got); diff != "" {
-				t.Errorf("\n%s\nrs.ComposedTemplates(...): -want, +got:\n%s", tc.reason, diff)
+func TestWhenSettled(t *testing.T) {
+	p := &v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeToCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To[string]("status.atProvider.endpoint"),
+			ToFieldPath:   ptr.To[string]("status.endpoint"),
+			Policy: &v1beta1.PatchPolicy{
+				WhenSettled: &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeObservedGeneration},
+			},
+		},
+	}
+	ocd := func(observedGeneration int64) *composed.Unstructured {
+		return &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.org/v1",
+			"kind":       "CD",
+			"metadata":   map[string]any{"generation": int64(2)},
+			"status": map[string]any{
+				"observedGeneration": observedGeneration,
+				"atProvider":         map[string]any{"endpoint": "new.example.org"},
+			},
+		}}}
+	}
+	xr := func(endpoint string) *composite.Unstructured {
+		xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.org/v1",
+			"kind":       "XR",
+		}}}
+		if endpoint != "" {
+			xr.Object["status"] = map[string]any{"endpoint": endpoint}
+		}
+		return xr
+	}
+
+	type args struct {
+		ocd *composed.Unstructured
+		oxr *composite.Unstructured
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   *composite.Unstructured
+	}{
+		"Settled": {
+			reason: "The patch should apply if the composed resource's status is settled.",
+			args: args{
+				ocd: ocd(2),
+				oxr: xr("old.example.org"),
+			},
+			want: xr("new.example.org"),
+		},
+		"NotSettled": {
+			reason: "The XR should keep its observed value if the composed resource's status isn't settled.",
+			args: args{
+				ocd: ocd(1),
+				oxr: xr("old.example.org"),
+			},
+			want: xr("old.example.org"),
+		},
+		"NotSettledNoObservedValue": {
+			reason: "Nothing should be patched if the composed resource's status isn't settled and the XR has no observed value.",
+			args: args{
+				ocd: ocd(1),
+				oxr: xr(""),
+			},
+			want: xr(""),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dxr := xr("")
+			err := ApplyToCompositePatch(WhenSettled(ApplyFromFieldPathPatch, tc.args.ocd, tc.args.oxr), p, tc.args.ocd, tc.args.oxr, dxr, nil)
+			if err != nil {
+				t.Fatalf("\n%s\nApplyToCompositePatch(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, dxr); diff != "" {
+				t.Errorf("\n%s\nApplyToCompositePatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestBaseFrom(t *testing.T) {
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"bases": map[string]any{
+			"bucket": map[string]any{"apiVersion": "example.org/v1", "kind": "Bucket"},
+			"string": "nope",
+		},
+	}}
+	fctx := &unstructured.Unstructured{Object: map[string]any{
+		"example.org/bases": map[string]any{
+			"bucket": map[string]any{"apiVersion": "example.org/v1", "kind": "Bucket"},
+		},
+	}}
+
+	type want struct {
+		base []byte
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		bf     *v1beta1.BaseFrom
+		want   want
+	}{
+		"FromEnvironment": {
+			reason: "The base should be read from the environment.",
+			bf:     &v1beta1.BaseFrom{EnvironmentFieldPath: ptr.To[string]("bases.bucket")},
+			want: want{
+				base: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`),
+			},
+		},
+		"FromContext": {
+			reason: "The base should be read from the context.",
+			bf:     &v1beta1.BaseFrom{ContextFieldPath: ptr.To[string]("[example.org/bases].bucket")},
+			want: want{
+				base: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`),
+			},
+		},
+		"NotFound": {
+			reason: "We should return an error if the base doesn't exist.",
+			bf:     &v1beta1.BaseFrom{EnvironmentFieldPath: ptr.To[string]("bases.missing")},
+			want: want{
+				err: func() error {
+					_, err := fieldpath.Pave(env.Object).GetValue("bases.missing")
+					return err
+				}(),
+			},
+		},
+		"NotAnObject": {
+			reason: "We should return an error if the base isn't an object.",
+			bf:     &v1beta1.BaseFrom{EnvironmentFieldPath: ptr.To[string]("bases.string")},
+			want: want{
+				err: errors.Errorf(errFmtBaseFromNotObject, "bases.string"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := BaseFrom(tc.bf, env, fctx)
+			if diff := cmp.Diff(string(tc.want.base), string(got)); diff != "" {
+				t.Errorf("\n%s\nBaseFrom(...): -want, +got:\n%s", tc.reason, diff)
 			}
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nrs.ComposedTemplates(...)): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("\n%s\nBaseFrom(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWithDefaultPatches(t *testing.T) {
+	def := v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To[string]("spec.region"),
+			ToFieldPath:   ptr.To[string]("spec.forProvider.region"),
+		},
+	}
+	own := v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To[string]("spec.size"),
+			ToFieldPath:   ptr.To[string]("spec.forProvider.size"),
+		},
+	}
+
+	type args struct {
+		d   *v1beta1.Defaults
+		cts []v1beta1.ComposedTemplate
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []v1beta1.ComposedTemplate
+	}{
+		"NoDefaults": {
+			reason: "Templates should be returned unchanged if there are no defaults.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "a", Patches: []v1beta1.ComposedPatch{own}}},
+			},
+			want: []v1beta1.ComposedTemplate{{Name: "a", Patches: []v1beta1.ComposedPatch{own}}},
+		},
+		"PrependDefaults": {
+			reason: "Default patches should be prepended to the patches of templates that don't skip them.",
+			args: args{
+				d: &v1beta1.Defaults{Patches: []v1beta1.ComposedPatch{def}},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "a", Patches: []v1beta1.ComposedPatch{own}},
+					{Name: "b"},
+					{Name: "c", SkipDefaultPatches: true, Patches: []v1beta1.ComposedPatch{own}},
+				},
+			},
+			want: []v1beta1.ComposedTemplate{
+				{Name: "a", Patches: []v1beta1.ComposedPatch{def, own}},
+				{Name: "b", Patches: []v1beta1.ComposedPatch{def}},
+				{Name: "c", SkipDefaultPatches: true, Patches: []v1beta1.ComposedPatch{own}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := WithDefaultPatches(tc.args.d, tc.args.cts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nWithDefaultPatches(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
@@ -783,6 +2504,51 @@ func TestResolveTransforms(t *testing.T) {
 				output: int64(4),
 			},
 		},
+		{
+			name: "TransformFails",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type:   v1beta1.StringTransformTypeFormat,
+						Format: ptr.To("%s-bucket"),
+					},
+				}, {
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeMultiply,
+						Multiply: ptr.To[int64](2),
+					},
+				}},
+				input: "cool",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtMathInputNonNumber, "cool-bucket"), errFmtTransformTypeFailed, v1beta1.TransformTypeMath), errFmtTransformAtIndex, 1, "math Multiply", "cool-bucket"),
+			},
+		},
+		{
+			name: "OptionalTransformFails",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type:     v1beta1.TransformTypeMath,
+					Optional: ptr.To(true),
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeMultiply,
+						Multiply: ptr.To[int64](2),
+					},
+				}, {
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type:   v1beta1.StringTransformTypeFormat,
+						Format: ptr.To("%s-bucket"),
+					},
+				}},
+				input: "cool",
+			},
+			want: want{
+				output: "cool-bucket",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -797,3 +2563,40 @@ func TestResolveTransforms(t *testing.T) {
 		})
 	}
 }
+
+func TestPaveObject(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		o      runtime.Object
+		want   runtime.Object
+	}{
+		"Unstructured": {
+			reason: "Changes to the paved view of an unstructured object should change the object.",
+			o:      &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{"apiVersion": "example.org/v1", "kind": "CD"}}},
+			want:   &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{"apiVersion": "example.org/v1", "kind": "CD", "spec": map[string]any{"coolness": "very"}}}},
+		},
+		"EmptyUnstructured": {
+			reason: "Changes to the paved view of an unstructured object with no content should change the object.",
+			o:      &unstructured.Unstructured{},
+			want:   &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"coolness": "very"}}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, write, err := paveObject(tc.o)
+			if err != nil {
+				t.Fatalf("\n%s\npaveObject(...): %s", tc.reason, err)
+			}
+			if err := p.SetValue("spec.coolness", "very"); err != nil {
+				t.Fatalf("\n%s\np.SetValue(...): %s", tc.reason, err)
+			}
+			if err := write(); err != nil {
+				t.Fatalf("\n%s\nwrite(): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, tc.o); diff != "" {
+				t.Errorf("\n%s\npaveObject(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Field paths of the policies a template may set on its composed resource.
+// The same paths on the composite resource override the template.
+const (
+	fieldPathManagementPolicies = "spec.managementPolicies"
+	fieldPathDeletionPolicy     = "spec.deletionPolicy"
+)
+
+// ApplyPolicies sets the management and deletion policies specified by the
+// supplied template on the supplied composed resource. Policies specified by
+// the composite resource take precedence over those specified by the template.
+func ApplyPolicies(t v1beta1.ComposedTemplate, xr *composite.Unstructured, cd *composed.Unstructured) error {
+	xp := fieldpath.Pave(xr.Object)
+	cp := fieldpath.Pave(cd.Object)
+
+	if t.ManagementPolicies != nil {
+		var mp any = t.ManagementPolicies
+		if v, err := xp.GetValue(fieldPathManagementPolicies); err == nil {
+			mp = v
+		}
+		if err := cp.SetValue(fieldPathManagementPolicies, mp); err != nil {
+			return errors.Wrapf(err, "cannot set %s", fieldPathManagementPolicies)
+		}
+	}
+
+	if t.DeletionPolicy != nil {
+		dp := string(*t.DeletionPolicy)
+		if v, err := xp.GetString(fieldPathDeletionPolicy); err == nil {
+			dp = v
+		}
+		if err := cp.SetString(fieldPathDeletionPolicy, dp); err != nil {
+			return errors.Wrapf(err, "cannot set %s", fieldPathDeletionPolicy)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestApplyPolicies(t *testing.T) {
+	type args struct {
+		t  v1beta1.ComposedTemplate
+		xr *composite.Unstructured
+		cd *composed.Unstructured
+	}
+	type want struct {
+		cd  *composed.Unstructured
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoPolicies": {
+			reason: "A template without policies should not change the composed resource.",
+			args: args{
+				t: v1beta1.ComposedTemplate{Name: "cool"},
+				xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{
+						"deletionPolicy": "Orphan",
+					},
+				}}},
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}},
+			},
+			want: want{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}},
+			},
+		},
+		"TemplatePolicies": {
+			reason: "Policies specified by the template should be set on the composed resource.",
+			args: args{
+				t: v1beta1.ComposedTemplate{
+					Name:               "cool",
+					ManagementPolicies: xpv1.ManagementPolicies{xpv1.ManagementActionObserve},
+					DeletionPolicy:     ptr.To(xpv1.DeletionOrphan),
+				},
+				xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}},
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}},
+			},
+			want: want{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{
+						"managementPolicies": []any{"Observe"},
+						"deletionPolicy":     "Orphan",
+					},
+				}}},
+			},
+		},
+		"CompositeOverride": {
+			reason: "Policies specified by the composite resource should override the template.",
+			args: args{
+				t: v1beta1.ComposedTemplate{
+					Name:               "cool",
+					ManagementPolicies: xpv1.ManagementPolicies{xpv1.ManagementActionAll},
+					DeletionPolicy:     ptr.To(xpv1.DeletionDelete),
+				},
+				xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{
+						"managementPolicies": []any{"Observe", "Create"},
+						"deletionPolicy":     "Orphan",
+					},
+				}}},
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}},
+			},
+			want: want{
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{
+						"managementPolicies": []any{"Observe", "Create"},
+						"deletionPolicy":     "Orphan",
+					},
+				}}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ApplyPolicies(tc.args.t, tc.args.xr, tc.args.cd)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyPolicies(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nApplyPolicies(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -2,12 +2,21 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/response"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
@@ -16,20 +25,23 @@ const (
 	errInvalidCheck = "invalid"
 	errPaveObject   = "cannot lookup field paths in supplied object"
 
-	errFmtRunCheck = "cannot run readiness check at index %d"
+	errFmtRunCheck          = "cannot run readiness check at index %d"
+	errFmtRunGroupCheck     = "cannot run %s readiness check at index %d"
+	errFmtSourceUnavailable = "readiness check source %s is not available"
+	errFmtReadyFromSource   = "readyFrom source %s is not supported"
 )
 
 // A ReadinessChecker checks whether a composed resource is ready or not.
 type ReadinessChecker interface {
-	IsReady(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
+	IsReady(ctx context.Context, o ConditionedObject, srcs ReadinessCheckSources, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
 }
 
 // A ReadinessCheckerFn checks whether a composed resource is ready or not.
-type ReadinessCheckerFn func(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
+type ReadinessCheckerFn func(ctx context.Context, o ConditionedObject, srcs ReadinessCheckSources, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
 
 // IsReady reports whether a composed resource is ready or not.
-func (fn ReadinessCheckerFn) IsReady(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error) {
-	return fn(ctx, o, rc...)
+func (fn ReadinessCheckerFn) IsReady(ctx context.Context, o ConditionedObject, srcs ReadinessCheckSources, rc ...v1beta1.ReadinessCheck) (ready bool, err error) {
+	return fn(ctx, o, srcs, rc...)
 }
 
 // A ConditionedObject is a runtime object with conditions.
@@ -38,15 +50,29 @@ type ConditionedObject interface {
 	resource.Conditioned
 }
 
+// ReadinessCheckSources are the objects, other than the composed resource
+// itself, that readiness checks may run against.
+type ReadinessCheckSources struct {
+	// Composite is the observed composite resource.
+	Composite ConditionedObject
+
+	// Environment is the Composition environment.
+	Environment runtime.Object
+
+	// ConnectionDetails are the observed composed resource's connection
+	// details.
+	ConnectionDetails map[string][]byte
+}
+
 // IsReady returns whether the composed resource is ready.
-func IsReady(_ context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (bool, error) {
+func IsReady(_ context.Context, o ConditionedObject, srcs ReadinessCheckSources, rc ...v1beta1.ReadinessCheck) (bool, error) {
 	// We don't have API server defaulting, so we default here.
 	if len(rc) == 0 {
 		return resource.IsConditionTrue(o.GetCondition(xpv1.TypeReady)), nil
 	}
 
 	for i := range rc {
-		ready, err := RunReadinessCheck(rc[i], o)
+		ready, err := RunReadinessCheck(rc[i], o, srcs)
 		if err != nil {
 			return false, errors.Wrapf(err, errFmtRunCheck, i)
 		}
@@ -57,20 +83,71 @@ func IsReady(_ context.Context, o ConditionedObject, rc ...v1beta1.ReadinessChec
 	return true, nil
 }
 
-// RunReadinessCheck runs the readiness check against the supplied object.
-func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, error) { //nolint:gocyclo // just a switch
+// RunReadinessCheck runs the readiness check against the supplied object, or
+// against one of the supplied sources if the check specifies one.
+func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject, srcs ReadinessCheckSources) (bool, error) { //nolint:gocyclo // just a switch
 	if err := ValidateReadinessCheck(c); err != nil {
 		return false, errors.Wrap(err, errInvalidCheck)
 	}
 
-	p, err := fieldpath.PaveObject(o)
+	switch c.Type { //nolint:exhaustive // Only groups are handled here.
+	case v1beta1.ReadinessCheckTypeAnyOf:
+		for i := range c.AnyOf {
+			ready, err := RunReadinessCheck(c.AnyOf[i], o, srcs)
+			if err != nil {
+				return false, errors.Wrapf(err, errFmtRunGroupCheck, c.Type, i)
+			}
+			if ready {
+				return true, nil
+			}
+		}
+		return false, nil
+	case v1beta1.ReadinessCheckTypeAllOf:
+		for i := range c.AllOf {
+			ready, err := RunReadinessCheck(c.AllOf[i], o, srcs)
+			if err != nil {
+				return false, errors.Wrapf(err, errFmtRunGroupCheck, c.Type, i)
+			}
+			if !ready {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	// The object whose fields we check, and the object whose conditions we
+	// check. The environment has no conditions - we validate that MatchCondition
+	// checks don't use it as a source.
+	var from runtime.Object = o
+	co := o
+	switch c.GetSource() {
+	case v1beta1.ReadinessCheckSourceComposed:
+	case v1beta1.ReadinessCheckSourceComposite:
+		if srcs.Composite == nil {
+			return false, errors.Errorf(errFmtSourceUnavailable, c.GetSource())
+		}
+		from, co = srcs.Composite, srcs.Composite
+	case v1beta1.ReadinessCheckSourceEnvironment:
+		if srcs.Environment == nil {
+			return false, errors.Errorf(errFmtSourceUnavailable, c.GetSource())
+		}
+		from = srcs.Environment
+	}
+
+	content, err := unstructuredContent(from)
 	if err != nil {
 		return false, errors.Wrap(err, errPaveObject)
 	}
+	p := fieldpath.Pave(content)
 
 	switch c.Type {
 	case v1beta1.ReadinessCheckTypeNone:
 		return true, nil
+	case v1beta1.ReadinessCheckTypeMatchConnectionDetailKey:
+		_, ok := srcs.ConnectionDetails[*c.ConnectionDetailKey]
+		return ok, nil
+	case v1beta1.ReadinessCheckTypeAnyOf, v1beta1.ReadinessCheckTypeAllOf:
+		// Handled above.
 	case v1beta1.ReadinessCheckTypeNonEmpty:
 		if _, err := p.GetValue(*c.FieldPath); err != nil {
 			return false, resource.Ignore(fieldpath.IsNotFound, err)
@@ -82,6 +159,16 @@ func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, err
 			return false, resource.Ignore(fieldpath.IsNotFound, err)
 		}
 		return val == *c.MatchString, nil
+	case v1beta1.ReadinessCheckTypeMatchRegexp:
+		val, err := p.GetString(*c.FieldPath)
+		if err != nil {
+			return false, resource.Ignore(fieldpath.IsNotFound, err)
+		}
+		re, err := compileRegexp(*c.MatchRegexp)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(val), nil
 	case v1beta1.ReadinessCheckTypeMatchInteger:
 		val, err := p.GetInteger(*c.FieldPath)
 		if err != nil {
@@ -89,8 +176,7 @@ func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, err
 		}
 		return val == *c.MatchInteger, nil
 	case v1beta1.ReadinessCheckTypeMatchCondition:
-		val := o.GetCondition(c.MatchCondition.Type)
-		return val.Status == c.MatchCondition.Status, nil
+		return MatchCondition(c.MatchCondition, co.GetCondition(c.MatchCondition.Type))
 	case v1beta1.ReadinessCheckTypeMatchFalse:
 		val, err := p.GetBool(*c.FieldPath)
 		if err != nil {
@@ -107,3 +193,146 @@ func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, err
 
 	return false, nil
 }
+
+// ReadyOverride returns the readiness the supplied ReadyFrom overrides a
+// composed resource's readiness with, read from one of the supplied sources. It
+// returns nil if the field path doesn't exist, in which case the composed
+// resource's readiness checks decide.
+func ReadyOverride(rf *v1beta1.ReadyFrom, srcs ReadinessCheckSources) (*bool, error) {
+	var from runtime.Object
+	switch rf.GetSource() { //nolint:exhaustive // We validate that the source isn't Composed.
+	case v1beta1.ReadinessCheckSourceComposite:
+		if srcs.Composite == nil {
+			return nil, errors.Errorf(errFmtSourceUnavailable, rf.GetSource())
+		}
+		from = srcs.Composite
+	case v1beta1.ReadinessCheckSourceEnvironment:
+		if srcs.Environment == nil {
+			return nil, errors.Errorf(errFmtSourceUnavailable, rf.GetSource())
+		}
+		from = srcs.Environment
+	default:
+		return nil, errors.Errorf(errFmtReadyFromSource, rf.GetSource())
+	}
+
+	content, err := unstructuredContent(from)
+	if err != nil {
+		return nil, errors.Wrap(err, errPaveObject)
+	}
+	ready, err := fieldpath.Pave(content).GetBool(rf.FieldPath)
+	if fieldpath.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ready, nil
+}
+
+// MatchCondition returns true if the supplied condition has the status of the
+// supplied match condition readiness check, and its reason and message match
+// the check's regular expressions, if any.
+func MatchCondition(m *v1beta1.MatchConditionReadinessCheck, c xpv1.Condition) (bool, error) {
+	if c.Status != m.Status {
+		return false, nil
+	}
+	for _, match := range []struct {
+		expr *string
+		val  string
+	}{{m.Reason, string(c.Reason)}, {m.Message, c.Message}} {
+		if match.expr == nil {
+			continue
+		}
+		re, err := compileRegexp(*match.expr)
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(match.val) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Reasons of a resource template's ready timeout condition, besides
+// ReasonReadyTimeout.
+const (
+	reasonReady    = "Ready"
+	reasonCreating = "Creating"
+)
+
+// CheckReadyTimeout returns a warning result if the supplied observed composed
+// resource isn't ready, and was created longer ago than the supplied timeout.
+// The observed composed resource is nil if it doesn't exist yet. If the timeout
+// configures a condition, it's set to reflect whether the composed resource is
+// ready. If the timeout hasn't passed yet, the Function is called again when
+// it does. CheckReadyTimeout returns true if it returned a warning result.
+func CheckReadyTimeout(rsp *fnv1.RunFunctionResponse, name string, to *v1beta1.Timeouts, ocd *composed.Unstructured, ready bool, now time.Time) bool {
+	condition := func(ready bool, reason, message string) {
+		if to.Condition == nil {
+			return
+		}
+		newCondition := response.ConditionFalse
+		if ready {
+			newCondition = response.ConditionTrue
+		}
+		c := newCondition(rsp, *to.Condition, reason)
+		if message != "" {
+			c = c.WithMessage(message)
+		}
+		c.TargetCompositeAndClaim()
+	}
+
+	switch {
+	case ready:
+		condition(true, reasonReady, "")
+		return false
+	case ocd == nil:
+		condition(false, reasonCreating, fmt.Sprintf("composed resource %q doesn't exist yet", name))
+		return false
+	}
+
+	age := now.Sub(ocd.GetCreationTimestamp().Time)
+	if age < to.Ready.Duration {
+		condition(false, reasonCreating, fmt.Sprintf("composed resource %q isn't ready yet", name))
+		requeueAfter(rsp, to.Ready.Duration-age)
+		return false
+	}
+
+	err := errors.Errorf("composed resource %q isn't ready %s after it was created", name, age.Round(time.Second))
+	condition(false, ReasonReadyTimeout, err.Error())
+	Warning(rsp, err, ResultDetails{Reason: ReasonReadyTimeout, Resource: name})
+	return true
+}
+
+// IsSettled returns true if the supplied observed composed resource's status
+// is settled according to the supplied check. It's always settled if there's
+// no check.
+func IsSettled(c *v1beta1.SettledCheck, cd *composed.Unstructured) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+	switch c.Type {
+	case v1beta1.SettledCheckTypeReady:
+		return cd.GetCondition(xpv1.TypeReady).Status == corev1.ConditionTrue, nil
+	case v1beta1.SettledCheckTypeObservedGeneration:
+		observed, err := cd.GetInteger("status.observedGeneration")
+		if fieldpath.IsNotFound(err) {
+			return cd.GetCondition(xpv1.TypeReady).ObservedGeneration == cd.GetGeneration(), nil
+		}
+		if err != nil {
+			return false, errors.Wrap(err, "cannot get observed generation")
+		}
+		return observed == cd.GetGeneration(), nil
+	case v1beta1.SettledCheckTypeFieldPath:
+		if c.FieldPath == nil {
+			return false, errors.New("fieldPath is required for a FieldPath settled check")
+		}
+		settled, err := cd.GetBool(*c.FieldPath)
+		if fieldpath.IsNotFound(err) {
+			return false, nil
+		}
+		return settled, errors.Wrapf(err, "cannot get settled field path %s", *c.FieldPath)
+	}
+	return false, errors.Errorf("unknown settled check type %q", c.Type)
+}
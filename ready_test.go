@@ -3,9 +3,15 @@ package main
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/ptr"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -14,6 +20,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	sdkcomposed "github.com/crossplane/function-sdk-go/resource/composed"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
@@ -21,9 +30,10 @@ var _ ReadinessChecker = ReadinessCheckerFn(IsReady)
 
 func TestIsReady(t *testing.T) {
 	type args struct {
-		ctx context.Context
-		o   ConditionedObject
-		rc  []v1beta1.ReadinessCheck
+		ctx  context.Context
+		o    ConditionedObject
+		srcs ReadinessCheckSources
+		rc   []v1beta1.ReadinessCheck
 	}
 	type want struct {
 		ready bool
@@ -84,6 +94,41 @@ func TestIsReady(t *testing.T) {
 				ready: false,
 			},
 		},
+		"MatchConditionReasonReady": {
+			reason: "A match condition should be ready if the condition's reason and message match its regular expressions.",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Available().WithMessage("endpoint is healthy"))),
+				rc: []v1beta1.ReadinessCheck{{
+					Type: v1beta1.ReadinessCheckTypeMatchCondition,
+					MatchCondition: &v1beta1.MatchConditionReadinessCheck{
+						Type:    xpv1.TypeReady,
+						Status:  corev1.ConditionTrue,
+						Reason:  ptr.To("^Available$"),
+						Message: ptr.To("healthy"),
+					},
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConditionReasonNotReady": {
+			reason: "A match condition shouldn't be ready if the condition's reason doesn't match its regular expression, even if the status matches.",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Available())),
+				rc: []v1beta1.ReadinessCheck{{
+					Type: v1beta1.ReadinessCheckTypeMatchCondition,
+					MatchCondition: &v1beta1.MatchConditionReadinessCheck{
+						Type:   xpv1.TypeReady,
+						Status: corev1.ConditionTrue,
+						Reason: ptr.To("^Synced$"),
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
 		"ExplictNone": {
 			reason: "If the only readiness check is explicitly 'None' the resource is always ready.",
 			args: args{
@@ -344,6 +389,216 @@ func TestIsReady(t *testing.T) {
 				ready: false,
 			},
 		},
+		"MatchRegexpReady": {
+			reason: "If the value of the field matches the regexp, it should return true",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"state": "AVAILABLE",
+						},
+					}
+				}),
+				rc: []v1beta1.ReadinessCheck{{
+					Type:        v1beta1.ReadinessCheckTypeMatchRegexp,
+					FieldPath:   ptr.To[string]("status.state"),
+					MatchRegexp: ptr.To[string]("^(RUNNING|AVAILABLE)$"),
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchRegexpNotReady": {
+			reason: "If the value of the field does not match the regexp, it should return false",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"state": "CREATING",
+						},
+					}
+				}),
+				rc: []v1beta1.ReadinessCheck{{
+					Type:        v1beta1.ReadinessCheckTypeMatchRegexp,
+					FieldPath:   ptr.To[string]("status.state"),
+					MatchRegexp: ptr.To[string]("^(RUNNING|AVAILABLE)$"),
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"AnyOfReady": {
+			reason: "If any check in an AnyOf group passes, it should return true",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"phase": "Ready",
+						},
+					}
+				}),
+				rc: []v1beta1.ReadinessCheck{{
+					Type: v1beta1.ReadinessCheckTypeAnyOf,
+					AnyOf: []v1beta1.ReadinessCheck{
+						{
+							Type:        v1beta1.ReadinessCheckTypeMatchString,
+							FieldPath:   ptr.To[string]("status.state"),
+							MatchString: ptr.To[string]("Ready"),
+						},
+						{
+							Type:        v1beta1.ReadinessCheckTypeMatchString,
+							FieldPath:   ptr.To[string]("status.phase"),
+							MatchString: ptr.To[string]("Ready"),
+						},
+					},
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"AnyOfNotReady": {
+			reason: "If no check in an AnyOf group passes, it should return false",
+			args: args{
+				o: composed.New(),
+				rc: []v1beta1.ReadinessCheck{{
+					Type: v1beta1.ReadinessCheckTypeAnyOf,
+					AnyOf: []v1beta1.ReadinessCheck{
+						{
+							Type:      v1beta1.ReadinessCheckTypeNonEmpty,
+							FieldPath: ptr.To[string]("status.state"),
+						},
+						{
+							Type:      v1beta1.ReadinessCheckTypeNonEmpty,
+							FieldPath: ptr.To[string]("status.phase"),
+						},
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"AllOfNotReady": {
+			reason: "If any check in an AllOf group fails, it should return false",
+			args: args{
+				o: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]any{
+						"status": map[string]any{
+							"state": "Ready",
+						},
+					}
+				}),
+				rc: []v1beta1.ReadinessCheck{{
+					Type: v1beta1.ReadinessCheckTypeAnyOf,
+					AnyOf: []v1beta1.ReadinessCheck{
+						{Type: v1beta1.ReadinessCheckTypeAllOf, AllOf: []v1beta1.ReadinessCheck{
+							{
+								Type:      v1beta1.ReadinessCheckTypeNonEmpty,
+								FieldPath: ptr.To[string]("status.state"),
+							},
+							{
+								Type:      v1beta1.ReadinessCheckTypeNonEmpty,
+								FieldPath: ptr.To[string]("status.phase"),
+							},
+						}},
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchStringFromEnvironmentReady": {
+			reason: "If the check's source is the environment, it should run against the environment",
+			args: args{
+				o: composed.New(),
+				srcs: ReadinessCheckSources{
+					Environment: &unstructured.Unstructured{Object: map[string]any{
+						"apiVersion": "internal.crossplane.io/v1alpha1",
+						"kind":       "Environment",
+						"approved":   "yes",
+					}},
+				},
+				rc: []v1beta1.ReadinessCheck{{
+					Type:        v1beta1.ReadinessCheckTypeMatchString,
+					Source:      v1beta1.ReadinessCheckSourceEnvironment,
+					FieldPath:   ptr.To[string]("approved"),
+					MatchString: ptr.To[string]("yes"),
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConditionFromCompositeNotReady": {
+			reason: "If the check's source is the composite resource, it should match the composite resource's conditions",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Available())),
+				srcs: ReadinessCheckSources{
+					Composite: composed.New(composed.WithConditions(xpv1.Unavailable())),
+				},
+				rc: []v1beta1.ReadinessCheck{{
+					Type:   v1beta1.ReadinessCheckTypeMatchCondition,
+					Source: v1beta1.ReadinessCheckSourceComposite,
+					MatchCondition: &v1beta1.MatchConditionReadinessCheck{
+						Type:   xpv1.TypeReady,
+						Status: corev1.ConditionTrue,
+					},
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"SourceUnavailable": {
+			reason: "If the check's source was not supplied, it should return an error",
+			args: args{
+				o: composed.New(),
+				rc: []v1beta1.ReadinessCheck{{
+					Type:      v1beta1.ReadinessCheckTypeNonEmpty,
+					Source:    v1beta1.ReadinessCheckSourceComposite,
+					FieldPath: ptr.To[string]("spec.foo"),
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtSourceUnavailable, v1beta1.ReadinessCheckSourceComposite), errFmtRunCheck, 0),
+			},
+		},
+		"MatchConnectionDetailKeyReady": {
+			reason: "If the connection detail key exists the resource should be ready, even if its Ready condition isn't true",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Unavailable())),
+				srcs: ReadinessCheckSources{
+					ConnectionDetails: map[string][]byte{"password": []byte("secret")},
+				},
+				rc: []v1beta1.ReadinessCheck{{
+					Type:                v1beta1.ReadinessCheckTypeMatchConnectionDetailKey,
+					ConnectionDetailKey: ptr.To("password"),
+				}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConnectionDetailKeyNotReady": {
+			reason: "If the connection detail key doesn't exist the resource shouldn't be ready",
+			args: args{
+				o: composed.New(composed.WithConditions(xpv1.Available())),
+				srcs: ReadinessCheckSources{
+					ConnectionDetails: map[string][]byte{"username": []byte("admin")},
+				},
+				rc: []v1beta1.ReadinessCheck{{
+					Type:                v1beta1.ReadinessCheckTypeMatchConnectionDetailKey,
+					ConnectionDetailKey: ptr.To("password"),
+				}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
 		"UnknownType": {
 			reason: "If unknown type is chosen, it should return an error",
 			args: args{
@@ -357,7 +612,7 @@ func TestIsReady(t *testing.T) {
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			ready, err := IsReady(tc.args.ctx, tc.args.o, tc.args.rc...)
+			ready, err := IsReady(tc.args.ctx, tc.args.o, tc.args.srcs, tc.args.rc...)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("\n%s\nIsReady(...): -want, +got:\n%s", tc.reason, diff)
 			}
@@ -367,3 +622,296 @@ func TestIsReady(t *testing.T) {
 		})
 	}
 }
+
+func TestReadyOverride(t *testing.T) {
+	xr := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"maintenance": map[string]any{"ready": true, "mode": "on"},
+		},
+	}}}
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"maintenance": map[string]any{"ready": false},
+	}}
+
+	type args struct {
+		rf   *v1beta1.ReadyFrom
+		srcs ReadinessCheckSources
+	}
+	type want struct {
+		ready *bool
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"FromComposite": {
+			reason: "The readiness should be overridden with a boolean of the composite resource by default",
+			args: args{
+				rf:   &v1beta1.ReadyFrom{FieldPath: "spec.maintenance.ready"},
+				srcs: ReadinessCheckSources{Composite: xr, Environment: env},
+			},
+			want: want{
+				ready: ptr.To(true),
+			},
+		},
+		"FromEnvironment": {
+			reason: "The readiness should be overridden with a boolean of the environment",
+			args: args{
+				rf:   &v1beta1.ReadyFrom{Source: v1beta1.ReadinessCheckSourceEnvironment, FieldPath: "maintenance.ready"},
+				srcs: ReadinessCheckSources{Composite: xr, Environment: env},
+			},
+			want: want{
+				ready: ptr.To(false),
+			},
+		},
+		"NotFound": {
+			reason: "The readiness shouldn't be overridden if the field path doesn't exist",
+			args: args{
+				rf:   &v1beta1.ReadyFrom{FieldPath: "spec.maintenance.enabled"},
+				srcs: ReadinessCheckSources{Composite: xr, Environment: env},
+			},
+		},
+		"NotABoolean": {
+			reason: "An error should be returned if the field path isn't a boolean",
+			args: args{
+				rf:   &v1beta1.ReadyFrom{FieldPath: "spec.maintenance.mode"},
+				srcs: ReadinessCheckSources{Composite: xr, Environment: env},
+			},
+			want: want{
+				err: errors.New("spec.maintenance.mode: not a bool"),
+			},
+		},
+		"SourceUnavailable": {
+			reason: "An error should be returned if the source isn't available",
+			args: args{
+				rf: &v1beta1.ReadyFrom{Source: v1beta1.ReadinessCheckSourceEnvironment, FieldPath: "maintenance.ready"},
+			},
+			want: want{
+				err: errors.Errorf(errFmtSourceUnavailable, v1beta1.ReadinessCheckSourceEnvironment),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ready, err := ReadyOverride(tc.args.rf, tc.args.srcs)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nReadyOverride(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ready, ready); diff != "" {
+				t.Errorf("\n%s\nReadyOverride(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckReadyTimeout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	created := func(ago time.Duration) *sdkcomposed.Unstructured {
+		cd := sdkcomposed.New()
+		cd.SetCreationTimestamp(metav1.NewTime(now.Add(-ago)))
+		return cd
+	}
+	to := &v1beta1.Timeouts{Ready: metav1.Duration{Duration: 10 * time.Minute}, Condition: ptr.To("DatabaseReady")}
+
+	type args struct {
+		to    *v1beta1.Timeouts
+		ocd   *sdkcomposed.Unstructured
+		ready bool
+	}
+	type want struct {
+		warned     bool
+		results    []*fnv1.Result
+		conditions []*fnv1.Condition
+		ttl        *durationpb.Duration
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Ready": {
+			reason: "A ready composed resource should set the condition to True.",
+			args: args{
+				to:    to,
+				ocd:   created(time.Hour),
+				ready: true,
+			},
+			want: want{
+				conditions: []*fnv1.Condition{
+					{Type: "DatabaseReady", Status: fnv1.Status_STATUS_CONDITION_TRUE, Reason: "Ready", Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum()},
+				},
+			},
+		},
+		"DoesNotExist": {
+			reason: "A composed resource that doesn't exist yet should set the condition to False.",
+			args: args{
+				to: to,
+			},
+			want: want{
+				conditions: []*fnv1.Condition{
+					{Type: "DatabaseReady", Status: fnv1.Status_STATUS_CONDITION_FALSE, Reason: "Creating", Message: ptr.To(`composed resource "database" doesn't exist yet`), Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum()},
+				},
+			},
+		},
+		"NotTimedOut": {
+			reason: "A composed resource that isn't ready before its timeout should set the condition to False, and call the Function again at its timeout.",
+			args: args{
+				to:  to,
+				ocd: created(4 * time.Minute),
+			},
+			want: want{
+				conditions: []*fnv1.Condition{
+					{Type: "DatabaseReady", Status: fnv1.Status_STATUS_CONDITION_FALSE, Reason: "Creating", Message: ptr.To(`composed resource "database" isn't ready yet`), Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum()},
+				},
+				ttl: durationpb.New(6 * time.Minute),
+			},
+		},
+		"TimedOut": {
+			reason: "A composed resource that isn't ready after its timeout should return a warning.",
+			args: args{
+				to:  to,
+				ocd: created(time.Hour),
+			},
+			want: want{
+				warned: true,
+				results: []*fnv1.Result{
+					{Severity: fnv1.Severity_SEVERITY_WARNING, Message: `composed resource "database" isn't ready 1h0m0s after it was created`, Reason: ptr.To(ReasonReadyTimeout), Target: fnv1.Target_TARGET_COMPOSITE.Enum()},
+				},
+				conditions: []*fnv1.Condition{
+					{Type: "DatabaseReady", Status: fnv1.Status_STATUS_CONDITION_FALSE, Reason: ReasonReadyTimeout, Message: ptr.To(`composed resource "database" isn't ready 1h0m0s after it was created`), Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum()},
+				},
+			},
+		},
+		"TimedOutWithoutCondition": {
+			reason: "A composed resource that isn't ready after its timeout should only return a warning if no condition is configured.",
+			args: args{
+				to:  &v1beta1.Timeouts{Ready: metav1.Duration{Duration: 10 * time.Minute}},
+				ocd: created(time.Hour),
+			},
+			want: want{
+				warned: true,
+				results: []*fnv1.Result{
+					{Severity: fnv1.Severity_SEVERITY_WARNING, Message: `composed resource "database" isn't ready 1h0m0s after it was created`, Reason: ptr.To(ReasonReadyTimeout), Target: fnv1.Target_TARGET_COMPOSITE.Enum()},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rsp := &fnv1.RunFunctionResponse{}
+			warned := CheckReadyTimeout(rsp, "database", tc.args.to, tc.args.ocd, tc.args.ready, now)
+			if diff := cmp.Diff(tc.want.warned, warned); diff != "" {
+				t.Errorf("%s\nCheckReadyTimeout(...): -want warned, +got warned:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.results, rsp.GetResults(), protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nCheckReadyTimeout(...): -want results, +got results:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.conditions, rsp.GetConditions(), protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nCheckReadyTimeout(...): -want conditions, +got conditions:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ttl, rsp.GetMeta().GetTtl(), protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nCheckReadyTimeout(...): -want TTL, +got TTL:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestIsSettled(t *testing.T) {
+	cd := func(generation int64, status map[string]any) *sdkcomposed.Unstructured {
+		cd := sdkcomposed.New()
+		cd.SetGeneration(generation)
+		if status != nil {
+			cd.Object["status"] = status
+		}
+		return cd
+	}
+
+	type args struct {
+		c  *v1beta1.SettledCheck
+		cd *sdkcomposed.Unstructured
+	}
+	type want struct {
+		settled bool
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoCheck": {
+			reason: "A composed resource is always settled if there's no check.",
+			args: args{
+				cd: cd(1, nil),
+			},
+			want: want{settled: true},
+		},
+		"ReadyFalse": {
+			reason: "A composed resource isn't settled until its Ready condition is True.",
+			args: args{
+				c:  &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeReady},
+				cd: cd(1, nil),
+			},
+			want: want{settled: false},
+		},
+		"ObservedGenerationMatches": {
+			reason: "A composed resource is settled if its status.observedGeneration matches its generation.",
+			args: args{
+				c:  &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeObservedGeneration},
+				cd: cd(2, map[string]any{"observedGeneration": int64(2)}),
+			},
+			want: want{settled: true},
+		},
+		"ObservedGenerationFromCondition": {
+			reason: "A composed resource without a status.observedGeneration should use its Ready condition's observed generation.",
+			args: args{
+				c: &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeObservedGeneration},
+				cd: cd(2, map[string]any{"conditions": []any{
+					map[string]any{"type": "Ready", "status": "True", "observedGeneration": int64(1)},
+				}}),
+			},
+			want: want{settled: false},
+		},
+		"FieldPathTrue": {
+			reason: "A composed resource is settled if the field path is true.",
+			args: args{
+				c:  &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeFieldPath, FieldPath: ptr.To("status.atProvider.settled")},
+				cd: cd(1, map[string]any{"atProvider": map[string]any{"settled": true}}),
+			},
+			want: want{settled: true},
+		},
+		"FieldPathMissing": {
+			reason: "A composed resource isn't settled if the field path doesn't exist.",
+			args: args{
+				c:  &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeFieldPath, FieldPath: ptr.To("status.atProvider.settled")},
+				cd: cd(1, nil),
+			},
+			want: want{settled: false},
+		},
+		"FieldPathNotBool": {
+			reason: "A field path that isn't a boolean should return an error.",
+			args: args{
+				c:  &v1beta1.SettledCheck{Type: v1beta1.SettledCheckTypeFieldPath, FieldPath: ptr.To("status.atProvider.settled")},
+				cd: cd(1, map[string]any{"atProvider": map[string]any{"settled": "yes"}}),
+			},
+			want: want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			settled, err := IsSettled(tc.args.c, tc.args.cd)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nIsSettled(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.settled, settled); diff != "" {
+				t.Errorf("\n%s\nIsSettled(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
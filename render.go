@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+)
+
+const (
+	// AnnotationKeyCompositionResourceName is the annotation Crossplane uses
+	// to associate a composed resource with the resource template it came
+	// from.
+	AnnotationKeyCompositionResourceName = "crossplane.io/composition-resource-name"
+
+	inputAPIVersion   = "pt.fn.crossplane.io/v1beta1"
+	inputAPIVersionV1 = "pt.fn.crossplane.io/v1"
+	inputKind         = "Resources"
+)
+
+// RenderCmd renders the desired state the Function would produce, without
+// running Crossplane or serving gRPC.
+type RenderCmd struct {
+	CompositeResource string `arg:"" type:"existingfile" help:"A YAML file specifying the observed composite resource (XR)."`
+	Input             string `arg:"" type:"existingfile" help:"A YAML file specifying the Function input. Either a Resources object, or a Composition with a pipeline step whose input is a Resources object."`
+
+	ObservedResources string `short:"o" type:"existingdir" help:"A directory of YAML files specifying observed composed resources. Each must have the crossplane.io/composition-resource-name annotation."`
+	Step              string `short:"s" help:"The Composition pipeline step whose input to use. Defaults to the first step whose input is a Resources object."`
+}
+
+// Run the render command.
+func (c *RenderCmd) Run() error {
+	xr, err := readObjects(c.CompositeResource)
+	if err != nil {
+		return errors.Wrap(err, "cannot read composite resource")
+	}
+	if len(xr) != 1 {
+		return errors.Errorf("%s must contain exactly one composite resource", c.CompositeResource)
+	}
+
+	in, err := ReadInput(c.Input, c.Step)
+	if err != nil {
+		return errors.Wrap(err, "cannot read Function input")
+	}
+
+	req := &fnv1.RunFunctionRequest{
+		Observed: &fnv1.State{Resources: map[string]*fnv1.Resource{}},
+		Desired:  &fnv1.State{},
+	}
+	if req.Input, err = structpb.NewStruct(in); err != nil {
+		return errors.Wrap(err, "cannot convert Function input")
+	}
+	s, err := structpb.NewStruct(xr[0])
+	if err != nil {
+		return errors.Wrap(err, "cannot convert composite resource")
+	}
+	req.Observed.Composite = &fnv1.Resource{Resource: s}
+
+	if c.ObservedResources != "" {
+		ocds, err := readObjectsDir(c.ObservedResources)
+		if err != nil {
+			return errors.Wrap(err, "cannot read observed composed resources")
+		}
+		for _, ocd := range ocds {
+			p := fieldpath.Pave(ocd)
+			name, err := p.GetString(fmt.Sprintf("metadata.annotations[%s]", AnnotationKeyCompositionResourceName))
+			if err != nil {
+				kind, _ := p.GetString("kind")
+				n, _ := p.GetString("metadata.name")
+				return errors.Wrapf(err, "observed composed resource %s %q has no %s annotation", kind, n, AnnotationKeyCompositionResourceName)
+			}
+			s, err := structpb.NewStruct(ocd)
+			if err != nil {
+				return errors.Wrapf(err, "cannot convert observed composed resource %q", name)
+			}
+			req.Observed.Resources[name] = &fnv1.Resource{Resource: s}
+		}
+	}
+
+	f := &Function{log: logging.NewNopLogger()}
+	rsp, err := f.RunFunction(context.Background(), req)
+	if err != nil {
+		return errors.Wrap(err, "cannot run Function")
+	}
+
+	return writeResponse(os.Stdout, os.Stderr, xr[0], rsp)
+}
+
+// writeResponse writes the desired composite and composed resources of the
+// supplied response as a YAML stream, and its results as plain text.
+func writeResponse(out, results io.Writer, xr map[string]any, rsp *fnv1.RunFunctionResponse) error {
+	for _, r := range rsp.GetResults() {
+		fmt.Fprintf(results, "%s: %s\n", r.GetSeverity(), r.GetMessage())
+		if r.GetSeverity() == fnv1.Severity_SEVERITY_FATAL {
+			return errors.New("Function returned a fatal result")
+		}
+	}
+
+	// Crossplane re-injects the XR's identity into its desired state. We do
+	// the same so the output is easier to read.
+	dxr := rsp.GetDesired().GetComposite().GetResource().AsMap()
+	p := fieldpath.Pave(dxr)
+	x := fieldpath.Pave(xr)
+	for _, path := range []string{"apiVersion", "kind", "metadata.name"} {
+		if v, err := x.GetValue(path); err == nil {
+			_ = p.SetValue(path, v)
+		}
+	}
+	if err := writeObject(out, dxr); err != nil {
+		return err
+	}
+
+	dcds := rsp.GetDesired().GetResources()
+	names := make([]string, 0, len(dcds))
+	for name := range dcds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dcd := dcds[name].GetResource().AsMap()
+		_ = fieldpath.Pave(dcd).SetValue(fmt.Sprintf("metadata.annotations[%s]", AnnotationKeyCompositionResourceName), name)
+		if err := writeObject(out, dcd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeObject writes the supplied object as a YAML document.
+func writeObject(w io.Writer, obj map[string]any) error {
+	b, err := sigsyaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal object to YAML")
+	}
+	_, err = fmt.Fprintf(w, "---\n%s", b)
+	return errors.Wrap(err, "cannot write object")
+}
+
+// ReadInput reads the Function input from the supplied YAML file. The file
+// may contain either a Resources object, or a Composition. If it contains a
+// Composition the input of the named pipeline step is returned. If no step is
+// named the input of the first step whose input is a Resources object is
+// returned.
+func ReadInput(path, step string) (map[string]any, error) {
+	ins, err := ReadInputs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, in := range ins {
+		if step == "" || in.Step == step {
+			return in.Input, nil
+		}
+	}
+	if step != "" {
+		return nil, errors.Errorf("cannot find Composition pipeline step %q whose input is a Resources object", step)
+	}
+	return nil, errors.New("cannot find a Composition pipeline step whose input is a Resources object")
+}
+
+// A StepInput is the Function input of a Composition pipeline step.
+type StepInput struct {
+	// Step is the name of the pipeline step. It's empty if the input wasn't
+	// read from a Composition.
+	Step string
+
+	// Input is the Resources object.
+	Input map[string]any
+
+	// CompositeTypeRef is the type of composite resource the Composition
+	// composes. It's empty if the input wasn't read from a Composition.
+	CompositeTypeRef schema.GroupVersionKind
+}
+
+// ReadInputs reads all Function inputs from the supplied YAML file. The file
+// may contain either a Resources object, or a Composition. If it contains a
+// Composition the input of every pipeline step whose input is a Resources
+// object is returned.
+func ReadInputs(path string) ([]StepInput, error) {
+	objs, err := readObjectsStrict(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) != 1 {
+		return nil, errors.Errorf("%s must contain exactly one object", path)
+	}
+	obj := fieldpath.Pave(objs[0])
+	if isResources(obj) {
+		return []StepInput{{Input: objs[0]}}, nil
+	}
+	if kind, _ := obj.GetString("kind"); kind != "Composition" {
+		return nil, errors.Errorf("%s must contain a Resources object or a Composition", path)
+	}
+	return CompositionInputs(objs[0])
+}
+
+// CompositionInputs returns the input of every pipeline step of the supplied
+// Composition whose input is a Resources object.
+func CompositionInputs(comp map[string]any) ([]StepInput, error) {
+	obj := fieldpath.Pave(comp)
+	av, _ := obj.GetString("spec.compositeTypeRef.apiVersion")
+	kind, _ := obj.GetString("spec.compositeTypeRef.kind")
+	xr := schema.FromAPIVersionAndKind(av, kind)
+
+	steps := []any{}
+	if err := obj.GetValueInto("spec.pipeline", &steps); err != nil {
+		return nil, errors.Wrap(err, "cannot get Composition pipeline")
+	}
+	out := []StepInput{}
+	for i := range steps {
+		s := fieldpath.Pave(map[string]any{"step": steps[i]})
+		name, _ := s.GetString("step.step")
+		in := map[string]any{}
+		if err := s.GetValueInto("step.input", &in); err != nil || !isResources(fieldpath.Pave(in)) {
+			continue
+		}
+		out = append(out, StepInput{Step: name, Input: in, CompositeTypeRef: xr})
+	}
+	return out, nil
+}
+
+// isResources returns true if the supplied object is a Resources object.
+func isResources(obj *fieldpath.Paved) bool {
+	av, _ := obj.GetString("apiVersion")
+	k, _ := obj.GetString("kind")
+	return (av == inputAPIVersion || av == inputAPIVersionV1) && k == inputKind
+}
+
+// readObjectsDir reads all objects from the YAML files in the supplied
+// directory.
+func readObjectsDir(dir string) ([]map[string]any, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read directory %s", dir)
+	}
+	out := []map[string]any{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(e.Name()); ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+		objs, err := readObjects(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, objs...)
+	}
+	return out, nil
+}
+
+// readObjects reads all objects from the supplied YAML (or JSON) file. The
+// file may contain multiple YAML documents.
+func readObjects(path string) ([]map[string]any, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open %s", path)
+	}
+	defer f.Close() //nolint:errcheck // Only reading.
+
+	out := []map[string]any{}
+	d := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := map[string]any{}
+		if err := d.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return out, nil
+			}
+			return nil, errors.Wrapf(err, "cannot parse %s", path)
+		}
+		// Skip empty documents.
+		if len(obj) == 0 {
+			continue
+		}
+		out = append(out, obj)
+	}
+}
+
+// readObjectsStrict is like readObjects, but returns an error if an object has
+// duplicate keys, for example a base template with two spec fields. The error
+// includes the line of the duplicate key within its YAML document.
+func readObjectsStrict(path string) ([]map[string]any, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open %s", path)
+	}
+	defer f.Close() //nolint:errcheck // Only reading.
+
+	out := []map[string]any{}
+	r := yaml.NewYAMLReader(bufio.NewReader(f))
+	for i := 1; ; i++ {
+		doc, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read %s", path)
+		}
+		// Strict unmarshalling detects duplicate keys, but converts numbers
+		// to float64, so we decode the document again below.
+		var v any
+		if err := sigsyaml.UnmarshalStrict(doc, &v); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse document %d of %s", i, path)
+		}
+		obj := map[string]any{}
+		if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(doc), 4096).Decode(&obj); err != nil && !errors.Is(err, io.EOF) {
+			return nil, errors.Wrapf(err, "cannot parse document %d of %s", i, path)
+		}
+		// Skip empty documents.
+		if len(obj) == 0 {
+			continue
+		}
+		out = append(out, obj)
+	}
+}
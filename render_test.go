@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+func TestReadInput(t *testing.T) {
+	resources := `
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: bucket
+`
+	composition := `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: cool
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: other
+    functionRef:
+      name: function-other
+    input:
+      apiVersion: other.fn.crossplane.io/v1beta1
+      kind: Input
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      resources:
+      - name: bucket
+`
+	input := map[string]any{
+		"apiVersion": "pt.fn.crossplane.io/v1beta1",
+		"kind":       "Resources",
+		"resources":  []any{map[string]any{"name": "bucket"}},
+	}
+
+	type args struct {
+		content string
+		step    string
+	}
+	type want struct {
+		in  map[string]any
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Resources": {
+			reason: "A file containing a Resources object should be returned as is.",
+			args:   args{content: resources},
+			want:   want{in: input},
+		},
+		"Composition": {
+			reason: "The first Resources input of a Composition pipeline should be returned.",
+			args:   args{content: composition},
+			want:   want{in: input},
+		},
+		"CompositionNamedStep": {
+			reason: "The input of the named Composition pipeline step should be returned.",
+			args:   args{content: composition, step: "patch-and-transform"},
+			want:   want{in: input},
+		},
+		"CompositionWrongStep": {
+			reason: "We should return an error if the named step's input isn't a Resources object.",
+			args:   args{content: composition, step: "other"},
+			want: want{
+				err: errors.New(`cannot find Composition pipeline step "other" whose input is a Resources object`),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "input.yaml")
+			if err := os.WriteFile(path, []byte(tc.args.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			in, err := ReadInput(path, tc.args.step)
+			if diff := cmp.Diff(tc.want.in, in); diff != "" {
+				t.Errorf("\n%s\nReadInput(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nReadInput(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	xr := map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "XR",
+		"metadata":   map[string]any{"name": "cool-xr"},
+	}
+	rsp := &fnv1.RunFunctionResponse{
+		Desired: &fnv1.State{
+			Composite: &fnv1.Resource{
+				Resource: resource.MustStructJSON(`{"status":{"widgets":"10"}}`),
+			},
+			Resources: map[string]*fnv1.Resource{
+				"b": {Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+				"a": {Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+			},
+		},
+		Results: []*fnv1.Result{
+			{Severity: fnv1.Severity_SEVERITY_WARNING, Message: "careful"},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	results := &bytes.Buffer{}
+	if err := writeResponse(out, results, xr, rsp); err != nil {
+		t.Fatalf("writeResponse(...): %s", err)
+	}
+
+	wantOut := `---
+apiVersion: example.org/v1
+kind: XR
+metadata:
+  name: cool-xr
+status:
+  widgets: "10"
+---
+apiVersion: example.org/v1
+kind: CD
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: a
+---
+apiVersion: example.org/v1
+kind: CD
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: b
+`
+	if diff := cmp.Diff(wantOut, out.String()); diff != "" {
+		t.Errorf("writeResponse(...): -want output, +got output:\n%s", diff)
+	}
+	if diff := cmp.Diff("SEVERITY_WARNING: careful\n", results.String()); diff != "" {
+		t.Errorf("writeResponse(...): -want results, +got results:\n%s", diff)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/response"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// ContextKeyResults is the Function pipeline context key at which structured
+// details of any results are recorded. Results only support a message, so
+// tooling that needs to know which resource or patch a result is about should
+// read this context key instead.
+const ContextKeyResults = "pt.fn.crossplane.io/results"
+
+// Reasons for results.
+const (
+	ReasonInvalidInput                 = "InvalidInput"
+	ReasonSuspiciousInput              = "SuspiciousInput"
+	ReasonInvalidEnvironment           = "InvalidEnvironment"
+	ReasonInvalidBase                  = "InvalidBase"
+	ReasonBaseNotObserved              = "BaseNotObserved"
+	ReasonPolicyFailed                 = "PolicyFailed"
+	ReasonPatchFailed                  = "PatchFailed"
+	ReasonRequiredFieldPathNotFound    = "RequiredFieldPathNotFound"
+	ReasonConnectionDetailsFailed      = "ConnectionDetailsFailed"
+	ReasonConnectionDetailsUnsupported = "ConnectionDetailsUnsupported"
+	ReasonReadinessCheckFailed         = "ReadinessCheckFailed"
+	ReasonExternalNameFailed           = "ExternalNameFailed"
+	ReasonMetadataFailed               = "MetadataFailed"
+	ReasonReadyTimeout                 = "ReadyTimeout"
+	ReasonAnnotationProtected          = "AnnotationProtected"
+	ReasonComposedResourcePruned       = "ComposedResourcePruned"
+	ReasonInventoryFailed              = "InventoryFailed"
+	ReasonComposedResourceConflict     = "ComposedResourceConflict"
+	ReasonFieldOwnershipFailed         = "FieldOwnershipFailed"
+)
+
+// ResultDetails are structured details of a result.
+type ResultDetails struct {
+	// Reason is a PascalCase, machine-readable reason for the result.
+	Reason string
+
+	// Resource is the name of the resource template the result is about, if
+	// any.
+	Resource string
+
+	// PatchIndex and PatchType identify the patch the result is about, if any.
+	PatchIndex *int
+	PatchType  v1beta1.PatchType
+
+	// Target is what the result's event is emitted for. It defaults to the
+	// composite resource. Use TARGET_COMPOSITE_AND_CLAIM for results the
+	// claim's owner can act on.
+	Target fnv1.Target
+}
+
+// Fatal adds a fatal result with the supplied details to the supplied
+// response. Fatal results always target the composite resource, because
+// Crossplane doesn't emit them for the claim.
+func Fatal(rsp *fnv1.RunFunctionResponse, err error, d ResultDetails) {
+	response.Fatal(rsp, err)
+	r := rsp.GetResults()[len(rsp.GetResults())-1]
+	r.Reason = &d.Reason
+	r.Target = fnv1.Target_TARGET_COMPOSITE.Enum()
+	recordResult(rsp, r, d)
+}
+
+// Warning adds a warning result with the supplied details to the supplied
+// response.
+func Warning(rsp *fnv1.RunFunctionResponse, err error, d ResultDetails) {
+	o := response.Warning(rsp, err).WithReason(d.Reason)
+	switch d.Target {
+	case fnv1.Target_TARGET_COMPOSITE_AND_CLAIM:
+		o.TargetCompositeAndClaim()
+	default:
+		o.TargetComposite()
+	}
+	recordResult(rsp, rsp.GetResults()[len(rsp.GetResults())-1], d)
+}
+
+// recordResult appends the details of the supplied result to the list at the
+// results context key.
+func recordResult(rsp *fnv1.RunFunctionResponse, r *fnv1.Result, d ResultDetails) {
+	fields := map[string]*structpb.Value{
+		"severity": structpb.NewStringValue(r.GetSeverity().String()),
+		"reason":   structpb.NewStringValue(d.Reason),
+		"message":  structpb.NewStringValue(r.GetMessage()),
+		"target":   structpb.NewStringValue(r.GetTarget().String()),
+	}
+	if d.Resource != "" {
+		fields["resource"] = structpb.NewStringValue(d.Resource)
+	}
+	if d.PatchIndex != nil {
+		fields["patchIndex"] = structpb.NewNumberValue(float64(*d.PatchIndex))
+	}
+	if d.PatchType != "" {
+		fields["patchType"] = structpb.NewStringValue(string(d.PatchType))
+	}
+
+	l := rsp.GetContext().GetFields()[ContextKeyResults].GetListValue()
+	if l == nil {
+		l = &structpb.ListValue{}
+	}
+	l.Values = append(l.GetValues(), structpb.NewStructValue(&structpb.Struct{Fields: fields}))
+	response.SetContextKey(rsp, ContextKeyResults, structpb.NewListValue(l))
+}
+
+// MergeResults appends the results and conditions recorded in one response to
+// another, along with the results' structured details. If the response being
+// merged requested a shorter TTL, the other response's TTL is shortened too.
+func MergeResults(to, from *fnv1.RunFunctionResponse) {
+	to.Results = append(to.GetResults(), from.GetResults()...)
+	to.Conditions = append(to.GetConditions(), from.GetConditions()...)
+	if ttl := from.GetMeta().GetTtl(); ttl != nil {
+		requeueAfter(to, ttl.AsDuration())
+	}
+
+	ml := from.GetContext().GetFields()[ContextKeyResults].GetListValue()
+	if len(ml.GetValues()) == 0 {
+		return
+	}
+	l := to.GetContext().GetFields()[ContextKeyResults].GetListValue()
+	if l == nil {
+		l = &structpb.ListValue{}
+	}
+	l.Values = append(l.GetValues(), ml.GetValues()...)
+	response.SetContextKey(to, ContextKeyResults, structpb.NewListValue(l))
+}
+
+// A ResultCollector collects the results of rendering resource templates. It
+// aggregates warnings that are the same except for the resource template they
+// are about into one warning, so a problem that affects many resource
+// templates doesn't flood the composite resource's events.
+type ResultCollector struct {
+	results []*collectedResult
+	classes map[string]*collectedResult
+
+	// rest holds any conditions and requested TTL.
+	rest *fnv1.RunFunctionResponse
+}
+
+type collectedResult struct {
+	result    *fnv1.Result
+	details   *structpb.Struct
+	resources []string
+}
+
+// NewResultCollector returns a new ResultCollector.
+func NewResultCollector() *ResultCollector {
+	return &ResultCollector{classes: map[string]*collectedResult{}, rest: &fnv1.RunFunctionResponse{}}
+}
+
+// Collect the results of the supplied response.
+func (c *ResultCollector) Collect(rsp *fnv1.RunFunctionResponse) {
+	c.rest.Conditions = append(c.rest.GetConditions(), rsp.GetConditions()...)
+	if ttl := rsp.GetMeta().GetTtl(); ttl != nil {
+		requeueAfter(c.rest, ttl.AsDuration())
+	}
+
+	// Results only have structured details if they were added using Fatal
+	// or Warning. If any weren't we can't tell which details belong to
+	// which result, so we don't aggregate them.
+	details := rsp.GetContext().GetFields()[ContextKeyResults].GetListValue().GetValues()
+	paired := len(details) == len(rsp.GetResults())
+
+	for i, r := range rsp.GetResults() {
+		cr := &collectedResult{result: r}
+		if paired {
+			cr.details = details[i].GetStructValue()
+		}
+		name := cr.details.GetFields()["resource"].GetStringValue()
+		if r.GetSeverity() != fnv1.Severity_SEVERITY_WARNING || name == "" {
+			c.results = append(c.results, cr)
+			continue
+		}
+		class := r.GetReason() + "/" + r.GetTarget().String() + "/" + strings.ReplaceAll(r.GetMessage(), strconv.Quote(name), "%q")
+		if agg, ok := c.classes[class]; ok {
+			agg.resources = append(agg.resources, name)
+			continue
+		}
+		cr.resources = []string{name}
+		c.classes[class] = cr
+		c.results = append(c.results, cr)
+	}
+}
+
+// MergeInto merges the collected results into the supplied response, in the
+// order they were collected. An aggregated warning has the message of the
+// first warning it aggregates, and lists the other resource templates it's
+// about. Its structured details include the names of all of the resource
+// templates it's about, and how many there are.
+func (c *ResultCollector) MergeInto(to *fnv1.RunFunctionResponse) {
+	from := proto.Clone(c.rest).(*fnv1.RunFunctionResponse) //nolint:forcetypeassert // Clone returns the type it's passed.
+	details := &structpb.ListValue{}
+	for _, cr := range c.results {
+		r, d := cr.result, cr.details
+		if n := len(cr.resources); n > 1 {
+			quoted := make([]string, 0, n-1)
+			for _, name := range cr.resources[1:] {
+				quoted = append(quoted, strconv.Quote(name))
+			}
+			r = proto.Clone(r).(*fnv1.Result) //nolint:forcetypeassert // Clone returns the type it's passed.
+			r.Message = fmt.Sprintf("%s (also affects %d other resource templates: %s)", r.GetMessage(), n-1, strings.Join(quoted, ", "))
+
+			d = proto.Clone(d).(*structpb.Struct) //nolint:forcetypeassert // Clone returns the type it's passed.
+			names := make([]*structpb.Value, 0, n)
+			for _, name := range cr.resources {
+				names = append(names, structpb.NewStringValue(name))
+			}
+			d.Fields["message"] = structpb.NewStringValue(r.GetMessage())
+			d.Fields["resources"] = structpb.NewListValue(&structpb.ListValue{Values: names})
+			d.Fields["count"] = structpb.NewNumberValue(float64(n))
+		}
+		from.Results = append(from.GetResults(), r)
+		if d != nil {
+			details.Values = append(details.GetValues(), structpb.NewStructValue(d))
+		}
+	}
+	if len(details.GetValues()) > 0 {
+		response.SetContextKey(from, ContextKeyResults, structpb.NewListValue(details))
+	}
+	MergeResults(to, from)
+}
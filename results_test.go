@@ -0,0 +1,185 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/response"
+)
+
+func TestResultCollector(t *testing.T) {
+	warning := func(name, msg string) *fnv1.RunFunctionResponse {
+		rsp := &fnv1.RunFunctionResponse{}
+		Warning(rsp, errors.New(msg), ResultDetails{Reason: ReasonPatchFailed, Resource: name})
+		return rsp
+	}
+
+	cases := map[string]struct {
+		reason string
+		rsps   []*fnv1.RunFunctionResponse
+		want   *fnv1.RunFunctionResponse
+	}{
+		"NoResults": {
+			reason: "Collecting responses without results shouldn't add any.",
+			rsps:   []*fnv1.RunFunctionResponse{{}, {}},
+			want:   &fnv1.RunFunctionResponse{},
+		},
+		"Aggregated": {
+			reason: "Warnings that differ only by resource template should be aggregated into the first.",
+			rsps: []*fnv1.RunFunctionResponse{
+				warning("a", `cannot render composed resource "a": spec.size: no such field`),
+				warning("b", `cannot render composed resource "b": spec.region: no such field`),
+				warning("c", `cannot render composed resource "c": spec.size: no such field`),
+				warning("d", `cannot render composed resource "d": spec.size: no such field`),
+			},
+			want: &fnv1.RunFunctionResponse{
+				Results: []*fnv1.Result{
+					{
+						Severity: fnv1.Severity_SEVERITY_WARNING,
+						Message:  `cannot render composed resource "a": spec.size: no such field (also affects 2 other resource templates: "c", "d")`,
+						Reason:   ptr.To(ReasonPatchFailed),
+						Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+					},
+					{
+						Severity: fnv1.Severity_SEVERITY_WARNING,
+						Message:  `cannot render composed resource "b": spec.region: no such field`,
+						Reason:   ptr.To(ReasonPatchFailed),
+						Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+					},
+				},
+				Context: contextWithResults(nil,
+					map[string]any{
+						"severity":  "SEVERITY_WARNING",
+						"reason":    ReasonPatchFailed,
+						"message":   `cannot render composed resource "a": spec.size: no such field (also affects 2 other resource templates: "c", "d")`,
+						"resource":  "a",
+						"resources": []any{"a", "c", "d"},
+						"count":     3,
+					},
+					map[string]any{
+						"severity": "SEVERITY_WARNING",
+						"reason":   ReasonPatchFailed,
+						"message":  `cannot render composed resource "b": spec.region: no such field`,
+						"resource": "b",
+					},
+				),
+			},
+		},
+		"NotAggregated": {
+			reason: "Results without structured details shouldn't be aggregated.",
+			rsps: func() []*fnv1.RunFunctionResponse {
+				a := &fnv1.RunFunctionResponse{}
+				response.Warning(a, errors.New("cool warning"))
+				b := &fnv1.RunFunctionResponse{}
+				response.Warning(b, errors.New("cool warning"))
+				return []*fnv1.RunFunctionResponse{a, b}
+			}(),
+			want: &fnv1.RunFunctionResponse{
+				Results: []*fnv1.Result{
+					{Severity: fnv1.Severity_SEVERITY_WARNING, Message: "cool warning", Target: fnv1.Target_TARGET_COMPOSITE.Enum()},
+					{Severity: fnv1.Severity_SEVERITY_WARNING, Message: "cool warning", Target: fnv1.Target_TARGET_COMPOSITE.Enum()},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewResultCollector()
+			for _, rsp := range tc.rsps {
+				c.Collect(rsp)
+			}
+			got := &fnv1.RunFunctionResponse{}
+			c.MergeInto(got)
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nMergeInto(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWarningAndFatal(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		add    func(rsp *fnv1.RunFunctionResponse)
+		want   *fnv1.RunFunctionResponse
+	}{
+		"WarningTargetsComposite": {
+			reason: "A warning should target the composite resource by default.",
+			add: func(rsp *fnv1.RunFunctionResponse) {
+				Warning(rsp, errors.New("cool warning"), ResultDetails{Reason: ReasonPatchFailed, Resource: "a"})
+			},
+			want: &fnv1.RunFunctionResponse{
+				Results: []*fnv1.Result{{
+					Severity: fnv1.Severity_SEVERITY_WARNING,
+					Message:  "cool warning",
+					Reason:   ptr.To(ReasonPatchFailed),
+					Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+				}},
+				Context: contextWithResults(nil, map[string]any{
+					"severity": "SEVERITY_WARNING",
+					"reason":   ReasonPatchFailed,
+					"message":  "cool warning",
+					"resource": "a",
+					"target":   "TARGET_COMPOSITE",
+				}),
+			},
+		},
+		"WarningTargetsCompositeAndClaim": {
+			reason: "A warning should target the composite resource and claim if its details say so.",
+			add: func(rsp *fnv1.RunFunctionResponse) {
+				Warning(rsp, errors.New("cool warning"), ResultDetails{Reason: ReasonRequiredFieldPathNotFound, Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM})
+			},
+			want: &fnv1.RunFunctionResponse{
+				Results: []*fnv1.Result{{
+					Severity: fnv1.Severity_SEVERITY_WARNING,
+					Message:  "cool warning",
+					Reason:   ptr.To(ReasonRequiredFieldPathNotFound),
+					Target:   fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+				}},
+				Context: contextWithResults(nil, map[string]any{
+					"severity": "SEVERITY_WARNING",
+					"reason":   ReasonRequiredFieldPathNotFound,
+					"message":  "cool warning",
+					"target":   "TARGET_COMPOSITE_AND_CLAIM",
+				}),
+			},
+		},
+		"FatalTargetsComposite": {
+			reason: "A fatal result should always target the composite resource, because Crossplane doesn't emit them for the claim.",
+			add: func(rsp *fnv1.RunFunctionResponse) {
+				Fatal(rsp, errors.New("cool fatal"), ResultDetails{Reason: ReasonPatchFailed, Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM})
+			},
+			want: &fnv1.RunFunctionResponse{
+				Results: []*fnv1.Result{{
+					Severity: fnv1.Severity_SEVERITY_FATAL,
+					Message:  "cool fatal",
+					Reason:   ptr.To(ReasonPatchFailed),
+					Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+				}},
+				Context: contextWithResults(nil, map[string]any{
+					"severity": "SEVERITY_FATAL",
+					"reason":   ReasonPatchFailed,
+					"message":  "cool fatal",
+					"target":   "TARGET_COMPOSITE",
+				}),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := &fnv1.RunFunctionResponse{}
+			tc.add(got)
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nadd(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Schemas are the OpenAPI schemas of composite and composed resources.
+type Schemas map[schema.GroupVersionKind]*extv1.JSONSchemaProps
+
+// LoadSchemas loads the schemas of all CRDs and XRDs in the supplied YAML
+// files, or in the YAML files in the supplied directories.
+func LoadSchemas(paths ...string) (Schemas, error) {
+	s := Schemas{}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot stat %s", path)
+		}
+		var objs []map[string]any
+		if fi.IsDir() {
+			objs, err = readObjectsDir(path)
+		} else {
+			objs, err = readObjects(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if err := s.add(obj); err != nil {
+				return nil, errors.Wrapf(err, "cannot load schema from %s", path)
+			}
+		}
+	}
+	return s, nil
+}
+
+// add the schemas of the supplied object, if it's a CRD or XRD.
+func (s Schemas) add(obj map[string]any) error {
+	p := fieldpath.Pave(obj)
+	kind, _ := p.GetString("kind")
+	if kind != "CustomResourceDefinition" && kind != "CompositeResourceDefinition" {
+		return nil
+	}
+	group, _ := p.GetString("spec.group")
+	k, _ := p.GetString("spec.names.kind")
+	versions := []struct {
+		Name   string `json:"name"`
+		Schema *struct {
+			OpenAPIV3Schema *extv1.JSONSchemaProps `json:"openAPIV3Schema"`
+		} `json:"schema"`
+	}{}
+	if err := p.GetValueInto("spec.versions", &versions); err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		s[schema.GroupVersionKind{Group: group, Version: v.Name, Kind: k}] = v.Schema.OpenAPIV3Schema
+	}
+	return nil
+}
+
+// CheckSchemas checks the field paths of the supplied input's patches against
+// the schemas of the resources they patch from and to. It checks that the
+// field paths exist, and that the type of a patched value is compatible with
+// the field it's patched to. It returns a warning for each problem found.
+// Resources without a known schema aren't checked.
+func CheckSchemas(r *v1beta1.Resources, xr schema.GroupVersionKind, s Schemas) []string {
+	warnings := []string{}
+	xrs := s[xr]
+
+	cts, err := ComposedTemplates(r.PatchSets, WithDefaultPatches(r.Defaults, r.Resources))
+	if err != nil {
+		return append(warnings, err.Error())
+	}
+	for i, t := range cts {
+		cds := s[baseGVK(t)]
+		for j := range t.Patches {
+			p := &t.Patches[j]
+			var from, to *extv1.JSONSchemaProps
+			switch p.GetType() { //nolint:exhaustive // Other patch types are between the environment or context.
+			case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite:
+				from, to = xrs, cds
+			case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
+				from, to = cds, xrs
+			case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment, v1beta1.PatchTypeFromContextFieldPath, v1beta1.PatchTypeFromExtraResourceFieldPath, v1beta1.PatchTypeFromClaimFieldPath, v1beta1.PatchTypeFromVariableFieldPath:
+				to = cds
+			case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment, v1beta1.PatchTypeToContextFieldPath:
+				from = cds
+			}
+			for _, w := range CheckPatchSchema(p, from, to) {
+				warnings = append(warnings, fmt.Sprintf("resources[%d].patches[%d]: %s", i, j, w))
+			}
+		}
+	}
+
+	if r.Environment == nil {
+		return warnings
+	}
+	for i := range r.Environment.Patches {
+		p := &r.Environment.Patches[i]
+		var from, to *extv1.JSONSchemaProps
+		switch p.GetType() { //nolint:exhaustive // Other patch types aren't valid environment patches.
+		case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineFromComposite:
+			from = xrs
+		case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineToComposite:
+			to = xrs
+		}
+		for _, w := range CheckPatchSchema(p, from, to) {
+			warnings = append(warnings, fmt.Sprintf("environment.patches[%d]: %s", i, w))
+		}
+	}
+	return warnings
+}
+
+// CheckPatchSchema checks the supplied patch against the supplied schemas of
+// the resources it patches from and to. Either schema may be nil, in which
+// case it's not checked.
+func CheckPatchSchema(p PatchInterface, from, to *extv1.JSONSchemaProps) []string {
+	warnings := []string{}
+
+	// The type of the value being patched, if we know it.
+	var vt string
+	if c := p.GetCombine(); c != nil {
+		for i, v := range c.Variables {
+			if _, err := SchemaAt(from, v.FromFieldPath); err != nil {
+				warnings = append(warnings, fmt.Sprintf("combine.variables[%d].fromFieldPath: %s", i, err))
+			}
+		}
+		switch c.Strategy {
+		case v1beta1.CombineStrategyString, v1beta1.CombineStrategyJoin:
+			vt = "string"
+		case v1beta1.CombineStrategyObject, v1beta1.CombineStrategyMerge:
+			vt = "object"
+		}
+	} else {
+		fs, err := SchemaAt(from, p.GetFromFieldPath())
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("fromFieldPath: %s", err))
+		}
+		if fs != nil {
+			vt = fs.Type
+		}
+	}
+
+	for i := range p.GetTransforms() {
+		t := p.GetTransforms()[i]
+		ot, err := t.GetOutputType()
+		if err != nil || ot == nil {
+			vt = ""
+			continue
+		}
+		vt = schemaType(*ot)
+	}
+
+	// We can't know what field path a template will render to.
+	if IsToFieldPathTemplate(p.GetToFieldPath()) {
+		return warnings
+	}
+
+	ts, err := SchemaAt(to, p.GetToFieldPath())
+	if err != nil {
+		return append(warnings, fmt.Sprintf("toFieldPath: %s", err))
+	}
+	if ts != nil && !compatible(vt, ts) {
+		warnings = append(warnings, fmt.Sprintf("toFieldPath: cannot patch a value of type %s to %s, which is of type %s", vt, p.GetToFieldPath(), ts.Type))
+	}
+	return warnings
+}
+
+// SchemaAt returns the schema of the supplied field path. It returns nil if
+// the schema can't be determined, for example because the supplied schema is
+// nil or the field path is within an object that preserves unknown fields. It
+// returns an error if the field path doesn't exist in the schema.
+func SchemaAt(s *extv1.JSONSchemaProps, path string) (*extv1.JSONSchemaProps, error) {
+	if s == nil || path == "" {
+		return nil, nil
+	}
+	if path == FieldPathWholeObject {
+		return s, nil
+	}
+	segs, err := fieldpath.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	// Schemas don't always describe the standard fields of a resource.
+	if len(segs) > 0 && segs[0].Type == fieldpath.SegmentField {
+		switch segs[0].Field {
+		case "apiVersion", "kind", "metadata":
+			return nil, nil
+		}
+	}
+	for i, seg := range segs {
+		if ptr.Deref(s.XPreserveUnknownFields, false) {
+			return nil, nil
+		}
+		switch {
+		case s.Type == "array" && (seg.Type == fieldpath.SegmentIndex || seg.Field == "*" || isKeySelector(seg)):
+			if s.Items == nil || s.Items.Schema == nil {
+				return nil, nil
+			}
+			s = s.Items.Schema
+		case seg.Type == fieldpath.SegmentField && s.Properties != nil && hasProperty(s, seg.Field):
+			p := s.Properties[seg.Field]
+			s = &p
+		case seg.Type == fieldpath.SegmentField && s.AdditionalProperties != nil && s.AdditionalProperties.Allows:
+			if s.AdditionalProperties.Schema == nil {
+				return nil, nil
+			}
+			s = s.AdditionalProperties.Schema
+		default:
+			return nil, errors.Errorf("%s does not exist in the schema", fieldpath.Segments(segs[:i+1]).String())
+		}
+	}
+	return s, nil
+}
+
+// isKeySelector returns true if the supplied segment selects an array element
+// by key.
+func isKeySelector(s fieldpath.Segment) bool {
+	_, _, ok := keySelector(s)
+	return ok
+}
+
+// hasProperty returns true if the supplied schema has the supplied property.
+func hasProperty(s *extv1.JSONSchemaProps, name string) bool {
+	_, ok := s.Properties[name]
+	return ok
+}
+
+// schemaType returns the OpenAPI type corresponding to the supplied transform
+// type.
+func schemaType(t v1beta1.TransformIOType) string {
+	switch t {
+	case v1beta1.TransformIOTypeString:
+		return "string"
+	case v1beta1.TransformIOTypeBool:
+		return "boolean"
+	case v1beta1.TransformIOTypeInt, v1beta1.TransformIOTypeInt64:
+		return "integer"
+	case v1beta1.TransformIOTypeFloat64:
+		return "number"
+	case v1beta1.TransformIOTypeObject:
+		return "object"
+	case v1beta1.TransformIOTypeArray:
+		return "array"
+	}
+	return ""
+}
+
+// compatible returns true if a value of the supplied OpenAPI type may be
+// patched to a field with the supplied schema. Unknown types are assumed to be
+// compatible.
+func compatible(t string, s *extv1.JSONSchemaProps) bool {
+	if t == "" || s.Type == "" || t == s.Type {
+		return true
+	}
+	// Math transforms preserve integers, so we can't tell integers and numbers
+	// apart.
+	numeric := func(t string) bool { return t == "integer" || t == "number" }
+	if numeric(t) && numeric(s.Type) {
+		return true
+	}
+	return s.XIntOrString && (t == "string" || t == "integer")
+}
+
+// baseGVK returns the GVK of the supplied template's base, if any.
+func baseGVK(t v1beta1.ComposedTemplate) schema.GroupVersionKind {
+	if t.Base == nil {
+		return schema.GroupVersionKind{}
+	}
+	tm := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}{}
+	if err := json.Unmarshal(t.Base.Raw, &tm); err != nil {
+		return schema.GroupVersionKind{}
+	}
+	return schema.FromAPIVersionAndKind(tm.APIVersion, tm.Kind)
+}
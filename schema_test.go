@@ -0,0 +1,181 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func testSchema() *extv1.JSONSchemaProps {
+	return &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"spec": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"region": {Type: "string"},
+					"size":   {Type: "integer"},
+					"port":   {XIntOrString: true},
+					"tags": {
+						Type:                 "object",
+						AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Allows: true, Schema: &extv1.JSONSchemaProps{Type: "string"}},
+					},
+					"rules": {
+						Type: "array",
+						Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+							Type: "object",
+							Properties: map[string]extv1.JSONSchemaProps{
+								"cidr": {Type: "string"},
+							},
+						}},
+					},
+					"parameters": {
+						Type:                   "object",
+						XPreserveUnknownFields: ptr.To(true),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSchemaAt(t *testing.T) {
+	type want struct {
+		typ string
+		err bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   want
+	}{
+		"Property":             {reason: "A property should be found.", path: "spec.region", want: want{typ: "string"}},
+		"AdditionalProperties": {reason: "Additional properties should be found.", path: "spec.tags[example.org/team]", want: want{typ: "string"}},
+		"ArrayIndex":           {reason: "Array items should be found by index.", path: "spec.rules[0].cidr", want: want{typ: "string"}},
+		"ArrayWildcard":        {reason: "Array items should be found by wildcard.", path: "spec.rules[*].cidr", want: want{typ: "string"}},
+		"PreserveUnknown":      {reason: "Fields that preserve unknown fields should accept any path.", path: "spec.parameters.anything.goes", want: want{}},
+		"Metadata":             {reason: "Metadata shouldn't be checked.", path: "metadata.labels[example.org/team]", want: want{}},
+		"Missing":              {reason: "A missing property should return an error.", path: "spec.regoin", want: want{err: true}},
+		"MissingNested":        {reason: "A missing nested property should return an error.", path: "spec.rules[0].cdir", want: want{err: true}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := SchemaAt(testSchema(), tc.path)
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("\n%s\nSchemaAt(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			got := ""
+			if s != nil {
+				got = s.Type
+			}
+			if diff := cmp.Diff(tc.want.typ, got); diff != "" {
+				t.Errorf("\n%s\nSchemaAt(...): -want type, +got type:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckPatchSchema(t *testing.T) {
+	type args struct {
+		p        PatchInterface
+		from, to *extv1.JSONSchemaProps
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"Valid": {
+			reason: "A patch between compatible fields should not return warnings.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{FromFieldPath: ptr.To[string]("spec.region")},
+				},
+				from: testSchema(),
+				to:   testSchema(),
+			},
+			want: []string{},
+		},
+		"MissingFields": {
+			reason: "A patch between fields that don't exist should return warnings.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.regoin"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.region"),
+					},
+				},
+				from: testSchema(),
+				to:   testSchema(),
+			},
+			want: []string{
+				"fromFieldPath: spec.regoin does not exist in the schema",
+				"toFieldPath: spec.forProvider does not exist in the schema",
+			},
+		},
+		"IncompatibleTransform": {
+			reason: "A patch whose transforms output a value incompatible with the target field should return a warning.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						ToFieldPath:   ptr.To[string]("spec.size"),
+						Transforms: []v1beta1.Transform{{
+							Type:   v1beta1.TransformTypeString,
+							String: &v1beta1.StringTransform{Type: v1beta1.StringTransformTypeFormat, Format: ptr.To[string]("%d")},
+						}},
+					},
+				},
+				from: testSchema(),
+				to:   testSchema(),
+			},
+			want: []string{
+				"toFieldPath: cannot patch a value of type string to spec.size, which is of type integer",
+			},
+		},
+		"IntOrString": {
+			reason: "A string may be patched to a field that is an int or a string.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.region"),
+						ToFieldPath:   ptr.To[string]("spec.port"),
+					},
+				},
+				from: testSchema(),
+				to:   testSchema(),
+			},
+			want: []string{},
+		},
+		"UnknownSchemas": {
+			reason: "A patch between resources without known schemas should not return warnings.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{FromFieldPath: ptr.To[string]("spec.regoin")},
+				},
+			},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CheckPatchSchema(tc.args.p, tc.args.from, tc.args.to)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nCheckPatchSchema(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
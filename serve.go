@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane/function-sdk-go"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+)
+
+// Default file names within the TLS certificates directory.
+const (
+	tlsCertFile = "tls.crt"
+	tlsKeyFile  = "tls.key"
+	tlsCAFile   = "ca.crt"
+)
+
+// Serve the supplied Function over gRPC, along with the supplied health
+// server if it isn't nil. Blocks until the server returns an error. This is
+// like function.Serve, but supports more server options.
+func (c *ServeCmd) Serve(fn fnv1.FunctionRunnerServiceServer, health healthpb.HealthServer) error {
+	creds, err := c.Credentials()
+	if err != nil {
+		return err
+	}
+
+	lis, err := net.Listen(c.Network, c.Address)
+	if err != nil {
+		return errors.Wrapf(err, "cannot listen for %s connections at address %q", c.Network, c.Address)
+	}
+
+	srv := grpc.NewServer(c.ServerOptions(creds)...)
+	reflection.Register(srv)
+	fnv1.RegisterFunctionRunnerServiceServer(srv, fn)
+	fnv1beta1.RegisterFunctionRunnerServiceServer(srv, function.ServeBeta(fn))
+	if health != nil {
+		healthpb.RegisterHealthServer(srv, health)
+	}
+	return errors.Wrap(srv.Serve(lis), "cannot serve gRPC connections")
+}
+
+// ServeHealth serves the supplied health server over gRPC without TLS, using
+// the supplied listener. Blocks until the server returns an error. Kubernetes
+// gRPC probes can't present a client certificate, so they can't check the
+// health server registered alongside the Function, which requires mTLS.
+func ServeHealth(lis net.Listener, health healthpb.HealthServer) error {
+	srv := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
+	healthpb.RegisterHealthServer(srv, health)
+	return errors.Wrap(srv.Serve(lis), "cannot serve gRPC health checks")
+}
+
+// ServerOptions returns the gRPC server options specified by the command's
+// flags.
+func (c *ServeCmd) ServerOptions(creds credentials.TransportCredentials) []grpc.ServerOption {
+	o := []grpc.ServerOption{grpc.Creds(creds), grpc.MaxRecvMsgSize(c.MaxRecvMessageSize)}
+	if c.MaxSendMessageSize > 0 {
+		o = append(o, grpc.MaxSendMsgSize(c.MaxSendMessageSize))
+	}
+	if c.KeepaliveTime > 0 || c.KeepaliveTimeout > 0 {
+		// Zero values use gRPC's defaults.
+		o = append(o, grpc.KeepaliveParams(keepalive.ServerParameters{Time: c.KeepaliveTime, Timeout: c.KeepaliveTimeout}))
+	}
+	if c.KeepaliveMinTime > 0 {
+		o = append(o, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: c.KeepaliveMinTime, PermitWithoutStream: true}))
+	}
+	return o
+}
+
+// Credentials returns the transport credentials specified by the command's
+// flags. The server certificate, key, and CA certificate are loaded from the
+// TLS certificates directory, unless their paths are specified explicitly.
+func (c *ServeCmd) Credentials() (credentials.TransportCredentials, error) {
+	if c.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	path := func(file, name string) string {
+		if file != "" {
+			return filepath.Clean(file)
+		}
+		return filepath.Clean(filepath.Join(c.TLSCertsDir, name))
+	}
+	if c.TLSCertsDir == "" && (c.TLSCertFile == "" || c.TLSKeyFile == "" || c.TLSCAFile == "") {
+		return nil, errors.New("no credentials provided - did you specify --insecure or --tls-certs-dir?")
+	}
+
+	crt, err := tls.LoadX509KeyPair(path(c.TLSCertFile, tlsCertFile), path(c.TLSKeyFile, tlsKeyFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load X509 keypair")
+	}
+
+	ca, err := os.ReadFile(path(c.TLSCAFile, tlsCAFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read CA certificate")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid CA certificate")
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{crt},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestCredentials(t *testing.T) {
+	type want struct {
+		protocol string
+		err      error
+	}
+	cases := map[string]struct {
+		reason string
+		c      ServeCmd
+		want   want
+	}{
+		"Insecure": {
+			reason: "Insecure credentials should be returned if --insecure is set, even if TLS flags are set.",
+			c:      ServeCmd{Insecure: true, TLSCertsDir: "/nonexistent"},
+			want:   want{protocol: "insecure"},
+		},
+		"NoCredentials": {
+			reason: "An error should be returned if neither --insecure nor TLS flags are set.",
+			c:      ServeCmd{},
+			want:   want{err: cmpopts.AnyError},
+		},
+		"MissingCertificates": {
+			reason: "An error should be returned if the TLS certificates can't be loaded.",
+			c:      ServeCmd{TLSCertFile: "/nonexistent/tls.crt", TLSKeyFile: "/nonexistent/tls.key", TLSCAFile: "/nonexistent/ca.crt"},
+			want:   want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			creds, err := tc.c.Credentials()
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nc.Credentials(): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.protocol, creds.Info().SecurityProtocol); diff != "" {
+				t.Errorf("\n%s\nc.Credentials(): -want protocol, +got protocol:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = ServeHealth(lis, NewHealthTracker(nil, 0)) }()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() //nolint:errcheck // Nothing to do if closing fails.
+
+	rsp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check(...): a probe without a client certificate should be able to check health: %v", err)
+	}
+	if diff := cmp.Diff(healthpb.HealthCheckResponse_SERVING, rsp.GetStatus()); diff != "" {
+		t.Errorf("Check(...): -want, +got:\n%s", diff)
+	}
+}
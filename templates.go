@@ -0,0 +1,641 @@
+package main
+
+import (
+	"context"
+	"iter"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// renderState is the state shared by all of the resource templates rendered
+// by a Function call.
+type renderState struct {
+	input  *v1beta1.Resources
+	parsed *parsedInput
+
+	oxr      *resource.Composite
+	dxr      *resource.Composite
+	observed map[resource.Name]resource.ObservedComposed
+	desired  map[resource.Name]*resource.DesiredComposed
+	env      *unstructured.Unstructured
+	fctx     *unstructured.Unstructured
+	extra    *unstructured.Unstructured
+	claim    *unstructured.Unstructured
+	vars     *unstructured.Unstructured
+	allowed  map[string]bool
+
+	xrAPIVersion string
+	xrKind       string
+}
+
+// A renderedTemplate is the outcome of rendering a resource template.
+type renderedTemplate struct {
+	// rsp holds any results, and any requested TTL. It's merged into the
+	// Function's response.
+	rsp *fnv1.RunFunctionResponse
+
+	// dcd is the rendered desired composed resource.
+	dcd *resource.DesiredComposed
+
+	// conn is the composite resource connection details extracted from the
+	// observed composed resource.
+	conn managed.ConnectionDetails
+
+	// pending is the names of FromStatusFieldPath connection details whose
+	// field path doesn't exist yet.
+	pending []string
+
+	// exists is true if the resource template corresponds to an existing,
+	// observed composed resource.
+	exists bool
+
+	// skipped is true if the desired composed resource shouldn't be added to
+	// the desired state, because a required field path wasn't found.
+	skipped bool
+
+	// ignored is true if the desired composed resource shouldn't be added to
+	// the desired state because it doesn't exist, and its resource template
+	// only renders it if it does. Unlike a skipped resource, an ignored
+	// resource doesn't stop the composite resource from becoming ready.
+	ignored bool
+
+	// fatal is true if rendering failed, and the Function should return.
+	fatal bool
+
+	warnings int
+}
+
+// renderTemplates renders the supplied resource templates, yielding the
+// outcome of each in the same order.
+//
+// Resource templates only interact by patching the desired composite resource,
+// the environment, or the Function pipeline context. Templates are rendered
+// concurrently unless one patches the environment or the context, which later
+// templates may read. A template waits until all the templates before it are
+// rendered before it patches the desired composite resource, so patches are
+// applied in the same order as if templates were rendered one at a time.
+func (f *Function) renderTemplates(ctx context.Context, log logging.Logger, s *renderState, cts []v1beta1.ComposedTemplate) iter.Seq2[int, *renderedTemplate] {
+	workers := min(f.maxConcurrentRenders, len(cts))
+	if workers <= 1 || !RenderIndependently(cts) {
+		return func(yield func(int, *renderedTemplate) bool) {
+			for i, t := range cts {
+				if !yield(i, f.renderTemplate(ctx, log, s, t, func() {})) {
+					return
+				}
+			}
+		}
+	}
+
+	return func(yield func(int, *renderedTemplate) bool) {
+		rts := make([]*renderedTemplate, len(cts))
+		done := make([]chan struct{}, len(cts))
+		for i := range done {
+			done[i] = make(chan struct{})
+		}
+
+		// Templates are sent to workers in order, so a template only ever
+		// waits for templates that are already being rendered.
+		next := make(chan int)
+		wg := &sync.WaitGroup{}
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					wait := func() {
+						for _, d := range done[:i] {
+							<-d
+						}
+					}
+					rts[i] = f.renderTemplate(ctx, log, s, cts[i], wait)
+					close(done[i])
+				}
+			}()
+		}
+		for i := range cts {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+
+		for i, rt := range rts {
+			if !yield(i, rt) {
+				return
+			}
+		}
+	}
+}
+
+// UnreadyDependencies returns the dependencies of the supplied resource template
+// that aren't ready. Only the supplied ready resource templates are ready.
+func UnreadyDependencies(t v1beta1.ComposedTemplate, ready map[string]bool) []string {
+	var unready []string
+	for _, name := range t.DependsOn {
+		if !ready[name] {
+			unready = append(unready, name)
+		}
+	}
+	return unready
+}
+
+// WaitingForEnvironment returns true if the supplied resource template waits
+// for a value the supplied environment doesn't have yet. Values are compared
+// as JSON, so numbers are equal regardless of their Go type.
+func WaitingForEnvironment(t v1beta1.ComposedTemplate, env *unstructured.Unstructured) bool {
+	if t.WaitFor == nil {
+		return false
+	}
+	v, err := fieldpath.Pave(env.Object).GetValue(t.WaitFor.EnvironmentFieldPath)
+	if err != nil {
+		return true
+	}
+	var want any
+	if err := json.Unmarshal(t.WaitFor.Equals.Raw, &want); err != nil {
+		return true
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return true
+	}
+	var got any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(got, want)
+}
+
+// A ComposedResourceConflict is a composed resource that two resource
+// templates render.
+type ComposedResourceConflict struct {
+	// Resource and Other are the names of the resource templates. Other is
+	// the earlier of the two.
+	Resource string
+	Other    string
+
+	GroupKind schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+// ConflictingComposedResources returns each of the supplied resource templates
+// whose desired composed resource has the same group, kind, namespace, and
+// name as that of an earlier resource template. Crossplane would apply both to
+// the same composed resource, so the last one applied wins. Composed resources
+// without a name aren't considered, because Crossplane generates one.
+func ConflictingComposedResources(cts []v1beta1.ComposedTemplate, desired map[resource.Name]*resource.DesiredComposed, renamed map[string]resource.Name) []ComposedResourceConflict {
+	type key struct {
+		gk        schema.GroupKind
+		namespace string
+		name      string
+	}
+	var conflicts []ComposedResourceConflict
+	seen := make(map[resource.Name]bool, len(cts))
+	rendered := make(map[key]string, len(cts))
+	for _, t := range cts {
+		name := resource.Name(t.Name)
+		if p, ok := renamed[t.Name]; ok {
+			name = p
+		}
+		dcd, ok := desired[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if dcd.Resource.GetName() == "" {
+			continue
+		}
+		k := key{gk: dcd.Resource.GroupVersionKind().GroupKind(), namespace: dcd.Resource.GetNamespace(), name: dcd.Resource.GetName()}
+		if other, ok := rendered[k]; ok {
+			conflicts = append(conflicts, ComposedResourceConflict{Resource: t.Name, Other: other, GroupKind: k.gk, Namespace: k.namespace, Name: k.name})
+			continue
+		}
+		rendered[k] = t.Name
+	}
+	return conflicts
+}
+
+// RenderIndependently returns true if the supplied resource templates can be
+// rendered concurrently. Templates can't be rendered concurrently if any
+// patches the environment or the Function pipeline context, or if two
+// templates have the same name.
+func RenderIndependently(cts []v1beta1.ComposedTemplate) bool {
+	names := make(map[string]bool, len(cts))
+	for _, t := range cts {
+		if names[t.Name] {
+			return false
+		}
+		names[t.Name] = true
+		for _, p := range t.Patches {
+			switch p.GetType() { //nolint:exhaustive // Only these types patch the environment or context.
+			case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment, v1beta1.PatchTypeToContextFieldPath:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// renderTemplate renders the supplied resource template. It calls wait before
+// it patches the desired composite resource.
+func (f *Function) renderTemplate(ctx context.Context, log logging.Logger, s *renderState, t v1beta1.ComposedTemplate, wait func()) *renderedTemplate { //nolint:gocognit,gocyclo // See below.
+	// This function is fairly complex, but more readable with less
+	// abstraction.
+
+	log = log.WithValues("resource-template-name", t.Name)
+	log.Debug("Processing resource template")
+
+	ctx, span := startSpan(ctx, f.tracer, "RenderResourceTemplate", attribute.String(attrResourceTemplateName, t.Name))
+	defer span.End()
+
+	rt := &renderedTemplate{rsp: &fnv1.RunFunctionResponse{}}
+	rsp := rt.rsp
+
+	if _, ok := s.observed[resource.Name(t.Name)]; t.SkipIfNotObserved && !ok {
+		log.Debug("Not adding composed resource to desired state because it doesn't exist, and skipIfNotObserved is true")
+		rt.ignored = true
+		return rt
+	}
+
+	dcd := &resource.DesiredComposed{Resource: composed.New()}
+
+	// If we have a base template, render it into our desired resource. If a
+	// previous Function produced a desired resource with this name we'll
+	// overwrite it. The base template may be inline, or read from the
+	// environment or context. If we don't have a base template we'll try to
+	// patch to and from a desired resource produced by a previous Function
+	// in the pipeline.
+	switch {
+	case t.BaseFrom != nil:
+		base, err := BaseFrom(t.BaseFrom, s.env, s.fctx)
+		if err != nil {
+			Fatal(rsp, errors.Wrapf(err, "cannot get base template of composed resource %q", t.Name), ResultDetails{Reason: ReasonInvalidBase, Resource: t.Name})
+			rt.fatal = true
+			return rt
+		}
+		cd, err := ParseBase(base, s.input.StripBaseNulls)
+		if err != nil {
+			Fatal(rsp, errors.Wrapf(err, "cannot parse base template of composed resource %q", t.Name), ResultDetails{Reason: ReasonInvalidBase, Resource: t.Name})
+			rt.fatal = true
+			return rt
+		}
+		dcd.Resource = cd
+	case t.BaseFromObserved != nil:
+		src, ok := s.observed[resource.Name(*t.BaseFromObserved)]
+		if !ok {
+			d := ResultDetails{Reason: ReasonBaseNotObserved, Resource: t.Name}
+			own, exists := s.observed[resource.Name(t.Name)]
+			if !exists {
+				err := errors.Errorf("not adding new composed resource %q to desired state because the observed composed resource %q it clones doesn't exist", t.Name, *t.BaseFromObserved)
+				if s.input.Strict {
+					Fatal(rsp, err, d)
+					rt.fatal = true
+					return rt
+				}
+				Warning(rsp, err, d)
+				rt.warnings++
+				rt.skipped = true
+				return rt
+			}
+
+			// We don't want to delete an existing composed resource because
+			// the resource it was cloned from is gone, so we clone it
+			// instead.
+			Warning(rsp, errors.Errorf("observed composed resource %q that composed resource %q clones doesn't exist: cloning the existing composed resource instead", *t.BaseFromObserved, t.Name), d)
+			rt.warnings++
+			src = own
+		}
+		dcd.Resource = CloneObserved(src.Resource)
+	case t.Base == nil:
+		cd, ok := s.desired[resource.Name(t.Name)]
+		if !ok {
+			Fatal(rsp, errors.Errorf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", t.Name), ResultDetails{Reason: ReasonInvalidBase, Resource: t.Name})
+			rt.fatal = true
+			return rt
+		}
+		// We want to return this resource unmutated if rendering fails.
+		dcd.Resource = cd.Resource.DeepCopy()
+	default:
+		if cd, ok := s.parsed.Base(t.Name); ok {
+			dcd.Resource = cd
+			break
+		}
+		cd, err := ParseBase(t.Base.Raw, s.input.StripBaseNulls)
+		if err != nil {
+			Fatal(rsp, errors.Wrapf(err, "cannot parse base template of composed resource %q", t.Name), ResultDetails{Reason: ReasonInvalidBase, Resource: t.Name})
+			rt.fatal = true
+			return rt
+		}
+		dcd.Resource = cd
+	}
+
+	ocd, exists := s.observed[resource.Name(t.Name)]
+	if exists {
+		rt.exists = true
+		log.Debug("Resource template corresponds to existing composed resource", "metadata-name", ocd.Resource.GetName())
+
+		// If this template corresponds to an existing observed resource we
+		// want to keep them associated. We copy only the namespace and
+		// name, not the entire observed state, because we're trying to
+		// produce only a partial 'overlay' of desired state.
+		dcd.Resource.SetNamespace(ocd.Resource.GetNamespace())
+		dcd.Resource.SetName(ocd.Resource.GetName())
+
+		// If requested, we start from the entire observed state (minus its
+		// status) instead, so we don't fight with fields that providers or
+		// webhooks set.
+		if t.RenderPolicy != nil && *t.RenderPolicy == v1beta1.RenderPolicyMergeObserved {
+			MergeObserved(ocd.Resource, dcd.Resource)
+		}
+
+		pending, required := PendingConnectionDetails(ocd.Resource, t.ConnectionDetails...)
+		rt.pending = pending
+
+		ready, err := IsReady(ctx, ocd.Resource, ReadinessCheckSources{Composite: s.oxr.Resource, Environment: s.env, ConnectionDetails: ocd.ConnectionDetails}, t.ReadinessChecks...)
+		if err != nil {
+			Warning(rsp, errors.Wrapf(err, "cannot check readiness of composed resource %q", t.Name), ResultDetails{Reason: ReasonReadinessCheckFailed, Resource: t.Name})
+			log.Info("Cannot check readiness of composed resource", "warning", err)
+			rt.warnings++
+		}
+		if err == nil {
+			f.metrics.ReadinessChecked(s.xrAPIVersion, s.xrKind, ready)
+		}
+		if ready && required {
+			log.Debug("Composed resource isn't ready until its required connection details exist", "pending-connection-details", pending)
+			ready = false
+		}
+		if ready {
+			dcd.Ready = resource.ReadyTrue
+		}
+
+		if !ready && t.ConnectionDetailsPolicy != nil && *t.ConnectionDetailsPolicy == v1beta1.ConnectionDetailsPolicyWhenReady {
+			log.Debug("Not extracting connection details until composed resource is ready")
+		} else {
+			conn, err := ExtractConnectionDetails(ocd.Resource, managed.ConnectionDetails(ocd.ConnectionDetails), s.env, t.ConnectionDetails...)
+			if err != nil {
+				Warning(rsp, errors.Wrapf(err, "cannot extract composite resource connection details from composed resource %q", t.Name), ResultDetails{Reason: ReasonConnectionDetailsFailed, Resource: t.Name})
+				log.Info("Cannot extract composite resource connection details from composed resource", "warning", err)
+				rt.warnings++
+			}
+			rt.conn = conn
+		}
+
+		log.Debug("Found corresponding observed resource",
+			"ready", ready,
+			"name", ocd.Resource.GetName())
+	}
+	if !exists {
+		rt.pending, _ = PendingConnectionDetails(nil, t.ConnectionDetails...)
+	}
+
+	if t.ReadyFrom != nil {
+		override, err := ReadyOverride(t.ReadyFrom, ReadinessCheckSources{Composite: s.oxr.Resource, Environment: s.env})
+		switch {
+		case err != nil:
+			Warning(rsp, errors.Wrapf(err, "cannot override readiness of composed resource %q", t.Name), ResultDetails{Reason: ReasonReadinessCheckFailed, Resource: t.Name})
+			log.Info("Cannot override readiness of composed resource", "warning", err)
+			rt.warnings++
+		case override == nil:
+		case *override:
+			log.Debug("Overriding readiness of composed resource", "ready", true)
+			dcd.Ready = resource.ReadyTrue
+		default:
+			log.Debug("Overriding readiness of composed resource", "ready", false)
+			dcd.Ready = resource.ReadyFalse
+		}
+	}
+
+	if t.Timeouts != nil {
+		var cd *composed.Unstructured
+		if exists {
+			cd = ocd.Resource
+		}
+		if CheckReadyTimeout(rsp, t.Name, t.Timeouts, cd, dcd.Ready == resource.ReadyTrue, time.Now()) {
+			log.Info("Composed resource isn't ready before its ready timeout")
+			rt.warnings++
+		}
+	}
+
+	PropagateMetadata(s.input.PropagateMetadata, s.oxr.Resource, dcd.Resource)
+
+	if err := ApplyComposedMetadata(t.Metadata, s.oxr.Resource, dcd.Resource); err != nil {
+		Warning(rsp, errors.Wrapf(err, "cannot set metadata of composed resource %q", t.Name), ResultDetails{Reason: ReasonMetadataFailed, Resource: t.Name})
+		log.Info("Cannot set metadata of composed resource", "warning", err)
+		rt.warnings++
+	}
+
+	if err := ApplyPolicies(t, s.oxr.Resource, dcd.Resource); err != nil {
+		Fatal(rsp, errors.Wrapf(err, "cannot apply policies to composed resource %q", t.Name), ResultDetails{Reason: ReasonPolicyFailed, Resource: t.Name})
+		rt.fatal = true
+		return rt
+	}
+
+	if t.ExternalName != nil {
+		if err := ApplyExternalName(t.ExternalName, s.oxr.Resource, ocd.Resource, dcd.Resource); err != nil {
+			if !fieldpath.IsNotFound(err) {
+				Fatal(rsp, errors.Wrapf(err, "cannot set external name of composed resource %q", t.Name), ResultDetails{Reason: ReasonExternalNameFailed, Resource: t.Name})
+				rt.fatal = true
+				return rt
+			}
+
+			// The external name depends on a composite resource field
+			// path that doesn't exist yet. We treat it like a patch from a
+			// required field path.
+			d := ResultDetails{Reason: ReasonRequiredFieldPathNotFound, Resource: t.Name}
+			if !exists {
+				err := errors.Wrapf(err, "not adding new composed resource %q to desired state because its external name can't be rendered", t.Name)
+				if s.input.Strict {
+					Fatal(rsp, err, d)
+					rt.fatal = true
+					return rt
+				}
+				Warning(rsp, err, d)
+				rt.warnings++
+				rt.skipped = true
+				return rt
+			}
+			Warning(rsp, errors.Wrapf(err, "cannot set external name of composed resource %q: keeping observed external name", t.Name), d)
+			rt.warnings++
+			if name := meta.GetExternalName(ocd.Resource); name != "" {
+				meta.SetExternalName(dcd.Resource, name)
+			}
+		}
+	}
+
+	if t.WriteConnectionSecretToRef != nil {
+		if err := ApplyConnectionSecretRef(t.WriteConnectionSecretToRef, s.oxr.Resource, dcd.Resource); err != nil {
+			if !fieldpath.IsNotFound(err) {
+				Fatal(rsp, errors.Wrapf(err, "cannot set connection secret of composed resource %q", t.Name), ResultDetails{Reason: ReasonConnectionDetailsFailed, Resource: t.Name})
+				rt.fatal = true
+				return rt
+			}
+
+			// Like the external name, the connection secret depends on a
+			// composite resource field path that doesn't exist yet.
+			d := ResultDetails{Reason: ReasonRequiredFieldPathNotFound, Resource: t.Name}
+			if !exists {
+				err := errors.Wrapf(err, "not adding new composed resource %q to desired state because its connection secret can't be rendered", t.Name)
+				if s.input.Strict {
+					Fatal(rsp, err, d)
+					rt.fatal = true
+					return rt
+				}
+				Warning(rsp, err, d)
+				rt.warnings++
+				rt.skipped = true
+				return rt
+			}
+			Warning(rsp, errors.Wrapf(err, "cannot set connection secret of composed resource %q: keeping observed connection secret", t.Name), d)
+			rt.warnings++
+			if ref, err := ocd.Resource.GetValue("spec.writeConnectionSecretToRef"); err == nil {
+				_ = dcd.Resource.SetValue("spec.writeConnectionSecretToRef", ref)
+			}
+		}
+	}
+
+	if exists && DeletesKeysNotInSource(t.Patches) {
+		CarryMergedKeys(ocd.Resource, dcd.Resource)
+	}
+
+	// Patches may not change the Crossplane annotations the resource template
+	// denies, so we restore them after patching.
+	denied := DeniedComposedAnnotations(t.CrossplaneAnnotations)
+	annotations := dcd.Resource.GetAnnotations()
+
+	// Run all patches that are to a desired composed resource, or from an
+	// observed composed resource.
+	patched := make([]string, 0, len(t.Patches))
+	for i := range t.Patches {
+		p := &t.Patches[i]
+		if !ToComposedResource(p) {
+			wait()
+		}
+		before := dcd.Resource.GetAnnotations()
+		_, pspan := startSpan(ctx, f.tracer, "ApplyPatch", attribute.Int(attrPatchIndex, i), attribute.String(attrPatchType, string(p.GetType())))
+		err := RedactPatchError(p, ApplyComposedPatch(p, ocd.Resource, dcd.Resource, s.oxr.Resource, s.dxr.Resource, s.env, s.fctx, s.extra, s.claim, s.vars, s.observed, s.allowed))
+		endPatchSpan(pspan, err)
+		if f.debugPatches {
+			if from := ComposedPatchSource(p, ocd.Resource, s.oxr.Resource, s.env, s.fctx, s.extra, s.claim, s.vars); from != nil {
+				log.Debug("Evaluated composed resource patch", append([]any{"patch-index", i, "patch-type", p.GetType()}, TracePatch(p, from).KeysAndValues()...)...)
+			}
+		}
+		if err != nil {
+			if fieldpath.IsNotFound(err) {
+				// This is a patch from a required field path that does not
+				// exist. The point of FromFieldPathPolicyRequired is to
+				// block creation of the new 'to' resource until the 'from'
+				// field path exists.
+				//
+				// The only kind of resource we could be patching to that
+				// might not exist at this point is a composed resource. So
+				// if we're patching to a composed resource that doesn't
+				// exist we want to avoid creating it. Otherwise, we just
+				// treat the patch from a required field path the same way
+				// we'd treat a patch from an optional field path and skip
+				// it.
+				if p.GetPolicy().GetFromFieldPathPolicy() == v1beta1.FromFieldPathPolicyRequired {
+					if d := p.GetPolicy().GetRequeueAfter(); d != nil {
+						requeueAfter(rsp, d.Duration)
+					}
+					d := ResultDetails{Reason: ReasonRequiredFieldPathNotFound, Resource: t.Name, PatchIndex: &i, PatchType: p.GetType()}
+					switch p.GetType() { //nolint:exhaustive // Only these types read a field the claim's owner sets.
+					case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeFromClaimFieldPath:
+						d.Target = fnv1.Target_TARGET_COMPOSITE_AND_CLAIM
+					}
+					if ToComposedResource(p) && !exists {
+						err := errors.Wrapf(err, "not adding new composed resource %q to desired state because %q patch at index %d has 'policy.fromFieldPath: Required'", t.Name, p.GetType(), i)
+						if s.input.Strict {
+							Fatal(rsp, err, d)
+							rt.fatal = true
+							return rt
+						}
+						Warning(rsp, err, d)
+
+						// There's no point processing further patches.
+						// They'll either be from an observed composed
+						// resource that doesn't exist yet, or to a desired
+						// composed resource that we'll discard.
+						rt.skipped = true
+						return rt
+					}
+					err := errors.Wrapf(err, "cannot render composed resource %q %q patch at index %d: ignoring 'policy.fromFieldPath: Required' because 'to' resource already exists", t.Name, p.GetType(), i)
+					if s.input.Strict {
+						Fatal(rsp, err, d)
+						rt.fatal = true
+						return rt
+					}
+					Warning(rsp, err, d)
+				}
+
+				// If any optional field path isn't found we just skip this
+				// patch and move on. The path may be populated by a
+				// subsequent patch.
+				continue
+			}
+			f.metrics.PatchFailed(s.xrAPIVersion, s.xrKind, p.GetType())
+			d := ResultDetails{Reason: ReasonPatchFailed, Resource: t.Name, PatchIndex: &i, PatchType: p.GetType()}
+			switch OnPatchFailure(s.input.OnPatchFailure, t) {
+			case v1beta1.PatchFailurePolicySkip:
+				log.Info("Skipping composed resource patch that failed", "patch-index", i, "patch-type", p.GetType(), "error", err)
+				continue
+			case v1beta1.PatchFailurePolicyWarn:
+				Warning(rsp, errors.Wrapf(err, "cannot render composed resource %q %q patch at index %d: skipping patch", t.Name, p.GetType(), i), d)
+				log.Info("Skipping composed resource patch that failed", "patch-index", i, "patch-type", p.GetType(), "warning", err)
+				rt.warnings++
+				continue
+			case v1beta1.PatchFailurePolicyFail:
+			}
+			Fatal(rsp, errors.Wrapf(err, "cannot render composed resource %q %q patch at index %d", t.Name, p.GetType(), i), d)
+			rt.fatal = true
+			return rt
+		}
+		if ToComposedResource(p) {
+			patched = append(patched, PatchedPath(p))
+
+			// Replacing all of the composed resource's annotations removes
+			// any Crossplane annotations that weren't patched, which is
+			// rarely intended.
+			if removed := RemovedCrossplaneAnnotations(before, dcd.Resource.GetAnnotations(), denied); len(removed) > 0 {
+				Warning(rsp, errors.Errorf("composed resource %q %q patch at index %d removed annotations %s because it replaced all annotations: use 'policy.toFieldPath: MergeObjects' to keep them", t.Name, p.GetType(), i, strings.Join(removed, ", ")), ResultDetails{Reason: ReasonAnnotationProtected, Resource: t.Name, PatchIndex: &i, PatchType: p.GetType()})
+				rt.warnings++
+			}
+		}
+	}
+
+	if restored := RestoreAnnotations(dcd.Resource, annotations, denied); len(restored) > 0 {
+		Warning(rsp, errors.Errorf("patches may not change annotations %s of composed resource %q: keeping their values from before patching", strings.Join(restored, ", "), t.Name), ResultDetails{Reason: ReasonAnnotationProtected, Resource: t.Name})
+		rt.warnings++
+	}
+
+	if exists {
+		if err := OmitHintFields(t.FieldOwnership, ocd.Resource, dcd.Resource); err != nil {
+			Warning(rsp, errors.Wrapf(err, "cannot omit hint fields of composed resource %q", t.Name), ResultDetails{Reason: ReasonFieldOwnershipFailed, Resource: t.Name})
+			rt.warnings++
+		}
+	}
+
+	if s.input.AnnotatePatchedPaths {
+		AnnotatePatchedPaths(dcd.Resource, patched)
+	}
+
+	rt.dcd = dcd
+	return rt
+}
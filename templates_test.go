@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestRenderIndependently(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		cts    []v1beta1.ComposedTemplate
+		want   bool
+	}{
+		"PatchesComposite": {
+			reason: "Templates that patch the composite resource can be rendered concurrently.",
+			cts: []v1beta1.ComposedTemplate{
+				{Name: "a", Patches: []v1beta1.ComposedPatch{{Type: v1beta1.PatchTypeToCompositeFieldPath}}},
+				{Name: "b", Patches: []v1beta1.ComposedPatch{{Type: v1beta1.PatchTypeFromCompositeFieldPath}}},
+			},
+			want: true,
+		},
+		"PatchesEnvironment": {
+			reason: "Templates that patch the environment can't be rendered concurrently.",
+			cts: []v1beta1.ComposedTemplate{
+				{Name: "a", Patches: []v1beta1.ComposedPatch{{Type: v1beta1.PatchTypeCombineToEnvironment}}},
+				{Name: "b", Patches: []v1beta1.ComposedPatch{{Type: v1beta1.PatchTypeFromEnvironmentFieldPath}}},
+			},
+			want: false,
+		},
+		"PatchesContext": {
+			reason: "Templates that patch the Function pipeline context can't be rendered concurrently.",
+			cts: []v1beta1.ComposedTemplate{
+				{Name: "a", Patches: []v1beta1.ComposedPatch{{Type: v1beta1.PatchTypeToContextFieldPath}}},
+				{Name: "b"},
+			},
+			want: false,
+		},
+		"DuplicateNames": {
+			reason: "Templates with the same name can't be rendered concurrently.",
+			cts: []v1beta1.ComposedTemplate{
+				{Name: "a"},
+				{Name: "a"},
+			},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RenderIndependently(tc.cts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nRenderIndependently(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRunFunctionConcurrently(t *testing.T) {
+	// Each template patches the same XR field, and emits a warning because
+	// a required field path doesn't exist.
+	cts := make([]v1beta1.ComposedTemplate, 0, 20)
+	observed := make(map[string]*fnv1.Resource, 20)
+	for i := range 20 {
+		name := fmt.Sprintf("resource-%d", i)
+		cts = append(cts, v1beta1.ComposedTemplate{
+			Name: name,
+			Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+			Patches: []v1beta1.ComposedPatch{
+				{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.widgets"),
+						ToFieldPath:   ptr.To[string]("spec.watchers"),
+					},
+				},
+				{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.missing"),
+						ToFieldPath:   ptr.To[string]("spec.missing"),
+						Policy: &v1beta1.PatchPolicy{
+							FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy](v1beta1.FromFieldPathPolicyRequired),
+						},
+					},
+				},
+				{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.name"),
+						ToFieldPath:   ptr.To[string]("status.lastName"),
+					},
+				},
+			},
+		})
+		observed[name] = &fnv1.Resource{
+			Resource: resource.MustStructJSON(fmt.Sprintf(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":%q}}`, name)),
+		}
+	}
+
+	// This template has no base template, and wasn't produced by a previous
+	// Function, so rendering fails.
+	failing := append([]v1beta1.ComposedTemplate{}, cts...)
+	failing[10] = v1beta1.ComposedTemplate{Name: failing[10].Name}
+
+	// These templates share a PatchSet, and read the same composite resource
+	// fields, including the whole composite resource. Run the test with -race
+	// to catch templates that write to values they share.
+	shared := make([]v1beta1.ComposedTemplate, 0, 20)
+	for i := range 20 {
+		shared = append(shared, v1beta1.ComposedTemplate{
+			Name: fmt.Sprintf("resource-%d", i),
+			Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+			Patches: []v1beta1.ComposedPatch{
+				{
+					Type:         v1beta1.PatchTypePatchSet,
+					PatchSetName: ptr.To[string]("shared"),
+				},
+				{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To[v1beta1.ToFieldPathPolicy](v1beta1.ToFieldPathPolicyForceMergeObjectsAppendArrays),
+						},
+					},
+				},
+				{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.tags"),
+						ToFieldPath:   ptr.To[string]("status.tags"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To[v1beta1.ToFieldPathPolicy](v1beta1.ToFieldPathPolicyMergeObjectsAppendArrays),
+						},
+					},
+				},
+			},
+		})
+	}
+	patchSets := []v1beta1.PatchSet{{
+		Name: "shared",
+		Patches: []v1beta1.PatchSetPatch{
+			{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To[string]("."),
+					ToFieldPath:   ptr.To[string]("spec.parent"),
+				},
+			},
+			{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To[string]("spec.tags"),
+					ToFieldPath:   ptr.To[string]("spec.forProvider.tags"),
+				},
+			},
+		},
+	}}
+
+	cases := map[string]struct {
+		reason string
+		in     *v1beta1.Resources
+	}{
+		"Successful": {
+			reason: "Rendering templates concurrently should produce the same response as rendering them one at a time.",
+			in:     &v1beta1.Resources{Resources: cts},
+		},
+		"Failing": {
+			reason: "Rendering templates concurrently should produce the same response as rendering them one at a time when one fails.",
+			in:     &v1beta1.Resources{Resources: failing},
+		},
+		"SharedSources": {
+			reason: "Rendering templates that share a PatchSet and read the same composite resource fields concurrently should produce the same response as rendering them one at a time.",
+			in:     &v1beta1.Resources{PatchSets: patchSets, Resources: shared},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := &fnv1.RunFunctionRequest{
+				Input: resource.MustStructObject(tc.in),
+				Observed: &fnv1.State{
+					Composite: &fnv1.Resource{
+						Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","metadata":{"name":"cool-xr"},"spec":{"widgets":"10","tags":{"team":"cool","owners":["a","b"]}}}`),
+					},
+					Resources: observed,
+				},
+			}
+
+			f := &Function{log: logging.NewNopLogger()}
+			want, err := f.RunFunction(context.Background(), req)
+			if err != nil {
+				t.Fatalf("f.RunFunction(...): %s", err)
+			}
+
+			cf := &Function{log: logging.NewNopLogger(), maxConcurrentRenders: 4}
+			for i := range 5 {
+				got, err := cf.RunFunction(context.Background(), req)
+				if err != nil {
+					t.Fatalf("cf.RunFunction(...): %s", err)
+				}
+				if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+					t.Errorf("%s\ncf.RunFunction(...) call %d: -want sequential, +got concurrent:\n%s", tc.reason, i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitingForEnvironment(t *testing.T) {
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"stages": map[string]any{
+			"networkDone": true,
+			"replicas":    int64(3),
+		},
+	}}
+
+	cases := map[string]struct {
+		reason string
+		t      v1beta1.ComposedTemplate
+		want   bool
+	}{
+		"NoWaitFor": {
+			reason: "A resource template that doesn't wait for the environment shouldn't be waiting.",
+			t:      v1beta1.ComposedTemplate{Name: "cool-resource"},
+			want:   false,
+		},
+		"Equal": {
+			reason: "A resource template shouldn't be waiting if the environment value equals the value it waits for.",
+			t: v1beta1.ComposedTemplate{
+				Name:    "cool-resource",
+				WaitFor: &v1beta1.WaitFor{EnvironmentFieldPath: "stages.networkDone", Equals: extv1.JSON{Raw: []byte(`true`)}},
+			},
+			want: false,
+		},
+		"EqualNumber": {
+			reason: "Numbers should be compared as JSON, regardless of their Go type.",
+			t: v1beta1.ComposedTemplate{
+				Name:    "cool-resource",
+				WaitFor: &v1beta1.WaitFor{EnvironmentFieldPath: "stages.replicas", Equals: extv1.JSON{Raw: []byte(`3`)}},
+			},
+			want: false,
+		},
+		"DifferentType": {
+			reason: "A resource template should be waiting if the environment value has a different type than the value it waits for.",
+			t: v1beta1.ComposedTemplate{
+				Name:    "cool-resource",
+				WaitFor: &v1beta1.WaitFor{EnvironmentFieldPath: "stages.networkDone", Equals: extv1.JSON{Raw: []byte(`"true"`)}},
+			},
+			want: true,
+		},
+		"NotFound": {
+			reason: "A resource template should be waiting if the environment field path doesn't exist.",
+			t: v1beta1.ComposedTemplate{
+				Name:    "cool-resource",
+				WaitFor: &v1beta1.WaitFor{EnvironmentFieldPath: "stages.databaseDone", Equals: extv1.JSON{Raw: []byte(`true`)}},
+			},
+			want: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := WaitingForEnvironment(tc.t, env)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nWaitingForEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConflictingComposedResources(t *testing.T) {
+	dcd := func(apiVersion, kind, namespace, name string) *resource.DesiredComposed {
+		cd := composed.New()
+		cd.SetAPIVersion(apiVersion)
+		cd.SetKind(kind)
+		cd.SetNamespace(namespace)
+		cd.SetName(name)
+		return &resource.DesiredComposed{Resource: cd}
+	}
+
+	cases := map[string]struct {
+		reason  string
+		cts     []v1beta1.ComposedTemplate
+		desired map[resource.Name]*resource.DesiredComposed
+		renamed map[string]resource.Name
+		want    []ComposedResourceConflict
+	}{
+		"NoConflicts": {
+			reason: "Composed resources with different kinds, namespaces, or names, or without a name, shouldn't conflict.",
+			cts:    []v1beta1.ComposedTemplate{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}, {Name: "f"}},
+			desired: map[resource.Name]*resource.DesiredComposed{
+				"a": dcd("example.org/v1", "Bucket", "", "cool"),
+				"b": dcd("example.org/v1", "Queue", "", "cool"),
+				"c": dcd("example.org/v1", "Bucket", "", "uncool"),
+				"d": dcd("example.org/v1", "Bucket", "other", "cool"),
+				"e": dcd("example.org/v1", "Bucket", "", ""),
+				"f": dcd("example.org/v1", "Bucket", "", ""),
+			},
+		},
+		"Conflicts": {
+			reason: "Composed resources with the same group, kind, namespace, and name should conflict, regardless of version.",
+			cts:    []v1beta1.ComposedTemplate{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			desired: map[resource.Name]*resource.DesiredComposed{
+				"a":     dcd("example.org/v1", "Bucket", "default", "cool"),
+				"old-b": dcd("example.org/v1beta1", "Bucket", "default", "cool"),
+				"c":     dcd("example.org/v1", "Bucket", "default", "cool"),
+			},
+			renamed: map[string]resource.Name{"b": "old-b"},
+			want: []ComposedResourceConflict{
+				{Resource: "b", Other: "a", GroupKind: schema.GroupKind{Group: "example.org", Kind: "Bucket"}, Namespace: "default", Name: "cool"},
+				{Resource: "c", Other: "a", GroupKind: schema.GroupKind{Group: "example.org", Kind: "Bucket"}, Namespace: "default", Name: "cool"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ConflictingComposedResources(tc.cts, tc.desired, tc.renamed)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nConflictingComposedResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
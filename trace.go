@@ -0,0 +1,298 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// AnnotationKeyPatchedPaths is the composed resource annotation that lists the
+// field paths patched by the Function, if annotatePatchedPaths is true.
+const AnnotationKeyPatchedPaths = "pt.fn.crossplane.io/patched-paths"
+
+// AnnotationKeyRenderOrdinal is the composed resource annotation that records
+// the composed resource's render ordinal, if annotateRenderOrdinals is true.
+const AnnotationKeyRenderOrdinal = "pt.fn.crossplane.io/render-ordinal"
+
+// Traced values read from a Secret or by a sensitive patch are replaced with
+// this, so that connection details and other secrets don't end up in logs.
+const redacted = "REDACTED"
+
+// A PatchTrace describes how a patch's value was resolved.
+type PatchTrace struct {
+	// From is the field paths the patch reads from.
+	From []string
+
+	// Inputs is the value read from each of the From field paths. It's
+	// shorter than From if a field path couldn't be read.
+	Inputs []any
+
+	// Combined is the value the inputs were combined into. It's only set for
+	// combine patches.
+	Combined any
+
+	// Outputs is the output of each of the patch's transforms.
+	Outputs []any
+
+	// To is the field path the patch writes to.
+	To string
+
+	// Err is the reason the patch's value couldn't be resolved, if any.
+	Err error
+}
+
+// KeysAndValues returns the trace as logging key-value pairs.
+func (t PatchTrace) KeysAndValues() []any {
+	kv := []any{"from", t.From, "inputs", t.Inputs}
+	if t.Combined != nil {
+		kv = append(kv, "combined", t.Combined)
+	}
+	kv = append(kv, "transform-outputs", t.Outputs, "to", t.To)
+	if t.Err != nil {
+		kv = append(kv, "error", t.Err)
+	}
+	return kv
+}
+
+// TracePatch resolves the value the supplied patch would patch from the
+// supplied object, recording the result of each step. It doesn't patch
+// anything. Values are redacted if the supplied object is a Secret, or the
+// patch is sensitive.
+func TracePatch(p PatchInterface, from runtime.Object) PatchTrace {
+	t := tracePatch(p, from)
+	t.Err = RedactPatchError(p, t.Err)
+	return t
+}
+
+func tracePatch(p PatchInterface, from runtime.Object) PatchTrace {
+	t := PatchTrace{To: p.GetToFieldPath()}
+
+	fromMap, err := unstructuredContent(from)
+	if err != nil {
+		t.Err = err
+		return t
+	}
+	gvk := from.GetObjectKind().GroupVersionKind()
+	redact := (gvk.Group == "" && gvk.Kind == "Secret") || p.GetSensitive()
+	value := func(v any) any {
+		if redact {
+			return redacted
+		}
+		return v
+	}
+
+	t.From = []string{p.GetFromFieldPath()}
+	c := p.GetCombine()
+	if IsCombinePatch(p) && c != nil {
+		t.From = make([]string, len(c.Variables))
+		for i, v := range c.Variables {
+			t.From[i] = v.FromFieldPath
+		}
+	}
+
+	in := make([]any, 0, len(t.From))
+	for i, fp := range t.From {
+		var v any
+		var err error
+		if IsCombinePatch(p) && c != nil {
+			v, err = CombineVariableValue(c.Variables[i], fromMap)
+		} else {
+			v, err = FromFieldPathValue(fromMap, fp)
+		}
+		if err != nil {
+			t.Err = err
+			return t
+		}
+		in = append(in, v)
+		t.Inputs = append(t.Inputs, value(v))
+	}
+
+	var v any
+	if len(in) > 0 {
+		v = in[0]
+	}
+	if IsCombinePatch(p) && c != nil {
+		if v, err = Combine(*c, in); err != nil {
+			t.Err = err
+			return t
+		}
+		t.Combined = value(v)
+	}
+
+	for i, tr := range p.GetTransforms() {
+		out, err := Resolve(tr, v)
+		switch {
+		case err != nil && tr.GetOptional():
+			out = v
+		case err != nil:
+			t.Err = errors.Wrapf(err, errFmtTransformAtIndex, i, DescribeTransform(tr), v)
+			return t
+		}
+		v = out
+		t.Outputs = append(t.Outputs, value(v))
+	}
+
+	return t
+}
+
+// IsCombinePatch returns true if the supplied patch combines several
+// variables into one value.
+func IsCombinePatch(p PatchInterface) bool {
+	switch p.GetType() { //nolint:exhaustive // Only these types are combine patches.
+	case v1beta1.PatchTypeCombineFromComposite,
+		v1beta1.PatchTypeCombineToComposite,
+		v1beta1.PatchTypeCombineFromEnvironment,
+		v1beta1.PatchTypeCombineToEnvironment:
+		return true
+	}
+	return false
+}
+
+// ComposedPatchSource returns the object the supplied composed resource patch
+// reads from, or nil if it doesn't exist.
+func ComposedPatchSource(p *v1beta1.ComposedPatch, ocd *composed.Unstructured, oxr *composite.Unstructured, env, fctx, extra, claim, vars *unstructured.Unstructured) runtime.Object {
+	switch p.GetType() { //nolint:exhaustive // PatchSets don't read from anything.
+	case v1beta1.PatchTypeToCompositeFieldPath,
+		v1beta1.PatchTypeCombineToComposite,
+		v1beta1.PatchTypeToEnvironmentFieldPath,
+		v1beta1.PatchTypeCombineToEnvironment,
+		v1beta1.PatchTypeToContextFieldPath:
+		if ocd == nil {
+			return nil
+		}
+		return ocd
+	case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite:
+		return oxr
+	case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
+		if env == nil {
+			return nil
+		}
+		return env
+	case v1beta1.PatchTypeFromContextFieldPath:
+		if fctx == nil {
+			return nil
+		}
+		return fctx
+	case v1beta1.PatchTypeFromExtraResourceFieldPath:
+		if extra == nil {
+			return nil
+		}
+		return extra
+	case v1beta1.PatchTypeFromClaimFieldPath:
+		if claim == nil {
+			return nil
+		}
+		return claim
+	case v1beta1.PatchTypeFromVariableFieldPath:
+		if vars == nil {
+			return nil
+		}
+		return vars
+	}
+	return nil
+}
+
+// PatchedPath returns a compact description of where the supplied composed
+// resource patch patches to and from, for example
+// spec.forProvider.region<-spec.region. Field paths in the environment or the
+// Function pipeline context are prefixed with environment: or context:, field
+// paths in extra resources or the claim with extraResources: or claim:, and
+// field paths in the input's variables with variables:.
+func PatchedPath(p *v1beta1.ComposedPatch) string {
+	prefix := ""
+	switch p.GetType() { //nolint:exhaustive // Other patches are from the XR, or not to a composed resource.
+	case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
+		prefix = "environment:"
+	case v1beta1.PatchTypeFromContextFieldPath:
+		prefix = "context:"
+	case v1beta1.PatchTypeFromExtraResourceFieldPath:
+		prefix = "extraResources:"
+	case v1beta1.PatchTypeFromClaimFieldPath:
+		prefix = "claim:"
+	case v1beta1.PatchTypeFromVariableFieldPath:
+		prefix = "variables:"
+	}
+
+	from := []string{prefix + p.GetFromFieldPath()}
+	if c := p.GetCombine(); IsCombinePatch(p) && c != nil {
+		from = make([]string, len(c.Variables))
+		for i, v := range c.Variables {
+			from[i] = prefix + v.FromFieldPath
+		}
+	}
+	return p.GetToFieldPath() + "<-" + strings.Join(from, "+")
+}
+
+// AnnotatePatchedPaths annotates the supplied composed resource with the
+// supplied patched paths.
+func AnnotatePatchedPaths(cd *composed.Unstructured, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	a := cd.GetAnnotations()
+	if a == nil {
+		a = make(map[string]string, 1)
+	}
+	a[AnnotationKeyPatchedPaths] = strings.Join(paths, ",")
+	cd.SetAnnotations(a)
+}
+
+// RenderOrdinals returns the render ordinal of each of the supplied composed
+// resource names, which are in render order. A composed resource keeps the
+// ordinal its observed composed resource is annotated with, so inserting or
+// removing a resource template doesn't change the ordinals of existing
+// composed resources. Other composed resources get the ordinals after the
+// largest observed ordinal, in render order.
+func RenderOrdinals(names []resource.Name, observed map[resource.Name]resource.ObservedComposed) map[resource.Name]int {
+	next := 0
+	for _, ocd := range observed {
+		if o, ok := observedRenderOrdinal(ocd); ok && o >= next {
+			next = o + 1
+		}
+	}
+	out := make(map[resource.Name]int, len(names))
+	for _, n := range names {
+		if o, ok := observedRenderOrdinal(observed[n]); ok {
+			out[n] = o
+			continue
+		}
+		out[n] = next
+		next++
+	}
+	return out
+}
+
+// observedRenderOrdinal returns the render ordinal the supplied observed
+// composed resource is annotated with, if any.
+func observedRenderOrdinal(ocd resource.ObservedComposed) (int, bool) {
+	if ocd.Resource == nil {
+		return 0, false
+	}
+	v, ok := ocd.Resource.GetAnnotations()[AnnotationKeyRenderOrdinal]
+	if !ok {
+		return 0, false
+	}
+	o, err := strconv.Atoi(v)
+	return o, err == nil && o >= 0
+}
+
+// AnnotateRenderOrdinal annotates the supplied composed resource with the
+// supplied render ordinal.
+func AnnotateRenderOrdinal(cd *composed.Unstructured, ordinal int) {
+	a := cd.GetAnnotations()
+	if a == nil {
+		a = make(map[string]string, 1)
+	}
+	a[AnnotationKeyRenderOrdinal] = strconv.Itoa(ordinal)
+	cd.SetAnnotations(a)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestTracePatch(t *testing.T) {
+	errNotFound := func(path string) error {
+		p := &fieldpath.Paved{}
+		_, err := p.GetValue(path)
+		return err
+	}
+
+	type args struct {
+		p    PatchInterface
+		from runtime.Object
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   PatchTrace
+	}{
+		"FromFieldPath": {
+			reason: "A patch from a field path should trace the value it read and each transform's output.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.region"),
+						ToFieldPath:   ptr.To("spec.forProvider.region"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeString,
+								String: &v1beta1.StringTransform{
+									Type:   v1beta1.StringTransformTypeFormat,
+									Format: ptr.To("%s-1"),
+								},
+							},
+							{
+								Type: v1beta1.TransformTypeString,
+								String: &v1beta1.StringTransform{
+									Type:    v1beta1.StringTransformTypeConvert,
+									Convert: ptr.To(v1beta1.StringConversionTypeToUpper),
+								},
+							},
+						},
+					},
+				},
+				from: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"spec":       map[string]any{"region": "us-east"},
+				}},
+			},
+			want: PatchTrace{
+				From:    []string{"spec.region"},
+				Inputs:  []any{"us-east"},
+				Outputs: []any{"us-east-1", "US-EAST-1"},
+				To:      "spec.forProvider.region",
+			},
+		},
+		"Combine": {
+			reason: "A combine patch should trace the value of each variable and the combined value.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{{FromFieldPath: "spec.a"}, {FromFieldPath: "spec.b"}},
+							Strategy:  v1beta1.CombineStrategyString,
+							String:    &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To("spec.ab"),
+					},
+				},
+				from: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"spec":       map[string]any{"a": "a", "b": "b"},
+				}},
+			},
+			want: PatchTrace{
+				From:     []string{"spec.a", "spec.b"},
+				Inputs:   []any{"a", "b"},
+				Combined: "a-b",
+				To:       "spec.ab",
+			},
+		},
+		"Secret": {
+			reason: "Values read from a Secret should be redacted.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("data.password"),
+						ToFieldPath:   ptr.To("status.password"),
+					},
+				},
+				from: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"data":       map[string]any{"password": "c2VjcmV0"},
+				}},
+			},
+			want: PatchTrace{
+				From:   []string{"data.password"},
+				Inputs: []any{redacted},
+				To:     "status.password",
+			},
+		},
+		"Sensitive": {
+			reason: "Values read by a sensitive patch, and errors that may contain them, should be redacted.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.password"),
+						ToFieldPath:   ptr.To("spec.forProvider.password"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeMap,
+							Map:  &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{}},
+						}},
+						Sensitive: ptr.To(true),
+					},
+				},
+				from: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+					"spec":       map[string]any{"password": "hunter2"},
+				}},
+			},
+			want: PatchTrace{
+				From:   []string{"spec.password"},
+				Inputs: []any{redacted},
+				To:     "spec.forProvider.password",
+				Err:    errors.New(errSensitivePatch),
+			},
+		},
+		"FromFieldPathNotFound": {
+			reason: "A patch from a field path that doesn't exist should trace why its value couldn't be resolved.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.missing"),
+						ToFieldPath:   ptr.To("spec.forProvider.region"),
+					},
+				},
+				from: &unstructured.Unstructured{Object: map[string]any{
+					"apiVersion": "example.org/v1",
+					"kind":       "XR",
+				}},
+			},
+			want: PatchTrace{
+				From: []string{"spec.missing"},
+				To:   "spec.forProvider.region",
+				Err:  errNotFound("spec.missing"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := TracePatch(tc.args.p, tc.args.from)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nTracePatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
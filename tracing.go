@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+)
+
+// The name of the service traces are exported as, and of the tracer that
+// records them.
+const tracerName = "function-patch-and-transform"
+
+// Attributes of the spans the Function records.
+const (
+	attrXRAPIVersion         = "xr.api_version"
+	attrXRKind               = "xr.kind"
+	attrXRName               = "xr.name"
+	attrComposition          = "composition"
+	attrResourceTemplateName = "resource_template.name"
+	attrPatchIndex           = "patch.index"
+	attrPatchType            = "patch.type"
+)
+
+// NewTracerProvider returns a tracer provider that exports a ratio of traces
+// to the supplied OTLP gRPC endpoint, for example otel-collector:4317. It must
+// be shut down to flush any buffered spans.
+func NewTracerProvider(ctx context.Context, endpoint string, insecure bool, ratio float64) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exp, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create OTLP trace exporter")
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(ratio)),
+		sdktrace.WithResource(sdkresource.NewSchemaless(attribute.String("service.name", tracerName))),
+	), nil
+}
+
+// startSpan starts a span using the supplied tracer. A nil tracer records
+// nothing.
+func startSpan(ctx context.Context, t trace.Tracer, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	return t.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endRunSpan ends the supplied RunFunction span. The span's status is an error
+// if the supplied response has a fatal result.
+func endRunSpan(span trace.Span, rsp *fnv1.RunFunctionResponse) {
+	for _, r := range rsp.GetResults() {
+		if r.GetSeverity() == fnv1.Severity_SEVERITY_FATAL {
+			span.SetStatus(codes.Error, r.GetMessage())
+			break
+		}
+	}
+	span.End()
+}
+
+// endPatchSpan ends the supplied patch span, recording the supplied error if
+// it's not nil. Patches from field paths that don't exist are usually skipped,
+// so their errors aren't recorded.
+func endPatchSpan(span trace.Span, err error) {
+	if err != nil && !fieldpath.IsNotFound(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
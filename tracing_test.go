@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestRunFunctionTracing(t *testing.T) {
+	type span struct {
+		Name   string
+		Parent string
+		Status codes.Code
+		Attrs  map[attribute.Key]attribute.Value
+	}
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	f := &Function{log: logging.NewNopLogger(), tracer: tp.Tracer(tracerName)}
+
+	req := &fnv1.RunFunctionRequest{
+		Input: resource.MustStructObject(&v1beta1.Resources{
+			Resources: []v1beta1.ComposedTemplate{{
+				Name: "cool-resource",
+				Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+				Patches: []v1beta1.ComposedPatch{{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.widgets"),
+						ToFieldPath:   ptr.To("spec.widgets"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeMath,
+							Math: &v1beta1.MathTransform{Type: v1beta1.MathTransformTypeMultiply, Multiply: ptr.To[int64](2)},
+						}},
+					},
+				}},
+			}},
+		}),
+		Observed: &fnv1.State{
+			Composite: &fnv1.Resource{
+				Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","metadata":{"name":"cool-xr"},"spec":{"widgets":"many","compositionRef":{"name":"cool-composition"}}}`),
+			},
+		},
+	}
+	if _, err := f.RunFunction(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]string{}
+	for _, s := range exp.GetSpans() {
+		names[s.SpanContext.SpanID().String()] = s.Name
+	}
+	got := make([]span, 0, len(exp.GetSpans()))
+	for _, s := range exp.GetSpans() {
+		attrs := make(map[attribute.Key]attribute.Value, len(s.Attributes))
+		for _, kv := range s.Attributes {
+			attrs[kv.Key] = kv.Value
+		}
+		got = append(got, span{Name: s.Name, Parent: names[s.Parent.SpanID().String()], Status: s.Status.Code, Attrs: attrs})
+	}
+
+	// Spans are exported as they end, so children come first.
+	want := []span{
+		{
+			Name:   "ApplyPatch",
+			Parent: "RenderResourceTemplate",
+			Status: codes.Error,
+			Attrs: map[attribute.Key]attribute.Value{
+				attrPatchIndex: attribute.IntValue(0),
+				attrPatchType:  attribute.StringValue(string(v1beta1.PatchTypeFromCompositeFieldPath)),
+			},
+		},
+		{
+			Name:   "RenderResourceTemplate",
+			Parent: "RunFunction",
+			Attrs: map[attribute.Key]attribute.Value{
+				attrResourceTemplateName: attribute.StringValue("cool-resource"),
+			},
+		},
+		{
+			Name:   "RunFunction",
+			Status: codes.Error,
+			Attrs: map[attribute.Key]attribute.Value{
+				attrXRAPIVersion: attribute.StringValue("example.org/v1"),
+				attrXRKind:       attribute.StringValue("XR"),
+				attrXRName:       attribute.StringValue("cool-xr"),
+				attrComposition:  attribute.StringValue("cool-composition"),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(attribute.Value{})); diff != "" {
+		t.Errorf("RunFunction(...): -want spans, +got spans:\n%s", diff)
+	}
+}
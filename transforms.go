@@ -9,10 +9,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/adler32"
-	"regexp"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/blang/semver/v4"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/utils/ptr"
@@ -29,13 +37,17 @@ const (
 	errFmtRequiredField                 = "%s is required by type %s"
 	errFmtConvertInputTypeNotSupported  = "invalid input type %T"
 	errFmtConvertFormatPairNotSupported = "conversion from %s to %s is not supported with format %s"
-	errFmtTransformAtIndex              = "transform at index %d returned error"
+	errFmtConvertOverflow               = "%g overflows int64"
+	errFmtConvertPrecisionLoss          = "%d can't be converted to float64 without losing precision"
+	errFmtTransformAtIndex              = "transform %d (%s) failed on value of type %T"
 	errFmtTypeNotSupported              = "transform type %s is not supported"
 	errFmtTransformConfigMissing        = "given transform type %s requires configuration"
 	errFmtTransformTypeFailed           = "%s transform could not resolve"
 	errFmtMapTypeNotSupported           = "type %s is not supported for map transform"
 	errFmtMapNotFound                   = "key %s is not found in map"
 	errFmtMapInvalidJSON                = "value for key %s is not valid JSON"
+	errFmtMapKeyRegexp                  = "cannot compile key %s as a regexp"
+	errMapDefaultInvalidJSON            = "default value is not valid JSON"
 
 	errFmtMatchPattern            = "cannot match pattern at index %d"
 	errFmtMatchParseResult        = "cannot parse result of pattern at index %d"
@@ -43,6 +55,7 @@ const (
 	errMatchFallbackBoth          = "cannot set both a fallback value and the fallback to input flag"
 	errFmtMatchPatternTypeInvalid = "unsupported pattern type '%s'"
 	errFmtMatchInputTypeInvalid   = "unsupported input type '%s'"
+	errFmtMatchArrayPolicyInvalid = "unsupported array policy '%s'"
 	errMatchRegexpCompile         = "cannot compile regexp"
 
 	errStringTransformTypeFailed        = "type %s is not supported for string transform type"
@@ -55,7 +68,37 @@ const (
 	errStringTransformTypeRegexpFailed  = "could not compile regexp"
 	errStringTransformTypeRegexpNoMatch = "regexp %q had no matches for group %d"
 	errStringTransformTypeReplace       = "string transform of type %s replace is not set"
+	errStringTransformTypeTruncate      = "string transform of type %s truncate is not set"
 	errStringConvertTypeFailed          = "type %s is not supported for string convert"
+	errFmtStringFormatVerb              = "verb %%%c can't format input of type %T"
+	errFmtStringFormatInvalid           = "invalid format string at offset %d"
+
+	errFmtTimeTransformTypeFailed = "type %s is not supported for time transform"
+	errFmtTimeInputNonString      = "input is required to be a string for time transform type %s, got %T"
+	errFmtTimeInputNonNumber      = "input is required to be a number for time transform type %s, got %T"
+	errFmtTimeParse               = "cannot parse input as a timestamp with layout %q"
+	errTimeParseDuration          = "cannot parse duration"
+
+	errFmtCIDRTransformTypeFailed = "type %s is not supported for cidr transform"
+	errFmtCIDRInputNonString      = "input is required to be a string for cidr transform type %s, got %T"
+	errCIDRParse                  = "cannot parse input as a CIDR"
+	errFmtCIDRSubnetTooLong       = "cannot extend a /%d prefix by %d bits: an IPv%d prefix can be at most %d bits long"
+	errFmtCIDRSubnetNumber        = "subnet number %d does not fit in %d new bits"
+	errFmtCIDRHostIndex           = "host index %d does not fit in a /%d prefix"
+	errCIDRNetmaskIPv6            = "netmask is only supported for IPv4 prefixes"
+
+	errFmtQuantityTransformTypeFailed = "type %s is not supported for quantity transform"
+	errFmtQuantityInputNotSupported   = "input is required to be a string or a number for quantity transform, got %T"
+	errQuantityParse                  = "cannot parse input as a quantity"
+
+	errFmtStringFuncInputNonString = "input is required to be a string for stringFunc transform, got %T"
+	errFmtStringFuncNotSupported   = "func %s is not supported for stringFunc transform"
+	errFmtStringFuncSplitIndex     = "index %d is out of range: splitting the input at %q returns %d elements"
+
+	errFmtSemverTransformTypeFailed = "type %s is not supported for semver transform"
+	errFmtSemverInputNonString      = "input is required to be a string for semver transform, got %T"
+	errSemverParse                  = "cannot parse input as a semantic version"
+	errSemverParseConstraint        = "cannot parse constraint"
 
 	errDecodeString = "string is not valid base64"
 	errMarshalJSON  = "cannot marshal to JSON"
@@ -63,6 +106,53 @@ const (
 	errAdler        = "unable to generate Adler checksum"
 )
 
+// DescribeTransform returns a short description of the supplied transform for
+// use in errors, for example string Format "%s-bucket". It includes the type of
+// the transform and, where it has one, the type of the operation it performs.
+// It never includes values that might be sensitive, like map values.
+func DescribeTransform(t v1beta1.Transform) string {
+	d := string(t.Type)
+	switch t.Type { //nolint:exhaustive // Other transforms don't have a type of operation.
+	case v1beta1.TransformTypeMath:
+		if t.Math != nil {
+			d += " " + string(t.Math.Type)
+		}
+	case v1beta1.TransformTypeString:
+		if t.String == nil {
+			break
+		}
+		d += " " + string(t.String.Type)
+		if t.String.Type == v1beta1.StringTransformTypeFormat && t.String.Format != nil {
+			d += fmt.Sprintf(" %q", *t.String.Format)
+		}
+	case v1beta1.TransformTypeConvert:
+		if t.Convert != nil {
+			d += " to " + string(t.Convert.ToType)
+		}
+	case v1beta1.TransformTypeTime:
+		if t.Time != nil {
+			d += " " + string(t.Time.Type)
+		}
+	case v1beta1.TransformTypeCIDR:
+		if t.CIDR != nil {
+			d += " " + string(t.CIDR.Type)
+		}
+	case v1beta1.TransformTypeQuantity:
+		if t.Quantity != nil {
+			d += " " + string(t.Quantity.Type)
+		}
+	case v1beta1.TransformTypeStringFunc:
+		if t.StringFunc != nil {
+			d += " " + string(t.StringFunc.Func)
+		}
+	case v1beta1.TransformTypeSemver:
+		if t.Semver != nil {
+			d += " " + string(t.Semver.Type)
+		}
+	}
+	return d
+}
+
 // Resolve the supplied Transform.
 func Resolve(t v1beta1.Transform, input any) (any, error) { //nolint:gocyclo // This is a long but simple/same-y switch.
 	var out any
@@ -78,7 +168,7 @@ func Resolve(t v1beta1.Transform, input any) (any, error) { //nolint:gocyclo //
 		if t.Map == nil {
 			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
 		}
-		out, err = ResolveMap(t.Map, input)
+		out, err = ResolveMap(t.Map, t.MapOptions, input)
 	case v1beta1.TransformTypeMatch:
 		if t.Match == nil {
 			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
@@ -94,6 +184,31 @@ func Resolve(t v1beta1.Transform, input any) (any, error) { //nolint:gocyclo //
 			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
 		}
 		out, err = ResolveConvert(t.Convert, input)
+	case v1beta1.TransformTypeTime:
+		if t.Time == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveTime(t.Time, input)
+	case v1beta1.TransformTypeCIDR:
+		if t.CIDR == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveCIDR(t.CIDR, input)
+	case v1beta1.TransformTypeQuantity:
+		if t.Quantity == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveQuantity(t.Quantity, input)
+	case v1beta1.TransformTypeStringFunc:
+		if t.StringFunc == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveStringFunc(t.StringFunc, input)
+	case v1beta1.TransformTypeSemver:
+		if t.Semver == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+		}
+		out, err = ResolveSemver(t.Semver, input)
 	default:
 		return nil, errors.Errorf(errFmtTypeNotSupported, string(t.Type))
 	}
@@ -112,8 +227,12 @@ func ResolveMath(t *v1beta1.MathTransform, input any) (any, error) {
 		return nil, errors.Errorf(errFmtMathInputNonNumber, input)
 	}
 	switch t.Type {
-	case v1beta1.MathTransformTypeMultiply:
-		return resolveMathMultiply(t, input)
+	case v1beta1.MathTransformTypeMultiply,
+		v1beta1.MathTransformTypeAdd,
+		v1beta1.MathTransformTypeSubtract,
+		v1beta1.MathTransformTypeDivide,
+		v1beta1.MathTransformTypeModulo:
+		return resolveMathArithmetic(t, input)
 	case v1beta1.MathTransformTypeClampMin, v1beta1.MathTransformTypeClampMax:
 		return resolveMathClamp(t, input)
 	default:
@@ -121,17 +240,43 @@ func ResolveMath(t *v1beta1.MathTransform, input any) (any, error) {
 	}
 }
 
-// resolveMathMultiply resolves a multiply transform, returning an error if the
-// input is not a number. If the input is a float, the result will be a float64, otherwise
-// it will be an int64.
-func resolveMathMultiply(t *v1beta1.MathTransform, input any) (any, error) {
+// resolveMathArithmetic resolves a multiply, add, subtract, divide, or modulo
+// transform, returning an error if the input is not a number. If the input is
+// a float, the result will be a float64, otherwise it will be an int64.
+func resolveMathArithmetic(t *v1beta1.MathTransform, input any) (any, error) {
+	var iop func(a int64) int64
+	var fop func(a float64) float64
+	switch t.Type { //nolint:exhaustive // We validate the type in ResolveMath
+	case v1beta1.MathTransformTypeMultiply:
+		b := *t.Multiply
+		iop = func(a int64) int64 { return a * b }
+		fop = func(a float64) float64 { return a * float64(b) }
+	case v1beta1.MathTransformTypeAdd:
+		b := *t.Add
+		iop = func(a int64) int64 { return a + b }
+		fop = func(a float64) float64 { return a + float64(b) }
+	case v1beta1.MathTransformTypeSubtract:
+		b := *t.Subtract
+		iop = func(a int64) int64 { return a - b }
+		fop = func(a float64) float64 { return a - float64(b) }
+	case v1beta1.MathTransformTypeDivide:
+		b := *t.Divide
+		iop = func(a int64) int64 { return a / b }
+		fop = func(a float64) float64 { return a / float64(b) }
+	case v1beta1.MathTransformTypeModulo:
+		b := *t.Modulo
+		iop = func(a int64) int64 { return a % b }
+		fop = func(a float64) float64 { return math.Mod(a, float64(b)) }
+	default:
+		return nil, errors.Errorf(errMathTransformTypeFailed, string(t.Type))
+	}
 	switch i := input.(type) {
 	case int:
-		return int64(i) * *t.Multiply, nil
+		return iop(int64(i)), nil
 	case int64:
-		return i * *t.Multiply, nil
+		return iop(i), nil
 	case float64:
-		return i * float64(*t.Multiply), nil
+		return fop(i), nil
 	default:
 		return nil, errors.Errorf(errFmtMathInputNonNumber, input)
 	}
@@ -169,28 +314,61 @@ func resolveMathClamp(t *v1beta1.MathTransform, input any) (any, error) {
 }
 
 // ResolveMap resolves a Map transform.
-func ResolveMap(t *v1beta1.MapTransform, input any) (any, error) {
-	switch i := input.(type) {
-	case string:
-		p, ok := t.Pairs[i]
-		if !ok {
+func ResolveMap(t *v1beta1.MapTransform, o *v1beta1.MapOptions, input any) (any, error) {
+	i, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtMapTypeNotSupported, fmt.Sprintf("%T", input))
+	}
+	k, ok, err := mapKey(t, o.GetType(), i)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if o == nil || o.Default == nil {
 			return nil, errors.Errorf(errFmtMapNotFound, i)
 		}
 		var val interface{}
-		if err := json.Unmarshal(p.Raw, &val); err != nil {
-			return nil, errors.Wrapf(err, errFmtMapInvalidJSON, i)
+		if err := json.Unmarshal(o.Default.Raw, &val); err != nil {
+			return nil, errors.Wrap(err, errMapDefaultInvalidJSON)
 		}
 		return val, nil
-	default:
-		return nil, errors.Errorf(errFmtMapTypeNotSupported, fmt.Sprintf("%T", input))
 	}
+	var val interface{}
+	if err := json.Unmarshal(t.Pairs[k].Raw, &val); err != nil {
+		return nil, errors.Wrapf(err, errFmtMapInvalidJSON, k)
+	}
+	return val, nil
+}
+
+// mapKey returns the key of the supplied map transform that matches the
+// supplied input, and whether any key matched.
+func mapKey(t *v1beta1.MapTransform, mt v1beta1.MapTransformType, input string) (string, bool, error) {
+	if mt != v1beta1.MapTransformTypeRegexp {
+		_, ok := t.Pairs[input]
+		return input, ok, nil
+	}
+	keys := make([]string, 0, len(t.Pairs))
+	for k := range t.Pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		re, err := compileRegexp(k)
+		if err != nil {
+			return "", false, errors.Wrapf(err, errFmtMapKeyRegexp, k)
+		}
+		if re.MatchString(input) {
+			return k, true, nil
+		}
+	}
+	return "", false, nil
 }
 
 // ResolveMatch resolves a Match transform.
 func ResolveMatch(t *v1beta1.MatchTransform, input any) (any, error) {
 	var output any
 	for i, p := range t.Patterns {
-		matches, err := Matches(p, input)
+		matches, matched, err := matchesInput(t.GetArrayPolicy(), p, input)
 		if err != nil {
 			return nil, errors.Wrapf(err, errFmtMatchPattern, i)
 		}
@@ -198,6 +376,9 @@ func ResolveMatch(t *v1beta1.MatchTransform, input any) (any, error) {
 			if err := unmarshalJSON(p.Result, &output); err != nil {
 				return nil, errors.Wrapf(err, errFmtMatchParseResult, i)
 			}
+			if p.ExpandResult && p.Type == v1beta1.MatchTransformPatternTypeRegexp {
+				return expandMatchResult(p, matched, output)
+			}
 			return output, nil
 		}
 	}
@@ -218,6 +399,42 @@ func ResolveMatch(t *v1beta1.MatchTransform, input any) (any, error) {
 	return output, nil
 }
 
+// matchesInput returns true if the pattern matches the supplied input, and the
+// input that matched. If the input is an array the pattern matches if any or
+// all of its elements match, according to the supplied policy. The input that
+// matched is then the first matching element.
+func matchesInput(ap v1beta1.MatchArrayPolicy, p v1beta1.MatchTransformPattern, input any) (bool, any, error) {
+	elems, ok := input.([]any)
+	if !ok {
+		m, err := Matches(p, input)
+		return m, input, err
+	}
+
+	var first any
+	matched := 0
+	for _, e := range elems {
+		m, err := Matches(p, e)
+		if err != nil {
+			return false, nil, err
+		}
+		if !m {
+			continue
+		}
+		if matched == 0 {
+			first = e
+		}
+		matched++
+	}
+
+	switch ap {
+	case v1beta1.MatchArrayPolicyAny:
+		return matched > 0, first, nil
+	case v1beta1.MatchArrayPolicyAll:
+		return matched > 0 && matched == len(elems), first, nil
+	}
+	return false, nil, errors.Errorf(errFmtMatchArrayPolicyInvalid, string(ap))
+}
+
 // Matches returns true if the pattern matches the supplied input.
 func Matches(p v1beta1.MatchTransformPattern, input any) (bool, error) {
 	switch p.Type {
@@ -233,9 +450,9 @@ func matchesLiteral(p v1beta1.MatchTransformPattern, input any) (bool, error) {
 	if p.Literal == nil {
 		return false, errors.Errorf(errFmtRequiredField, "literal", v1beta1.MatchTransformPatternTypeLiteral)
 	}
-	inputStr, ok := input.(string)
-	if !ok {
-		return false, errors.Errorf(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	inputStr, err := matchInputString(input)
+	if err != nil {
+		return false, err
 	}
 	return inputStr == *p.Literal, nil
 }
@@ -244,20 +461,71 @@ func matchesRegexp(p v1beta1.MatchTransformPattern, input any) (bool, error) {
 	if p.Regexp == nil {
 		return false, errors.Errorf(errFmtRequiredField, "regexp", v1beta1.MatchTransformPatternTypeRegexp)
 	}
-	re, err := regexp.Compile(*p.Regexp)
+	re, err := compileRegexp(*p.Regexp)
 	if err != nil {
 		return false, errors.Wrap(err, errMatchRegexpCompile)
 	}
-	if input == nil {
-		return false, errors.Errorf(errFmtMatchInputTypeInvalid, "null")
-	}
-	inputStr, ok := input.(string)
-	if !ok {
-		return false, errors.Errorf(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	inputStr, err := matchInputString(input)
+	if err != nil {
+		return false, err
 	}
 	return re.MatchString(inputStr), nil
 }
 
+// expandMatchResult expands references to the capture groups of the supplied
+// regexp pattern in any strings in the supplied result.
+func expandMatchResult(p v1beta1.MatchTransformPattern, input, result any) (any, error) {
+	re, err := compileRegexp(*p.Regexp)
+	if err != nil {
+		return nil, errors.Wrap(err, errMatchRegexpCompile)
+	}
+	inputStr, err := matchInputString(input)
+	if err != nil {
+		return nil, err
+	}
+	match := re.FindStringSubmatchIndex(inputStr)
+
+	var expand func(v any) any
+	expand = func(v any) any {
+		switch t := v.(type) {
+		case string:
+			return string(re.ExpandString(nil, t, inputStr, match))
+		case map[string]any:
+			for k, e := range t {
+				t[k] = expand(e)
+			}
+		case []any:
+			for i, e := range t {
+				t[i] = expand(e)
+			}
+		}
+		return v
+	}
+	return expand(result), nil
+}
+
+// matchInputString returns the string patterns are matched against for the
+// supplied input. Numbers and booleans are formatted as they'd appear in JSON,
+// so an integer 200 matches a literal "200".
+func matchInputString(input any) (string, error) {
+	switch i := input.(type) {
+	case string:
+		return i, nil
+	case bool:
+		return strconv.FormatBool(i), nil
+	case int:
+		return strconv.Itoa(i), nil
+	case int64:
+		return strconv.FormatInt(i, 10), nil
+	case float64:
+		return strconv.FormatFloat(i, 'f', -1, 64), nil
+	case nil:
+		return "", errors.Errorf(errFmtMatchInputTypeInvalid, "null")
+	default:
+		return "", errors.Errorf(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	}
+}
+
 // unmarshalJSON is a small utility function that returns nil if j contains no
 // data. json.Unmarshal seems to not be able to handle this.
 func unmarshalJSON(j extv1.JSON, output *any) error {
@@ -267,6 +535,99 @@ func unmarshalJSON(j extv1.JSON, output *any) error {
 	return json.Unmarshal(j.Raw, output)
 }
 
+// A formatVerb is a verb in a Go format string.
+type formatVerb struct {
+	verb rune
+	arg  int
+}
+
+// parseFormatVerbs returns the verbs of the supplied Go format string, and the
+// zero-based index of the argument each formats. Verbs that take their width
+// or precision from an argument aren't supported.
+func parseFormatVerbs(format string) ([]formatVerb, error) {
+	var verbs []formatVerb
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		start := i
+		i++
+		for i < len(format) && strings.ContainsRune("+-# 0", rune(format[i])) {
+			i++
+		}
+		for i < len(format) && (format[i] >= '0' && format[i] <= '9' || format[i] == '.') {
+			i++
+		}
+		if i < len(format) && format[i] == '[' {
+			end := strings.IndexByte(format[i:], ']')
+			if end < 0 {
+				return nil, errors.Errorf(errFmtStringFormatInvalid, start)
+			}
+			n, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || n < 1 {
+				return nil, errors.Errorf(errFmtStringFormatInvalid, start)
+			}
+			arg = n - 1
+			i += end + 1
+		}
+		for i < len(format) && (format[i] >= '0' && format[i] <= '9' || format[i] == '.') {
+			i++
+		}
+		if i >= len(format) || format[i] == '*' || format[i] == '[' {
+			return nil, errors.Errorf(errFmtStringFormatInvalid, start)
+		}
+		r, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		if r == '%' {
+			continue
+		}
+		verbs = append(verbs, formatVerb{verb: r, arg: arg})
+		arg++
+	}
+	return verbs, nil
+}
+
+// formatVerbSupports returns true if the supplied verb can format the supplied
+// input.
+func formatVerbSupports(verb rune, input any) bool {
+	if verb == 'v' || verb == 'T' {
+		return true
+	}
+	switch input.(type) {
+	case string:
+		return strings.ContainsRune("sqxX", verb)
+	case bool:
+		return verb == 't'
+	case int, int64:
+		return strings.ContainsRune("bcdoOqxXU", verb)
+	case float64:
+		return strings.ContainsRune("beEfFgGxX", verb)
+	}
+	return false
+}
+
+// formatRepeated formats the supplied input with every verb of the supplied
+// format string.
+func formatRepeated(format string, input any) (string, error) {
+	verbs, err := parseFormatVerbs(format)
+	if err != nil {
+		return "", err
+	}
+	args := 0
+	for _, v := range verbs {
+		if !formatVerbSupports(v.verb, input) {
+			return "", errors.Errorf(errFmtStringFormatVerb, v.verb, input)
+		}
+		args = max(args, v.arg+1)
+	}
+	in := make([]any, args)
+	for i := range in {
+		in[i] = input
+	}
+	return fmt.Sprintf(format, in...), nil
+}
+
 // ResolveString resolves a String transform.
 func ResolveString(t *v1beta1.StringTransform, input any) (string, error) { //nolint:gocyclo // This is a long but simple switch.
 	switch t.Type {
@@ -274,6 +635,9 @@ func ResolveString(t *v1beta1.StringTransform, input any) (string, error) { //no
 		if t.Format == nil {
 			return "", errors.Errorf(errStringTransformTypeFormat, string(t.Type))
 		}
+		if t.FormatInput != nil && *t.FormatInput == v1beta1.StringFormatInputRepeat {
+			return formatRepeated(*t.Format, input)
+		}
 		return fmt.Sprintf(*t.Format, input), nil
 	case v1beta1.StringTransformTypeConvert:
 		if t.Convert == nil {
@@ -300,6 +664,11 @@ func ResolveString(t *v1beta1.StringTransform, input any) (string, error) { //no
 			return "", errors.Errorf(errStringTransformTypeReplace, string(t.Type))
 		}
 		return stringReplaceTransform(input, *t.Replace), nil
+	case v1beta1.StringTransformTypeTruncate:
+		if t.Truncate == nil {
+			return "", errors.Errorf(errStringTransformTypeTruncate, string(t.Type))
+		}
+		return stringTruncateTransform(input, *t.Truncate), nil
 	default:
 		return "", errors.Errorf(errStringTransformTypeFailed, string(t.Type))
 	}
@@ -385,7 +754,7 @@ func stringJoinTransform(input any, r v1beta1.StringTransformJoin) (string, erro
 }
 
 func stringRegexpTransform(input any, r v1beta1.StringTransformRegexp) (string, error) {
-	re, err := regexp.Compile(r.Match)
+	re, err := compileRegexp(r.Match)
 	if err != nil {
 		return "", errors.Wrap(err, errStringTransformTypeRegexpFailed)
 	}
@@ -406,6 +775,302 @@ func stringReplaceTransform(input any, r v1beta1.StringTransformReplace) string
 	return strings.ReplaceAll(str, r.Search, r.Replace)
 }
 
+// truncateHashLength is the number of hex characters of the hash appended to
+// a truncated string.
+const truncateHashLength = 8
+
+func stringTruncateTransform(input any, t v1beta1.StringTransformTruncate) string {
+	str := fmt.Sprintf("%v", input)
+	r := []rune(str)
+	if len(r) <= t.MaxLength {
+		return str
+	}
+	if !t.SuffixHash {
+		return string(r[:t.MaxLength])
+	}
+	hash := sha256.Sum256([]byte(str))
+	return string(r[:t.MaxLength-truncateHashLength-1]) + "-" + hex.EncodeToString(hash[:])[:truncateHashLength]
+}
+
+// ResolveTime resolves a Time transform.
+func ResolveTime(t *v1beta1.TimeTransform, input any) (any, error) {
+	if err := ValidateTimeTransform(t); err != nil {
+		return nil, err
+	}
+
+	switch t.Type {
+	case v1beta1.TimeTransformTypeFromUnix:
+		var sec int64
+		switch i := input.(type) {
+		case int:
+			sec = int64(i)
+		case int64:
+			sec = i
+		case float64:
+			sec = int64(i)
+		default:
+			return nil, errors.Errorf(errFmtTimeInputNonNumber, t.Type, input)
+		}
+		return time.Unix(sec, 0).UTC().Format(t.GetLayout()), nil
+	case v1beta1.TimeTransformTypeToUnix, v1beta1.TimeTransformTypeFormat, v1beta1.TimeTransformTypeAdd:
+		// These types all parse a timestamp - handled below.
+	default:
+		return nil, errors.Errorf(errFmtTimeTransformTypeFailed, string(t.Type))
+	}
+
+	str, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtTimeInputNonString, t.Type, input)
+	}
+	ts, err := time.Parse(t.GetInputLayout(), str)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtTimeParse, t.GetInputLayout())
+	}
+
+	switch t.Type { //nolint:exhaustive // FromUnix is handled above.
+	case v1beta1.TimeTransformTypeToUnix:
+		return ts.Unix(), nil
+	case v1beta1.TimeTransformTypeAdd:
+		d, err := time.ParseDuration(*t.Duration)
+		if err != nil {
+			return nil, errors.Wrap(err, errTimeParseDuration)
+		}
+		ts = ts.Add(d)
+	}
+	return ts.Format(t.GetLayout()), nil
+}
+
+// ResolveCIDR resolves a CIDR transform.
+func ResolveCIDR(t *v1beta1.CIDRTransform, input any) (any, error) {
+	if err := ValidateCIDRTransform(t); err != nil {
+		return nil, err
+	}
+
+	str, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtCIDRInputNonString, t.Type, input)
+	}
+	p, err := netip.ParsePrefix(str)
+	if err != nil {
+		return nil, errors.Wrap(err, errCIDRParse)
+	}
+	p = p.Masked()
+	base := new(big.Int).SetBytes(p.Addr().AsSlice())
+	size := p.Addr().BitLen()
+
+	switch t.Type {
+	case v1beta1.CIDRTransformTypeSubnet:
+		bits := p.Bits() + int(*t.NewBits)
+		if bits > size {
+			return nil, errors.Errorf(errFmtCIDRSubnetTooLong, p.Bits(), *t.NewBits, ipVersion(p.Addr()), size)
+		}
+		num := big.NewInt(*t.Number)
+		if num.BitLen() > int(*t.NewBits) {
+			return nil, errors.Errorf(errFmtCIDRSubnetNumber, *t.Number, *t.NewBits)
+		}
+		addr := intToAddr(base.Or(base, num.Lsh(num, uint(size-bits))), p.Addr().Is4())
+		return netip.PrefixFrom(addr, bits).String(), nil
+	case v1beta1.CIDRTransformTypeHost:
+		hosts := new(big.Int).Lsh(big.NewInt(1), uint(size-p.Bits()))
+		idx := big.NewInt(*t.Index)
+		if idx.Sign() < 0 {
+			idx.Add(hosts, idx)
+		}
+		if idx.Sign() < 0 || idx.Cmp(hosts) >= 0 {
+			return nil, errors.Errorf(errFmtCIDRHostIndex, *t.Index, p.Bits())
+		}
+		return intToAddr(base.Add(base, idx), p.Addr().Is4()).String(), nil
+	case v1beta1.CIDRTransformTypeNetmask:
+		if !p.Addr().Is4() {
+			return nil, errors.New(errCIDRNetmaskIPv6)
+		}
+		return net.IP(net.CIDRMask(p.Bits(), size)).String(), nil
+	default:
+		return nil, errors.Errorf(errFmtCIDRTransformTypeFailed, string(t.Type))
+	}
+}
+
+// ResolveQuantity resolves a Quantity transform.
+func ResolveQuantity(t *v1beta1.QuantityTransform, input any) (any, error) {
+	if err := ValidateQuantityTransform(t); err != nil {
+		return nil, err
+	}
+
+	var str string
+	switch i := input.(type) {
+	case string:
+		str = i
+	case int64:
+		str = strconv.FormatInt(i, 10)
+	case int:
+		str = strconv.Itoa(i)
+	case float64:
+		str = strconv.FormatFloat(i, 'f', -1, 64)
+	default:
+		return nil, errors.Errorf(errFmtQuantityInputNotSupported, input)
+	}
+	q, err := resource.ParseQuantity(str)
+	if err != nil {
+		return nil, errors.Wrap(err, errQuantityParse)
+	}
+
+	switch t.Type {
+	case v1beta1.QuantityTransformTypeMultiply:
+		// AsDec mutates the quantity it's called on, and the transform
+		// may be shared by concurrent Function calls.
+		m := t.Multiply.DeepCopy()
+		d := q.AsDec()
+		d.Mul(d, m.AsDec())
+		return resource.NewDecimalQuantity(*d, q.Format).String(), nil
+	case v1beta1.QuantityTransformTypeAdd:
+		q.Add(*t.Add)
+		return q.String(), nil
+	default:
+		return nil, errors.Errorf(errFmtQuantityTransformTypeFailed, string(t.Type))
+	}
+}
+
+// stringFuncs are the functions a stringFunc transform can call.
+var stringFuncs = map[v1beta1.StringFuncName]func(t *v1beta1.StringFuncTransform, in string) (string, error){
+	v1beta1.StringFuncTrimSpace: func(_ *v1beta1.StringFuncTransform, in string) (string, error) {
+		return strings.TrimSpace(in), nil
+	},
+	v1beta1.StringFuncIndent: func(t *v1beta1.StringFuncTransform, in string) (string, error) {
+		pad := strings.Repeat(" ", int(*t.Indent))
+		return pad + strings.ReplaceAll(in, "\n", "\n"+pad), nil
+	},
+	v1beta1.StringFuncSnakeCase: func(_ *v1beta1.StringFuncTransform, in string) (string, error) {
+		ws := words(in)
+		for i := range ws {
+			ws[i] = strings.ToLower(ws[i])
+		}
+		return strings.Join(ws, "_"), nil
+	},
+	v1beta1.StringFuncCamelCase: func(_ *v1beta1.StringFuncTransform, in string) (string, error) {
+		ws := words(in)
+		for i, w := range ws {
+			r, size := utf8.DecodeRuneInString(w)
+			ws[i] = string(unicode.ToUpper(r)) + strings.ToLower(w[size:])
+		}
+		return strings.Join(ws, ""), nil
+	},
+	v1beta1.StringFuncSplitIndex: func(t *v1beta1.StringFuncTransform, in string) (string, error) {
+		parts := strings.Split(in, t.Split.Separator)
+		if t.Split.Index >= int64(len(parts)) {
+			return "", errors.Errorf(errFmtStringFuncSplitIndex, t.Split.Index, t.Split.Separator, len(parts))
+		}
+		return parts[t.Split.Index], nil
+	},
+}
+
+// ResolveStringFunc resolves a StringFunc transform.
+func ResolveStringFunc(t *v1beta1.StringFuncTransform, input any) (any, error) {
+	if err := ValidateStringFuncTransform(t); err != nil {
+		return nil, err
+	}
+	str, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtStringFuncInputNonString, input)
+	}
+	fn, ok := stringFuncs[t.Func]
+	if !ok {
+		return nil, errors.Errorf(errFmtStringFuncNotSupported, string(t.Func))
+	}
+	out, err := fn(t, str)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResolveSemver resolves a Semver transform.
+func ResolveSemver(t *v1beta1.SemverTransform, input any) (any, error) {
+	if err := ValidateSemverTransform(t); err != nil {
+		return nil, err
+	}
+	str, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtSemverInputNonString, input)
+	}
+	v, err := semver.ParseTolerant(str)
+	if err != nil {
+		return nil, errors.Wrap(err, errSemverParse)
+	}
+	switch t.Type {
+	case v1beta1.SemverTransformTypeNormalize:
+		return v.String(), nil
+	case v1beta1.SemverTransformTypeMajor:
+		return int64(v.Major), nil //nolint:gosec // Versions don't overflow int64 in practice.
+	case v1beta1.SemverTransformTypeMinor:
+		return int64(v.Minor), nil //nolint:gosec // Versions don't overflow int64 in practice.
+	case v1beta1.SemverTransformTypePatch:
+		return int64(v.Patch), nil //nolint:gosec // Versions don't overflow int64 in practice.
+	case v1beta1.SemverTransformTypeSatisfies:
+		r, err := semver.ParseRange(*t.Constraint)
+		if err != nil {
+			return nil, errors.Wrap(err, errSemverParseConstraint)
+		}
+		return r(v), nil
+	default:
+		return nil, errors.Errorf(errFmtSemverTransformTypeFailed, string(t.Type))
+	}
+}
+
+// words splits the supplied string into words. Words are separated by any
+// character that isn't a letter or a digit, and start at each upper case
+// letter that follows a lower case letter or a digit. The last upper case
+// letter of a run of upper case letters starts a new word if a lower case
+// letter follows it, so "HTTPServer" is split into "HTTP" and "Server".
+func words(s string) []string {
+	var ws []string
+	rs := []rune(s)
+	start := -1
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				ws = append(ws, string(rs[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		if unicode.IsUpper(r) {
+			prev := rs[i-1]
+			next := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				ws = append(ws, string(rs[start:i]))
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		ws = append(ws, string(rs[start:]))
+	}
+	return ws
+}
+
+// intToAddr returns the IPv4 or IPv6 address represented by the supplied
+// integer.
+func intToAddr(i *big.Int, is4 bool) netip.Addr {
+	if is4 {
+		var b [4]byte
+		return netip.AddrFrom4([4]byte(i.FillBytes(b[:])))
+	}
+	var b [16]byte
+	return netip.AddrFrom16([16]byte(i.FillBytes(b[:])))
+}
+
+// ipVersion returns the IP version of the supplied address.
+func ipVersion(a netip.Addr) int {
+	if a.Is4() {
+		return 4
+	}
+	return 6
+}
+
 // ResolveConvert resolves a Convert transform by looking up the appropriate
 // conversion function for the given input type and invoking it.
 func ResolveConvert(t *v1beta1.ConvertTransform, input any) (any, error) {
@@ -446,6 +1111,29 @@ func GetConversionFunc(t *v1beta1.ConvertTransform, from v1beta1.TransformIOType
 			return input, nil
 		}, nil
 	}
+
+	// Numeric conversions may be configured to round, or to fail rather than
+	// overflow or lose precision.
+	if t.GetFormat() == v1beta1.ConvertTransformFormatNone {
+		switch {
+		case from == v1beta1.TransformIOTypeFloat64 && to == v1beta1.TransformIOTypeInt64:
+			return func(i any) (any, error) {
+				return floatToInt(i.(float64), t.GetRounding(), t.GetOverflowPolicy())
+			}, nil
+		case from == v1beta1.TransformIOTypeInt64 && to == v1beta1.TransformIOTypeFloat64 && t.GetOverflowPolicy() == v1beta1.ConvertTransformOverflowPolicyError:
+			return func(i any) (any, error) {
+				n, ok := i.(int64)
+				if !ok {
+					n = int64(i.(int))
+				}
+				if n > maxExactFloat || n < -maxExactFloat {
+					return nil, errors.Errorf(errFmtConvertPrecisionLoss, n)
+				}
+				return float64(n), nil
+			}, nil
+		}
+	}
+
 	f, ok := conversions[conversionPair{from: from, to: to, format: t.GetFormat()}]
 	if !ok {
 		return nil, errors.Errorf(v1beta1.ErrFmtConvertFormatPairNotSupported, originalFrom, to, t.GetFormat())
@@ -453,6 +1141,26 @@ func GetConversionFunc(t *v1beta1.ConvertTransform, from v1beta1.TransformIOType
 	return f, nil
 }
 
+// floatToInt rounds the supplied float64 to an int64. If the rounded value
+// doesn't fit in an int64 it returns an error if the supplied overflow policy
+// is Error.
+func floatToInt(f float64, r v1beta1.ConvertTransformRounding, o v1beta1.ConvertTransformOverflowPolicy) (any, error) {
+	switch r {
+	case v1beta1.ConvertTransformRoundingFloor:
+		f = math.Floor(f)
+	case v1beta1.ConvertTransformRoundingCeil:
+		f = math.Ceil(f)
+	case v1beta1.ConvertTransformRoundingRound:
+		f = math.Round(f)
+	case v1beta1.ConvertTransformRoundingTruncate:
+		f = math.Trunc(f)
+	}
+	if o == v1beta1.ConvertTransformOverflowPolicyError && (math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64) {
+		return nil, errors.Errorf(errFmtConvertOverflow, f)
+	}
+	return int64(f), nil
+}
+
 // The unparam linter is complaining that these functions always return a nil
 // error, but we need this to be the case given some other functions in the map
 // may return an error.
@@ -504,12 +1212,29 @@ var conversions = map[conversionPair]func(any) (any, error){
 	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatNone}: func(i any) (any, error) { //nolint:unparam // See note above.
 		return strconv.FormatFloat(i.(float64), 'f', -1, 64), nil
 	},
-	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeInt64, format: v1beta1.ConvertTransformFormatNone}: func(i any) (any, error) { //nolint:unparam // See note above.
-		return int64(i.(float64)), nil
-	},
 	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeBool, format: v1beta1.ConvertTransformFormatNone}: func(i any) (any, error) { //nolint:unparam // See note above.
 		return i.(float64) == float64(1), nil
 	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeInt64, format: v1beta1.ConvertTransformFormatDuration}: func(i any) (any, error) {
+		d, err := time.ParseDuration(i.(string))
+		if err != nil {
+			return nil, err
+		}
+		return int64(d / time.Second), nil
+	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeFloat64, format: v1beta1.ConvertTransformFormatDuration}: func(i any) (any, error) {
+		d, err := time.ParseDuration(i.(string))
+		if err != nil {
+			return nil, err
+		}
+		return d.Seconds(), nil
+	},
+	{from: v1beta1.TransformIOTypeInt64, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatDuration}: func(i any) (any, error) { //nolint:unparam // See note above.
+		return (time.Duration(i.(int64)) * time.Second).String(), nil
+	},
+	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatDuration}: func(i any) (any, error) { //nolint:unparam // See note above.
+		return time.Duration(i.(float64) * float64(time.Second)).String(), nil
+	},
 	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeObject, format: v1beta1.ConvertTransformFormatJSON}: func(i any) (any, error) {
 		o := map[string]any{}
 		return o, json.Unmarshal([]byte(i.(string)), &o)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -31,8 +32,9 @@ func TestMapResolve(t *testing.T) {
 	}
 
 	type args struct {
-		t *v1beta1.MapTransform
-		i any
+		t    *v1beta1.MapTransform
+		opts *v1beta1.MapOptions
+		i    any
 	}
 	type want struct {
 		o   any
@@ -106,10 +108,55 @@ func TestMapResolve(t *testing.T) {
 				o: []interface{}{"foo", "bar"},
 			},
 		},
+		"Default": {
+			args: args{
+				t:    &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON("voila")}},
+				opts: &v1beta1.MapOptions{Default: ptr.To(asJSON("hello"))},
+				i:    "hi",
+			},
+			want: want{
+				o: "hello",
+			},
+		},
+		"RegexpKeys": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{
+					"^us-":       asJSON("americas"),
+					"^eu-":       asJSON("europe"),
+					"^eu-west-1": asJSON("ireland"),
+				}},
+				opts: &v1beta1.MapOptions{Type: ptr.To(v1beta1.MapTransformTypeRegexp)},
+				i:    "eu-west-1",
+			},
+			want: want{
+				// ^eu- is the first matching key in lexical order.
+				o: "europe",
+			},
+		},
+		"RegexpKeysDefault": {
+			args: args{
+				t:    &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"^us-": asJSON("americas")}},
+				opts: &v1beta1.MapOptions{Type: ptr.To(v1beta1.MapTransformTypeRegexp), Default: ptr.To(asJSON("elsewhere"))},
+				i:    "ap-south-1",
+			},
+			want: want{
+				o: "elsewhere",
+			},
+		},
+		"RegexpKeysNotFound": {
+			args: args{
+				t:    &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"^us-": asJSON("americas")}},
+				opts: &v1beta1.MapOptions{Type: ptr.To(v1beta1.MapTransformTypeRegexp)},
+				i:    "ap-south-1",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapNotFound, "ap-south-1"),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := ResolveMap(tc.t, tc.i)
+			got, err := ResolveMap(tc.t, tc.opts, tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -147,20 +194,71 @@ func TestMatchResolve(t *testing.T) {
 		args
 		want
 	}{
-		"ErrNonStringInput": {
+		"ErrObjectInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("5"),
+						},
+					},
+				},
+				i: map[string]any{"five": 5},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtMatchInputTypeInvalid, "map[string]interface {}"), errFmtMatchPattern, 0),
+			},
+		},
+		"NumberInput": {
 			args: args{
 				t: &v1beta1.MatchTransform{
 					Patterns: []v1beta1.MatchTransformPattern{
 						{
 							Type:    v1beta1.MatchTransformPatternTypeLiteral,
 							Literal: ptr.To[string]("5"),
+							Result:  asJSON("five"),
 						},
 					},
 				},
 				i: 5,
 			},
 			want: want{
-				err: errors.Wrapf(errors.Errorf(errFmtMatchInputTypeInvalid, "int"), errFmtMatchPattern, 0),
+				o: "five",
+			},
+		},
+		"FloatInputRegexp": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp: ptr.To[string]("^5[0-9]{2}$"),
+							Result: asJSON("server error"),
+						},
+					},
+				},
+				i: float64(503),
+			},
+			want: want{
+				o: "server error",
+			},
+		},
+		"BoolInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("true"),
+							Result:  asJSON("yes"),
+						},
+					},
+				},
+				i: true,
+			},
+			want: want{
+				o: "yes",
 			},
 		},
 		"ErrFallbackValueAndToInput": {
@@ -428,6 +526,150 @@ func TestMatchResolve(t *testing.T) {
 				err: errors.Wrapf(errors.Errorf(errFmtRequiredField, "literal", string(v1beta1.MatchTransformPatternTypeLiteral)), errFmtMatchPattern, 0),
 			},
 		},
+		"ExpandCaptureGroups": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:         v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp:       ptr.To[string]("^(?P<region>[a-z]+)-(\\d+)$"),
+							Result:       asJSON("${region}-$2-suffix"),
+							ExpandResult: true,
+						},
+					},
+				},
+				i: "eu-1",
+			},
+			want: want{
+				o: "eu-1-suffix",
+			},
+		},
+		"ExpandCaptureGroupsInObject": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:         v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp:       ptr.To[string]("^([a-z]+)-(\\d+)$"),
+							Result:       asJSON(map[string]any{"region": "$1", "zones": []any{"${2}a", "$$2"}, "count": 2}),
+							ExpandResult: true,
+						},
+					},
+				},
+				i: "eu-1",
+			},
+			want: want{
+				o: map[string]any{"region": "eu", "zones": []any{"1a", "$2"}, "count": float64(2)},
+			},
+		},
+		"DontExpandCaptureGroups": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp: ptr.To[string]("^([a-z]+)-(\\d+)$"),
+							Result: asJSON("$1"),
+						},
+					},
+				},
+				i: "eu-1",
+			},
+			want: want{
+				o: "$1",
+			},
+		},
+		"ArrayAnyMatches": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("backups"),
+							Result:  asJSON(true),
+						},
+					},
+					FallbackValue: asJSON(false),
+				},
+				i: []any{"metrics", "backups"},
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"ArrayAllDoesntMatch": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp: ptr.To[string]("^eu-"),
+							Result: asJSON("eu-only"),
+						},
+					},
+					FallbackValue: asJSON("global"),
+					ArrayPolicy:   ptr.To(v1beta1.MatchArrayPolicyAll),
+				},
+				i: []any{"eu-1", "us-1"},
+			},
+			want: want{
+				o: "global",
+			},
+		},
+		"ArrayEmpty": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp: ptr.To[string](".*"),
+							Result: asJSON(true),
+						},
+					},
+					FallbackValue: asJSON(false),
+					ArrayPolicy:   ptr.To(v1beta1.MatchArrayPolicyAll),
+				},
+				i: []any{},
+			},
+			want: want{
+				o: false,
+			},
+		},
+		"ArrayExpandFirstMatch": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:         v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp:       ptr.To[string]("^tier-(\\w+)$"),
+							Result:       asJSON("$1"),
+							ExpandResult: true,
+						},
+					},
+				},
+				i: []any{"metrics", "tier-gold", "tier-silver"},
+			},
+			want: want{
+				o: "gold",
+			},
+		},
+		"ErrArrayElement": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("backups"),
+							Result:  asJSON(true),
+						},
+					},
+				},
+				i: []any{"metrics", map[string]any{}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtMatchInputTypeInvalid, "map[string]interface {}"), errFmtMatchPattern, 0),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -451,6 +693,10 @@ func TestMathResolve(t *testing.T) {
 		multiplier *int64
 		clampMin   *int64
 		clampMax   *int64
+		add        *int64
+		subtract   *int64
+		divide     *int64
+		modulo     *int64
 		i          any
 	}
 	type want struct {
@@ -610,10 +856,95 @@ func TestMathResolve(t *testing.T) {
 				},
 			},
 		},
+		"AddSuccess": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeAdd,
+				add:      &two,
+				i:        3,
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"AddSuccessFloat64": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeAdd,
+				add:      &two,
+				i:        float64(1.5),
+			},
+			want: want{
+				o: float64(3.5),
+			},
+		},
+		"SubtractSuccess": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeSubtract,
+				subtract: &two,
+				i:        int64(3),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"DivideSuccess": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeDivide,
+				divide:   &two,
+				i:        7,
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"DivideSuccessFloat64": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeDivide,
+				divide:   &two,
+				i:        float64(7),
+			},
+			want: want{
+				o: float64(3.5),
+			},
+		},
+		"DivideByZero": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeDivide,
+				divide:   ptr.To[int64](0),
+				i:        7,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "divide",
+				},
+			},
+		},
+		"ModuloSuccess": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeModulo,
+				modulo:   &two,
+				i:        int64(7),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"ModuloNoConfig": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeModulo,
+				i:        7,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "modulo",
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			tr := &v1beta1.MathTransform{Type: tc.mathType, Multiply: tc.multiplier, ClampMin: tc.clampMin, ClampMax: tc.clampMax}
+			tr := &v1beta1.MathTransform{Type: tc.mathType, Multiply: tc.multiplier, ClampMin: tc.clampMin, ClampMax: tc.clampMax, Add: tc.add, Subtract: tc.subtract, Divide: tc.divide, Modulo: tc.modulo}
 			got, err := ResolveMath(tr, tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
@@ -634,14 +965,16 @@ func TestMathResolve(t *testing.T) {
 func TestStringResolve(t *testing.T) {
 
 	type args struct {
-		stype   v1beta1.StringTransformType
-		fmts    *string
-		convert *v1beta1.StringConversionType
-		trim    *string
-		regexp  *v1beta1.StringTransformRegexp
-		join    *v1beta1.StringTransformJoin
-		replace *v1beta1.StringTransformReplace
-		i       any
+		stype    v1beta1.StringTransformType
+		fmts     *string
+		convert  *v1beta1.StringConversionType
+		trim     *string
+		regexp   *v1beta1.StringTransformRegexp
+		join     *v1beta1.StringTransformJoin
+		replace  *v1beta1.StringTransformReplace
+		truncate *v1beta1.StringTransformTruncate
+		input    *v1beta1.StringFormatInput
+		i        any
 	}
 	type want struct {
 		o   string
@@ -706,6 +1039,61 @@ func TestStringResolve(t *testing.T) {
 				o: "the largest 8",
 			},
 		},
+		"FmtRepeat": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  ptr.To("%s.%s.svc.%[1]q"),
+				input: ptr.To(v1beta1.StringFormatInputRepeat),
+				i:     "db",
+			},
+			want: want{
+				o: `db.db.svc."db"`,
+			},
+		},
+		"FmtRepeatNumber": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  ptr.To("%d-%05.1f%%"),
+				input: ptr.To(v1beta1.StringFormatInputRepeat),
+				i:     2.5,
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringFormatVerb, 'd', 2.5),
+			},
+		},
+		"FmtRepeatVerbMismatch": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  ptr.To("%s-%d"),
+				input: ptr.To(v1beta1.StringFormatInputRepeat),
+				i:     "db",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringFormatVerb, 'd', "db"),
+			},
+		},
+		"FmtRepeatFloat": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  ptr.To("%.0f/%05.1f%%"),
+				input: ptr.To(v1beta1.StringFormatInputRepeat),
+				i:     2.5,
+			},
+			want: want{
+				o: "2/002.5%",
+			},
+		},
+		"FmtRepeatInvalid": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  ptr.To("%s-%*d"),
+				input: ptr.To(v1beta1.StringFormatInputRepeat),
+				i:     "db",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringFormatInvalid, 3),
+			},
+		},
 		"ConvertNotSet": {
 			args: args{
 				stype: v1beta1.StringTransformTypeConvert,
@@ -1080,17 +1468,58 @@ func TestStringResolve(t *testing.T) {
 				o: "Croplane",
 			},
 		},
+		"TruncateShort": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{MaxLength: 32, SuffixHash: true},
+				i:        "short-name",
+			},
+			want: want{
+				o: "short-name",
+			},
+		},
+		"Truncate": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{MaxLength: 6},
+				i:        "a-very-long-name-for-a-load-balancer",
+			},
+			want: want{
+				o: "a-very",
+			},
+		},
+		"TruncateSuffixHash": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{MaxLength: 20, SuffixHash: true},
+				i:        "a-very-long-name-for-a-load-balancer",
+			},
+			want: want{
+				o: "a-very-long-a05f74e9",
+			},
+		},
+		"TruncateNotSet": {
+			args: args{
+				stype: v1beta1.StringTransformTypeTruncate,
+				i:     "Crossplane",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeTruncate, v1beta1.StringTransformTypeTruncate),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 
 			tr := &v1beta1.StringTransform{Type: tc.stype,
-				Format:  tc.fmts,
-				Convert: tc.convert,
-				Trim:    tc.trim,
-				Regexp:  tc.regexp,
-				Join:    tc.join,
-				Replace: tc.replace,
+				Format:      tc.fmts,
+				Convert:     tc.convert,
+				Trim:        tc.trim,
+				Regexp:      tc.regexp,
+				Join:        tc.join,
+				Replace:     tc.replace,
+				Truncate:    tc.truncate,
+				FormatInput: tc.input,
 			}
 
 			got, err := ResolveString(tr, tc.i)
@@ -1105,11 +1534,457 @@ func TestStringResolve(t *testing.T) {
 	}
 }
 
-func TestConvertResolve(t *testing.T) {
+func TestTimeResolve(t *testing.T) {
 	type args struct {
-		to     v1beta1.TransformIOType
-		format *v1beta1.ConvertTransformFormat
-		i      any
+		t *v1beta1.TimeTransform
+		i any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InvalidType": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: "bad"},
+				i: "2024-01-02T03:04:05Z",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"ToUnix": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeToUnix},
+				i: "2024-01-02T03:04:05Z",
+			},
+			want: want{
+				o: int64(1704164645),
+			},
+		},
+		"ToUnixInputLayout": {
+			args: args{
+				t: &v1beta1.TimeTransform{
+					Type:        v1beta1.TimeTransformTypeToUnix,
+					InputLayout: ptr.To("2006-01-02"),
+				},
+				i: "2024-01-02",
+			},
+			want: want{
+				o: int64(1704153600),
+			},
+		},
+		"ToUnixNonString": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeToUnix},
+				i: 42,
+			},
+			want: want{
+				err: errors.Errorf(errFmtTimeInputNonString, v1beta1.TimeTransformTypeToUnix, 42),
+			},
+		},
+		"ToUnixParseError": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeToUnix},
+				i: "yesterday",
+			},
+			want: want{
+				err: errors.Wrapf(func() error {
+					_, err := time.Parse(time.RFC3339, "yesterday")
+					return err
+				}(), errFmtTimeParse, time.RFC3339),
+			},
+		},
+		"FromUnixInt64": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeFromUnix},
+				i: int64(1704164645),
+			},
+			want: want{
+				o: "2024-01-02T03:04:05Z",
+			},
+		},
+		"FromUnixFloat64WithLayout": {
+			args: args{
+				t: &v1beta1.TimeTransform{
+					Type:   v1beta1.TimeTransformTypeFromUnix,
+					Layout: ptr.To("2006-01-02"),
+				},
+				i: float64(1704164645),
+			},
+			want: want{
+				o: "2024-01-02",
+			},
+		},
+		"FromUnixNonNumber": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeFromUnix},
+				i: "1704164645",
+			},
+			want: want{
+				err: errors.Errorf(errFmtTimeInputNonNumber, v1beta1.TimeTransformTypeFromUnix, "1704164645"),
+			},
+		},
+		"Format": {
+			args: args{
+				t: &v1beta1.TimeTransform{
+					Type:   v1beta1.TimeTransformTypeFormat,
+					Layout: ptr.To("Jan 2, 2006"),
+				},
+				i: "2024-01-02T03:04:05Z",
+			},
+			want: want{
+				o: "Jan 2, 2024",
+			},
+		},
+		"FormatNoLayout": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeFormat},
+				i: "2024-01-02T03:04:05Z",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "layout",
+				},
+			},
+		},
+		"Add": {
+			args: args{
+				t: &v1beta1.TimeTransform{
+					Type:     v1beta1.TimeTransformTypeAdd,
+					Duration: ptr.To("-24h"),
+				},
+				i: "2024-01-02T03:04:05Z",
+			},
+			want: want{
+				o: "2024-01-01T03:04:05Z",
+			},
+		},
+		"AddNoDuration": {
+			args: args{
+				t: &v1beta1.TimeTransform{Type: v1beta1.TimeTransformTypeAdd},
+				i: "2024-01-02T03:04:05Z",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "duration",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveTime(tc.args.t, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("ResolveTime(...): -want, +got:\n%s", diff)
+			}
+			fieldErr := &field.Error{}
+			if err != nil && errors.As(err, &fieldErr) {
+				fieldErr.Detail = ""
+				fieldErr.BadValue = nil
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("ResolveTime(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCIDRResolve(t *testing.T) {
+	type args struct {
+		t *v1beta1.CIDRTransform
+		i any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InvalidType": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: "bad"},
+				i: "10.0.0.0/16",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"NonStringInput": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeNetmask},
+				i: 42,
+			},
+			want: want{
+				err: errors.Errorf(errFmtCIDRInputNonString, v1beta1.CIDRTransformTypeNetmask, 42),
+			},
+		},
+		"Subnet": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeSubnet, NewBits: ptr.To[int64](8), Number: ptr.To[int64](2)},
+				i: "10.0.0.0/16",
+			},
+			want: want{
+				o: "10.0.2.0/24",
+			},
+		},
+		"SubnetUnmaskedInput": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeSubnet, NewBits: ptr.To[int64](4), Number: ptr.To[int64](15)},
+				i: "10.1.2.3/16",
+			},
+			want: want{
+				o: "10.1.240.0/20",
+			},
+		},
+		"SubnetIPv6": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeSubnet, NewBits: ptr.To[int64](8), Number: ptr.To[int64](1)},
+				i: "fd00:fd12:3456:7800::/56",
+			},
+			want: want{
+				o: "fd00:fd12:3456:7801::/64",
+			},
+		},
+		"SubnetTooLong": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeSubnet, NewBits: ptr.To[int64](8), Number: ptr.To[int64](0)},
+				i: "10.0.0.0/30",
+			},
+			want: want{
+				err: errors.Errorf(errFmtCIDRSubnetTooLong, 30, 8, 4, 32),
+			},
+		},
+		"SubnetNumberTooBig": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeSubnet, NewBits: ptr.To[int64](2), Number: ptr.To[int64](4)},
+				i: "10.0.0.0/16",
+			},
+			want: want{
+				err: errors.Errorf(errFmtCIDRSubnetNumber, 4, 2),
+			},
+		},
+		"SubnetNoNewBits": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeSubnet, Number: ptr.To[int64](2)},
+				i: "10.0.0.0/16",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "newBits",
+				},
+			},
+		},
+		"Host": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeHost, Index: ptr.To[int64](5)},
+				i: "10.0.2.0/24",
+			},
+			want: want{
+				o: "10.0.2.5",
+			},
+		},
+		"HostNegativeIndex": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeHost, Index: ptr.To[int64](-2)},
+				i: "10.0.2.0/24",
+			},
+			want: want{
+				o: "10.0.2.254",
+			},
+		},
+		"HostIndexTooBig": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeHost, Index: ptr.To[int64](256)},
+				i: "10.0.2.0/24",
+			},
+			want: want{
+				err: errors.Errorf(errFmtCIDRHostIndex, 256, 24),
+			},
+		},
+		"Netmask": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeNetmask},
+				i: "10.0.2.0/20",
+			},
+			want: want{
+				o: "255.255.240.0",
+			},
+		},
+		"NetmaskIPv6": {
+			args: args{
+				t: &v1beta1.CIDRTransform{Type: v1beta1.CIDRTransformTypeNetmask},
+				i: "fd00::/64",
+			},
+			want: want{
+				err: errors.New(errCIDRNetmaskIPv6),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveCIDR(tc.args.t, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("ResolveCIDR(...): -want, +got:\n%s", diff)
+			}
+			fieldErr := &field.Error{}
+			if err != nil && errors.As(err, &fieldErr) {
+				fieldErr.Detail = ""
+				fieldErr.BadValue = nil
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("ResolveCIDR(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuantityResolve(t *testing.T) {
+	type args struct {
+		t *v1beta1.QuantityTransform
+		i any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InvalidType": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: "bad"},
+				i: "500Mi",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"MultiplyRequiresFactor": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeMultiply},
+				i: "500Mi",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "multiply",
+				},
+			},
+		},
+		"UnsupportedInput": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeMultiply, Multiply: ptr.To(resource.MustParse("2"))},
+				i: true,
+			},
+			want: want{
+				err: errors.Errorf(errFmtQuantityInputNotSupported, true),
+			},
+		},
+		"InvalidQuantity": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeMultiply, Multiply: ptr.To(resource.MustParse("2"))},
+				i: "lots",
+			},
+			want: want{
+				err: errors.Wrap(resource.ErrFormatWrong, errQuantityParse),
+			},
+		},
+		"MultiplyBinarySI": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeMultiply, Multiply: ptr.To(resource.MustParse("2"))},
+				i: "500Mi",
+			},
+			want: want{
+				o: "1000Mi",
+			},
+		},
+		"MultiplyByFraction": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeMultiply, Multiply: ptr.To(resource.MustParse("1.5"))},
+				i: "1Gi",
+			},
+			want: want{
+				o: "1536Mi",
+			},
+		},
+		"MultiplyNumber": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeMultiply, Multiply: ptr.To(resource.MustParse("3"))},
+				i: int64(2),
+			},
+			want: want{
+				o: "6",
+			},
+		},
+		"AddMilli": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeAdd, Add: ptr.To(resource.MustParse("500m"))},
+				i: "1",
+			},
+			want: want{
+				o: "1500m",
+			},
+		},
+		"AddNegative": {
+			args: args{
+				t: &v1beta1.QuantityTransform{Type: v1beta1.QuantityTransformTypeAdd, Add: ptr.To(resource.MustParse("-512Mi"))},
+				i: "2Gi",
+			},
+			want: want{
+				o: "1536Mi",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveQuantity(tc.args.t, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("ResolveQuantity(...): -want, +got:\n%s", diff)
+			}
+			fieldErr := &field.Error{}
+			if err != nil && errors.As(err, &fieldErr) {
+				fieldErr.Detail = ""
+				fieldErr.BadValue = nil
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("ResolveQuantity(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertResolve(t *testing.T) {
+	type args struct {
+		to       v1beta1.TransformIOType
+		format   *v1beta1.ConvertTransformFormat
+		rounding *v1beta1.ConvertTransformRounding
+		overflow *v1beta1.ConvertTransformOverflowPolicy
+		i        any
 	}
 	type want struct {
 		o   any
@@ -1242,10 +2117,123 @@ func TestConvertResolve(t *testing.T) {
 				o: int64(1),
 			},
 		},
+		"Float64ToInt64Floor": {
+			args: args{
+				i:        float64(-1.5),
+				to:       v1beta1.TransformIOTypeInt64,
+				rounding: ptr.To(v1beta1.ConvertTransformRoundingFloor),
+			},
+			want: want{
+				o: int64(-2),
+			},
+		},
+		"Float64ToIntCeil": {
+			args: args{
+				i:        float64(2.1),
+				to:       v1beta1.TransformIOTypeInt,
+				rounding: ptr.To(v1beta1.ConvertTransformRoundingCeil),
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"Float64ToInt64Round": {
+			args: args{
+				i:        float64(2.5),
+				to:       v1beta1.TransformIOTypeInt64,
+				rounding: ptr.To(v1beta1.ConvertTransformRoundingRound),
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"Float64ToInt64Overflow": {
+			args: args{
+				i:        float64(1e19),
+				to:       v1beta1.TransformIOTypeInt64,
+				overflow: ptr.To(v1beta1.ConvertTransformOverflowPolicyError),
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertOverflow, float64(1e19)),
+			},
+		},
+		"Int64ToFloat64PrecisionLoss": {
+			args: args{
+				i:        int64(1<<53 + 1),
+				to:       v1beta1.TransformIOTypeFloat64,
+				overflow: ptr.To(v1beta1.ConvertTransformOverflowPolicyError),
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertPrecisionLoss, int64(1<<53+1)),
+			},
+		},
+		"StringToInt64Duration": {
+			args: args{
+				i:      "1m30.5s",
+				to:     v1beta1.TransformIOTypeInt64,
+				format: ptr.To(v1beta1.ConvertTransformFormatDuration),
+			},
+			want: want{
+				o: int64(90),
+			},
+		},
+		"StringToFloat64Duration": {
+			args: args{
+				i:      "1m30.5s",
+				to:     v1beta1.TransformIOTypeFloat64,
+				format: ptr.To(v1beta1.ConvertTransformFormatDuration),
+			},
+			want: want{
+				o: float64(90.5),
+			},
+		},
+		"StringToInt64InvalidDuration": {
+			args: args{
+				i:      "90",
+				to:     v1beta1.TransformIOTypeInt64,
+				format: ptr.To(v1beta1.ConvertTransformFormatDuration),
+			},
+			want: want{
+				err: func() error {
+					_, err := time.ParseDuration("90")
+					return err
+				}(),
+			},
+		},
+		"Int64ToStringDuration": {
+			args: args{
+				i:      int64(90),
+				to:     v1beta1.TransformIOTypeString,
+				format: ptr.To(v1beta1.ConvertTransformFormatDuration),
+			},
+			want: want{
+				o: "1m30s",
+			},
+		},
+		"Float64ToStringDuration": {
+			args: args{
+				i:      float64(1.5),
+				to:     v1beta1.TransformIOTypeString,
+				format: ptr.To(v1beta1.ConvertTransformFormatDuration),
+			},
+			want: want{
+				o: "1.5s",
+			},
+		},
+		"Int64ToFloat64Exact": {
+			args: args{
+				i:        int64(1 << 53),
+				to:       v1beta1.TransformIOTypeFloat64,
+				overflow: ptr.To(v1beta1.ConvertTransformOverflowPolicyError),
+			},
+			want: want{
+				o: float64(1 << 53),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			tr := &v1beta1.ConvertTransform{ToType: tc.args.to, Format: tc.format}
+			tr := &v1beta1.ConvertTransform{ToType: tc.args.to, Format: tc.format, Rounding: tc.rounding, Overflow: tc.overflow}
 			got, err := ResolveConvert(tr, tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
@@ -1390,3 +2378,297 @@ func TestConvertTransformGetConversionFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestStringFuncResolve(t *testing.T) {
+	type args struct {
+		t *v1beta1.StringFuncTransform
+		i any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InvalidFunc": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: "bad"},
+				i: "cool",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "func",
+				},
+			},
+		},
+		"IndentRequiresSpaces": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncIndent},
+				i: "cool",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "indent",
+				},
+			},
+		},
+		"NonStringInput": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncTrimSpace},
+				i: int64(42),
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringFuncInputNonString, int64(42)),
+			},
+		},
+		"TrimSpace": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncTrimSpace},
+				i: " \tcool\n",
+			},
+			want: want{
+				o: "cool",
+			},
+		},
+		"Indent": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncIndent, Indent: ptr.To[int64](2)},
+				i: "a: b\nc: d",
+			},
+			want: want{
+				o: "  a: b\n  c: d",
+			},
+		},
+		"SnakeCase": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncSnakeCase},
+				i: "HTTPServer2Name-v1 beta",
+			},
+			want: want{
+				o: "http_server2_name_v1_beta",
+			},
+		},
+		"CamelCase": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncCamelCase},
+				i: "http_server-name",
+			},
+			want: want{
+				o: "HttpServerName",
+			},
+		},
+		"SplitIndex": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncSplitIndex, Split: &v1beta1.StringFuncSplit{Separator: ".", Index: 1}},
+				i: "db.example.org",
+			},
+			want: want{
+				o: "example",
+			},
+		},
+		"SplitIndexOutOfRange": {
+			args: args{
+				t: &v1beta1.StringFuncTransform{Func: v1beta1.StringFuncSplitIndex, Split: &v1beta1.StringFuncSplit{Separator: ".", Index: 3}},
+				i: "db.example.org",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringFuncSplitIndex, 3, ".", 3),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveStringFunc(tc.args.t, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("ResolveStringFunc(...): -want, +got:\n%s", diff)
+			}
+			fieldErr := &field.Error{}
+			if err != nil && errors.As(err, &fieldErr) {
+				fieldErr.Detail = ""
+				fieldErr.BadValue = nil
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("ResolveStringFunc(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSemverResolve(t *testing.T) {
+	type args struct {
+		t *v1beta1.SemverTransform
+		i any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InvalidType": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: "bad"},
+				i: "1.2.3",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"SatisfiesRequiresConstraint": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeSatisfies},
+				i: "1.2.3",
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "constraint",
+				},
+			},
+		},
+		"NonStringInput": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeMajor},
+				i: int64(1),
+			},
+			want: want{
+				err: errors.Errorf(errFmtSemverInputNonString, int64(1)),
+			},
+		},
+		"InvalidVersion": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeMajor},
+				i: "latest",
+			},
+			want: want{
+				err: errors.Wrap(errors.New(`Invalid character(s) found in major number "0latest"`), errSemverParse),
+			},
+		},
+		"Normalize": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeNormalize},
+				i: "v1.28",
+			},
+			want: want{
+				o: "1.28.0",
+			},
+		},
+		"Major": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeMajor},
+				i: "v1.28.3-eks",
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"Minor": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeMinor},
+				i: "v1.28.3-eks",
+			},
+			want: want{
+				o: int64(28),
+			},
+		},
+		"Patch": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypePatch},
+				i: "v1.28.3-eks",
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"Satisfies": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeSatisfies, Constraint: ptr.To(">=1.27.0 <2.0.0")},
+				i: "1.28",
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"DoesNotSatisfy": {
+			args: args{
+				t: &v1beta1.SemverTransform{Type: v1beta1.SemverTransformTypeSatisfies, Constraint: ptr.To(">=1.29.0 || <1.0.0")},
+				i: "1.28.3",
+			},
+			want: want{
+				o: false,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveSemver(tc.args.t, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("ResolveSemver(...): -want, +got:\n%s", diff)
+			}
+			fieldErr := &field.Error{}
+			if err != nil && errors.As(err, &fieldErr) {
+				fieldErr.Detail = ""
+				fieldErr.BadValue = nil
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("ResolveSemver(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDescribeTransform(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		t      v1beta1.Transform
+		want   string
+	}{
+		"Map": {
+			reason: "A transform without a type of operation should be described by its type.",
+			t:      v1beta1.Transform{Type: v1beta1.TransformTypeMap, Map: &v1beta1.MapTransform{}},
+			want:   "map",
+		},
+		"StringFormat": {
+			reason: "A string Format transform should be described with its format.",
+			t: v1beta1.Transform{Type: v1beta1.TransformTypeString, String: &v1beta1.StringTransform{
+				Type:   v1beta1.StringTransformTypeFormat,
+				Format: ptr.To("%s-bucket"),
+			}},
+			want: `string Format "%s-bucket"`,
+		},
+		"Convert": {
+			reason: "A convert transform should be described with the type it converts to.",
+			t:      v1beta1.Transform{Type: v1beta1.TransformTypeConvert, Convert: &v1beta1.ConvertTransform{ToType: v1beta1.TransformIOTypeInt64}},
+			want:   "convert to int64",
+		},
+		"MissingConfig": {
+			reason: "A transform without configuration should be described by its type.",
+			t:      v1beta1.Transform{Type: v1beta1.TransformTypeMath},
+			want:   "math",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DescribeTransform(tc.t)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nDescribeTransform(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
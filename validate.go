@@ -3,9 +3,16 @@ package main
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/blang/semver/v4"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
@@ -32,69 +39,671 @@ func WrapFieldErrorList(errs field.ErrorList, path *field.Path) field.ErrorList
 	return errs
 }
 
-// ValidateResources validates the Resources object.
-func ValidateResources(r *v1beta1.Resources) *field.Error {
-	for _, ps := range r.PatchSets {
-		if err := ValidatePatchSet(ps); err != nil {
-			return err
+// ValidateResources validates the Resources object. It returns every problem
+// it finds, not just the first.
+func ValidateResources(r *v1beta1.Resources) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, ps := range r.PatchSets {
+		errs = append(errs, WrapFieldErrorList(ValidatePatchSet(ps), field.NewPath("patchSets").Index(i))...)
+	}
+	errs = append(errs, ValidatePatchSetCycles(r.PatchSets)...)
+	if r.Defaults != nil {
+		for i, p := range r.Defaults.Patches {
+			p := p
+			if err := ValidatePatch(&p); err != nil {
+				errs = append(errs, WrapFieldError(err, field.NewPath("defaults", "patches").Index(i)))
+			}
 		}
 	}
-	if len(r.Resources) == 0 && (r.Environment == nil || len(r.Environment.Patches) == 0) {
-		return field.Required(field.NewPath("resources"), "resources or environment patches are required")
+	if err := validatePatchFailurePolicy(field.NewPath("onPatchFailure"), r.OnPatchFailure); err != nil {
+		errs = append(errs, err)
+	}
+	if len(r.Resources) == 0 && (r.Environment == nil || len(r.Environment.Patches)+len(r.Environment.EnvironmentConfigs) == 0) {
+		errs = append(errs, field.Required(field.NewPath("resources"), "resources, environment patches, or environment configs are required"))
 	}
 	for i, r := range r.Resources {
-		if err := ValidateComposedTemplate(r); err != nil {
-			return WrapFieldError(err, field.NewPath("resources").Index(i))
+		errs = append(errs, WrapFieldErrorList(ValidateComposedTemplate(r), field.NewPath("resources").Index(i))...)
+	}
+	if err := ValidateDependencies(r.Resources); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, ValidatePreviousNames(r.Resources)...)
+	errs = append(errs, WrapFieldErrorList(ValidateEnvironment(r.Environment), field.NewPath("environment"))...)
+	names := make(map[string]bool, len(r.ExtraResources))
+	for i, er := range r.ExtraResources {
+		if err := ValidateExtraResource(er); err != nil {
+			errs = append(errs, WrapFieldError(err, field.NewPath("extraResources").Index(i)))
+			continue
+		}
+		if IsReservedExtraResourceName(er.Name) {
+			errs = append(errs, field.Invalid(field.NewPath("extraResources").Index(i).Child("name"), er.Name, "name is reserved"))
+			continue
 		}
+		if names[er.Name] {
+			errs = append(errs, field.Duplicate(field.NewPath("extraResources").Index(i).Child("name"), er.Name))
+		}
+		names[er.Name] = true
+	}
+	templates := make(map[string]bool, len(r.Resources))
+	for _, t := range r.Resources {
+		templates[t.Name] = true
 	}
-	if err := ValidateEnvironment(r.Environment); err != nil {
-		return WrapFieldError(err, field.NewPath("environment"))
+	for i, cd := range r.ConnectionDetails {
+		if err := ValidateCompositeConnectionDetail(cd); err != nil {
+			errs = append(errs, WrapFieldError(err, field.NewPath("connectionDetails").Index(i)))
+			continue
+		}
+		if cd.FromResource != nil && !templates[*cd.FromResource] {
+			errs = append(errs, field.NotFound(field.NewPath("connectionDetails").Index(i).Child("fromResource"), *cd.FromResource))
+		}
+	}
+	errs = append(errs, ValidateCrossplaneAnnotations(r)...)
+	errs = append(errs, ValidateVariables(r)...)
+	for i, e := range r.Events {
+		if err := ValidateEvent(e); err != nil {
+			errs = append(errs, WrapFieldError(err, field.NewPath("events").Index(i)))
+			continue
+		}
+		if e.Resource != nil && !templates[*e.Resource] {
+			errs = append(errs, field.NotFound(field.NewPath("events").Index(i).Child("resource"), *e.Resource))
+		}
+	}
+	types := make(map[string]bool, len(r.StatusConditions))
+	for i, sc := range r.StatusConditions {
+		path := field.NewPath("statusConditions").Index(i)
+		switch {
+		case sc.Type == "":
+			errs = append(errs, field.Required(path.Child("type"), "type is required"))
+		case IsReservedConditionType(sc.Type):
+			errs = append(errs, field.Invalid(path.Child("type"), sc.Type, "type is managed by Crossplane"))
+		case types[sc.Type]:
+			errs = append(errs, field.Duplicate(path.Child("type"), sc.Type))
+		}
+		types[sc.Type] = true
+		if len(sc.Resources) == 0 {
+			errs = append(errs, field.Required(path.Child("resources"), "at least one resource is required"))
+		}
+		for j, name := range sc.Resources {
+			if !templates[name] {
+				errs = append(errs, field.NotFound(path.Child("resources").Index(j), name))
+			}
+		}
+	}
+	if p := r.WriteInventoryTo; p != nil {
+		if s, err := fieldpath.Parse(*p); err != nil || len(s) < 2 || s[0].Field != "status" {
+			errs = append(errs, field.Invalid(field.NewPath("writeInventoryTo"), *p, "must be a field path of the composite resource's status, for example status.inventory"))
+		}
+	}
+	return errs
+}
+
+// ValidateVariables validates the input's variables, and that patches of type
+// FromVariableFieldPath only read variables that exist.
+func ValidateVariables(r *v1beta1.Resources) field.ErrorList {
+	errs := field.ErrorList{}
+	names := make([]string, 0, len(r.Variables))
+	for name := range r.Variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if IsReservedVariableName(name) {
+			errs = append(errs, field.Invalid(field.NewPath("variables").Key(name), name, "name is reserved"))
+		}
+	}
+	variable := func(path *field.Path, p PatchInterface) {
+		if p.GetType() != v1beta1.PatchTypeFromVariableFieldPath {
+			return
+		}
+		segs, err := fieldpath.Parse(p.GetFromFieldPath())
+		if err != nil || len(segs) == 0 {
+			// ValidatePatch reports invalid field paths.
+			return
+		}
+		if _, ok := r.Variables[segs[0].Field]; !ok {
+			errs = append(errs, field.NotFound(path.Child("fromFieldPath"), p.GetFromFieldPath()))
+		}
+	}
+	for i, ps := range r.PatchSets {
+		for j, p := range ps.Patches {
+			variable(field.NewPath("patchSets").Index(i).Child("patches").Index(j), &p)
+		}
+	}
+	if r.Defaults != nil {
+		for i, p := range r.Defaults.Patches {
+			variable(field.NewPath("defaults", "patches").Index(i), &p)
+		}
+	}
+	for i, t := range r.Resources {
+		for j, p := range t.Patches {
+			variable(field.NewPath("resources").Index(i).Child("patches").Index(j), &p)
+		}
+	}
+	return errs
+}
+
+// ValidateCrossplaneAnnotations validates the policies that configure whether
+// patches may change the annotations Crossplane uses to manage a resource,
+// and that patches only change the annotations their policies allow. Patches
+// from PatchSets and default patches that change a composed resource's denied
+// annotations aren't reported, because they're reverted when the Function
+// runs.
+func ValidateCrossplaneAnnotations(r *v1beta1.Resources) field.ErrorList {
+	errs := field.ErrorList{}
+	if err := validateCrossplaneAnnotationPolicies(field.NewPath("crossplaneAnnotations"), r.CrossplaneAnnotations); err != nil {
+		errs = append(errs, err)
+	}
+	allowed := AllowedCompositeAnnotations(r.CrossplaneAnnotations)
+	toComposite := func(path *field.Path, p PatchInterface) {
+		if k, ok := PatchedAnnotation(p.GetToFieldPath()); ok && slices.Contains(crossplaneAnnotations, k) && !allowed[k] {
+			errs = append(errs, field.Forbidden(path.Child("toFieldPath"), fmt.Sprintf("patches may not change the composite resource's %s annotation unless crossplaneAnnotations allows it", k)))
+		}
+	}
+	for i, ps := range r.PatchSets {
+		for j, p := range ps.Patches {
+			if p.GetType() == v1beta1.PatchTypeToCompositeFieldPath || p.GetType() == v1beta1.PatchTypeCombineToComposite {
+				toComposite(field.NewPath("patchSets").Index(i).Child("patches").Index(j), &p)
+			}
+		}
+	}
+	if r.Defaults != nil {
+		for i, p := range r.Defaults.Patches {
+			if p.GetType() == v1beta1.PatchTypeToCompositeFieldPath || p.GetType() == v1beta1.PatchTypeCombineToComposite {
+				toComposite(field.NewPath("defaults", "patches").Index(i), &p)
+			}
+		}
+	}
+	if e := r.Environment; e != nil {
+		for i, p := range e.Patches {
+			switch p.GetType() { //nolint:exhaustive // Only patches to the XR are relevant.
+			case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
+				toComposite(field.NewPath("environment", "patches").Index(i), &p)
+			}
+		}
+	}
+	for i, t := range r.Resources {
+		path := field.NewPath("resources").Index(i)
+		if err := validateCrossplaneAnnotationPolicies(path.Child("crossplaneAnnotations"), t.CrossplaneAnnotations); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		denied := DeniedComposedAnnotations(t.CrossplaneAnnotations)
+		for j, p := range t.Patches {
+			if !ToComposedResource(&p) {
+				if p.GetType() == v1beta1.PatchTypeToCompositeFieldPath || p.GetType() == v1beta1.PatchTypeCombineToComposite {
+					toComposite(path.Child("patches").Index(j), &p)
+				}
+				continue
+			}
+			if k, ok := PatchedAnnotation(p.GetToFieldPath()); ok && denied[k] {
+				errs = append(errs, field.Forbidden(path.Child("patches").Index(j).Child("toFieldPath"), fmt.Sprintf("crossplaneAnnotations denies patches to the composed resource's %s annotation", k)))
+			}
+		}
+	}
+	return errs
+}
+
+func validateCrossplaneAnnotationPolicies(path *field.Path, a *v1beta1.CrossplaneAnnotations) *field.Error {
+	if a == nil {
+		return nil
+	}
+	for _, p := range []struct {
+		name   string
+		policy *v1beta1.CrossplaneAnnotationPolicy
+	}{{"externalName", a.ExternalName}, {"paused", a.Paused}} {
+		if p.policy == nil {
+			continue
+		}
+		switch *p.policy {
+		case v1beta1.CrossplaneAnnotationPolicyAllow, v1beta1.CrossplaneAnnotationPolicyDeny:
+		default:
+			return field.Invalid(path.Child(p.name), string(*p.policy), "unknown Crossplane annotation policy")
+		}
+	}
+	return nil
+}
+
+// ValidateEvent validates an Event.
+func ValidateEvent(e v1beta1.Event) *field.Error {
+	if e.Message == "" {
+		return field.Required(field.NewPath("message"), "message is required")
+	}
+	for _, v := range ExternalNameVariables(e.Message) {
+		prefix := eventVariablePrefixComposite
+		if e.Resource != nil && strings.HasPrefix(v, eventVariablePrefixResource) {
+			prefix = eventVariablePrefixResource
+		}
+		if !strings.HasPrefix(v, prefix) || v == prefix {
+			return field.Invalid(field.NewPath("message"), e.Message, fmt.Sprintf("variable {%s} must be a composite resource field path prefixed with %s, or a composed resource field path prefixed with %s if resource is set", v, eventVariablePrefixComposite, eventVariablePrefixResource))
+		}
+	}
+	switch e.GetType() {
+	case v1beta1.EventTypeNormal, v1beta1.EventTypeWarning:
+	default:
+		return field.Invalid(field.NewPath("type"), e.GetType(), "unknown event type")
+	}
+	switch e.GetTarget() {
+	case v1beta1.EventTargetComposite, v1beta1.EventTargetCompositeAndClaim:
+	default:
+		return field.Invalid(field.NewPath("target"), e.GetTarget(), "unknown event target")
 	}
 	return nil
 }
 
 // ValidateComposedTemplate validates a ComposedTemplate.
-func ValidateComposedTemplate(t v1beta1.ComposedTemplate) *field.Error {
+func ValidateComposedTemplate(t v1beta1.ComposedTemplate) field.ErrorList {
+	errs := field.ErrorList{}
 	if t.Name == "" {
-		return field.Required(field.NewPath("name"), "name is required")
+		errs = append(errs, field.Required(field.NewPath("name"), "name is required"))
+	}
+	if bf := t.BaseFrom; bf != nil {
+		if t.Base != nil {
+			errs = append(errs, field.Invalid(field.NewPath("baseFrom"), "", "base and baseFrom are mutually exclusive"))
+		}
+		if (bf.EnvironmentFieldPath == nil) == (bf.ContextFieldPath == nil) {
+			errs = append(errs, field.Required(field.NewPath("baseFrom"), "exactly one of environmentFieldPath or contextFieldPath is required"))
+		}
+	}
+	if t.Base != nil {
+		errs = append(errs, ValidateBase(t.Base.Raw)...)
+	}
+	if n := t.BaseFromObserved; n != nil {
+		switch {
+		case t.Base != nil || t.BaseFrom != nil:
+			errs = append(errs, field.Invalid(field.NewPath("baseFromObserved"), *n, "base, baseFrom, and baseFromObserved are mutually exclusive"))
+		case *n == "":
+			errs = append(errs, field.Required(field.NewPath("baseFromObserved"), "baseFromObserved must name a resource template"))
+		case *n == t.Name:
+			errs = append(errs, field.Invalid(field.NewPath("baseFromObserved"), *n, "a resource template can't clone its own composed resource"))
+		}
 	}
 	for i, p := range t.Patches {
 		p := p
 		if err := ValidatePatch(&p); err != nil {
-			return WrapFieldError(err, field.NewPath("patches").Index(i))
+			errs = append(errs, WrapFieldError(err, field.NewPath("patches").Index(i)))
 		}
 	}
+	if err := ValidateExternalName(t.ExternalName); err != nil {
+		errs = append(errs, WrapFieldError(err, field.NewPath("externalName")))
+	}
+	if err := ValidateWaitFor(t.WaitFor); err != nil {
+		errs = append(errs, WrapFieldError(err, field.NewPath("waitFor")))
+	}
+	if err := ValidateConnectionSecretRef(t.WriteConnectionSecretToRef); err != nil {
+		errs = append(errs, WrapFieldError(err, field.NewPath("writeConnectionSecretToRef")))
+	}
+	if err := ValidateComposedMetadata(t.Metadata); err != nil {
+		errs = append(errs, WrapFieldError(err, field.NewPath("metadata")))
+	}
 	for i, cd := range t.ConnectionDetails {
 		if err := ValidateConnectionDetail(cd); err != nil {
-			return WrapFieldError(err, field.NewPath("connectionDetails").Index(i))
+			errs = append(errs, WrapFieldError(err, field.NewPath("connectionDetails").Index(i)))
+			continue
+		}
+		if cd.FromResource != nil {
+			errs = append(errs, field.Forbidden(field.NewPath("connectionDetails").Index(i).Child("fromResource"), "fromResource is only supported by the top-level connectionDetails"))
 		}
 	}
 	for i, rc := range t.ReadinessChecks {
 		if err := ValidateReadinessCheck(rc); err != nil {
-			return WrapFieldError(err, field.NewPath("readinessChecks").Index(i))
+			errs = append(errs, WrapFieldError(err, field.NewPath("readinessChecks").Index(i)))
+		}
+	}
+	if err := ValidateReadyFrom(t.ReadyFrom); err != nil {
+		errs = append(errs, WrapFieldError(err, field.NewPath("readyFrom")))
+	}
+	for i, fo := range t.FieldOwnership {
+		if err := ValidateFieldOwnership(fo); err != nil {
+			errs = append(errs, WrapFieldError(err, field.NewPath("fieldOwnership").Index(i)))
+		}
+	}
+	for i, a := range t.ManagementPolicies {
+		switch a {
+		case xpv1.ManagementActionObserve,
+			xpv1.ManagementActionCreate,
+			xpv1.ManagementActionUpdate,
+			xpv1.ManagementActionDelete,
+			xpv1.ManagementActionLateInitialize,
+			xpv1.ManagementActionAll:
+		default:
+			errs = append(errs, field.Invalid(field.NewPath("managementPolicies").Index(i), string(a), "unknown management action"))
+		}
+	}
+	if dp := t.DeletionPolicy; dp != nil && *dp != xpv1.DeletionOrphan && *dp != xpv1.DeletionDelete {
+		errs = append(errs, field.Invalid(field.NewPath("deletionPolicy"), string(*dp), "unknown deletion policy"))
+	}
+	if err := validatePatchFailurePolicy(field.NewPath("onPatchFailure"), t.OnPatchFailure); err != nil {
+		errs = append(errs, err)
+	}
+	if rp := t.RenderPolicy; rp != nil && *rp != v1beta1.RenderPolicyBase && *rp != v1beta1.RenderPolicyMergeObserved {
+		errs = append(errs, field.Invalid(field.NewPath("renderPolicy"), string(*rp), "unknown render policy"))
+	}
+	if cp := t.ConnectionDetailsPolicy; cp != nil && *cp != v1beta1.ConnectionDetailsPolicyAlways && *cp != v1beta1.ConnectionDetailsPolicyWhenReady {
+		errs = append(errs, field.Invalid(field.NewPath("connectionDetailsPolicy"), string(*cp), "unknown connection details policy"))
+	}
+	if to := t.Timeouts; to != nil {
+		if to.Ready.Duration <= 0 {
+			errs = append(errs, field.Invalid(field.NewPath("timeouts", "ready"), to.Ready.Duration.String(), "must be positive"))
+		}
+		if to.Condition != nil && *to.Condition == "" {
+			errs = append(errs, field.Required(field.NewPath("timeouts", "condition"), "condition type cannot be empty"))
+		}
+	}
+	return errs
+}
+
+func validatePatchFailurePolicy(path *field.Path, p *v1beta1.PatchFailurePolicy) *field.Error {
+	if p == nil {
+		return nil
+	}
+	switch *p {
+	case v1beta1.PatchFailurePolicySkip, v1beta1.PatchFailurePolicyWarn, v1beta1.PatchFailurePolicyFail:
+		return nil
+	}
+	return field.Invalid(path, string(*p), "unknown patch failure policy")
+}
+
+// ValidatePreviousNames validates that the previous names of resource
+// templates aren't the names of resource templates, and that no two resource
+// templates had the same previous name.
+func ValidatePreviousNames(cts []v1beta1.ComposedTemplate) field.ErrorList {
+	errs := field.ErrorList{}
+	names := make(map[string]bool, len(cts))
+	for _, t := range cts {
+		names[t.Name] = true
+	}
+	previous := map[string]bool{}
+	for i, t := range cts {
+		for j, p := range t.PreviousNames {
+			path := field.NewPath("resources").Index(i).Child("previousNames").Index(j)
+			switch {
+			case p == "":
+				errs = append(errs, field.Required(path, "previous name cannot be empty"))
+			case names[p]:
+				errs = append(errs, field.Invalid(path, p, "previous name is the name of a resource template"))
+			case previous[p]:
+				errs = append(errs, field.Duplicate(path, p))
+			}
+			previous[p] = true
+		}
+	}
+	return errs
+}
+
+// ValidateDependencies validates that resource templates only depend on other
+// resource templates, and that their dependencies don't form a cycle.
+func ValidateDependencies(cts []v1beta1.ComposedTemplate) *field.Error {
+	deps := make(map[string][]string, len(cts))
+	for _, t := range cts {
+		deps[t.Name] = t.DependsOn
+	}
+	for i, t := range cts {
+		for j, name := range t.DependsOn {
+			if _, ok := deps[name]; !ok {
+				return field.NotFound(field.NewPath("resources").Index(i).Child("dependsOn").Index(j), name)
+			}
+		}
+	}
+
+	// A depth-first search from each resource template. A template that's
+	// visited again before its search finishes is part of a cycle.
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(cts))
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		for _, d := range deps[name] {
+			if !visit(d) {
+				return false
+			}
+		}
+		state[name] = visited
+		return true
+	}
+	for i, t := range cts {
+		if !visit(t.Name) {
+			return field.Invalid(field.NewPath("resources").Index(i).Child("dependsOn"), t.DependsOn, "dependencies must not form a cycle")
+		}
+	}
+	return nil
+}
+
+// ValidateReadyFrom validates a ReadyFrom.
+func ValidateReadyFrom(rf *v1beta1.ReadyFrom) *field.Error {
+	if rf == nil {
+		return nil
+	}
+	switch rf.GetSource() { //nolint:exhaustive // Composed resources can't override their own readiness.
+	case v1beta1.ReadinessCheckSourceComposite, v1beta1.ReadinessCheckSourceEnvironment:
+	default:
+		return field.Invalid(field.NewPath("source"), rf.Source, "source must be Composite or Environment")
+	}
+	if rf.FieldPath == "" {
+		return field.Required(field.NewPath("fieldPath"), "fieldPath is required")
+	}
+	if _, err := fieldpath.Parse(rf.FieldPath); err != nil {
+		return field.Invalid(field.NewPath("fieldPath"), rf.FieldPath, err.Error())
+	}
+	return nil
+}
+
+// ValidateFieldOwnership validates a FieldOwnership.
+func ValidateFieldOwnership(fo v1beta1.FieldOwnership) *field.Error {
+	switch fo.GetPolicy() {
+	case v1beta1.FieldOwnershipPolicyOwned, v1beta1.FieldOwnershipPolicyHint:
+	default:
+		return field.Invalid(field.NewPath("policy"), fo.Policy, "unknown field ownership policy")
+	}
+	if fo.FieldPath == "" {
+		return field.Required(field.NewPath("fieldPath"), "fieldPath is required")
+	}
+	if _, err := fieldpath.Parse(fo.FieldPath); err != nil {
+		return field.Invalid(field.NewPath("fieldPath"), fo.FieldPath, err.Error())
+	}
+	if strings.Contains(fo.FieldPath, "[*]") {
+		return field.Invalid(field.NewPath("fieldPath"), fo.FieldPath, "fieldPath cannot contain wildcards")
+	}
+	return nil
+}
+
+// ValidateWaitFor validates a WaitFor.
+func ValidateWaitFor(w *v1beta1.WaitFor) *field.Error {
+	if w == nil {
+		return nil
+	}
+	if w.EnvironmentFieldPath == "" {
+		return field.Required(field.NewPath("environmentFieldPath"), "environmentFieldPath is required")
+	}
+	if _, err := fieldpath.Parse(w.EnvironmentFieldPath); err != nil {
+		return field.Invalid(field.NewPath("environmentFieldPath"), w.EnvironmentFieldPath, err.Error())
+	}
+	if len(w.Equals.Raw) == 0 {
+		return field.Required(field.NewPath("equals"), "equals is required")
+	}
+	return nil
+}
+
+// ValidateExternalName validates an ExternalName.
+func ValidateExternalName(en *v1beta1.ExternalName) *field.Error {
+	if en == nil {
+		return nil
+	}
+	if en.Format == "" {
+		return field.Required(field.NewPath("format"), "format is required")
+	}
+	for _, v := range ExternalNameVariables(en.Format) {
+		if !strings.HasPrefix(v, externalNameVariablePrefix) || v == externalNameVariablePrefix {
+			return field.Invalid(field.NewPath("format"), en.Format, fmt.Sprintf("variable {%s} must be a composite resource field path prefixed with %s", v, externalNameVariablePrefix))
+		}
+	}
+	switch en.GetPolicy() {
+	case v1beta1.OverwritePolicyAlways, v1beta1.OverwritePolicyIfUnset:
+	default:
+		return field.Invalid(field.NewPath("policy"), en.GetPolicy(), "invalid external name policy")
+	}
+	return nil
+}
+
+// ValidateConnectionSecretRef validates a ConnectionSecretRef.
+func ValidateConnectionSecretRef(ref *v1beta1.ConnectionSecretRef) *field.Error {
+	if ref == nil {
+		return nil
+	}
+	if ref.Name == "" {
+		return field.Required(field.NewPath("name"), "name is required")
+	}
+	for _, f := range []struct {
+		name   string
+		format *string
+	}{{"name", &ref.Name}, {"namespace", ref.Namespace}} {
+		if f.format == nil {
+			continue
+		}
+		for _, v := range ExternalNameVariables(*f.format) {
+			if !strings.HasPrefix(v, externalNameVariablePrefix) || v == externalNameVariablePrefix {
+				return field.Invalid(field.NewPath(f.name), *f.format, fmt.Sprintf("variable {%s} must be a composite resource field path prefixed with %s", v, externalNameVariablePrefix))
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateComposedMetadata validates a ComposedMetadata.
+func ValidateComposedMetadata(m *v1beta1.ComposedMetadata) *field.Error {
+	if m == nil {
+		return nil
+	}
+	for _, f := range []struct {
+		name   string
+		values map[string]string
+	}{{"labels", m.Labels}, {"annotations", m.Annotations}} {
+		keys := make([]string, 0, len(f.values))
+		for k := range f.values {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			for _, v := range ExternalNameVariables(f.values[k]) {
+				if !strings.HasPrefix(v, externalNameVariablePrefix) || v == externalNameVariablePrefix {
+					return field.Invalid(field.NewPath(f.name).Key(k), f.values[k], fmt.Sprintf("variable {%s} must be a composite resource field path prefixed with %s", v, externalNameVariablePrefix))
+				}
+			}
 		}
 	}
 	return nil
 }
 
 // ValidatePatchSet validates a PatchSet.
-func ValidatePatchSet(ps v1beta1.PatchSet) *field.Error {
+func ValidatePatchSet(ps v1beta1.PatchSet) field.ErrorList {
+	errs := field.ErrorList{}
 	if ps.Name == "" {
-		return field.Required(field.NewPath("name"), "name is required")
+		errs = append(errs, field.Required(field.NewPath("name"), "name is required"))
 	}
 	for i, p := range ps.Patches {
 		p := p
 		if err := ValidatePatch(&p); err != nil {
-			return WrapFieldError(err, field.NewPath("patches").Index(i))
+			errs = append(errs, WrapFieldError(err, field.NewPath("patches").Index(i)))
 		}
 	}
-	return nil
+	return errs
+}
+
+// ValidatePatchSetCycles validates that no PatchSet includes itself, directly
+// or via other PatchSets.
+func ValidatePatchSetCycles(pss []v1beta1.PatchSet) field.ErrorList {
+	errs := field.ErrorList{}
+	includes := make(map[string][]string, len(pss))
+	for _, ps := range pss {
+		for _, p := range ps.Patches {
+			if p.GetType() == v1beta1.PatchTypePatchSet && p.PatchSetName != nil {
+				includes[ps.Name] = append(includes[ps.Name], *p.PatchSetName)
+			}
+		}
+	}
+
+	// reaches returns true if the named PatchSet includes the target
+	// PatchSet, directly or indirectly.
+	var reaches func(name, target string, seen map[string]bool) bool
+	reaches = func(name, target string, seen map[string]bool) bool {
+		if name == target {
+			return true
+		}
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+		for _, n := range includes[name] {
+			if reaches(n, target, seen) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, ps := range pss {
+		for j, p := range ps.Patches {
+			if p.GetType() != v1beta1.PatchTypePatchSet || p.PatchSetName == nil {
+				continue
+			}
+			if reaches(*p.PatchSetName, ps.Name, map[string]bool{}) {
+				errs = append(errs, field.Invalid(field.NewPath("patchSets").Index(i).Child("patches").Index(j).Child("patchSetName"), *p.PatchSetName, fmt.Sprintf("PatchSet %q includes itself", ps.Name)))
+			}
+		}
+	}
+	return errs
 }
 
 // ValidateEnvironment validates (patches to and from) the Environment.
-func ValidateEnvironment(e *v1beta1.Environment) *field.Error {
+func ValidateEnvironment(e *v1beta1.Environment) field.ErrorList {
+	errs := field.ErrorList{}
 	if e == nil {
-		return nil
+		return errs
+	}
+	keys := make([]string, 0, len(e.DefaultData))
+	for k := range e.DefaultData {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if IsReservedVariableName(k) {
+			errs = append(errs, field.Invalid(field.NewPath("defaultData").Key(k), k, "key is reserved"))
+		}
+	}
+	for i, ec := range e.EnvironmentConfigs {
+		if err := ValidateEnvironmentConfig(ec); err != nil {
+			errs = append(errs, WrapFieldError(err, field.NewPath("environmentConfigs").Index(i)))
+		}
+	}
+	for i, f := range e.Schema {
+		if f.FieldPath == "" {
+			errs = append(errs, field.Required(field.NewPath("schema").Index(i).Child("fieldPath"), "fieldPath is required"))
+			continue
+		}
+		if _, err := fieldpath.Parse(f.FieldPath); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("schema").Index(i).Child("fieldPath"), f.FieldPath, err.Error()))
+		}
+		if f.Type == nil {
+			continue
+		}
+		switch *f.Type {
+		case v1beta1.EnvironmentFieldTypeString,
+			v1beta1.EnvironmentFieldTypeInteger,
+			v1beta1.EnvironmentFieldTypeNumber,
+			v1beta1.EnvironmentFieldTypeBoolean,
+			v1beta1.EnvironmentFieldTypeObject,
+			v1beta1.EnvironmentFieldTypeArray:
+		default:
+			errs = append(errs, field.Invalid(field.NewPath("schema").Index(i).Child("type"), *f.Type, "unknown environment field type"))
+		}
 	}
 	for i, p := range e.Patches {
 		p := p
@@ -107,21 +716,119 @@ func ValidateEnvironment(e *v1beta1.Environment) *field.Error {
 			v1beta1.PatchTypeFromEnvironmentFieldPath,
 			v1beta1.PatchTypeToEnvironmentFieldPath:
 		default:
-			return field.Invalid(field.NewPath("patches").Index(i).Key("type"), p.GetType(), "invalid environment patch type")
+			errs = append(errs, field.Invalid(field.NewPath("patches").Index(i).Key("type"), p.GetType(), "invalid environment patch type"))
+			continue
+		}
+
+		switch p.GetStage() {
+		case v1beta1.EnvironmentPatchStageBefore, v1beta1.EnvironmentPatchStageAfter:
+		default:
+			errs = append(errs, field.Invalid(field.NewPath("patches").Index(i).Key("stage"), p.GetStage(), "invalid environment patch stage"))
+			continue
 		}
 
 		if err := ValidatePatch(&p); err != nil {
-			return WrapFieldError(err, field.NewPath("patches").Index(i))
+			errs = append(errs, WrapFieldError(err, field.NewPath("patches").Index(i)))
+			continue
+		}
+
+		// Environment patches of this type are to the XR.
+		if p.GetType() == v1beta1.PatchTypeFromEnvironmentFieldPath {
+			if err := ValidateCompositeToFieldPath(p.GetToFieldPath()); err != nil {
+				errs = append(errs, WrapFieldError(err, field.NewPath("patches").Index(i)))
+			}
 		}
 	}
+	return errs
+}
+
+// ValidateExtraResource validates an ExtraResource.
+func ValidateExtraResource(er v1beta1.ExtraResource) *field.Error {
+	if er.Name == "" {
+		return field.Required(field.NewPath("name"), "name is required")
+	}
+	if er.APIVersion == "" {
+		return field.Required(field.NewPath("apiVersion"), "apiVersion is required")
+	}
+	if er.Kind == "" {
+		return field.Required(field.NewPath("kind"), "kind is required")
+	}
+	if (er.MatchName == nil) == (len(er.MatchLabels) == 0) {
+		return field.Required(field.NewPath("matchName"), "exactly one of matchName or matchLabels is required")
+	}
+	return nil
+}
+
+// ValidateEnvironmentConfig validates an EnvironmentConfig.
+func ValidateEnvironmentConfig(ec v1beta1.EnvironmentConfig) *field.Error {
+	switch ec.GetType() {
+	case v1beta1.EnvironmentConfigTypeReference:
+		if ec.Ref == nil || ec.Ref.Name == "" {
+			return field.Required(field.NewPath("ref", "name"), "ref.name is required for type Reference")
+		}
+	case v1beta1.EnvironmentConfigTypeSelector:
+		if ec.Selector == nil || len(ec.Selector.MatchLabels) == 0 {
+			return field.Required(field.NewPath("selector", "matchLabels"), "selector.matchLabels is required for type Selector")
+		}
+		for i, m := range ec.Selector.MatchLabels {
+			p := field.NewPath("selector", "matchLabels").Index(i)
+			if m.Key == "" {
+				return field.Required(p.Child("key"), "key is required")
+			}
+			switch m.GetType() {
+			case v1beta1.EnvironmentConfigLabelMatcherTypeValue:
+				if m.Value == nil {
+					return field.Required(p.Child("value"), "value is required for type Value")
+				}
+			case v1beta1.EnvironmentConfigLabelMatcherTypeFromCompositeFieldPath:
+				if m.ValueFromFieldPath == nil {
+					return field.Required(p.Child("valueFromFieldPath"), "valueFromFieldPath is required for type FromCompositeFieldPath")
+				}
+			default:
+				return field.Invalid(p.Child("type"), m.Type, "invalid label matcher type")
+			}
+		}
+	default:
+		return field.Invalid(field.NewPath("type"), ec.Type, "invalid environment config type")
+	}
 	return nil
 }
 
+// ValidateCompositeToFieldPath checks that a patch to the supplied field path
+// of the XR won't corrupt metadata that Crossplane manages. Only labels and
+// annotations may be patched, excluding any crossplane.io/ keys. The external
+// name and paused annotations are the exception. ValidateCrossplaneAnnotations
+// checks whether patches may change them.
+func ValidateCompositeToFieldPath(path string) *field.Error {
+	segs, err := fieldpath.Parse(path)
+	if err != nil {
+		return field.Invalid(field.NewPath("toFieldPath"), path, err.Error())
+	}
+	if len(segs) == 0 || segs[0].Field != "metadata" {
+		return nil
+	}
+	if len(segs) == 1 {
+		return field.Invalid(field.NewPath("toFieldPath"), path, "cannot patch all of the composite resource's metadata")
+	}
+	switch segs[1].Field {
+	case "labels", "annotations":
+		if len(segs) > 2 && strings.HasPrefix(segs[2].Field, protectedMetadataPrefix) && (segs[1].Field != "annotations" || !slices.Contains(crossplaneAnnotations, segs[2].Field)) {
+			return field.Invalid(field.NewPath("toFieldPath"), path, fmt.Sprintf("cannot patch composite resource metadata keys prefixed with %s", protectedMetadataPrefix))
+		}
+		return nil
+	default:
+		return field.Invalid(field.NewPath("toFieldPath"), path, "only the composite resource's labels and annotations may be patched")
+	}
+}
+
 // ValidateReadinessCheck checks if the readiness check is logically valid.
 func ValidateReadinessCheck(r v1beta1.ReadinessCheck) *field.Error { //nolint:gocyclo // This function is not that complex, just a switch
 	if !r.Type.IsValid() {
 		return field.Invalid(field.NewPath("type"), string(r.Type), "unknown readiness check type")
 	}
+	if src := r.GetSource(); !src.IsValid() {
+		return field.Invalid(field.NewPath("source"), string(src), "unknown readiness check source")
+	}
 	switch r.Type {
 	case v1beta1.ReadinessCheckTypeNone:
 		return nil
@@ -129,15 +836,37 @@ func ValidateReadinessCheck(r v1beta1.ReadinessCheck) *field.Error { //nolint:go
 		if r.MatchString == nil {
 			return field.Required(field.NewPath("matchString"), "cannot be nil for type MatchString")
 		}
+	case v1beta1.ReadinessCheckTypeMatchRegexp:
+		if r.MatchRegexp == nil {
+			return field.Required(field.NewPath("matchRegexp"), "cannot be nil for type MatchRegexp")
+		}
+		if _, err := regexp.Compile(*r.MatchRegexp); err != nil {
+			return field.Invalid(field.NewPath("matchRegexp"), *r.MatchRegexp, "invalid regexp")
+		}
 	case v1beta1.ReadinessCheckTypeMatchInteger:
 		if r.MatchInteger == nil {
 			return field.Required(field.NewPath("matchInteger"), "cannot be nil for type MatchInteger")
 		}
 	case v1beta1.ReadinessCheckTypeMatchCondition:
+		if r.GetSource() == v1beta1.ReadinessCheckSourceEnvironment {
+			return field.Invalid(field.NewPath("source"), string(r.GetSource()), "cannot be Environment for type MatchCondition")
+		}
 		if err := ValidateMatchConditionReadinessCheck(r.MatchCondition); err != nil {
 			return WrapFieldError(err, field.NewPath("matchCondition"))
 		}
 		return nil
+	case v1beta1.ReadinessCheckTypeMatchConnectionDetailKey:
+		if r.GetSource() != v1beta1.ReadinessCheckSourceComposed {
+			return field.Invalid(field.NewPath("source"), string(r.GetSource()), "must be Composed for type MatchConnectionDetailKey")
+		}
+		if r.ConnectionDetailKey == nil || *r.ConnectionDetailKey == "" {
+			return field.Required(field.NewPath("connectionDetailKey"), "cannot be empty for type MatchConnectionDetailKey")
+		}
+		return nil
+	case v1beta1.ReadinessCheckTypeAnyOf:
+		return ValidateReadinessCheckGroup(field.NewPath("anyOf"), r.AnyOf)
+	case v1beta1.ReadinessCheckTypeAllOf:
+		return ValidateReadinessCheckGroup(field.NewPath("allOf"), r.AllOf)
 	case v1beta1.ReadinessCheckTypeNonEmpty, v1beta1.ReadinessCheckTypeMatchFalse, v1beta1.ReadinessCheckTypeMatchTrue:
 		// No specific validation required.
 	}
@@ -148,6 +877,20 @@ func ValidateReadinessCheck(r v1beta1.ReadinessCheck) *field.Error { //nolint:go
 	return nil
 }
 
+// ValidateReadinessCheckGroup checks if a group of readiness checks is
+// logically valid.
+func ValidateReadinessCheckGroup(path *field.Path, rcs []v1beta1.ReadinessCheck) *field.Error {
+	if len(rcs) == 0 {
+		return field.Required(path, "at least one readiness check must be specified in a group")
+	}
+	for i, rc := range rcs {
+		if err := ValidateReadinessCheck(rc); err != nil {
+			return WrapFieldError(err, path.Index(i))
+		}
+	}
+	return nil
+}
+
 // ValidateMatchConditionReadinessCheck checks if the match condition is
 // logically valid.
 func ValidateMatchConditionReadinessCheck(m *v1beta1.MatchConditionReadinessCheck) *field.Error {
@@ -160,16 +903,55 @@ func ValidateMatchConditionReadinessCheck(m *v1beta1.MatchConditionReadinessChec
 	if m.Status == "" {
 		return field.Required(field.NewPath("status"), "cannot be empty for type MatchCondition")
 	}
+	if m.Reason != nil {
+		if _, err := regexp.Compile(*m.Reason); err != nil {
+			return field.Invalid(field.NewPath("reason"), *m.Reason, "invalid regexp")
+		}
+	}
+	if m.Message != nil {
+		if _, err := regexp.Compile(*m.Message); err != nil {
+			return field.Invalid(field.NewPath("message"), *m.Message, "invalid regexp")
+		}
+	}
+	return nil
+}
+
+// ValidateToFieldPathTemplate validates the supplied patch's toFieldPath, if
+// it's a template.
+func ValidateToFieldPathTemplate(p PatchInterface) *field.Error {
+	if !IsToFieldPathTemplate(p.GetToFieldPath()) {
+		return nil
+	}
+	if _, err := ToFieldPathTemplate(p.GetToFieldPath(), nil); err != nil {
+		return field.Invalid(field.NewPath("toFieldPath"), p.GetToFieldPath(), err.Error())
+	}
+	if p.GetPolicy().GetOverwritePolicy() == v1beta1.OverwritePolicyIfUnset {
+		return field.Invalid(field.NewPath("policy", "overwrite"), v1beta1.OverwritePolicyIfUnset, "cannot be used when toFieldPath is a template")
+	}
 	return nil
 }
 
 // ValidatePatch validates a ComposedPatch.
 func ValidatePatch(p PatchInterface) *field.Error { //nolint: gocyclo // This is a long but simple/same-y switch.
+	switch p.GetType() { //nolint:exhaustive // Only patches to the XR are relevant.
+	case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
+		if err := ValidateCompositeToFieldPath(p.GetToFieldPath()); err != nil {
+			return err
+		}
+	}
+	if err := ValidateToFieldPathTemplate(p); err != nil {
+		return err
+	}
 	switch p.GetType() {
 	case v1beta1.PatchTypeFromCompositeFieldPath,
 		v1beta1.PatchTypeToCompositeFieldPath,
 		v1beta1.PatchTypeFromEnvironmentFieldPath,
-		v1beta1.PatchTypeToEnvironmentFieldPath:
+		v1beta1.PatchTypeToEnvironmentFieldPath,
+		v1beta1.PatchTypeFromContextFieldPath,
+		v1beta1.PatchTypeToContextFieldPath,
+		v1beta1.PatchTypeFromExtraResourceFieldPath,
+		v1beta1.PatchTypeFromClaimFieldPath,
+		v1beta1.PatchTypeFromVariableFieldPath:
 		if p.GetFromFieldPath() == "" {
 			return field.Required(field.NewPath("fromFieldPath"), fmt.Sprintf("fromFieldPath must be set for patch type %s", p.GetType()))
 		}
@@ -208,12 +990,25 @@ func ValidatePatch(p PatchInterface) *field.Error { //nolint: gocyclo // This is
 			v1beta1.ToFieldPathPolicyMergeObjectsAppendArrays,
 			v1beta1.ToFieldPathPolicyForceMergeObjects,
 			v1beta1.ToFieldPathPolicyForceMergeObjectsAppendArrays,
+			v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource,
+			v1beta1.ToFieldPathPolicySetIfEmpty,
 			v1beta1.ToFieldPathPolicyMergeObject, //nolint:staticcheck // MergeObject is deprecated but we must still support it.
 			v1beta1.ToFieldPathPolicyAppendArray: //nolint:staticcheck // AppendArray is deprecated but we must still support it.
 			// ok
 		default:
 			return field.Invalid(field.NewPath("policy", "toFieldPathPolicy"), pp.GetToFieldPathPolicy(), "unknown toFieldPathPolicy")
 		}
+		if (pp.GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicyMergeObjectsDeleteKeysNotInSource || pp.GetToFieldPathPolicy() == v1beta1.ToFieldPathPolicySetIfEmpty) && strings.Contains(p.GetToFieldPath(), "[*]") {
+			return field.Invalid(field.NewPath("policy", "toFieldPathPolicy"), pp.GetToFieldPathPolicy(), "cannot be used when toFieldPath contains wildcards")
+		}
+		if pp.GetExpandArrays() {
+			if IsCombinePatch(p) {
+				return field.Invalid(field.NewPath("policy", "expandArrays"), true, "cannot be used with combine patches")
+			}
+			if !IsToFieldPathTemplate(p.GetToFieldPath()) && strings.Count(p.GetToFieldPath(), "[*]") != 1 {
+				return field.Invalid(field.NewPath("policy", "expandArrays"), true, "toFieldPath must contain exactly one [*] wildcard")
+			}
+		}
 		switch pp.GetFromFieldPathPolicy() {
 		case v1beta1.FromFieldPathPolicyRequired,
 			v1beta1.FromFieldPathPolicyOptional:
@@ -221,6 +1016,29 @@ func ValidatePatch(p PatchInterface) *field.Error { //nolint: gocyclo // This is
 		default:
 			return field.Invalid(field.NewPath("policy", "fromFieldPathPolicy"), pp.GetFromFieldPathPolicy(), "unknown fromFieldPathPolicy")
 		}
+		if d := pp.GetRequeueAfter(); d != nil && d.Duration <= 0 {
+			return field.Invalid(field.NewPath("policy", "requeueAfter"), d.Duration.String(), "must be greater than zero")
+		}
+		switch pp.GetOverwritePolicy() {
+		case v1beta1.OverwritePolicyAlways,
+			v1beta1.OverwritePolicyIfUnset:
+			// ok
+		default:
+			return field.Invalid(field.NewPath("policy", "overwrite"), pp.GetOverwritePolicy(), "unknown overwrite policy")
+		}
+		if c := pp.GetWhenSettled(); c != nil {
+			switch c.Type {
+			case v1beta1.SettledCheckTypeReady,
+				v1beta1.SettledCheckTypeObservedGeneration:
+				// ok
+			case v1beta1.SettledCheckTypeFieldPath:
+				if c.FieldPath == nil || *c.FieldPath == "" {
+					return field.Required(field.NewPath("policy", "whenSettled", "fieldPath"), fmt.Sprintf("fieldPath must be set for settled check type %s", c.Type))
+				}
+			default:
+				return field.Invalid(field.NewPath("policy", "whenSettled", "type"), c.Type, "unknown settled check type")
+			}
+		}
 	}
 	return nil
 }
@@ -232,6 +1050,11 @@ func ValidateCombine(c *v1beta1.Combine) *field.Error {
 		if c.String == nil {
 			return field.Required(field.NewPath("string"), fmt.Sprintf("string must be set for combine strategy %s", c.Strategy))
 		}
+	case v1beta1.CombineStrategyJoin:
+		if c.Join == nil {
+			return field.Required(field.NewPath("join"), fmt.Sprintf("join must be set for combine strategy %s", c.Strategy))
+		}
+	case v1beta1.CombineStrategyObject, v1beta1.CombineStrategyMerge:
 	case "":
 		return field.Required(field.NewPath("strategy"), "a combine strategy must be provided")
 	default:
@@ -242,10 +1065,42 @@ func ValidateCombine(c *v1beta1.Combine) *field.Error {
 		return field.Required(field.NewPath("variables"), "at least one variable must be provided")
 	}
 
-	for i := range c.Variables {
-		if c.Variables[i].FromFieldPath == "" {
-			return field.Required(field.NewPath("variables").Index(i).Child("fromFieldPath"), "fromFieldPath must be set for each combine variable")
+	names := make(map[string]bool, len(c.Variables))
+	for i, v := range c.Variables {
+		p := field.NewPath("variables").Index(i)
+		if v.FromFieldPath == "" {
+			return field.Required(p.Child("fromFieldPath"), "fromFieldPath must be set for each combine variable")
+		}
+		switch v.GetPolicy() {
+		case v1beta1.FromFieldPathPolicyRequired:
+		case v1beta1.FromFieldPathPolicyOptional:
+			if v.Default == nil {
+				return field.Required(p.Child("default"), "default must be set for an optional combine variable")
+			}
+		default:
+			return field.Invalid(p.Child("policy"), v.GetPolicy(), "unknown combine variable policy")
+		}
+		if src := v.Source; src != nil {
+			switch src.Type {
+			case v1beta1.CombineVariableSourceTypeComposite, v1beta1.CombineVariableSourceTypeEnvironment:
+			case v1beta1.CombineVariableSourceTypeComposed:
+				if src.ResourceName == nil || *src.ResourceName == "" {
+					return field.Required(p.Child("source", "resourceName"), fmt.Sprintf("resourceName must be set for combine variable source type %s", src.Type))
+				}
+			default:
+				return field.Invalid(p.Child("source", "type"), src.Type, "unknown combine variable source type")
+			}
 		}
+		if c.Strategy != v1beta1.CombineStrategyObject {
+			continue
+		}
+		if v.Name == "" {
+			return field.Required(p.Child("name"), fmt.Sprintf("name must be set for each combine variable for combine strategy %s", c.Strategy))
+		}
+		if names[v.Name] {
+			return field.Duplicate(p.Child("name"), v.Name)
+		}
+		names[v.Name] = true
 	}
 
 	return nil
@@ -263,7 +1118,10 @@ func ValidateTransform(t v1beta1.Transform) *field.Error { //nolint:gocyclo // T
 		if t.Map == nil {
 			return field.Required(field.NewPath("map"), "given transform type map requires configuration")
 		}
-		return WrapFieldError(ValidateMapTransform(t.Map), field.NewPath("map"))
+		if err := ValidateMapTransform(t.Map); err != nil {
+			return WrapFieldError(err, field.NewPath("map"))
+		}
+		return WrapFieldError(ValidateMapOptions(t.MapOptions, t.Map), field.NewPath("mapOptions"))
 	case v1beta1.TransformTypeMatch:
 		if t.Match == nil {
 			return field.Required(field.NewPath("match"), "given transform type match requires configuration")
@@ -281,6 +1139,31 @@ func ValidateTransform(t v1beta1.Transform) *field.Error { //nolint:gocyclo // T
 		if err := ValidateConvertTransform(t.Convert); err != nil {
 			return WrapFieldError(err, field.NewPath("convert"))
 		}
+	case v1beta1.TransformTypeTime:
+		if t.Time == nil {
+			return field.Required(field.NewPath("time"), "given transform type time requires configuration")
+		}
+		return WrapFieldError(ValidateTimeTransform(t.Time), field.NewPath("time"))
+	case v1beta1.TransformTypeCIDR:
+		if t.CIDR == nil {
+			return field.Required(field.NewPath("cidr"), "given transform type cidr requires configuration")
+		}
+		return WrapFieldError(ValidateCIDRTransform(t.CIDR), field.NewPath("cidr"))
+	case v1beta1.TransformTypeQuantity:
+		if t.Quantity == nil {
+			return field.Required(field.NewPath("quantity"), "given transform type quantity requires configuration")
+		}
+		return WrapFieldError(ValidateQuantityTransform(t.Quantity), field.NewPath("quantity"))
+	case v1beta1.TransformTypeStringFunc:
+		if t.StringFunc == nil {
+			return field.Required(field.NewPath("stringFunc"), "given transform type stringFunc requires configuration")
+		}
+		return WrapFieldError(ValidateStringFuncTransform(t.StringFunc), field.NewPath("stringFunc"))
+	case v1beta1.TransformTypeSemver:
+		if t.Semver == nil {
+			return field.Required(field.NewPath("semver"), "given transform type semver requires configuration")
+		}
+		return WrapFieldError(ValidateSemverTransform(t.Semver), field.NewPath("semver"))
 	default:
 		// Should never happen
 		return field.Invalid(field.NewPath("type"), t.Type, "unknown transform type")
@@ -307,6 +1190,28 @@ func ValidateMathTransform(m *v1beta1.MathTransform) *field.Error {
 		if m.ClampMax == nil {
 			return field.Required(field.NewPath("clampMax"), "must specify a value if a clamp max math transform is specified")
 		}
+	case v1beta1.MathTransformTypeAdd:
+		if m.Add == nil {
+			return field.Required(field.NewPath("add"), "must specify a value if an add math transform is specified")
+		}
+	case v1beta1.MathTransformTypeSubtract:
+		if m.Subtract == nil {
+			return field.Required(field.NewPath("subtract"), "must specify a value if a subtract math transform is specified")
+		}
+	case v1beta1.MathTransformTypeDivide:
+		if m.Divide == nil {
+			return field.Required(field.NewPath("divide"), "must specify a value if a divide math transform is specified")
+		}
+		if *m.Divide == 0 {
+			return field.Invalid(field.NewPath("divide"), *m.Divide, "cannot divide by zero")
+		}
+	case v1beta1.MathTransformTypeModulo:
+		if m.Modulo == nil {
+			return field.Required(field.NewPath("modulo"), "must specify a value if a modulo math transform is specified")
+		}
+		if *m.Modulo == 0 {
+			return field.Invalid(field.NewPath("modulo"), *m.Modulo, "cannot divide by zero")
+		}
 	default:
 		return field.Invalid(field.NewPath("type"), m.Type, "unknown math transform type")
 	}
@@ -321,6 +1226,22 @@ func ValidateMapTransform(m *v1beta1.MapTransform) *field.Error {
 	return nil
 }
 
+// ValidateMapOptions validates the MapOptions of the supplied MapTransform.
+func ValidateMapOptions(o *v1beta1.MapOptions, m *v1beta1.MapTransform) *field.Error {
+	switch o.GetType() {
+	case v1beta1.MapTransformTypeExact:
+	case v1beta1.MapTransformTypeRegexp:
+		for k := range m.Pairs {
+			if _, err := regexp.Compile(k); err != nil {
+				return field.Invalid(field.NewPath("type"), o.GetType(), fmt.Sprintf("key %q is not a valid regexp", k))
+			}
+		}
+	default:
+		return field.Invalid(field.NewPath("type"), o.GetType(), "unknown map transform type")
+	}
+	return nil
+}
+
 // ValidateMatchTransform validates a MatchTransform.
 func ValidateMatchTransform(m *v1beta1.MatchTransform) *field.Error {
 	if len(m.Patterns) == 0 {
@@ -331,6 +1252,11 @@ func ValidateMatchTransform(m *v1beta1.MatchTransform) *field.Error {
 			return WrapFieldError(err, field.NewPath("patterns").Index(i))
 		}
 	}
+	switch m.GetArrayPolicy() {
+	case v1beta1.MatchArrayPolicyAny, v1beta1.MatchArrayPolicyAll:
+	default:
+		return field.Invalid(field.NewPath("arrayPolicy"), m.GetArrayPolicy(), "unknown array policy")
+	}
 	return nil
 }
 
@@ -341,6 +1267,9 @@ func ValidateMatchTransformPattern(p v1beta1.MatchTransformPattern) *field.Error
 		if p.Literal == nil {
 			return field.Required(field.NewPath("literal"), "literal pattern type requires a literal")
 		}
+		if p.ExpandResult {
+			return field.Invalid(field.NewPath("expandResult"), p.ExpandResult, "only regexp patterns can expand their result")
+		}
 	case v1beta1.MatchTransformPatternTypeRegexp:
 		if p.Regexp == nil {
 			return field.Required(field.NewPath("regexp"), "regexp pattern type requires a regexp")
@@ -364,6 +1293,15 @@ func ValidateStringTransform(s *v1beta1.StringTransform) *field.Error { //nolint
 		if s.Format == nil {
 			return field.Required(field.NewPath("fmt"), "format transform requires a format")
 		}
+		switch fi := s.FormatInput; {
+		case fi == nil || *fi == v1beta1.StringFormatInputOnce:
+		case *fi == v1beta1.StringFormatInputRepeat:
+			if _, err := parseFormatVerbs(*s.Format); err != nil {
+				return field.Invalid(field.NewPath("fmt"), *s.Format, err.Error())
+			}
+		default:
+			return field.Invalid(field.NewPath("formatInput"), *fi, "unknown format input")
+		}
 	case v1beta1.StringTransformTypeConvert:
 		if s.Convert == nil {
 			return field.Required(field.NewPath("convert"), "convert transform requires a conversion type")
@@ -393,6 +1331,16 @@ func ValidateStringTransform(s *v1beta1.StringTransform) *field.Error { //nolint
 		if s.Replace.Search == "" {
 			return field.Required(field.NewPath("replace", "search"), "replace transform requires a search")
 		}
+	case v1beta1.StringTransformTypeTruncate:
+		if s.Truncate == nil {
+			return field.Required(field.NewPath("truncate"), "truncate transform requires a truncate")
+		}
+		if s.Truncate.MaxLength < 1 {
+			return field.Invalid(field.NewPath("truncate", "maxLength"), s.Truncate.MaxLength, "maxLength must be at least 1")
+		}
+		if s.Truncate.SuffixHash && s.Truncate.MaxLength <= truncateHashLength+1 {
+			return field.Invalid(field.NewPath("truncate", "maxLength"), s.Truncate.MaxLength, fmt.Sprintf("maxLength must be at least %d to suffix a hash", truncateHashLength+2))
+		}
 	default:
 		return field.Invalid(field.NewPath("type"), s.Type, "unknown string transform type")
 	}
@@ -407,6 +1355,141 @@ func ValidateConvertTransform(t *v1beta1.ConvertTransform) *field.Error {
 	if !t.ToType.IsValid() {
 		return field.Invalid(field.NewPath("toType"), t.ToType, "invalid type")
 	}
+	switch t.GetRounding() {
+	case v1beta1.ConvertTransformRoundingTruncate,
+		v1beta1.ConvertTransformRoundingFloor,
+		v1beta1.ConvertTransformRoundingCeil,
+		v1beta1.ConvertTransformRoundingRound:
+	default:
+		return field.Invalid(field.NewPath("rounding"), t.GetRounding(), "invalid rounding")
+	}
+	switch t.GetOverflowPolicy() {
+	case v1beta1.ConvertTransformOverflowPolicyIgnore, v1beta1.ConvertTransformOverflowPolicyError:
+	default:
+		return field.Invalid(field.NewPath("overflow"), t.GetOverflowPolicy(), "invalid overflow policy")
+	}
+	return nil
+}
+
+// ValidateTimeTransform validates a TimeTransform.
+func ValidateTimeTransform(t *v1beta1.TimeTransform) *field.Error {
+	switch t.Type {
+	case v1beta1.TimeTransformTypeToUnix, v1beta1.TimeTransformTypeFromUnix:
+	case v1beta1.TimeTransformTypeFormat:
+		if t.Layout == nil || *t.Layout == "" {
+			return field.Required(field.NewPath("layout"), "format time transform requires a layout")
+		}
+	case v1beta1.TimeTransformTypeAdd:
+		if t.Duration == nil {
+			return field.Required(field.NewPath("duration"), "add time transform requires a duration")
+		}
+		if _, err := time.ParseDuration(*t.Duration); err != nil {
+			return field.Invalid(field.NewPath("duration"), *t.Duration, "invalid duration")
+		}
+	case "":
+		return field.Required(field.NewPath("type"), "time transform type is required")
+	default:
+		return field.Invalid(field.NewPath("type"), t.Type, "unknown time transform type")
+	}
+	return nil
+}
+
+// ValidateCIDRTransform validates a CIDRTransform.
+func ValidateCIDRTransform(t *v1beta1.CIDRTransform) *field.Error {
+	switch t.Type {
+	case v1beta1.CIDRTransformTypeSubnet:
+		if t.NewBits == nil {
+			return field.Required(field.NewPath("newBits"), "subnet cidr transform requires newBits")
+		}
+		if *t.NewBits < 1 || *t.NewBits > 128 {
+			return field.Invalid(field.NewPath("newBits"), *t.NewBits, "must be between 1 and 128")
+		}
+		if t.Number == nil {
+			return field.Required(field.NewPath("number"), "subnet cidr transform requires a number")
+		}
+		if *t.Number < 0 {
+			return field.Invalid(field.NewPath("number"), *t.Number, "must not be negative")
+		}
+	case v1beta1.CIDRTransformTypeHost:
+		if t.Index == nil {
+			return field.Required(field.NewPath("index"), "host cidr transform requires an index")
+		}
+	case v1beta1.CIDRTransformTypeNetmask:
+	case "":
+		return field.Required(field.NewPath("type"), "cidr transform type is required")
+	default:
+		return field.Invalid(field.NewPath("type"), t.Type, "unknown cidr transform type")
+	}
+	return nil
+}
+
+// ValidateQuantityTransform validates a QuantityTransform.
+func ValidateQuantityTransform(t *v1beta1.QuantityTransform) *field.Error {
+	switch t.Type {
+	case v1beta1.QuantityTransformTypeMultiply:
+		if t.Multiply == nil {
+			return field.Required(field.NewPath("multiply"), "multiply quantity transform requires a factor")
+		}
+	case v1beta1.QuantityTransformTypeAdd:
+		if t.Add == nil {
+			return field.Required(field.NewPath("add"), "add quantity transform requires a quantity")
+		}
+	case "":
+		return field.Required(field.NewPath("type"), "quantity transform type is required")
+	default:
+		return field.Invalid(field.NewPath("type"), t.Type, "unknown quantity transform type")
+	}
+	return nil
+}
+
+// ValidateSemverTransform validates a SemverTransform.
+func ValidateSemverTransform(t *v1beta1.SemverTransform) *field.Error {
+	switch t.Type {
+	case v1beta1.SemverTransformTypeNormalize,
+		v1beta1.SemverTransformTypeMajor,
+		v1beta1.SemverTransformTypeMinor,
+		v1beta1.SemverTransformTypePatch:
+	case v1beta1.SemverTransformTypeSatisfies:
+		if t.Constraint == nil {
+			return field.Required(field.NewPath("constraint"), "satisfies semver transform requires a constraint")
+		}
+		if _, err := semver.ParseRange(*t.Constraint); err != nil {
+			return field.Invalid(field.NewPath("constraint"), *t.Constraint, err.Error())
+		}
+	case "":
+		return field.Required(field.NewPath("type"), "semver transform type is required")
+	default:
+		return field.Invalid(field.NewPath("type"), t.Type, "unknown semver transform type")
+	}
+	return nil
+}
+
+// ValidateStringFuncTransform validates a StringFuncTransform.
+func ValidateStringFuncTransform(t *v1beta1.StringFuncTransform) *field.Error {
+	switch t.Func {
+	case v1beta1.StringFuncTrimSpace, v1beta1.StringFuncSnakeCase, v1beta1.StringFuncCamelCase:
+	case v1beta1.StringFuncIndent:
+		if t.Indent == nil {
+			return field.Required(field.NewPath("indent"), "indent string func requires a number of spaces")
+		}
+		if *t.Indent < 0 {
+			return field.Invalid(field.NewPath("indent"), *t.Indent, "cannot be negative")
+		}
+	case v1beta1.StringFuncSplitIndex:
+		if t.Split == nil {
+			return field.Required(field.NewPath("split"), "splitIndex string func requires split configuration")
+		}
+		if t.Split.Separator == "" {
+			return field.Required(field.NewPath("split", "separator"), "separator cannot be empty")
+		}
+		if t.Split.Index < 0 {
+			return field.Invalid(field.NewPath("split", "index"), t.Split.Index, "cannot be negative")
+		}
+	case "":
+		return field.Required(field.NewPath("func"), "string func is required")
+	default:
+		return field.Invalid(field.NewPath("func"), t.Func, "unknown string func")
+	}
 	return nil
 }
 
@@ -422,18 +1505,125 @@ func ValidateConnectionDetail(cd v1beta1.ConnectionDetail) *field.Error {
 		return field.Required(field.NewPath("name"), "name is required")
 	}
 	switch cd.Type {
+	case v1beta1.ConnectionDetailTypeCombine:
+		if cd.Combine == nil {
+			return field.Required(field.NewPath("combine"), "combine connection detail requires configuration")
+		}
+		if err := ValidateConnectionDetailCombine(cd.Combine); err != nil {
+			return WrapFieldError(err, field.NewPath("combine"))
+		}
+	case v1beta1.ConnectionDetailTypeFromStatusFieldPath:
+		if cd.FromFieldPath == nil {
+			return field.Required(field.NewPath("fromFieldPath"), "from status field path connection detail requires a field path")
+		}
+		if s, err := fieldpath.Parse(*cd.FromFieldPath); err != nil || len(s) < 2 || s[0].Field != "status" {
+			return field.Invalid(field.NewPath("fromFieldPath"), *cd.FromFieldPath, "from status field path connection detail requires a field path under status")
+		}
+	default:
+		if err := validateConnectionDetailSource(cd.Type, cd.FromConnectionSecretKey, cd.FromFieldPath, cd.Value); err != nil {
+			return err
+		}
+	}
+	switch cd.Policy.GetFromFieldPathPolicy() {
+	case v1beta1.FromFieldPathPolicyOptional, v1beta1.FromFieldPathPolicyRequired:
+	default:
+		return field.Invalid(field.NewPath("policy", "fromFieldPath"), cd.Policy.GetFromFieldPathPolicy(), "unknown from field path policy")
+	}
+	switch cd.GetEncoding() {
+	case v1beta1.ConnectionDetailEncodingNone, v1beta1.ConnectionDetailEncodingBase64, v1beta1.ConnectionDetailEncodingJSON:
+	default:
+		return field.Invalid(field.NewPath("encoding"), cd.GetEncoding(), "unknown connection detail encoding")
+	}
+	for i, t := range cd.Transforms {
+		if err := ValidateTransform(t); err != nil {
+			return WrapFieldError(err, field.NewPath("transforms").Index(i))
+		}
+	}
+	return nil
+}
+
+// ValidateConnectionDetailCombine checks if a connection detail combine is
+// logically valid.
+func ValidateConnectionDetailCombine(c *v1beta1.ConnectionDetailCombine) *field.Error {
+	switch c.Strategy {
+	case v1beta1.CombineStrategyString:
+		if c.String == nil {
+			return field.Required(field.NewPath("string"), fmt.Sprintf("string must be set for combine strategy %s", c.Strategy))
+		}
+	case "":
+		return field.Required(field.NewPath("strategy"), "a combine strategy must be provided")
+	default:
+		return field.Invalid(field.NewPath("strategy"), c.Strategy, "unknown strategy type")
+	}
+
+	if len(c.Variables) == 0 {
+		return field.Required(field.NewPath("variables"), "at least one variable must be provided")
+	}
+
+	for i, v := range c.Variables {
+		p := field.NewPath("variables").Index(i)
+		if v.Type == "" {
+			return field.Required(p.Child("type"), "combine variable type is required")
+		}
+		if !v.Type.IsValid() || v.Type == v1beta1.ConnectionDetailTypeCombine || v.Type == v1beta1.ConnectionDetailTypeFromStatusFieldPath {
+			return field.Invalid(p.Child("type"), string(v.Type), "unsupported combine variable type")
+		}
+		if err := validateConnectionDetailSource(v.Type, v.FromConnectionSecretKey, v.FromFieldPath, v.Value); err != nil {
+			return WrapFieldError(err, p)
+		}
+	}
+
+	return nil
+}
+
+// validateConnectionDetailSource checks that the field required by the
+// supplied connection detail type is set.
+func validateConnectionDetailSource(t v1beta1.ConnectionDetailType, key, path, value *string) *field.Error {
+	switch t { //nolint:exhaustive // Combine has no single source.
 	case v1beta1.ConnectionDetailTypeFromValue:
-		if cd.Value == nil {
+		if value == nil {
 			return field.Required(field.NewPath("value"), "value connection detail requires a value")
 		}
 	case v1beta1.ConnectionDetailTypeFromConnectionSecretKey:
-		if cd.FromConnectionSecretKey == nil {
+		if key == nil {
 			return field.Required(field.NewPath("fromConnectionSecretKey"), "from connection secret key connection detail requires a key")
 		}
-	case v1beta1.ConnectionDetailTypeFromFieldPath:
-		if cd.FromFieldPath == nil {
+	case v1beta1.ConnectionDetailTypeFromFieldPath, v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath:
+		if path == nil {
 			return field.Required(field.NewPath("fromFieldPath"), "from field path connection detail requires a field path")
 		}
 	}
 	return nil
 }
+
+// ValidateCompositeConnectionDetail checks if a connection detail that isn't
+// associated with a composed resource is logically valid.
+func ValidateCompositeConnectionDetail(cd v1beta1.ConnectionDetail) *field.Error {
+	if err := ValidateConnectionDetail(cd); err != nil {
+		return err
+	}
+	if cd.FromResource != nil {
+		if *cd.FromResource == "" {
+			return field.Required(field.NewPath("fromResource"), "fromResource must name a resource template")
+		}
+		return nil
+	}
+	if cd.Type == v1beta1.ConnectionDetailTypeCombine {
+		for i, v := range cd.Combine.Variables {
+			if !compositeConnectionDetailType(v.Type) {
+				return field.Invalid(field.NewPath("combine", "variables").Index(i).Child("type"), string(v.Type), "combine variable type requires a composed resource")
+			}
+		}
+		return nil
+	}
+	if !compositeConnectionDetailType(cd.Type) {
+		return field.Invalid(field.NewPath("type"), string(cd.Type), "connection detail type requires a composed resource")
+	}
+	return nil
+}
+
+// compositeConnectionDetailType returns true if the supplied connection detail
+// type doesn't need a composed resource.
+func compositeConnectionDetailType(t v1beta1.ConnectionDetailType) bool {
+	return t == v1beta1.ConnectionDetailTypeFromValue || t == v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 
@@ -56,6 +57,45 @@ func TestValidateReadinessCheck(t *testing.T) {
 				},
 			},
 		},
+		"ValidTypeMatchConnectionDetailKey": {
+			reason: "Type matchConnectionDetailKey should be valid without a field path",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type:                v1beta1.ReadinessCheckTypeMatchConnectionDetailKey,
+					ConnectionDetailKey: ptr.To[string]("password"),
+				},
+			},
+		},
+		"InvalidTypeMatchConnectionDetailKeySource": {
+			reason: "Type matchConnectionDetailKey should only support the Composed source",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type:                v1beta1.ReadinessCheckTypeMatchConnectionDetailKey,
+					Source:              v1beta1.ReadinessCheckSourceComposite,
+					ConnectionDetailKey: ptr.To[string]("password"),
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "source",
+				},
+			},
+		},
+		"InvalidTypeMatchConnectionDetailKeyMissingKey": {
+			reason: "Type matchConnectionDetailKey should require a connection detail key",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: v1beta1.ReadinessCheckTypeMatchConnectionDetailKey,
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "connectionDetailKey",
+				},
+			},
+		},
 		"ValidTypeMatchTrue": {
 			reason: "Type matchTrue should be valid",
 			args: args{
@@ -74,6 +114,73 @@ func TestValidateReadinessCheck(t *testing.T) {
 				},
 			},
 		},
+		"InvalidTypeAnyOfEmpty": {
+			reason: "Type anyOf without any readiness checks should be invalid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: v1beta1.ReadinessCheckTypeAnyOf,
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "anyOf",
+				},
+			},
+		},
+		"InvalidTypeAllOfNested": {
+			reason: "Type allOf should validate the readiness checks it groups",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: v1beta1.ReadinessCheckTypeAllOf,
+					AllOf: []v1beta1.ReadinessCheck{
+						{Type: v1beta1.ReadinessCheckTypeNone},
+						{Type: v1beta1.ReadinessCheckTypeMatchString, FieldPath: ptr.To[string]("spec.foo")},
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "allOf[1].matchString",
+				},
+			},
+		},
+		"InvalidTypeMatchRegexp": {
+			reason: "Type matchRegexp with an invalid regexp should be invalid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type:        v1beta1.ReadinessCheckTypeMatchRegexp,
+					MatchRegexp: ptr.To[string]("(unclosed"),
+					FieldPath:   ptr.To[string]("spec.foo"),
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "matchRegexp",
+				},
+			},
+		},
+		"InvalidTypeMatchConditionReason": {
+			reason: "Type matchCondition with an invalid reason regexp should be invalid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: v1beta1.ReadinessCheckTypeMatchCondition,
+					MatchCondition: &v1beta1.MatchConditionReadinessCheck{
+						Type:   "someType",
+						Status: "someStatus",
+						Reason: ptr.To[string]("(unclosed"),
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "matchCondition.reason",
+				},
+			},
+		},
 		"InvalidType": {
 			reason: "Invalid type",
 			args: args{
@@ -125,6 +232,23 @@ func TestValidateConnectionDetail(t *testing.T) {
 				},
 			},
 		},
+		"InvalidEncoding": {
+			reason: "An unknown encoding should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:     v1beta1.ConnectionDetailTypeFromValue,
+					Name:     "port",
+					Value:    ptr.To("5432"),
+					Encoding: ptr.To(v1beta1.ConnectionDetailEncoding("Hex")),
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "encoding",
+				},
+			},
+		},
 		"EmptyName": {
 			reason: "An empty name should cause a validation error",
 			args: args{
@@ -185,6 +309,99 @@ func TestValidateConnectionDetail(t *testing.T) {
 				},
 			},
 		},
+		"InvalidFromEnvironmentFieldPath": {
+			reason: "An invalid from environment field path should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeFromEnvironmentFieldPath,
+					Name: "cool",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"MissingCombine": {
+			reason: "A combine connection detail without configuration should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeCombine,
+					Name: "cool",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "combine",
+				},
+			},
+		},
+		"InvalidCombineVariable": {
+			reason: "A combine variable missing its source should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeCombine,
+					Name: "cool",
+					Combine: &v1beta1.ConnectionDetailCombine{
+						Variables: []v1beta1.ConnectionDetailCombineVariable{
+							{Type: v1beta1.ConnectionDetailTypeFromFieldPath},
+						},
+						Strategy: v1beta1.CombineStrategyString,
+						String:   &v1beta1.StringCombine{Format: "%s"},
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "combine.variables[0].fromFieldPath",
+				},
+			},
+		},
+		"NestedCombineVariable": {
+			reason: "A combine variable of type Combine should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeCombine,
+					Name: "cool",
+					Combine: &v1beta1.ConnectionDetailCombine{
+						Variables: []v1beta1.ConnectionDetailCombineVariable{
+							{Type: v1beta1.ConnectionDetailTypeCombine},
+						},
+						Strategy: v1beta1.CombineStrategyString,
+						String:   &v1beta1.StringCombine{Format: "%s"},
+					},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "combine.variables[0].type",
+				},
+			},
+		},
+		"InvalidTransform": {
+			reason: "An invalid transform should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:  v1beta1.ConnectionDetailTypeFromValue,
+					Name:  "cool",
+					Value: ptr.To[string]("cooler"),
+					Transforms: []v1beta1.Transform{{
+						Type: v1beta1.TransformTypeMath,
+					}},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "transforms[0].math",
+				},
+			},
+		},
 		"ValidValue": {
 			reason: "An valid value should not cause a validation error",
 			args: args{
@@ -224,6 +441,53 @@ func TestValidateConnectionDetail(t *testing.T) {
 				output: nil,
 			},
 		},
+		"FromStatusFieldPathOutsideStatus": {
+			reason: "A from status field path connection detail should cause a validation error if its field path isn't under status",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+					Name:          "cool",
+					FromFieldPath: ptr.To[string]("spec.forProvider.coolness"),
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"InvalidConnectionDetailPolicy": {
+			reason: "An unknown from field path policy should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+					Name:          "cool",
+					FromFieldPath: ptr.To[string]("status.atProvider.coolness"),
+					Policy:        &v1beta1.ConnectionDetailPolicy{FromFieldPath: ptr.To[v1beta1.FromFieldPathPolicy]("Sometimes")},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "policy.fromFieldPath",
+				},
+			},
+		},
+		"ValidFromStatusFieldPath": {
+			reason: "A valid from status field path should not cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:          v1beta1.ConnectionDetailTypeFromStatusFieldPath,
+					Name:          "cool",
+					FromFieldPath: ptr.To[string]("status.atProvider.coolness"),
+					Policy:        &v1beta1.ConnectionDetailPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)},
+				},
+			},
+			want: want{
+				output: nil,
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -260,116 +524,235 @@ func TestValidatePatch(t *testing.T) {
 				},
 			},
 		},
-		"FromCompositeFieldPathWithInvalidTransforms": {
-			reason: "FromCompositeFieldPath with invalid transforms should return error",
+		"ToCompositeFieldPathLabel": {
+			reason: "ToCompositeFieldPath patch to an XR label should be valid",
 			args: args{
 				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
 					Patch: v1beta1.Patch{
-						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
-						Transforms: []v1beta1.Transform{
-							{
-								Type: v1beta1.TransformTypeMath,
-								Math: nil,
-							},
-						},
+						FromFieldPath: ptr.To[string]("status.atProvider.id"),
+						ToFieldPath:   ptr.To[string]("metadata.labels[example.org/id]"),
+					},
+				},
+			},
+		},
+		"ToCompositeFieldPathProtectedLabel": {
+			reason: "ToCompositeFieldPath patch to an XR label managed by Crossplane should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("status.atProvider.id"),
+						ToFieldPath:   ptr.To[string]("metadata.labels[crossplane.io/composite]"),
 					},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "transforms[0].math",
+					Type:  field.ErrorTypeInvalid,
+					Field: "toFieldPath",
 				},
 			},
 		},
-		"InvalidFromCompositeFieldPathMissingFromFieldPath": {
-			reason: "Invalid FromCompositeFieldPath missing FromFieldPath should return error",
+		"ToFieldPathTemplate": {
+			reason: "A patch with a toFieldPath template should be valid",
 			args: args{
 				patch: v1beta1.ComposedPatch{
 					Type: v1beta1.PatchTypeFromCompositeFieldPath,
 					Patch: v1beta1.Patch{
-						FromFieldPath: nil,
+						FromFieldPath: ptr.To[string]("spec.tag"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags[{{ .key }}]"),
 					},
 				},
 			},
-			want: want{
-				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "fromFieldPath",
-				},
-			},
 		},
-		"InvalidFromCompositeFieldPathMissingToFieldPath": {
-			reason: "Invalid ToCompositeFieldPath missing ToFieldPath should return error",
+		"InvalidToFieldPathTemplate": {
+			reason: "A patch with a toFieldPath template that can't be parsed should return error",
 			args: args{
 				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
 					Patch: v1beta1.Patch{
-						ToFieldPath: nil,
+						FromFieldPath: ptr.To[string]("spec.tag"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.tags[{{ .key ]"),
 					},
 				},
 			},
 			want: want{
-				&field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "fromFieldPath",
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "toFieldPath",
 				},
 			},
 		},
-		"Invalidv1beta1.PatchSetMissingv1beta1.PatchSetName": {
-			reason: "Invalid v1beta1.PatchSet missing v1beta1.PatchSetName should return error",
+		"ToFieldPathTemplateOverwriteIfUnset": {
+			reason: "A patch with a toFieldPath template that only overwrites unset fields should return error",
 			args: args{
 				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypePatchSet,
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("status.atProvider.id"),
+						ToFieldPath:   ptr.To[string]("status.ids[{{ . }}]"),
+						Policy: &v1beta1.PatchPolicy{
+							Overwrite: ptr.To(v1beta1.OverwritePolicyIfUnset),
+						},
+					},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "patchSetName",
+					Type:  field.ErrorTypeInvalid,
+					Field: "policy.overwrite",
 				},
 			},
 		},
-		"InvalidCombineMissingCombine": {
-			reason: "Invalid Combine missing Combine should return error",
+		"ExpandArraysWildcards": {
+			reason: "A patch that expands arrays should have exactly one wildcard in its toFieldPath",
 			args: args{
 				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeCombineToComposite,
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.zones"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.subnets[*].zones[*]"),
+						Policy: &v1beta1.PatchPolicy{
+							ExpandArrays: ptr.To(true),
+						},
+					},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "combine",
+					Type:  field.ErrorTypeInvalid,
+					Field: "policy.expandArrays",
 				},
 			},
 		},
-		"InvalidCombineMissingToFieldPath": {
-			reason: "Invalid Combine missing ToFieldPath should return error",
+		"ToCompositeFieldPathMetadataName": {
+			reason: "ToCompositeFieldPath patch to XR metadata other than labels and annotations should return error",
 			args: args{
 				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeCombineToComposite,
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
 					Patch: v1beta1.Patch{
-						Combine: &v1beta1.Combine{
-							Variables: []v1beta1.CombineVariable{
-								{
-									FromFieldPath: "spec.forProvider.foo",
-								},
-							},
-						},
-						ToFieldPath: nil,
+						FromFieldPath: ptr.To[string]("metadata.name"),
 					},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
+					Type:  field.ErrorTypeInvalid,
 					Field: "toFieldPath",
 				},
 			},
 		},
-	}
+		"FromCompositeFieldPathWithInvalidTransforms": {
+			reason: "FromCompositeFieldPath with invalid transforms should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeMath,
+								Math: nil,
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "transforms[0].math",
+				},
+			},
+		},
+		"InvalidFromCompositeFieldPathMissingFromFieldPath": {
+			reason: "Invalid FromCompositeFieldPath missing FromFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: nil,
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"InvalidFromCompositeFieldPathMissingToFieldPath": {
+			reason: "Invalid ToCompositeFieldPath missing ToFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						ToFieldPath: nil,
+					},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"Invalidv1beta1.PatchSetMissingv1beta1.PatchSetName": {
+			reason: "Invalid v1beta1.PatchSet missing v1beta1.PatchSetName should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypePatchSet,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "patchSetName",
+				},
+			},
+		},
+		"InvalidCombineMissingCombine": {
+			reason: "Invalid Combine missing Combine should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineToComposite,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "combine",
+				},
+			},
+		},
+		"InvalidCombineMissingToFieldPath": {
+			reason: "Invalid Combine missing ToFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineToComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{
+									FromFieldPath: "spec.forProvider.foo",
+								},
+							},
+						},
+						ToFieldPath: nil,
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "toFieldPath",
+				},
+			},
+		},
+	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			err := ValidatePatch(&tc.args.patch)
@@ -493,6 +876,110 @@ func TestValidateCombine(t *testing.T) {
 				},
 			},
 		},
+		"OptionalVariableWithoutDefault": {
+			reason: "An optional combine variable requires a default value",
+			args: args{
+				combine: v1beta1.Combine{
+					Strategy: v1beta1.CombineStrategyMerge,
+					Variables: []v1beta1.CombineVariable{
+						{FromFieldPath: "a"},
+						{FromFieldPath: "b", Policy: ptr.To(v1beta1.FromFieldPathPolicyOptional)},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "variables[1].default",
+				},
+			},
+		},
+		"ComposedSourceWithoutResourceName": {
+			reason: "A combine variable read from a composed resource requires the resource template's name",
+			args: args{
+				combine: v1beta1.Combine{
+					Strategy: v1beta1.CombineStrategyMerge,
+					Variables: []v1beta1.CombineVariable{
+						{FromFieldPath: "a", Source: &v1beta1.CombineVariableSource{Type: v1beta1.CombineVariableSourceTypeEnvironment}},
+						{FromFieldPath: "b", Source: &v1beta1.CombineVariableSource{Type: v1beta1.CombineVariableSourceTypeComposed}},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "variables[1].source.resourceName",
+				},
+			},
+		},
+		"JoinMissingConfig": {
+			reason: "A combine with the Join strategy requires join configuration",
+			args: args{
+				combine: v1beta1.Combine{
+					Strategy: v1beta1.CombineStrategyJoin,
+					Variables: []v1beta1.CombineVariable{
+						{FromFieldPath: "a"},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "join",
+				},
+			},
+		},
+		"ObjectMissingName": {
+			reason: "A combine with the Object strategy requires every variable to have a name",
+			args: args{
+				combine: v1beta1.Combine{
+					Strategy: v1beta1.CombineStrategyObject,
+					Variables: []v1beta1.CombineVariable{
+						{FromFieldPath: "a", Name: "a"},
+						{FromFieldPath: "b"},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "variables[1].name",
+				},
+			},
+		},
+		"ObjectDuplicateName": {
+			reason: "A combine with the Object strategy requires unique variable names",
+			args: args{
+				combine: v1beta1.Combine{
+					Strategy: v1beta1.CombineStrategyObject,
+					Variables: []v1beta1.CombineVariable{
+						{FromFieldPath: "a", Name: "a"},
+						{FromFieldPath: "b", Name: "a"},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeDuplicate,
+					Field: "variables[1].name",
+				},
+			},
+		},
+		"ValidMerge": {
+			reason: "A combine with the Merge strategy needs no configuration",
+			args: args{
+				combine: v1beta1.Combine{
+					Strategy: v1beta1.CombineStrategyMerge,
+					Variables: []v1beta1.CombineVariable{
+						{FromFieldPath: "a"},
+						{FromFieldPath: "b"},
+					},
+				},
+			},
+			want: want{
+				err: nil,
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -781,6 +1268,50 @@ func TestValidateTransform(t *testing.T) {
 				},
 			},
 		},
+		"ValidTimeAdd": {
+			reason: "Time transform of type Add with a valid duration should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeTime,
+					Time: &v1beta1.TimeTransform{
+						Type:     v1beta1.TimeTransformTypeAdd,
+						Duration: ptr.To("1h30m"),
+					},
+				},
+			},
+		},
+		"InvalidTimeMissingConfig": {
+			reason: "Time transform without a TimeTransform should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeTime,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "time",
+				},
+			},
+		},
+		"InvalidTimeAddBadDuration": {
+			reason: "Time transform of type Add with an unparseable duration should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeTime,
+					Time: &v1beta1.TimeTransform{
+						Type:     v1beta1.TimeTransformTypeAdd,
+						Duration: ptr.To("a while"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "time.duration",
+				},
+			},
+		},
 		"ValidConvert": {
 			reason: "Convert transform with valid format and toType should be valid",
 			args: args{
@@ -803,3 +1334,729 @@ func TestValidateTransform(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateExtraResource(t *testing.T) {
+	type args struct {
+		er v1beta1.ExtraResource
+	}
+
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidMatchName": {
+			reason: "An extra resource requested by name should be valid",
+			args: args{
+				er: v1beta1.ExtraResource{
+					Name:       "config",
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					MatchName:  ptr.To[string]("cool-config"),
+				},
+			},
+		},
+		"MissingKind": {
+			reason: "An extra resource without a kind should be invalid",
+			args: args{
+				er: v1beta1.ExtraResource{
+					Name:       "config",
+					APIVersion: "v1",
+					MatchName:  ptr.To[string]("cool-config"),
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "kind",
+				},
+			},
+		},
+		"MatchNameAndLabels": {
+			reason: "An extra resource requested by both name and labels should be invalid",
+			args: args{
+				er: v1beta1.ExtraResource{
+					Name:        "config",
+					APIVersion:  "v1",
+					Kind:        "ConfigMap",
+					MatchName:   ptr.To[string]("cool-config"),
+					MatchLabels: map[string]string{"cool": "true"},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "matchName",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateExtraResource(tc.args.er)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateExtraResource(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateEnvironmentConfig(t *testing.T) {
+	type args struct {
+		ec v1beta1.EnvironmentConfig
+	}
+
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidReference": {
+			reason: "An EnvironmentConfig selected by name should be valid",
+			args: args{
+				ec: v1beta1.EnvironmentConfig{
+					Ref: &v1beta1.EnvironmentConfigReference{Name: "cool-config"},
+				},
+			},
+		},
+		"MissingRef": {
+			reason: "An EnvironmentConfig of type Reference without a ref should be invalid",
+			args: args{
+				ec: v1beta1.EnvironmentConfig{
+					Type: v1beta1.EnvironmentConfigTypeReference,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "ref.name",
+				},
+			},
+		},
+		"MissingLabelValue": {
+			reason: "A label matcher of type Value without a value should be invalid",
+			args: args{
+				ec: v1beta1.EnvironmentConfig{
+					Type: v1beta1.EnvironmentConfigTypeSelector,
+					Selector: &v1beta1.EnvironmentConfigSelector{
+						MatchLabels: []v1beta1.EnvironmentConfigLabelMatcher{
+							{
+								Key:  "region",
+								Type: v1beta1.EnvironmentConfigLabelMatcherTypeValue,
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "selector.matchLabels[0].value",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateEnvironmentConfig(tc.args.ec)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateEnvironmentConfig(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	type args struct {
+		cts []v1beta1.ComposedTemplate
+	}
+
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Valid": {
+			reason: "Resource templates that depend on other resource templates should be valid",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "a", DependsOn: []string{"b", "c"}},
+					{Name: "b", DependsOn: []string{"c"}},
+					{Name: "c"},
+				},
+			},
+		},
+		"UnknownDependency": {
+			reason: "A resource template that depends on an unknown resource template should be invalid",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "a", DependsOn: []string{"b"}},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeNotFound,
+					Field: "resources[0].dependsOn[0]",
+				},
+			},
+		},
+		"Cycle": {
+			reason: "Resource templates whose dependencies form a cycle should be invalid",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "a"},
+					{Name: "b", DependsOn: []string{"c"}},
+					{Name: "c", DependsOn: []string{"b"}},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "resources[1].dependsOn",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateDependencies(tc.args.cts)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateDependencies(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateFieldOwnership(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		fo     v1beta1.FieldOwnership
+		want   *field.Error
+	}{
+		"Valid": {
+			reason: "A hint with a field path should be valid",
+			fo:     v1beta1.FieldOwnership{FieldPath: "spec.forProvider.instanceType", Policy: v1beta1.FieldOwnershipPolicyHint},
+		},
+		"UnknownPolicy": {
+			reason: "A field ownership with an unknown policy should be invalid",
+			fo:     v1beta1.FieldOwnership{FieldPath: "spec.forProvider.instanceType", Policy: "Shared"},
+			want: &field.Error{
+				Type:  field.ErrorTypeInvalid,
+				Field: "policy",
+			},
+		},
+		"MissingFieldPath": {
+			reason: "A field ownership without a field path should be invalid",
+			fo:     v1beta1.FieldOwnership{Policy: v1beta1.FieldOwnershipPolicyHint},
+			want: &field.Error{
+				Type:  field.ErrorTypeRequired,
+				Field: "fieldPath",
+			},
+		},
+		"Wildcard": {
+			reason: "A field ownership with a wildcard field path should be invalid",
+			fo:     v1beta1.FieldOwnership{FieldPath: "spec.forProvider.rules[*].port"},
+			want: &field.Error{
+				Type:  field.ErrorTypeInvalid,
+				Field: "fieldPath",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateFieldOwnership(tc.fo)
+			if diff := cmp.Diff(tc.want, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateFieldOwnership(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateWaitFor(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		w      *v1beta1.WaitFor
+		want   *field.Error
+	}{
+		"Valid": {
+			reason: "A WaitFor with an environment field path and a value should be valid",
+			w:      &v1beta1.WaitFor{EnvironmentFieldPath: "stages.networkDone", Equals: extv1.JSON{Raw: []byte(`"true"`)}},
+		},
+		"MissingEnvironmentFieldPath": {
+			reason: "A WaitFor without an environment field path should be invalid",
+			w:      &v1beta1.WaitFor{Equals: extv1.JSON{Raw: []byte(`"true"`)}},
+			want: &field.Error{
+				Type:  field.ErrorTypeRequired,
+				Field: "environmentFieldPath",
+			},
+		},
+		"InvalidEnvironmentFieldPath": {
+			reason: "A WaitFor with an environment field path that can't be parsed should be invalid",
+			w:      &v1beta1.WaitFor{EnvironmentFieldPath: "stages[", Equals: extv1.JSON{Raw: []byte(`"true"`)}},
+			want: &field.Error{
+				Type:  field.ErrorTypeInvalid,
+				Field: "environmentFieldPath",
+			},
+		},
+		"MissingEquals": {
+			reason: "A WaitFor without a value should be invalid",
+			w:      &v1beta1.WaitFor{EnvironmentFieldPath: "stages.networkDone"},
+			want: &field.Error{
+				Type:  field.ErrorTypeRequired,
+				Field: "equals",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateWaitFor(tc.w)
+			if diff := cmp.Diff(tc.want, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateWaitFor(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	type args struct {
+		r *v1beta1.Resources
+	}
+
+	type want struct {
+		errs field.ErrorList
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Valid": {
+			reason: "Valid resources should return no errors",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name: "cool-resource",
+							Patches: []v1beta1.ComposedPatch{
+								{
+									Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{FromFieldPath: ptr.To[string]("spec.widgets")},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{},
+			},
+		},
+		"MultipleErrors": {
+			reason: "Every problem should be returned, not just the first",
+			args: args{
+				r: &v1beta1.Resources{
+					PatchSets: []v1beta1.PatchSet{
+						{
+							Name: "cool-patches",
+							Patches: []v1beta1.PatchSetPatch{
+								{Type: v1beta1.PatchTypeToCompositeFieldPath},
+							},
+						},
+					},
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name: "cool-resource",
+							Patches: []v1beta1.ComposedPatch{
+								{
+									Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{FromFieldPath: ptr.To[string]("spec.widgets")},
+								},
+								{Type: v1beta1.PatchTypeFromCompositeFieldPath},
+							},
+						},
+						{
+							Patches: []v1beta1.ComposedPatch{
+								{Type: v1beta1.PatchTypeCombineFromComposite},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "patchSets[0].patches[0].fromFieldPath",
+					},
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "resources[0].patches[1].fromFieldPath",
+					},
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "resources[1].name",
+					},
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "resources[1].patches[0].combine",
+					},
+				},
+			},
+		},
+		"CrossplaneAnnotations": {
+			reason: "Patches should only change the Crossplane annotations their policies allow.",
+			args: args{
+				r: &v1beta1.Resources{
+					CrossplaneAnnotations: &v1beta1.CrossplaneAnnotations{
+						Paused: ptr.To(v1beta1.CrossplaneAnnotationPolicyAllow),
+					},
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name: "cool-resource",
+							CrossplaneAnnotations: &v1beta1.CrossplaneAnnotations{
+								ExternalName: ptr.To(v1beta1.CrossplaneAnnotationPolicyDeny),
+							},
+							Patches: []v1beta1.ComposedPatch{
+								{
+									Type: v1beta1.PatchTypeToCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.paused"),
+										ToFieldPath:   ptr.To[string]("metadata.annotations[crossplane.io/paused]"),
+									},
+								},
+								{
+									Type: v1beta1.PatchTypeToCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.name"),
+										ToFieldPath:   ptr.To[string]("metadata.annotations[crossplane.io/external-name]"),
+									},
+								},
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.name"),
+										ToFieldPath:   ptr.To[string]("metadata.annotations[crossplane.io/external-name]"),
+									},
+								},
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.paused"),
+										ToFieldPath:   ptr.To[string]("metadata.annotations[crossplane.io/paused]"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeForbidden,
+						Field: "resources[0].patches[1].toFieldPath",
+					},
+					{
+						Type:  field.ErrorTypeForbidden,
+						Field: "resources[0].patches[2].toFieldPath",
+					},
+				},
+			},
+		},
+		"BaseFromObserved": {
+			reason: "A resource template can't clone its own composed resource, or have another base.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name:             "blue",
+							BaseFromObserved: ptr.To("blue"),
+						},
+						{
+							Name:             "green",
+							Base:             &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							BaseFromObserved: ptr.To("blue"),
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "resources[0].baseFromObserved",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "resources[1].baseFromObserved",
+					},
+				},
+			},
+		},
+		"WriteConnectionSecretToRef": {
+			reason: "A connection secret reference needs a name, and its variables must be composite resource field paths.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name:                       "no-name",
+							WriteConnectionSecretToRef: &v1beta1.ConnectionSecretRef{},
+						},
+						{
+							Name: "bad-namespace",
+							WriteConnectionSecretToRef: &v1beta1.ConnectionSecretRef{
+								Name:      "{xr.metadata.uid}-db",
+								Namespace: ptr.To("{spec.namespace}"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "resources[0].writeConnectionSecretToRef.name",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "resources[1].writeConnectionSecretToRef.namespace",
+					},
+				},
+			},
+		},
+		"Variables": {
+			reason: "Variables shouldn't use reserved names, and patches should only read variables that exist.",
+			args: args{
+				r: &v1beta1.Resources{
+					Variables: map[string]extv1.JSON{
+						"domain": {Raw: []byte(`"example.org"`)},
+						"kind":   {Raw: []byte(`"Bucket"`)},
+					},
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name: "cool-resource",
+							Patches: []v1beta1.ComposedPatch{
+								{
+									Type:  v1beta1.PatchTypeFromVariableFieldPath,
+									Patch: v1beta1.Patch{FromFieldPath: ptr.To[string]("domain"), ToFieldPath: ptr.To[string]("spec.domain")},
+								},
+								{
+									Type:  v1beta1.PatchTypeFromVariableFieldPath,
+									Patch: v1beta1.Patch{FromFieldPath: ptr.To[string]("account.id"), ToFieldPath: ptr.To[string]("spec.account")},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "variables[kind]",
+					},
+					{
+						Type:  field.ErrorTypeNotFound,
+						Field: "resources[0].patches[1].fromFieldPath",
+					},
+				},
+			},
+		},
+		"ConnectionDetailsFromResource": {
+			reason: "Only top-level connection details may read a composed resource by name, and it must exist.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name: "database",
+							ConnectionDetails: []v1beta1.ConnectionDetail{
+								{Name: "password", Type: v1beta1.ConnectionDetailTypeFromConnectionSecretKey, FromConnectionSecretKey: ptr.To("password"), FromResource: ptr.To("database")},
+							},
+						},
+					},
+					ConnectionDetails: []v1beta1.ConnectionDetail{
+						{Name: "password", Type: v1beta1.ConnectionDetailTypeFromConnectionSecretKey, FromConnectionSecretKey: ptr.To("password"), FromResource: ptr.To("database")},
+						{Name: "url", Type: v1beta1.ConnectionDetailTypeFromFieldPath, FromFieldPath: ptr.To("status.url"), FromResource: ptr.To("queue")},
+						{Name: "endpoint", Type: v1beta1.ConnectionDetailTypeFromFieldPath, FromFieldPath: ptr.To("status.endpoint")},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeForbidden,
+						Field: "resources[0].connectionDetails[0].fromResource",
+					},
+					{
+						Type:  field.ErrorTypeNotFound,
+						Field: "connectionDetails[1].fromResource",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "connectionDetails[2].type",
+					},
+				},
+			},
+		},
+		"Metadata": {
+			reason: "Variables of labels and annotations must be composite resource field paths.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{
+							Name: "bucket",
+							Metadata: &v1beta1.ComposedMetadata{
+								Labels: map[string]string{"xr": "{xr.metadata.name}", "region": "{spec.region}"},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "resources[0].metadata.labels[region]",
+					},
+				},
+			},
+		},
+		"PatchSetCycle": {
+			reason: "PatchSets may include other PatchSets, but not themselves.",
+			args: args{
+				r: &v1beta1.Resources{
+					PatchSets: []v1beta1.PatchSet{
+						{
+							Name: "org",
+							Patches: []v1beta1.PatchSetPatch{
+								{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("team")},
+							},
+						},
+						{
+							Name: "team",
+							Patches: []v1beta1.PatchSetPatch{
+								{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("org")},
+							},
+						},
+						{
+							Name: "resource",
+							Patches: []v1beta1.PatchSetPatch{
+								{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To("team")},
+							},
+						},
+					},
+					Resources: []v1beta1.ComposedTemplate{{Name: "cool-resource"}},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "patchSets[0].patches[0].patchSetName",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "patchSets[1].patches[0].patchSetName",
+					},
+				},
+			},
+		},
+		"PreviousNames": {
+			reason: "Previous names shouldn't be the names of resource templates, or previous names of another resource template.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "database", PreviousNames: []string{"db", "cache"}},
+						{Name: "cache", PreviousNames: []string{"db"}},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "resources[0].previousNames[1]",
+					},
+					{
+						Type:  field.ErrorTypeDuplicate,
+						Field: "resources[1].previousNames[0]",
+					},
+				},
+			},
+		},
+		"StatusConditions": {
+			reason: "Status conditions need a unique type that Crossplane doesn't manage, and resources that exist.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{{Name: "network"}},
+					StatusConditions: []v1beta1.StatusCondition{
+						{Type: "NetworkReady", Resources: []string{"network"}},
+						{Type: "NetworkReady", Resources: []string{"network"}},
+						{Type: "Ready", Resources: []string{"network"}},
+						{Type: "DatabaseReady", Resources: []string{"database"}},
+						{Type: "CacheReady"},
+					},
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeDuplicate,
+						Field: "statusConditions[1].type",
+					},
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "statusConditions[2].type",
+					},
+					{
+						Type:  field.ErrorTypeNotFound,
+						Field: "statusConditions[3].resources[0]",
+					},
+					{
+						Type:  field.ErrorTypeRequired,
+						Field: "statusConditions[4].resources",
+					},
+				},
+			},
+		},
+		"WriteInventoryToSpec": {
+			reason: "The inventory can only be written to the composite resource's status.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources:        []v1beta1.ComposedTemplate{{Name: "network"}},
+					WriteInventoryTo: ptr.To("spec.inventory"),
+				},
+			},
+			want: want{
+				errs: field.ErrorList{
+					{
+						Type:  field.ErrorTypeInvalid,
+						Field: "writeInventoryTo",
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			errs := ValidateResources(tc.args.r)
+			if diff := cmp.Diff(tc.want.errs, errs, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// VariablesObject returns an object that FromVariableFieldPath patches can read
+// the supplied variables from. Each of its keys is the name of a variable. The
+// variables apiVersion and kind are reserved, because the object has its own.
+func VariablesObject(vars map[string]extv1.JSON) (*unstructured.Unstructured, error) {
+	o := &unstructured.Unstructured{Object: make(map[string]any, len(vars))}
+	for name, v := range vars {
+		var value any
+		if err := json.Unmarshal(v.Raw, &value); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse variable %q", name)
+		}
+		o.Object[name] = value
+	}
+	o.SetGroupVersionKind(internalVariablesGVK)
+	return o, nil
+}
+
+// IsReservedVariableName returns true if the supplied variable name is
+// reserved for the Function's own use.
+func IsReservedVariableName(name string) bool {
+	return name == "apiVersion" || name == "kind"
+}
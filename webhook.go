@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/crossplane/function-sdk-go"
+)
+
+const (
+	// maxAdmissionReviewBytes is the largest AdmissionReview the webhook
+	// decodes. The API server accepts objects of up to 3MiB, and an
+	// AdmissionReview for an update contains both the new and old object.
+	maxAdmissionReviewBytes = 7 * 1024 * 1024
+
+	// The API server waits at most 30 seconds for a webhook to respond.
+	webhookTimeout = 30 * time.Second
+)
+
+// WebhookCmd serves a validating admission webhook for Compositions.
+type WebhookCmd struct {
+	Debug bool `short:"d" help:"Emit debug logs in addition to info logs."`
+
+	Address     string `help:"Address at which to listen for HTTPS admission review requests." default:":9444"`
+	TLSCertFile string `required:"" type:"existingfile" help:"Path to the webhook server certificate." env:"TLS_SERVER_CERT_FILE"`
+	TLSKeyFile  string `required:"" type:"existingfile" help:"Path to the webhook server certificate's private key." env:"TLS_SERVER_KEY_FILE"`
+}
+
+// Run the webhook command.
+func (c *WebhookCmd) Run() error {
+	log, err := function.NewLogger(c.Debug)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/validate", NewAdmissionHandler(log))
+	srv := &http.Server{
+		Addr:              c.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       webhookTimeout,
+		WriteTimeout:      webhookTimeout,
+	}
+
+	log.Info("Serving admission webhook", "address", c.Address)
+	return errors.Wrap(srv.ListenAndServeTLS(c.TLSCertFile, c.TLSKeyFile), "cannot serve admission webhook")
+}
+
+// An AdmissionHandler handles admission review requests for Compositions. It
+// denies a Composition if the input of any of its pipeline steps that's a
+// Resources object is invalid.
+type AdmissionHandler struct {
+	log logging.Logger
+}
+
+// NewAdmissionHandler returns an AdmissionHandler that logs to the supplied
+// logger.
+func NewAdmissionHandler(log logging.Logger) *AdmissionHandler {
+	return &AdmissionHandler{log: log}
+}
+
+// ServeHTTP reviews the AdmissionReview in the supplied request.
+func (h *AdmissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "admission review requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdmissionReviewBytes)).Decode(review); err != nil {
+		code := http.StatusBadRequest
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, fmt.Sprintf("cannot decode admission review: %s", err), code)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	rsp := ReviewComposition(review.Request)
+	if !rsp.Allowed {
+		h.log.Debug("Denied Composition", "name", review.Request.Name, "reason", rsp.Result.Message)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&admissionv1.AdmissionReview{TypeMeta: review.TypeMeta, Response: rsp}); err != nil {
+		h.log.Info("Cannot write admission review response", "error", err)
+	}
+}
+
+// ReviewComposition returns the response to the supplied admission request
+// for a Composition. Requests without an object, for example to delete a
+// Composition, are allowed. Input that's valid but probably doesn't do what
+// its author intended is allowed with warnings.
+func ReviewComposition(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	rsp := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+	if len(req.Object.Raw) == 0 {
+		return rsp
+	}
+
+	comp := map[string]any{}
+	if err := json.Unmarshal(req.Object.Raw, &comp); err != nil {
+		return deny(rsp, errors.Wrap(err, "cannot decode Composition"))
+	}
+	ins, err := CompositionInputs(comp)
+	if err != nil {
+		return deny(rsp, err)
+	}
+
+	errs := []error{}
+	for _, in := range ins {
+		r, err := DecodeInput(in.Input, false)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "step %q", in.Step))
+			continue
+		}
+		if verrs := ValidateResources(r); len(verrs) > 0 {
+			errs = append(errs, errors.Wrapf(verrs.ToAggregate(), "step %q", in.Step))
+			continue
+		}
+		for _, warning := range Analyze(r) {
+			rsp.Warnings = append(rsp.Warnings, fmt.Sprintf("step %q: %s", in.Step, warning))
+		}
+	}
+	if len(errs) > 0 {
+		return deny(rsp, errors.Join(errs...))
+	}
+	return rsp
+}
+
+func deny(rsp *admissionv1.AdmissionResponse, err error) *admissionv1.AdmissionResponse {
+	rsp.Allowed = false
+	rsp.Warnings = nil
+	rsp.Result = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: err.Error(),
+		Reason:  metav1.StatusReasonInvalid,
+		Code:    http.StatusUnprocessableEntity,
+	}
+	return rsp
+}
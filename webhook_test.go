@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+func TestReviewComposition(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		req    *admissionv1.AdmissionRequest
+		want   *admissionv1.AdmissionResponse
+	}{
+		"Delete": {
+			reason: "A request without an object should be allowed.",
+			req:    &admissionv1.AdmissionRequest{UID: "cool-uid", Operation: admissionv1.Delete},
+			want:   &admissionv1.AdmissionResponse{UID: "cool-uid", Allowed: true},
+		},
+		"Valid": {
+			reason: "A Composition with valid input should be allowed.",
+			req: &admissionv1.AdmissionRequest{
+				UID: "cool-uid",
+				Object: runtime.RawExtension{Raw: []byte(`{
+					"apiVersion": "apiextensions.crossplane.io/v1",
+					"kind": "Composition",
+					"spec": {"mode": "Pipeline", "pipeline": [{"step": "pt", "input": {
+						"apiVersion": "pt.fn.crossplane.io/v1beta1",
+						"kind": "Resources",
+						"resources": [{"name": "bucket", "base": {"apiVersion": "example.org/v1", "kind": "Bucket"}}]
+					}}]}
+				}`)},
+			},
+			want: &admissionv1.AdmissionResponse{UID: "cool-uid", Allowed: true},
+		},
+		"ValidWithWarnings": {
+			reason: "A Composition with input that probably doesn't do what its author intended should be allowed with warnings.",
+			req: &admissionv1.AdmissionRequest{
+				UID: "cool-uid",
+				Object: runtime.RawExtension{Raw: []byte(`{
+					"apiVersion": "apiextensions.crossplane.io/v1",
+					"kind": "Composition",
+					"spec": {"mode": "Pipeline", "pipeline": [{"step": "pt", "input": {
+						"apiVersion": "pt.fn.crossplane.io/v1beta1",
+						"kind": "Resources",
+						"patchSets": [{"name": "unused", "patches": []}],
+						"resources": [{"name": "bucket", "base": {"apiVersion": "example.org/v1", "kind": "Bucket"}}]
+					}}]}
+				}`)},
+			},
+			want: &admissionv1.AdmissionResponse{
+				UID:      "cool-uid",
+				Allowed:  true,
+				Warnings: []string{`step "pt": patchSets[0]: PatchSet "unused" isn't used by any resource template`},
+			},
+		},
+		"Invalid": {
+			reason: "A Composition with invalid input should be denied with its step.",
+			req: &admissionv1.AdmissionRequest{
+				UID: "cool-uid",
+				Object: runtime.RawExtension{Raw: []byte(`{
+					"apiVersion": "apiextensions.crossplane.io/v1",
+					"kind": "Composition",
+					"spec": {"mode": "Pipeline", "pipeline": [
+						{"step": "other", "input": {"apiVersion": "example.org/v1", "kind": "Other"}},
+						{"step": "pt", "input": {
+							"apiVersion": "pt.fn.crossplane.io/v1beta1",
+							"kind": "Resources",
+							"resources": [{"name": "bucket", "base": {"apiVersion": "example.org/v1", "kind": "Bucket"}, "patches": [
+								{"type": "FromCompositeFieldPath", "fromFieldPath": "spec.name", "transforms": [{"type": "string", "string": {"type": "Format"}}]}
+							]}]
+						}}
+					]}
+				}`)},
+			},
+			want: &admissionv1.AdmissionResponse{
+				UID:     "cool-uid",
+				Allowed: false,
+				Result: &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: `step "pt": resources[0].patches[0].transforms[0].string.fmt: Required value: format transform requires a format`,
+					Reason:  metav1.StatusReasonInvalid,
+					Code:    http.StatusUnprocessableEntity,
+				},
+			},
+		},
+		"NotJSON": {
+			reason: "An object that isn't JSON should be denied.",
+			req:    &admissionv1.AdmissionRequest{UID: "cool-uid", Object: runtime.RawExtension{Raw: []byte(`{`)}},
+			want: &admissionv1.AdmissionResponse{
+				UID:     "cool-uid",
+				Allowed: false,
+				Result:  &metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonInvalid, Code: http.StatusUnprocessableEntity},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ReviewComposition(tc.req)
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(metav1.Status{}, "Message")); diff != "" {
+				t.Errorf("%s\nReviewComposition(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if tc.want.Result != nil && tc.want.Result.Message != "" {
+				if diff := cmp.Diff(tc.want.Result.Message, got.Result.Message); diff != "" {
+					t.Errorf("%s\nReviewComposition(...): -want message, +got message:\n%s", tc.reason, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestAdmissionHandler(t *testing.T) {
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  &admissionv1.AdmissionRequest{UID: "cool-uid"},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewAdmissionHandler(logging.NewNopLogger()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP(...): want status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	got := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	want := &admissionv1.AdmissionReview{
+		TypeMeta: review.TypeMeta,
+		Response: &admissionv1.AdmissionResponse{UID: "cool-uid", Allowed: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ServeHTTP(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestAdmissionHandlerRequestTooLarge(t *testing.T) {
+	// A valid JSON string that's larger than the largest AdmissionReview.
+	body := append(append([]byte(`{"kind":"`), bytes.Repeat([]byte("a"), maxAdmissionReviewBytes)...), []byte(`"}`)...)
+
+	rec := httptest.NewRecorder()
+	NewAdmissionHandler(logging.NewNopLogger()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("ServeHTTP(...): want status %d, got %d", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+}